@@ -0,0 +1,9 @@
+// cmd/ipcr-design/main.go
+package main
+
+import (
+	"ipcr/internal/appshell"
+	"ipcr/internal/designapp"
+)
+
+func main() { appshell.Main(designapp.RunContext) }
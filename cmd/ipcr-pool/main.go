@@ -0,0 +1,9 @@
+// cmd/ipcr-pool/main.go
+package main
+
+import (
+	"ipcr/internal/appshell"
+	"ipcr/internal/poolapp"
+)
+
+func main() { appshell.Main(poolapp.RunContext) }
@@ -0,0 +1,9 @@
+// cmd/ipcr-rt/main.go
+package main
+
+import (
+	"ipcr/internal/appshell"
+	"ipcr/internal/rtapp"
+)
+
+func main() { appshell.Main(rtapp.RunContext) }
@@ -0,0 +1,9 @@
+// cmd/ipcr-validate/main.go
+package main
+
+import (
+	"ipcr/internal/appshell"
+	"ipcr/internal/validateapp"
+)
+
+func main() { appshell.Main(validateapp.RunContext) }
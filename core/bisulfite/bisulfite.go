@@ -0,0 +1,83 @@
+// core/bisulfite/bisulfite.go
+
+// Package bisulfite models in-silico bisulfite conversion for methylation
+// PCR (MSP/BSP). Unmethylated cytosines read as thymine after conversion;
+// methylated CpG cytosines stay C. Both strands are converted, and each is
+// expressed in top-strand coordinates so the engine scans it as usual.
+package bisulfite
+
+// Strand is the converted genomic strand.
+type Strand string
+
+const (
+	Top    Strand = "top"    // C→T on the top strand
+	Bottom Strand = "bottom" // C→T on the bottom strand: G→A in top-strand coordinates
+)
+
+// Methylation states a product implies.
+const (
+	Methylated   = "methylated"   // needs the CpGs under its primers methylated
+	Unmethylated = "unmethylated" // needs them unmethylated
+	Any          = "any"          // no CpG under its primers: methylation-independent
+)
+
+// Conversion is one converted template: a strand with every CpG either
+// methylated (kept as C) or unmethylated (converted).
+type Conversion struct {
+	Strand     Strand
+	Methylated bool
+}
+
+// Conversions are the four converted templates.
+var Conversions = []Conversion{
+	{Top, false}, {Top, true},
+	{Bottom, false}, {Bottom, true},
+}
+
+// State is Methylated or Unmethylated.
+func (c Conversion) State() string {
+	if c.Methylated {
+		return Methylated
+	}
+	return Unmethylated
+}
+
+func (c Conversion) String() string { return string(c.Strand) + "/" + c.State() }
+
+// Convert returns a converted copy of seq (top strand, 5'→3'). Case is
+// kept. CpG context does not wrap around the ends of seq.
+func Convert(seq []byte, c Conversion) []byte {
+	out := make([]byte, len(seq))
+	for i, b := range seq {
+		switch {
+		case c.Strand == Top && (b == 'C' || b == 'c') && !(c.Methylated && CpG(seq, Top, i)):
+			b += 'T' - 'C'
+		case c.Strand == Bottom && (b == 'G' || b == 'g') && !(c.Methylated && CpG(seq, Bottom, i)):
+			b -= 'G' - 'A'
+		}
+		out[i] = b
+	}
+	return out
+}
+
+// CpG reports whether seq[i] is the methylable base of a CpG on strand s:
+// the C on the top strand, or the G (the bottom strand's C) on the bottom.
+func CpG(seq []byte, s Strand, i int) bool {
+	if i < 0 || i >= len(seq) {
+		return false
+	}
+	switch s {
+	case Top:
+		return upper(seq[i]) == 'C' && i+1 < len(seq) && upper(seq[i+1]) == 'G'
+	case Bottom:
+		return upper(seq[i]) == 'G' && i > 0 && upper(seq[i-1]) == 'C'
+	}
+	return false
+}
+
+func upper(b byte) byte {
+	if b >= 'a' && b <= 'z' {
+		return b - ('a' - 'A')
+	}
+	return b
+}
@@ -0,0 +1,27 @@
+package bisulfite
+
+import "testing"
+
+func TestConvert(t *testing.T) {
+	seq := []byte("ACGTCCGAcgTGCA")
+	cases := []struct {
+		c    Conversion
+		want string
+	}{
+		{Conversion{Top, false}, "ATGTTTGAtgTGTA"},
+		{Conversion{Top, true}, "ACGTTCGAcgTGTA"},
+		{Conversion{Bottom, false}, "ACATCCAAcaTACA"},
+		{Conversion{Bottom, true}, "ACGTCCGAcgTACA"},
+	}
+	for _, tc := range cases {
+		if got := string(Convert(seq, tc.c)); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.c, got, tc.want)
+		}
+	}
+	if string(seq) != "ACGTCCGAcgTGCA" {
+		t.Fatal("Convert must not modify its input")
+	}
+	if !CpG(seq, Top, 1) || CpG(seq, Top, 4) || !CpG(seq, Bottom, 2) || CpG(seq, Bottom, 11) || CpG(seq, Top, 13) {
+		t.Fatal("CpG positions")
+	}
+}
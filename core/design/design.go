@@ -0,0 +1,291 @@
+// core/design/design.go
+
+// Package design proposes primer pairs for a target region: it enumerates
+// candidate oligos on both strands, filters them by length, GC, 3' clamp,
+// nearest-neighbor Tm and secondary structure, then ranks compatible pairs.
+package design
+
+import (
+	"errors"
+	"ipcr-core/primer"
+	"ipcr-core/thermo"
+	"math"
+	"sort"
+)
+
+// Constraints bound candidate primers and pairs. Zero values fall back to
+// DefaultConstraints for the length, product, GC and Tm windows.
+type Constraints struct {
+	MinLen, MaxLen         int     // primer length (nt)
+	MinProduct, MaxProduct int     // amplicon length (nt)
+	MinGC, MaxGC           float64 // GC fraction, 0..1
+	MinTm, MaxTm           float64 // °C under Conditions
+	MaxTmDiff              float64 // max |Tm(fwd) − Tm(rev)| (°C); 0 = unlimited
+	Clamp                  int     // 3'-terminal bases that must be G/C (0 = off)
+
+	// Structures more stable than these ΔG values (kcal/mol at the annealing
+	// temperature) reject a primer (hairpin, self-dimer) or a pair (cross-dimer).
+	MinHairpinDG float64
+	MinDimerDG   float64
+
+	Conditions thermo.Conditions
+
+	// Target region [TargetStart, TargetEnd) that every product must contain;
+	// primers never overlap it. TargetEnd == 0 means no target.
+	TargetStart, TargetEnd int
+
+	// Candidates is how many of the best oligos per strand are paired
+	// (0 = 200). MaxPairs caps the result (0 = 10).
+	Candidates int
+	MaxPairs   int
+}
+
+// DefaultConstraints returns conventional PCR primer design limits.
+func DefaultConstraints() Constraints {
+	return Constraints{
+		MinLen: 18, MaxLen: 25,
+		MinProduct: 100, MaxProduct: 300,
+		MinGC: 0.40, MaxGC: 0.60,
+		MinTm: 57, MaxTm: 63,
+		MaxTmDiff:    3,
+		Clamp:        1,
+		MinHairpinDG: -3,
+		MinDimerDG:   -6,
+		Conditions:   thermo.DefaultConditions(),
+		Candidates:   200,
+		MaxPairs:     10,
+	}
+}
+
+// Oligo is one candidate primer. Pos/Len give its binding site on the plus
+// strand; Seq is 5'→3' (reverse-complemented for reverse primers).
+type Oligo struct {
+	Seq         string
+	Pos, Len    int
+	TmC         float64
+	GC          float64
+	HairpinDG   float64 // 0 when no structure was found
+	SelfDimerDG float64
+	Penalty     float64
+}
+
+// Pair is a ranked primer pair. Lower Penalty is better.
+type Pair struct {
+	Forward, Reverse Oligo
+	ProductLen       int
+	CrossDimerDG     float64
+	Penalty          float64
+}
+
+// Validate reports inconsistent constraints.
+func (c Constraints) Validate() error {
+	switch {
+	case c.MinLen < 8 || c.MaxLen < c.MinLen || c.MaxLen > 60:
+		return errors.New("design: primer length range must satisfy 8 ≤ min ≤ max ≤ 60")
+	case c.MinProduct < 2*c.MinLen || c.MaxProduct < c.MinProduct:
+		return errors.New("design: product range must satisfy 2×min primer length ≤ min ≤ max")
+	case c.MinGC < 0 || c.MaxGC > 1 || c.MaxGC < c.MinGC:
+		return errors.New("design: GC window must satisfy 0 ≤ min ≤ max ≤ 1")
+	case c.MaxTm < c.MinTm:
+		return errors.New("design: Tm window must satisfy min ≤ max")
+	case c.MaxTmDiff < 0:
+		return errors.New("design: max Tm difference must be ≥ 0")
+	case c.Clamp < 0 || c.Clamp > c.MinLen:
+		return errors.New("design: 3' clamp must be between 0 and the minimum primer length")
+	case c.TargetStart < 0 || c.TargetEnd < c.TargetStart:
+		return errors.New("design: target region must satisfy 0 ≤ start ≤ end")
+	case c.Candidates < 0 || c.MaxPairs < 0:
+		return errors.New("design: candidate and pair limits must be ≥ 0")
+	}
+	return nil
+}
+
+// Design proposes up to MaxPairs primer pairs on seq, best first.
+func Design(seq []byte, c Constraints) ([]Pair, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if c.TargetEnd > len(seq) {
+		return nil, errors.New("design: target region extends past the end of the sequence")
+	}
+	if c.Candidates == 0 {
+		c.Candidates = 200
+	}
+	if c.MaxPairs == 0 {
+		c.MaxPairs = 10
+	}
+	c.Conditions = c.Conditions.WithDefaults()
+	sopts := thermo.DefaultStructureOptions(c.Conditions)
+
+	// Binding-site windows: forward sites end before the target, reverse sites
+	// start after it, and both stay within one maximum product of it.
+	ts, te := c.TargetStart, c.TargetEnd
+	fLo, fHi := 0, len(seq)
+	rLo, rHi := 0, len(seq)
+	if te > 0 {
+		fLo, fHi = max(0, te-c.MaxProduct), ts
+		rLo, rHi = te, min(len(seq), ts+c.MaxProduct)
+	}
+	fwd := bestOligos(candidates(seq, fLo, fHi, false, c), c, sopts)
+	rev := bestOligos(candidates(seq, rLo, rHi, true, c), c, sopts)
+
+	var pairs []Pair
+	for _, f := range fwd {
+		for _, r := range rev {
+			if f.Pos+f.Len > r.Pos {
+				continue
+			}
+			n := r.Pos + r.Len - f.Pos
+			if n < c.MinProduct || n > c.MaxProduct {
+				continue
+			}
+			diff := math.Abs(f.TmC - r.TmC)
+			if c.MaxTmDiff > 0 && diff > c.MaxTmDiff {
+				continue
+			}
+			pairs = append(pairs, Pair{Forward: f, Reverse: r, ProductLen: n, Penalty: f.Penalty + r.Penalty + diff})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairLess(pairs[i], pairs[j]) })
+
+	out := make([]Pair, 0, c.MaxPairs)
+	for _, p := range pairs {
+		if len(out) == c.MaxPairs {
+			break
+		}
+		dg, ok := structureDG(thermo.BestCrossDimerV2(p.Forward.Seq, p.Reverse.Seq, sopts))
+		if !ok || dg < c.MinDimerDG {
+			continue
+		}
+		p.CrossDimerDG = dg
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// candidates enumerates oligos with binding sites inside [lo, hi) that pass
+// the sequence-only and Tm filters.
+func candidates(seq []byte, lo, hi int, reverse bool, c Constraints) []Oligo {
+	optTm := (c.MinTm + c.MaxTm) / 2
+	var out []Oligo
+	for pos := lo; pos+c.MinLen <= hi; pos++ {
+		for n := c.MinLen; n <= c.MaxLen && pos+n <= hi; n++ {
+			site := seq[pos : pos+n]
+			s := site
+			if reverse {
+				s = primer.RevComp(site)
+			}
+			gc, ok := gcFraction(s)
+			if !ok {
+				break // non-ACGT base; longer oligos from pos contain it too
+			}
+			if gc < c.MinGC || gc > c.MaxGC || !clamped(s, c.Clamp) {
+				continue
+			}
+			d, err := thermo.PerfectDuplex(string(s), complement(s), c.Conditions)
+			if err != nil || d.TmC < c.MinTm || d.TmC > c.MaxTm {
+				continue
+			}
+			out = append(out, Oligo{
+				Seq: string(s), Pos: pos, Len: n, TmC: d.TmC, GC: gc,
+				Penalty: math.Abs(d.TmC-optTm) + 10*math.Abs(gc-0.5),
+			})
+		}
+	}
+	return out
+}
+
+// bestOligos keeps the lowest-penalty oligos that pass the structure checks.
+func bestOligos(list []Oligo, c Constraints, sopts thermo.StructureOptions) []Oligo {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Penalty != list[j].Penalty {
+			return list[i].Penalty < list[j].Penalty
+		}
+		return list[i].Pos < list[j].Pos
+	})
+	out := make([]Oligo, 0, min(len(list), c.Candidates))
+	for _, o := range list {
+		if len(out) == c.Candidates {
+			break
+		}
+		hp, ok := structureDG(thermo.BestHairpinV2(o.Seq, sopts))
+		if !ok || hp < c.MinHairpinDG {
+			continue
+		}
+		sd, ok := structureDG(thermo.BestSelfDimerV2(o.Seq, sopts))
+		if !ok || sd < c.MinDimerDG {
+			continue
+		}
+		o.HairpinDG, o.SelfDimerDG = hp, sd
+		out = append(out, o)
+	}
+	return out
+}
+
+// structureDG returns the structure ΔG (0 when none forms); ok is false on
+// evaluation errors.
+func structureDG(r thermo.StructureResult, found bool, err error) (float64, bool) {
+	if err != nil {
+		return 0, false
+	}
+	if !found {
+		return 0, true
+	}
+	return r.DeltaGAtAnnealKcal, true
+}
+
+func pairLess(a, b Pair) bool {
+	if a.Penalty != b.Penalty {
+		return a.Penalty < b.Penalty
+	}
+	if a.Forward.Pos != b.Forward.Pos {
+		return a.Forward.Pos < b.Forward.Pos
+	}
+	if a.Reverse.Pos != b.Reverse.Pos {
+		return a.Reverse.Pos < b.Reverse.Pos
+	}
+	if a.Forward.Len != b.Forward.Len {
+		return a.Forward.Len < b.Forward.Len
+	}
+	return a.Reverse.Len < b.Reverse.Len
+}
+
+func gcFraction(s []byte) (float64, bool) {
+	gc := 0
+	for _, b := range s {
+		switch b {
+		case 'G', 'C':
+			gc++
+		case 'A', 'T':
+		default:
+			return 0, false
+		}
+	}
+	return float64(gc) / float64(len(s)), true
+}
+
+func clamped(s []byte, n int) bool {
+	for _, b := range s[len(s)-n:] {
+		if b != 'G' && b != 'C' {
+			return false
+		}
+	}
+	return true
+}
+
+func complement(s []byte) string {
+	out := make([]byte, len(s))
+	for i, b := range s {
+		switch b {
+		case 'A':
+			out[i] = 'T'
+		case 'C':
+			out[i] = 'G'
+		case 'G':
+			out[i] = 'C'
+		case 'T':
+			out[i] = 'A'
+		}
+	}
+	return string(out)
+}
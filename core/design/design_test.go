@@ -0,0 +1,74 @@
+package design
+
+import (
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"strings"
+	"testing"
+)
+
+func TestDesignPairsSatisfyConstraints(t *testing.T) {
+	seq := []byte(seqtest.Random(3)(1200))
+	c := DefaultConstraints()
+	c.TargetStart, c.TargetEnd = 500, 560
+	c.MaxPairs = 5
+
+	pairs, err := Design(seq, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) == 0 {
+		t.Fatal("expected at least one pair")
+	}
+	for i, p := range pairs {
+		f, r := p.Forward, p.Reverse
+		if string(seq[f.Pos:f.Pos+f.Len]) != f.Seq {
+			t.Fatalf("pair %d: forward %s does not match its site", i, f.Seq)
+		}
+		if string(primer.RevComp(seq[r.Pos:r.Pos+r.Len])) != r.Seq {
+			t.Fatalf("pair %d: reverse %s does not match its site", i, r.Seq)
+		}
+		if f.Pos+f.Len > c.TargetStart || r.Pos < c.TargetEnd {
+			t.Fatalf("pair %d overlaps the target: %+v", i, p)
+		}
+		if p.ProductLen < c.MinProduct || p.ProductLen > c.MaxProduct {
+			t.Fatalf("pair %d product %d out of range", i, p.ProductLen)
+		}
+		for _, o := range []Oligo{f, r} {
+			if o.TmC < c.MinTm || o.TmC > c.MaxTm || o.GC < c.MinGC || o.GC > c.MaxGC {
+				t.Fatalf("pair %d oligo %+v violates Tm/GC window", i, o)
+			}
+			if !strings.ContainsAny(o.Seq[len(o.Seq)-1:], "GC") {
+				t.Fatalf("pair %d oligo %s lacks 3' clamp", i, o.Seq)
+			}
+		}
+		if i > 0 && pairs[i-1].Penalty > p.Penalty {
+			t.Fatalf("pairs not ranked by penalty")
+		}
+	}
+}
+
+func TestDesignRejectsBadConstraints(t *testing.T) {
+	c := DefaultConstraints()
+	c.MinLen, c.MaxLen = 25, 18
+	if _, err := Design([]byte(seqtest.Random(1)(500)), c); err == nil {
+		t.Fatal("expected constraint error")
+	}
+	c = DefaultConstraints()
+	c.TargetStart, c.TargetEnd = 100, 900
+	if _, err := Design([]byte(seqtest.Random(1)(500)), c); err == nil {
+		t.Fatal("expected out-of-range target error")
+	}
+}
+
+func TestCandidatesSkipNonACGT(t *testing.T) {
+	seq := []byte(strings.Repeat("GCATGCATGCATGCATGCATGCATNGCATGCATGCATGC", 2))
+	c := DefaultConstraints()
+	c.MinTm, c.MaxTm = 0, 100
+	c.MinGC, c.MaxGC = 0, 1
+	for _, o := range candidates(seq, 0, len(seq), false, c) {
+		if strings.Contains(o.Seq, "N") {
+			t.Fatalf("candidate %s contains N", o.Seq)
+		}
+	}
+}
@@ -5,7 +5,7 @@ import "ipcr-core/primer"
 
 // SimulateBatchBruteForce is a deliberately slow correctness oracle for the
 // optimized SimulateBatch path. It scans every primer orientation with the
-// current full-verification matcher, then reuses joinProducts so product
+// current full-verification matcher (gapped when MaxIndels > 0), then reuses joinProducts so product
 // construction, length filtering, circular handling, mismatch-index flipping,
 // and optional site capture stay identical to the production path.
 func (e *Engine) SimulateBatchBruteForce(seqID string, seq []byte, pairs []primer.Pair) []Product {
@@ -15,18 +15,28 @@ func (e *Engine) SimulateBatchBruteForce(seqID string, seq []byte, pairs []prime
 
 	per := make([]perPair, len(pairs))
 	hc := e.cfg.HitCap
-	tw := e.cfg.TerminalWindow
 
 	for i := range pairs {
 		fwdA := []byte(pairs[i].Forward)
 		fwdB := []byte(pairs[i].Reverse)
 		rcA := primer.RevComp(fwdA)
 		rcB := primer.RevComp(fwdB)
+		l := limitsFor(e.cfg, pairs[i])
+		tw := l.tw
 
-		per[i].fwdA = primer.FindMatches(seq, fwdA, e.cfg.MaxMM, hc, tw)
-		per[i].fwdB = primer.FindMatches(seq, fwdB, e.cfg.MaxMM, hc, tw)
-		per[i].revA = filterLeftTW(primer.FindMatches(seq, rcA, e.cfg.MaxMM, hc, 0), tw)
-		per[i].revB = filterLeftTW(primer.FindMatches(seq, rcB, e.cfg.MaxMM, hc, 0), tw)
+		if e.cfg.MaxIndels > 0 {
+			gaps := e.cfg.MaxIndels
+			per[i].fwdA = primer.FindGappedMatches(seq, fwdA, l.mmA, gaps, hc, 0, tw, false)
+			per[i].fwdB = primer.FindGappedMatches(seq, fwdB, l.mmB, gaps, hc, 0, tw, false)
+			per[i].revA = primer.FindGappedMatches(seq, rcA, l.mmA, gaps, hc, tw, 0, true)
+			per[i].revB = primer.FindGappedMatches(seq, rcB, l.mmB, gaps, hc, tw, 0, true)
+			continue
+		}
+
+		per[i].fwdA = primer.FindMatches(seq, fwdA, l.mmA, hc, tw)
+		per[i].fwdB = primer.FindMatches(seq, fwdB, l.mmB, hc, tw)
+		per[i].revA = filterLeftTW(primer.FindMatches(seq, rcA, l.mmA, hc, 0), tw)
+		per[i].revB = filterLeftTW(primer.FindMatches(seq, rcB, l.mmB, hc, 0), tw)
 	}
 
 	var out []Product
@@ -88,6 +88,9 @@ type CompiledPanel struct {
 	Automaton    Automaton
 
 	Have []orientationMask
+
+	limits []pairLimits // per-pair verification limits (Cfg plus pair overrides)
+	maxMM  int          // largest mismatch limit of any orientation
 }
 
 // CompilePanel builds seed/automaton and primer-orientation state once for a
@@ -116,8 +119,18 @@ func (e *Engine) CompilePanel(pairs []primer.Pair) *CompiledPanel {
 		cp.rcB[i] = cp.appendPrimerBytes(primer.RevComp(cp.fwdBSeq(i)))
 	}
 
+	cp.limits = panelLimits(e.cfg, cp.Pairs)
+	cp.maxMM = maxLimitMM(cp.limits)
+
 	// Build deduplicated concrete A/C/G/T seed patterns and AC automaton.
-	patterns, seedHave := buildSeedPatterns(cp.Pairs, e.cfg.SeedLen, e.cfg.TerminalWindow, e.cfg.MaxMM)
+	// Gapped mode swaps the approximate neighbourhoods for pigeonhole segments.
+	var patterns []SeedPattern
+	var seedHave map[int]map[byte]bool
+	if e.cfg.MaxIndels > 0 {
+		patterns, seedHave = buildGappedSeedPatterns(cp.Pairs, e.cfg.SeedLen, cp.limits, e.cfg.MaxIndels)
+	} else {
+		patterns, seedHave = buildLimitedSeedPatterns(cp.Pairs, e.cfg.SeedLen, cp.limits)
+	}
 	cp.SeedPatterns = patterns
 	cp.Automaton, _ = buildAC(patterns)
 	cp.Have = make([]orientationMask, len(cp.Pairs))
@@ -160,10 +173,38 @@ func (e *Engine) SimulateCompiledWithScratch(seqID string, seq []byte, cp *Compi
 // dense-hit chunks. The scratch value is worker-local and must not be shared
 // concurrently.
 func (e *Engine) ForEachCompiledProduct(seqID string, seq []byte, cp *CompiledPanel, scratch *SimulationScratch, emit func(Product) error) error {
+	if cp == nil {
+		return nil
+	}
+	return e.forEachCompiledProduct(seqID, seq, cp, scratch, func(fn func(endPos, patternIdx int)) {
+		scanACEach(seq, cp.Automaton, fn)
+	}, emit)
+}
+
+// seedScanner streams (endPos, patternIdx) seed hits for one sequence, either
+// from the automaton or from a prebuilt seed index.
+type seedScanner func(fn func(endPos, patternIdx int))
+
+func (e *Engine) forEachCompiledProduct(seqID string, seq []byte, cp *CompiledPanel, scratch *SimulationScratch, scan seedScanner, emit func(Product) error) error {
 	if cp == nil || len(cp.Pairs) == 0 || emit == nil {
 		return nil
 	}
 
+	run, per := e.collectCompiled(seq, cp, scratch, scan)
+	for i := range cp.Pairs {
+		if err := run.forEachJoinedProduct(seqID, seq, cp.Pairs[i],
+			per[i].fwdA, per[i].fwdB, per[i].revA, per[i].revB, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectCompiled verifies every primer orientation of cp against seq and
+// returns the per-pair match lists (backed by scratch) together with an engine
+// bound to cp.Cfg. Joining into products, or reporting bare binding sites, is
+// left to the caller.
+func (e *Engine) collectCompiled(seq []byte, cp *CompiledPanel, scratch *SimulationScratch, scan seedScanner) (*Engine, []perPair) {
 	cfg := cp.Cfg
 	run := e
 	if e == nil || e.cfg != cfg {
@@ -175,18 +216,20 @@ func (e *Engine) ForEachCompiledProduct(seqID string, seq []byte, cp *CompiledPa
 	} else {
 		scratch.reset(len(cp.Pairs))
 	}
+	if cfg.MaxIndels > 0 {
+		collectGappedCompiled(seq, cp, scratch, scan)
+		return run, scratch.per
+	}
 	per := scratch.per
 	collectors := scratch.collectors
 
-	maxMM := cfg.MaxMM
-	tw := cfg.TerminalWindow
 	hitCap := cfg.HitCap
 
 	// HitCap truncation is order-sensitive in primer.FindMatches. With non-ACGT
 	// reset bytes, the AC pass plus halo pass can observe valid starts in a
 	// different order, so preserve capped semantics by using the full scanner in
 	// that uncommon mode. Unlimited-hit mismatch scans use local halos instead.
-	hasResetByte := maxMM > 0 && sequenceHasAutomatonReset(seq)
+	hasResetByte := cp.maxMM > 0 && sequenceHasAutomatonReset(seq)
 	forceFallback := hasResetByte && hitCap > 0
 
 	addHit := func(pairIdx int, which byte, start int) {
@@ -194,22 +237,23 @@ func (e *Engine) ForEachCompiledProduct(seqID string, seq []byte, cp *CompiledPa
 			return
 		}
 
+		l := cp.limits[pairIdx]
 		switch which {
 		case 'A':
-			collectors[pairIdx].fwdA.addVerified(seq, start, cp.fwdASeq(pairIdx), maxMM, 0, tw, hitCap)
+			collectors[pairIdx].fwdA.addVerified(seq, start, cp.fwdASeq(pairIdx), l.mmA, 0, l.tw, hitCap)
 		case 'B':
-			collectors[pairIdx].fwdB.addVerified(seq, start, cp.fwdBSeq(pairIdx), maxMM, 0, tw, hitCap)
+			collectors[pairIdx].fwdB.addVerified(seq, start, cp.fwdBSeq(pairIdx), l.mmB, 0, l.tw, hitCap)
 		case 'a':
-			collectors[pairIdx].revA.addVerified(seq, start, cp.rcASeq(pairIdx), maxMM, tw, 0, hitCap)
+			collectors[pairIdx].revA.addVerified(seq, start, cp.rcASeq(pairIdx), l.mmA, l.tw, 0, hitCap)
 		case 'b':
-			collectors[pairIdx].revB.addVerified(seq, start, cp.rcBSeq(pairIdx), maxMM, tw, 0, hitCap)
+			collectors[pairIdx].revB.addVerified(seq, start, cp.rcBSeq(pairIdx), l.mmB, l.tw, 0, hitCap)
 		}
 	}
 
 	// Verify around seed hits. If the compiled panel has no seeds, skip the
 	// otherwise pointless automaton pass and go straight to the fallback scanners.
 	if len(cp.SeedPatterns) > 0 && !cp.Automaton.empty() && !forceFallback {
-		scanACEach(seq, cp.Automaton, func(endPos, patternIdx int) {
+		scan(func(endPos, patternIdx int) {
 			pattern := cp.SeedPatterns[patternIdx]
 			// AC reports endPos as the index of the last byte of the seed pattern.
 			// SeedOffset is the seed start inside the full orientation-specific
@@ -241,27 +285,21 @@ func (e *Engine) ForEachCompiledProduct(seqID string, seq []byte, cp *CompiledPa
 		per[i].revA = collectors[i].revA.matches
 		per[i].revB = collectors[i].revB.matches
 
+		l := cp.limits[i]
 		if forceFallback || !compiledHas(cp.Have, i, 'A') {
-			per[i].fwdA = primer.FindMatches(seq, cp.fwdASeq(i), cfg.MaxMM, hitCap, tw)
+			per[i].fwdA = primer.FindMatches(seq, cp.fwdASeq(i), l.mmA, hitCap, l.tw)
 		}
 		if forceFallback || !compiledHas(cp.Have, i, 'B') {
-			per[i].fwdB = primer.FindMatches(seq, cp.fwdBSeq(i), cfg.MaxMM, hitCap, tw)
+			per[i].fwdB = primer.FindMatches(seq, cp.fwdBSeq(i), l.mmB, hitCap, l.tw)
 		}
 		if forceFallback || !compiledHas(cp.Have, i, 'a') {
-			raw := primer.FindMatches(seq, cp.rcASeq(i), cfg.MaxMM, hitCap, 0)
-			per[i].revA = filterLeftTW(raw, tw)
+			raw := primer.FindMatches(seq, cp.rcASeq(i), l.mmA, hitCap, 0)
+			per[i].revA = filterLeftTW(raw, l.tw)
 		}
 		if forceFallback || !compiledHas(cp.Have, i, 'b') {
-			raw := primer.FindMatches(seq, cp.rcBSeq(i), cfg.MaxMM, hitCap, 0)
-			per[i].revB = filterLeftTW(raw, tw)
+			raw := primer.FindMatches(seq, cp.rcBSeq(i), l.mmB, hitCap, 0)
+			per[i].revB = filterLeftTW(raw, l.tw)
 		}
 	}
-
-	for i := range cp.Pairs {
-		if err := run.forEachJoinedProduct(seqID, seq, cp.Pairs[i],
-			per[i].fwdA, per[i].fwdB, per[i].revA, per[i].revB, emit); err != nil {
-			return err
-		}
-	}
-	return nil
+	return run, per
 }
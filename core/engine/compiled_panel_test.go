@@ -258,3 +258,50 @@ func TestCompilePanelStoresPrimerOrientationsInByteSlab(t *testing.T) {
 		t.Fatalf("primerBytes length = %d, want %d", got, wantBytes)
 	}
 }
+
+func TestCompiledPanelHonoursPerPairLimits(t *testing.T) {
+	one, zero, tw := 1, 0, 2
+	circ := true
+	pairs := []primer.Pair{
+		{ID: "strict", Forward: "ACGTACGG", Reverse: "GGTACCAT", MaxMMFwd: &zero, MaxMMRev: &zero},
+		{ID: "loose", Forward: "ACGTACGG", Reverse: "GGTACCAT", MaxMMFwd: &one, MaxMMRev: &one, TerminalWindow: &tw},
+		{ID: "fwd-only", Forward: "ACGTACGG", Reverse: "GGTACCAT", MaxMMFwd: &one},
+		{ID: "ring", Forward: "ACGTACGG", Reverse: "GGTACCAT", Circular: &circ},
+	}
+	rcRev := string(primer.RevComp([]byte("GGTACCAT")))
+	seqs := map[string][]byte{
+		"exact":  []byte("TTT" + "ACGTACGG" + "AAAAAAAA" + rcRev + "TTT"),
+		"fwd-mm": []byte("TTT" + "ACCTACGG" + "AAAAAAAA" + rcRev + "TTT"),
+		"rev-mm": []byte("TTT" + "ACGTACGG" + "AAAAAAAA" + "ATGCTACC" + "TTT"),
+		"wrap":   []byte(rcRev + "AAAAAAAAAAAA" + "ACGTACGG" + "AAAAAAAA"),
+	}
+	for _, cfg := range []Config{
+		{MaxMM: 0, MinLen: 1, MaxLen: 60, SeedLen: 4},
+		{MaxMM: 2, TerminalWindow: 3, MinLen: 1, MaxLen: 60, SeedLen: 4},
+		{MaxMM: 0, MaxIndels: 1, MinLen: 1, MaxLen: 60},
+	} {
+		eng := New(cfg)
+		cp := eng.CompilePanel(pairs)
+		for seqID, seq := range seqs {
+			got := eng.SimulateCompiled(seqID, seq, cp)
+			want := eng.SimulateBatchBruteForce(seqID, seq, pairs)
+			assertProductMultisetEqual(t, got, want)
+		}
+	}
+
+	eng := New(Config{MaxMM: 0, MinLen: 1, MaxLen: 60, SeedLen: 4})
+	ids := map[string]int{}
+	for _, p := range eng.SimulateCompiled("fwd-mm", seqs["fwd-mm"], eng.CompilePanel(pairs)) {
+		ids[p.ExperimentID]++
+	}
+	if ids["strict"] != 0 || ids["loose"] == 0 || ids["fwd-only"] == 0 {
+		t.Fatalf("per-pair mismatch limits not applied: %v", ids)
+	}
+	ids = map[string]int{}
+	for _, p := range eng.SimulateCompiled("wrap", seqs["wrap"], eng.CompilePanel(pairs)) {
+		ids[p.ExperimentID]++
+	}
+	if ids["ring"] == 0 || ids["strict"] != 0 {
+		t.Fatalf("per-pair circular topology not applied: %v", ids)
+	}
+}
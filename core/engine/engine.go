@@ -16,6 +16,7 @@ type Config struct {
 	NeedSites      bool // only compute FwdSite/RevSite for pretty text
 	SeedLen        int  // seed length for multi-pattern scan (0=auto/full-length as implemented in seed.go)
 	Circular       bool // treat templates as circular if true
+	MaxIndels      int  // insertions/deletions allowed per primer site (0=ungapped)
 }
 
 // Engine runs PCR simulations with given config.
@@ -139,13 +140,19 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 		return nil
 	}
 
+	circular := limitsFor(e.cfg, p).circular
+
+	// Gapped sites can be up to MaxIndels shorter or longer than the primer,
+	// so candidate windows are widened and exact lengths re-checked below.
+	slack := e.cfg.MaxIndels
+
 	// --- A (fwd) × rc(B) => "forward"
 	revB = sortMatchesByPos(revB)
 	for _, ma := range fwdA {
-		last := len(seq) - blen
+		last := len(seq) - blen + slack
 		lo := ma.Pos + 1 // strictly to the right
 		if minL > 0 {
-			lo = ma.Pos + minL - blen
+			lo = ma.Pos + minL - blen - slack
 			if lo <= ma.Pos {
 				lo = ma.Pos + 1
 			}
@@ -155,7 +162,7 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 		}
 		hi := last
 		if maxL > 0 {
-			hi = ma.Pos + maxL - blen
+			hi = ma.Pos + maxL - blen + slack
 			if hi > last {
 				hi = last
 			}
@@ -168,7 +175,7 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 				for j := iMax; j >= iMin; j-- {
 					mb := revB[j]
 					bStart := mb.Pos
-					end := bStart + blen
+					end := bStart + siteLen(mb, blen)
 					length := end - ma.Pos
 					if (minL != 0 && length < minL) || (maxL != 0 && length > maxL) {
 						continue
@@ -176,11 +183,11 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 
 					var fwdSite, revSite string
 					if e.cfg.NeedSites {
-						if ma.Pos+alen <= len(seq) {
-							fwdSite = string(seq[ma.Pos : ma.Pos+alen])
+						if ma.Pos+siteLen(ma, alen) <= len(seq) {
+							fwdSite = string(seq[ma.Pos : ma.Pos+siteLen(ma, alen)])
 						}
-						if bStart+blen <= len(seq) {
-							revSite = string(primer.RevComp(seq[bStart : bStart+blen]))
+						if end <= len(seq) {
+							revSite = string(primer.RevComp(seq[bStart:end]))
 						}
 					}
 					if err := emit(Product{
@@ -194,6 +201,8 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 						RevMM:          mb.Mismatches,
 						FwdMismatchIdx: ma.MismatchIdx,
 						RevMismatchIdx: flip(blen, mb.MismatchIdx),
+						FwdIndels:      ma.Indels,
+						RevIndels:      primer.FlipIndels(blen, mb.Indels),
 						FwdPrimer:      p.Forward,
 						RevPrimer:      p.Reverse,
 						FwdSite:        fwdSite,
@@ -206,12 +215,12 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 		}
 
 		// Circular wrap-around: allow rev match before forward match
-		if e.cfg.Circular {
+		if circular {
 			// segment from forward to end: X; need remainder on the left to meet min/max
 			X := len(seq) - ma.Pos
 			loWrap := 0
 			if minL > 0 {
-				needed := minL - X - blen
+				needed := minL - X - blen - slack
 				if needed < 0 {
 					needed = 0
 				}
@@ -219,7 +228,7 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 			}
 			hiWrap := ma.Pos - 1
 			if maxL > 0 {
-				allowed := maxL - X - blen
+				allowed := maxL - X - blen + slack
 				if allowed < hiWrap {
 					hiWrap = allowed
 				}
@@ -233,7 +242,7 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 					if bStart >= ma.Pos {
 						continue
 					}
-					end := bStart + blen
+					end := bStart + siteLen(mb, blen)
 					length := (len(seq) - ma.Pos) + end
 					if (minL != 0 && length < minL) || (maxL != 0 && length > maxL) {
 						continue
@@ -241,8 +250,8 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 
 					var fwdSite, revSite string
 					if e.cfg.NeedSites {
-						if ma.Pos+alen <= len(seq) {
-							fwdSite = string(seq[ma.Pos : ma.Pos+alen])
+						if ma.Pos+siteLen(ma, alen) <= len(seq) {
+							fwdSite = string(seq[ma.Pos : ma.Pos+siteLen(ma, alen)])
 						}
 						if end <= len(seq) {
 							revSite = string(primer.RevComp(seq[bStart:end]))
@@ -259,6 +268,8 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 						RevMM:          mb.Mismatches,
 						FwdMismatchIdx: ma.MismatchIdx,
 						RevMismatchIdx: flip(blen, mb.MismatchIdx),
+						FwdIndels:      ma.Indels,
+						RevIndels:      primer.FlipIndels(blen, mb.Indels),
 						FwdPrimer:      p.Forward,
 						RevPrimer:      p.Reverse,
 						FwdSite:        fwdSite,
@@ -274,10 +285,10 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 	// --- B (fwd) × rc(A) => "revcomp"
 	revA = sortMatchesByPos(revA)
 	for _, mb := range fwdB {
-		last := len(seq) - alen
+		last := len(seq) - alen + slack
 		lo := mb.Pos + 1
 		if minL > 0 {
-			lo = mb.Pos + minL - alen
+			lo = mb.Pos + minL - alen - slack
 			if lo <= mb.Pos {
 				lo = mb.Pos + 1
 			}
@@ -287,7 +298,7 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 		}
 		hi := last
 		if maxL > 0 {
-			hi = mb.Pos + maxL - alen
+			hi = mb.Pos + maxL - alen + slack
 			if hi > last {
 				hi = last
 			}
@@ -299,7 +310,7 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 				for j := iMax; j >= iMin; j-- {
 					ma := revA[j]
 					aStart := ma.Pos
-					end := aStart + alen
+					end := aStart + siteLen(ma, alen)
 					length := end - mb.Pos
 					if (minL != 0 && length < minL) || (maxL != 0 && length > maxL) {
 						continue
@@ -307,11 +318,11 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 
 					var fwdSite, revSite string
 					if e.cfg.NeedSites {
-						if mb.Pos+blen <= len(seq) {
-							fwdSite = string(seq[mb.Pos : mb.Pos+blen])
+						if mb.Pos+siteLen(mb, blen) <= len(seq) {
+							fwdSite = string(seq[mb.Pos : mb.Pos+siteLen(mb, blen)])
 						}
-						if aStart+alen <= len(seq) {
-							revSite = string(primer.RevComp(seq[aStart : aStart+alen]))
+						if end <= len(seq) {
+							revSite = string(primer.RevComp(seq[aStart:end]))
 						}
 					}
 					if err := emit(Product{
@@ -325,6 +336,8 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 						RevMM:          ma.Mismatches,
 						FwdMismatchIdx: mb.MismatchIdx,
 						RevMismatchIdx: flip(alen, ma.MismatchIdx),
+						FwdIndels:      mb.Indels,
+						RevIndels:      primer.FlipIndels(alen, ma.Indels),
 						FwdPrimer:      p.Reverse,
 						RevPrimer:      p.Forward,
 						FwdSite:        fwdSite,
@@ -337,11 +350,11 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 		}
 
 		// Circular wrap-around: allow rc(A) before B forward match
-		if e.cfg.Circular {
+		if circular {
 			X := len(seq) - mb.Pos
 			loWrap := 0
 			if minL > 0 {
-				needed := minL - X - alen
+				needed := minL - X - alen - slack
 				if needed < 0 {
 					needed = 0
 				}
@@ -349,7 +362,7 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 			}
 			hiWrap := mb.Pos - 1
 			if maxL > 0 {
-				allowed := maxL - X - alen
+				allowed := maxL - X - alen + slack
 				if allowed < hiWrap {
 					hiWrap = allowed
 				}
@@ -363,7 +376,7 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 					if aStart >= mb.Pos {
 						continue
 					}
-					end := aStart + alen
+					end := aStart + siteLen(ma, alen)
 					length := (len(seq) - mb.Pos) + end
 					if (minL != 0 && length < minL) || (maxL != 0 && length > maxL) {
 						continue
@@ -371,8 +384,8 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 
 					var fwdSite, revSite string
 					if e.cfg.NeedSites {
-						if mb.Pos+blen <= len(seq) {
-							fwdSite = string(seq[mb.Pos : mb.Pos+blen])
+						if mb.Pos+siteLen(mb, blen) <= len(seq) {
+							fwdSite = string(seq[mb.Pos : mb.Pos+siteLen(mb, blen)])
 						}
 						if end <= len(seq) {
 							revSite = string(primer.RevComp(seq[aStart:end]))
@@ -389,6 +402,8 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 						RevMM:          ma.Mismatches,
 						FwdMismatchIdx: mb.MismatchIdx,
 						RevMismatchIdx: flip(alen, ma.MismatchIdx),
+						FwdIndels:      mb.Indels,
+						RevIndels:      primer.FlipIndels(alen, ma.Indels),
 						FwdPrimer:      p.Reverse,
 						RevPrimer:      p.Forward,
 						FwdSite:        fwdSite,
@@ -402,3 +417,11 @@ func (e *Engine) forEachJoinedProduct(seqID string, seq []byte, p primer.Pair,
 	}
 	return nil
 }
+
+// siteLen returns the template span of a verified primer site.
+func siteLen(m primer.Match, primerLen int) int {
+	if m.Length > 0 {
+		return m.Length
+	}
+	return primerLen
+}
@@ -74,7 +74,7 @@ func forEachNonACGTHaloStart(seqLen, primerLen int, ranges []seqRange, fn func(s
 }
 
 func scanNonACGTHalos(seq []byte, cp *CompiledPanel, tryStart func(pairIdx int, which byte, start int)) {
-	if cp == nil || cp.Cfg.MaxMM <= 0 || tryStart == nil {
+	if cp == nil || cp.maxMM <= 0 || tryStart == nil {
 		return
 	}
 
@@ -0,0 +1,163 @@
+// core/engine/indel.go
+package engine
+
+import "ipcr-core/primer"
+
+// Indel-tolerant matching (Config.MaxIndels > 0).
+//
+// Approximate seed neighbourhoods assume a fixed primer-to-template offset, so
+// they stop being complete candidate generators once gaps are allowed. Gapped
+// mode instead splits every primer orientation into MaxMM+MaxIndels+1 disjoint
+// segments: with at most that many edits, at least one segment occurs exactly
+// in the template, shifted by at most MaxIndels from its ungapped position.
+// Each segment hit therefore proposes a small band of 5'-end anchors that are
+// verified with primer.AlignGappedAt.
+
+// minGappedSeedLen is the shortest pigeonhole segment worth scanning for.
+// Shorter segments hit nearly everywhere, so those orientations use the
+// exhaustive gapped scanner instead.
+const minGappedSeedLen = 6
+
+func buildGappedSeedPatterns(pairs []primer.Pair, seedLen int, limits []pairLimits, maxIndels int) (patterns []SeedPattern, has map[int]map[byte]bool) {
+	builder := newSeedPatternBuilder(4 * len(pairs))
+	has = make(map[int]map[byte]bool, len(pairs))
+	if seedLen < 0 {
+		return nil, has
+	}
+
+	addOrientation := func(pairIdx int, which byte, pat []byte) {
+		segments := limits[pairIdx].mm(which) + maxIndels + 1
+		segLen := len(pat) / segments
+		if segLen > 32 {
+			// A sub-span of an edit-free segment is still edit-free.
+			segLen = 32
+		}
+		if segLen < minGappedSeedLen {
+			return
+		}
+
+		type segSeeds struct {
+			off      int
+			variants [][]byte
+		}
+		all := make([]segSeeds, 0, segments)
+		for s := 0; s < segments; s++ {
+			off := s * (len(pat) / segments)
+			var variants [][]byte
+			ok := enumerateSeedVariants(pat[off:off+segLen], off, len(pat), 0, 0, 0,
+				ApproxSeedMaxVariantsPerOrientation, func(seed []byte) {
+					variants = append(variants, seed)
+				})
+			if !ok {
+				return
+			}
+			all = append(all, segSeeds{off: off, variants: variants})
+		}
+
+		for _, seg := range all {
+			payload := SeedPayload{PairIdx: pairIdx, Which: which, PrimerLen: len(pat), SeedOffset: seg.off}
+			for _, seed := range seg.variants {
+				if !builder.add(seed, payload) {
+					return
+				}
+			}
+		}
+		m, ok := has[pairIdx]
+		if !ok {
+			m = make(map[byte]bool, 4)
+			has[pairIdx] = m
+		}
+		m[which] = true
+	}
+
+	for i, p := range pairs {
+		a := []byte(p.Forward)
+		b := []byte(p.Reverse)
+		addOrientation(i, 'A', a)
+		addOrientation(i, 'B', b)
+		addOrientation(i, 'a', primer.RevComp(a))
+		addOrientation(i, 'b', primer.RevComp(b))
+	}
+	return builder.patterns, has
+}
+
+// addGapped verifies one 5'-end anchor. Anchors are site starts for forward
+// orientations and exclusive site ends for reverse-complement orientations,
+// so each amplicon boundary is verified once regardless of site length.
+func (c *matchCollector) addGapped(seq []byte, anchor int, pat []byte, maxMM, maxIndels, leftTW, rightTW int, anchorRight bool, hitCap int) {
+	if hitCap > 0 && len(c.matches) >= hitCap {
+		return
+	}
+	if c.seenStart(anchor) {
+		return
+	}
+	c.markStart(anchor)
+
+	m, ok := primer.AlignGappedAt(seq, anchor, pat, maxMM, maxIndels, leftTW, rightTW, anchorRight)
+	if !ok {
+		return
+	}
+	c.matches = append(c.matches, m)
+}
+
+// collectGappedCompiled is the Config.MaxIndels > 0 counterpart of
+// collectCompiled; it fills scratch.per.
+func collectGappedCompiled(seq []byte, cp *CompiledPanel, scratch *SimulationScratch, scan seedScanner) {
+	cfg := cp.Cfg
+	per := scratch.per
+	collectors := scratch.collectors
+	maxIndels := cfg.MaxIndels
+	hitCap := cfg.HitCap
+
+	if len(cp.SeedPatterns) > 0 && !cp.Automaton.empty() {
+		scan(func(endPos, patternIdx int) {
+			pattern := cp.SeedPatterns[patternIdx]
+			segStart := endPos - (len(pattern.Pat) - 1)
+			for _, payload := range pattern.Payloads {
+				i := payload.PairIdx
+				if i < 0 || i >= len(cp.Pairs) {
+					continue
+				}
+				start := segStart - payload.SeedOffset
+				maxMM, tw := cp.limits[i].mm(payload.Which), cp.limits[i].tw
+				for delta := -maxIndels; delta <= maxIndels; delta++ {
+					switch payload.Which {
+					case 'A':
+						collectors[i].fwdA.addGapped(seq, start+delta, cp.fwdASeq(i), maxMM, maxIndels, 0, tw, false, hitCap)
+					case 'B':
+						collectors[i].fwdB.addGapped(seq, start+delta, cp.fwdBSeq(i), maxMM, maxIndels, 0, tw, false, hitCap)
+					case 'a':
+						collectors[i].revA.addGapped(seq, start+payload.PrimerLen+delta, cp.rcASeq(i), maxMM, maxIndels, tw, 0, true, hitCap)
+					case 'b':
+						collectors[i].revB.addGapped(seq, start+payload.PrimerLen+delta, cp.rcBSeq(i), maxMM, maxIndels, tw, 0, true, hitCap)
+					}
+				}
+			}
+		})
+	}
+
+	for i := range cp.Pairs {
+		per[i].fwdA = collectors[i].fwdA.matches
+		per[i].fwdB = collectors[i].fwdB.matches
+		per[i].revA = collectors[i].revA.matches
+		per[i].revB = collectors[i].revB.matches
+
+		l := cp.limits[i]
+		if !compiledHas(cp.Have, i, 'A') {
+			per[i].fwdA = primer.FindGappedMatches(seq, cp.fwdASeq(i), l.mmA, maxIndels, hitCap, 0, l.tw, false)
+		}
+		if !compiledHas(cp.Have, i, 'B') {
+			per[i].fwdB = primer.FindGappedMatches(seq, cp.fwdBSeq(i), l.mmB, maxIndels, hitCap, 0, l.tw, false)
+		}
+		if !compiledHas(cp.Have, i, 'a') {
+			per[i].revA = primer.FindGappedMatches(seq, cp.rcASeq(i), l.mmA, maxIndels, hitCap, l.tw, 0, true)
+		}
+		if !compiledHas(cp.Have, i, 'b') {
+			per[i].revB = primer.FindGappedMatches(seq, cp.rcBSeq(i), l.mmB, maxIndels, hitCap, l.tw, 0, true)
+		}
+		per[i].fwdA = primer.BestGappedMatches(per[i].fwdA, len(cp.fwdASeq(i)), maxIndels, false)
+		per[i].fwdB = primer.BestGappedMatches(per[i].fwdB, len(cp.fwdBSeq(i)), maxIndels, false)
+		per[i].revA = primer.BestGappedMatches(per[i].revA, len(cp.rcASeq(i)), maxIndels, true)
+		per[i].revB = primer.BestGappedMatches(per[i].revB, len(cp.rcBSeq(i)), maxIndels, true)
+	}
+}
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+)
+
+func TestSimulateBatchGappedSites(t *testing.T) {
+	fwd := "GATCCTAGGCTTACGGATCA"
+	rev := "TTGCCAGTCAAGCTGAACGT"
+
+	fwdSite := fwd[:12] + fwd[13:]         // template lacks primer base 12
+	revPrimed := rev[:12] + "T" + rev[12:] // template carries an extra base
+	seq := []byte("AAAAA" + fwdSite + strings.Repeat("C", 20) + string(primer.RevComp([]byte(revPrimed))) + "AAAAA")
+	pairs := []primer.Pair{{ID: "gapped", Forward: fwd, Reverse: rev}}
+
+	strict := New(Config{MaxMM: 1, TerminalWindow: 3, MinLen: 1, MaxLen: 200})
+	if got := strict.SimulateBatch("s", seq, pairs); len(got) != 0 {
+		t.Fatalf("ungapped run should not find gapped sites, got %+v", got)
+	}
+
+	eng := New(Config{MaxMM: 1, TerminalWindow: 3, MinLen: 1, MaxLen: 200, MaxIndels: 1, NeedSites: true})
+	got := eng.SimulateBatch("s", seq, pairs)
+	if len(got) != 1 {
+		t.Fatalf("expected one gapped product, got %d: %+v", len(got), got)
+	}
+	p := got[0]
+	if p.Start != 5 || p.Length != 19+20+21 || p.End != p.Start+p.Length {
+		t.Fatalf("unexpected coordinates: start=%d end=%d len=%d", p.Start, p.End, p.Length)
+	}
+	if !reflect.DeepEqual(p.FwdIndels, []primer.Indel{{Pos: 12}}) {
+		t.Fatalf("FwdIndels = %+v", p.FwdIndels)
+	}
+	if !reflect.DeepEqual(p.RevIndels, []primer.Indel{{Pos: 12, Ins: true}}) {
+		t.Fatalf("RevIndels = %+v", p.RevIndels)
+	}
+	if p.FwdSite != fwdSite || p.RevSite != revPrimed {
+		t.Fatalf("sites = %q / %q", p.FwdSite, p.RevSite)
+	}
+}
+
+func TestSimulateBatchGappedExactSiteOnce(t *testing.T) {
+	// Both primers start with a homopolymer run, so the anchors next to each
+	// exact site also align with a single gap.
+	fwd := "CCGATTCAACCTTAGCCATG"
+	rev := "GGTGACCATGATCCAAGTCA"
+	seq := []byte("TTTAT" + fwd + strings.Repeat("A", 30) + string(primer.RevComp([]byte(rev))) + "TATTT")
+	pairs := []primer.Pair{{ID: "exact", Forward: fwd, Reverse: rev}}
+
+	eng := New(Config{MaxMM: 1, TerminalWindow: 3, MinLen: 1, MaxLen: 200, MaxIndels: 1})
+	got := eng.SimulateBatch("s", seq, pairs)
+	if len(got) != 1 || got[0].Start != 5 || got[0].End != 75 || len(got[0].FwdIndels)+len(got[0].RevIndels) != 0 {
+		t.Fatalf("want the exact product 5-75 only, got %+v", got)
+	}
+}
+
+func TestSimulateBatchGappedMatchesBruteForceOracle(t *testing.T) {
+	randSeq := seqtest.Random(7)
+	rng := rand.New(rand.NewSource(7))
+	mutate := func(s string) string {
+		b := []byte(s)
+		switch pos := 3 + rng.Intn(len(b)-8); rng.Intn(3) {
+		case 0:
+			b = append(b[:pos], b[pos+1:]...)
+		case 1:
+			b = append(b[:pos], append([]byte{"ACGT"[rng.Intn(4)]}, b[pos:]...)...)
+		default:
+			b[pos] = "ACGT"[rng.Intn(4)]
+		}
+		return string(b)
+	}
+
+	for trial := 0; trial < 20; trial++ {
+		fwd, rev := randSeq(20), randSeq(22)
+		seq := randSeq(30) + mutate(fwd) + randSeq(40) + string(primer.RevComp([]byte(mutate(rev)))) + randSeq(30)
+		seq += string(primer.RevComp([]byte(seq[10:120])))
+		pairs := []primer.Pair{{ID: fmt.Sprintf("p%d", trial), Forward: fwd, Reverse: rev}}
+
+		for _, cfg := range []Config{
+			{MaxMM: 0, MaxIndels: 1, TerminalWindow: 3, MinLen: 1, MaxLen: 500},
+			{MaxMM: 1, MaxIndels: 1, TerminalWindow: 0, MinLen: 1, MaxLen: 500},
+			{MaxMM: 1, MaxIndels: 2, TerminalWindow: 2, MinLen: 1, MaxLen: 500},
+		} {
+			eng := New(cfg)
+			fast := eng.SimulateBatch("seq", []byte(seq), pairs)
+			brute := eng.SimulateBatchBruteForce("seq", []byte(seq), pairs)
+			assertProductMultisetEqual(t, fast, brute)
+			if gappedKey(fast) != gappedKey(brute) {
+				t.Fatalf("trial %d cfg %+v: indel annotations differ", trial, cfg)
+			}
+		}
+	}
+}
+
+func gappedKey(ps []Product) string {
+	keys := make([]string, 0, len(ps))
+	for _, p := range ps {
+		keys = append(keys, fmt.Sprintf("%d-%d:%v:%v", p.Start, p.End, p.FwdIndels, p.RevIndels))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ";")
+}
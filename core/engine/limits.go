@@ -0,0 +1,53 @@
+// core/engine/limits.go
+package engine
+
+import "ipcr-core/primer"
+
+// pairLimits are the verification limits of one primer pair: the run-wide
+// Config values unless the pair overrides them (extended panel TSV).
+type pairLimits struct {
+	mmA, mmB int // max mismatches of the forward (A) and reverse (B) primer
+	tw       int
+	circular bool
+}
+
+func limitsFor(cfg Config, p primer.Pair) pairLimits {
+	l := pairLimits{mmA: cfg.MaxMM, mmB: cfg.MaxMM, tw: cfg.TerminalWindow, circular: cfg.Circular}
+	if p.MaxMMFwd != nil {
+		l.mmA = *p.MaxMMFwd
+	}
+	if p.MaxMMRev != nil {
+		l.mmB = *p.MaxMMRev
+	}
+	if p.TerminalWindow != nil {
+		l.tw = *p.TerminalWindow
+	}
+	if p.Circular != nil {
+		l.circular = *p.Circular
+	}
+	return l
+}
+
+// mm is the mismatch limit of an orientation; 'a' is rc(A) and shares A's.
+func (l pairLimits) mm(which byte) int {
+	if which == 'B' || which == 'b' {
+		return l.mmB
+	}
+	return l.mmA
+}
+
+func panelLimits(cfg Config, pairs []primer.Pair) []pairLimits {
+	out := make([]pairLimits, len(pairs))
+	for i, p := range pairs {
+		out[i] = limitsFor(cfg, p)
+	}
+	return out
+}
+
+func maxLimitMM(limits []pairLimits) int {
+	m := 0
+	for _, l := range limits {
+		m = max(m, l.mmA, l.mmB)
+	}
+	return m
+}
@@ -0,0 +1,64 @@
+// core/engine/locate.go
+package engine
+
+import "sort"
+
+// SeedLocator enumerates exact occurrences of concrete A/C/G/T seed patterns in
+// one sequence, typically backed by a prebuilt on-disk k-mer index. Starts are
+// 0-based; patterns shorter than MinSeedLen cannot be looked up.
+type SeedLocator interface {
+	MinSeedLen() int
+	ForEachOccurrence(pat []byte, fn func(start int))
+}
+
+// ForEachCompiledProductIndexed is ForEachCompiledProduct with seed hits taken
+// from loc instead of a linear automaton pass over seq. Hits are replayed in
+// automaton order (by end position, longest pattern first) so candidate
+// verification, hit-cap truncation and therefore products are identical to the
+// streaming path. When any seed pattern is shorter than loc supports, the
+// automaton scan is used instead.
+func (e *Engine) ForEachCompiledProductIndexed(seqID string, seq []byte, cp *CompiledPanel, scratch *SimulationScratch, loc SeedLocator, emit func(Product) error) error {
+	if cp == nil {
+		return nil
+	}
+	if !locatorCovers(cp, loc) {
+		return e.ForEachCompiledProduct(seqID, seq, cp, scratch, emit)
+	}
+	return e.forEachCompiledProduct(seqID, seq, cp, scratch, indexedScanner(cp, loc), emit)
+}
+
+// indexedScanner replays loc's seed occurrences in automaton order.
+func indexedScanner(cp *CompiledPanel, loc SeedLocator) seedScanner {
+	return func(fn func(endPos, patternIdx int)) {
+		type hit struct{ end, idx int }
+		var hits []hit
+		for i, pattern := range cp.SeedPatterns {
+			n := len(pattern.Pat)
+			loc.ForEachOccurrence(pattern.Pat, func(start int) {
+				hits = append(hits, hit{end: start + n - 1, idx: i})
+			})
+		}
+		sort.Slice(hits, func(a, b int) bool {
+			if hits[a].end != hits[b].end {
+				return hits[a].end < hits[b].end
+			}
+			return len(cp.SeedPatterns[hits[a].idx].Pat) > len(cp.SeedPatterns[hits[b].idx].Pat)
+		})
+		for _, h := range hits {
+			fn(h.end, h.idx)
+		}
+	}
+}
+
+func locatorCovers(cp *CompiledPanel, loc SeedLocator) bool {
+	if loc == nil {
+		return false
+	}
+	k := loc.MinSeedLen()
+	for _, pattern := range cp.SeedPatterns {
+		if len(pattern.Pat) < k {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"ipcr-core/primer"
+)
+
+// naiveLocator is a brute-force SeedLocator used to check that indexed seed
+// lookups replay the automaton pass exactly.
+type naiveLocator struct {
+	seq []byte
+	k   int
+}
+
+func (l naiveLocator) MinSeedLen() int { return l.k }
+
+func (l naiveLocator) ForEachOccurrence(pat []byte, fn func(start int)) {
+	for i := 0; i+len(pat) <= len(l.seq); i++ {
+		if bytes.Equal(l.seq[i:i+len(pat)], pat) {
+			fn(i)
+		}
+	}
+}
+
+func TestForEachCompiledProductIndexedMatchesAutomaton(t *testing.T) {
+	seq := []byte("TTTACGTACGGATTCAAAAGGTACCATCGGTTTNNACGTACGGATTCAACCCGGTACCATCGGAAA")
+	pairs := []primer.Pair{{ID: "p", Forward: "ACGTACGGATTC", Reverse: "CCGATGGTACC"}}
+
+	for _, cfg := range []Config{
+		{MaxMM: 0, TerminalWindow: 3, MinLen: 1, MaxLen: 200, SeedLen: 8},
+		{MaxMM: 1, TerminalWindow: 2, MinLen: 1, MaxLen: 200, SeedLen: 10, HitCap: 1},
+		{MaxMM: 1, MaxIndels: 1, MinLen: 1, MaxLen: 200},
+	} {
+		eng := New(cfg)
+		cp := eng.CompilePanel(pairs)
+		collect := func(indexed bool) []Product {
+			var out []Product
+			emit := func(p Product) error { out = append(out, p); return nil }
+			if indexed {
+				_ = eng.ForEachCompiledProductIndexed("s", seq, cp, nil, naiveLocator{seq: seq, k: 4}, emit)
+			} else {
+				_ = eng.ForEachCompiledProduct("s", seq, cp, nil, emit)
+			}
+			return out
+		}
+		want, got := collect(false), collect(true)
+		if len(want) == 0 {
+			t.Fatalf("cfg %+v: expected products", cfg)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("cfg %+v: indexed products differ\n got: %+v\nwant: %+v", cfg, got, want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 // core/engine/product.go
 package engine
 
+import "ipcr-core/primer"
+
 type Product struct {
 	ExperimentID string `json:"experiment_id"`
 	SequenceID   string `json:"sequence_id"`
@@ -15,6 +17,27 @@ type Product struct {
 	FwdMismatchIdx []int `json:"fwd_mismatch_idx,omitempty"`
 	RevMismatchIdx []int `json:"rev_mismatch_idx,omitempty"`
 
+	// gap positions (primer 5'→3', 0-based); only set when Config.MaxIndels > 0
+	FwdIndels []primer.Indel `json:"fwd_indels,omitempty"`
+	RevIndels []primer.Indel `json:"rev_indels,omitempty"`
+
+	// soft-masked reference bases under each primer site; only set when the
+	// pipeline runs with a "report" mask policy
+	FwdMasked int `json:"fwd_masked,omitempty"`
+	RevMasked int `json:"rev_masked,omitempty"`
+
+	// converted strand ("top"/"bottom") and implied methylation state; only
+	// set when the pipeline scans bisulfite-converted templates
+	BisulfiteStrand string `json:"bisulfite_strand,omitempty"`
+	Methylation     string `json:"methylation,omitempty"`
+
+	// known variants under the primer sites; only set with a VCF
+	Variants []VariantHit `json:"variants,omitempty"`
+
+	// the alternate allele (Allele.Label) the product needs: it forms on
+	// the allele's haplotype but not on the reference; only set with a VCF
+	AltAllele string `json:"alt_allele,omitempty"`
+
 	// pretty support: primer seqs and matching target sites (in the same 5'→3' orientation)
 	FwdPrimer string `json:"-"`
 	RevPrimer string `json:"-"`
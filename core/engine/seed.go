@@ -150,6 +150,12 @@ func flattenSeedPatterns(patterns []SeedPattern) []Seed {
 }
 
 func buildSeedPatterns(pairs []primer.Pair, seedLen, terminalWindow, maxMM int) (patterns []SeedPattern, has map[int]map[byte]bool) {
+	return buildLimitedSeedPatterns(pairs, seedLen, panelLimits(Config{MaxMM: maxMM, TerminalWindow: terminalWindow}, pairs))
+}
+
+// buildLimitedSeedPatterns builds seed neighbourhoods with each pair's own
+// mismatch and terminal-window limits.
+func buildLimitedSeedPatterns(pairs []primer.Pair, seedLen int, limits []pairLimits) (patterns []SeedPattern, has map[int]map[byte]bool) {
 	builder := newSeedPatternBuilder(4 * len(pairs))
 	has = make(map[int]map[byte]bool, len(pairs))
 
@@ -167,7 +173,7 @@ func buildSeedPatterns(pairs []primer.Pair, seedLen, terminalWindow, maxMM int)
 		m[w] = true
 	}
 
-	addOrientation := func(pairIdx int, which byte, pat []byte, preferRight bool, leftTW, rightTW int) {
+	addOrientation := func(pairIdx int, which byte, pat []byte, preferRight bool, maxMM, leftTW, rightTW int) {
 		if len(pat) == 0 {
 			return
 		}
@@ -218,14 +224,16 @@ func buildSeedPatterns(pairs []primer.Pair, seedLen, terminalWindow, maxMM int)
 		ra := primer.RevComp(a)
 		rb := primer.RevComp(b)
 
+		l := limits[i]
+
 		// Forward primer orientations: protect the 3' end via rightTW.
-		addOrientation(i, 'A', a, true, 0, terminalWindow)
-		addOrientation(i, 'B', b, true, 0, terminalWindow)
+		addOrientation(i, 'A', a, true, l.mmA, 0, l.tw)
+		addOrientation(i, 'B', b, true, l.mmB, 0, l.tw)
 
 		// Reverse-complement orientations are scanned on the forward genomic
 		// strand, so the original primer's 3' end is the left side of rc(primer).
-		addOrientation(i, 'a', ra, false, terminalWindow, 0)
-		addOrientation(i, 'b', rb, false, terminalWindow, 0)
+		addOrientation(i, 'a', ra, false, l.mmA, l.tw, 0)
+		addOrientation(i, 'b', rb, false, l.mmB, l.tw, 0)
 	}
 	return builder.patterns, has
 }
@@ -0,0 +1,165 @@
+// core/engine/sites.go
+package engine
+
+import "ipcr-core/primer"
+
+// BindingSite is one primer-template binding site, reported whether or not a
+// partner primer binds within amplicon range. Sites come from the same seeded
+// candidate generation and verification as products, so every primer site that
+// could take part in a product is reported exactly once.
+type BindingSite struct {
+	ExperimentID string `json:"experiment_id"`
+	SequenceID   string `json:"sequence_id"`
+	Primer       string `json:"primer"` // "forward" or "reverse" primer of the pair
+	Strand       string `json:"strand"` // "+" when the primer matches seq as written, "-" when its reverse complement does
+	Start        int    `json:"start"`
+	End          int    `json:"end"`
+
+	// mismatch and gap positions (primer 5'→3', 0-based)
+	Mismatches  int            `json:"mismatches"`
+	MismatchIdx []int          `json:"mismatch_idx,omitempty"`
+	Indels      []primer.Indel `json:"indels,omitempty"`
+
+	Masked int `json:"masked,omitempty"` // soft-masked bases in the site ("report" mask policy)
+
+	PrimerSeq string `json:"primer_seq"`
+	Site      string `json:"site"` // template site in primer 5'→3' orientation
+
+	SourceFile string `json:"source_file"`
+}
+
+// ThreePrimeDist is the distance of the mismatch or gap closest to the primer
+// 3' end (0 = terminal base), or -1 for a perfect site.
+func (s BindingSite) ThreePrimeDist() int {
+	n := len(s.PrimerSeq)
+	best := -1
+	consider := func(pos int) {
+		if d := n - 1 - pos; d >= 0 && (best < 0 || d < best) {
+			best = d
+		}
+	}
+	for _, i := range s.MismatchIdx {
+		consider(i)
+	}
+	for _, d := range s.Indels {
+		consider(d.Pos)
+	}
+	return best
+}
+
+// ThreePrimeMismatches counts mismatches and gaps within the last n primer
+// bases.
+func (s BindingSite) ThreePrimeMismatches(n int) int {
+	cut := len(s.PrimerSeq) - n
+	count := 0
+	for _, i := range s.MismatchIdx {
+		if i >= cut {
+			count++
+		}
+	}
+	for _, d := range s.Indels {
+		if d.Pos >= cut {
+			count++
+		}
+	}
+	return count
+}
+
+// ForEachCompiledSite scans one sequence with a precompiled primer panel and
+// calls emit for every single-primer binding site instead of joining sites into
+// products. Product-length bounds and Circular do not apply. Sites are emitted
+// per pair, forward primer first, in ascending Start order per orientation.
+func (e *Engine) ForEachCompiledSite(seqID string, seq []byte, cp *CompiledPanel, scratch *SimulationScratch, emit func(BindingSite) error) error {
+	if cp == nil {
+		return nil
+	}
+	return e.forEachCompiledSite(seqID, seq, cp, scratch, func(fn func(endPos, patternIdx int)) {
+		scanACEach(seq, cp.Automaton, fn)
+	}, emit)
+}
+
+// ForEachCompiledSiteIndexed is ForEachCompiledSite with seed hits taken from
+// loc; see ForEachCompiledProductIndexed.
+func (e *Engine) ForEachCompiledSiteIndexed(seqID string, seq []byte, cp *CompiledPanel, scratch *SimulationScratch, loc SeedLocator, emit func(BindingSite) error) error {
+	if cp == nil {
+		return nil
+	}
+	if !locatorCovers(cp, loc) {
+		return e.ForEachCompiledSite(seqID, seq, cp, scratch, emit)
+	}
+	return e.forEachCompiledSite(seqID, seq, cp, scratch, indexedScanner(cp, loc), emit)
+}
+
+func (e *Engine) forEachCompiledSite(seqID string, seq []byte, cp *CompiledPanel, scratch *SimulationScratch, scan seedScanner, emit func(BindingSite) error) error {
+	if len(cp.Pairs) == 0 || emit == nil {
+		return nil
+	}
+
+	_, per := e.collectCompiled(seq, cp, scratch, scan)
+	for i, p := range cp.Pairs {
+		a, b := cp.fwdASeq(i), cp.fwdBSeq(i)
+		if err := emitSites(seqID, seq, p.ID, "forward", "+", a, per[i].fwdA, false, emit); err != nil {
+			return err
+		}
+		if err := emitSites(seqID, seq, p.ID, "forward", "-", a, per[i].revA, true, emit); err != nil {
+			return err
+		}
+		// Self pairs and other identical-primer pairs would repeat every site.
+		if p.Reverse == p.Forward {
+			continue
+		}
+		if err := emitSites(seqID, seq, p.ID, "reverse", "+", b, per[i].fwdB, false, emit); err != nil {
+			return err
+		}
+		if err := emitSites(seqID, seq, p.ID, "reverse", "-", b, per[i].revB, true, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitSites reports verified matches of one orientation. Matches of the
+// reverse-complemented primer (rc=true) are mapped back to primer 5'→3'
+// coordinates.
+func emitSites(seqID string, seq []byte, expID, which, strand string, pat []byte, ms []primer.Match, rc bool, emit func(BindingSite) error) error {
+	n := len(pat)
+	for _, m := range sortMatchesByPos(ms) {
+		end := m.Pos + siteLen(m, n)
+		if m.Pos < 0 || end > len(seq) {
+			continue
+		}
+		s := BindingSite{
+			ExperimentID: expID,
+			SequenceID:   seqID,
+			Primer:       which,
+			Strand:       strand,
+			Start:        m.Pos,
+			End:          end,
+			Mismatches:   m.Mismatches,
+			MismatchIdx:  m.MismatchIdx,
+			Indels:       m.Indels,
+			PrimerSeq:    string(pat),
+			Site:         string(seq[m.Pos:end]),
+		}
+		if rc {
+			s.MismatchIdx = flipIdx(n, m.MismatchIdx)
+			s.Indels = primer.FlipIndels(n, m.Indels)
+			s.Site = string(primer.RevComp(seq[m.Pos:end]))
+		}
+		if err := emit(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flipIdx(n int, idx []int) []int {
+	if len(idx) == 0 {
+		return nil
+	}
+	out := make([]int, len(idx))
+	for i, v := range idx {
+		out[len(idx)-1-i] = n - 1 - v
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+)
+
+func bruteForceSites(cfg Config, seq []byte, p primer.Pair) []string {
+	var out []string
+	add := func(which, strand string, pat []byte, ms []primer.Match) {
+		for _, m := range ms {
+			out = append(out, fmt.Sprintf("%s%s@%d+%d/%d", which, strand, m.Pos, siteLen(m, len(pat)), m.Mismatches))
+		}
+	}
+	a, b := []byte(p.Forward), []byte(p.Reverse)
+	tw := cfg.TerminalWindow
+	add("forward", "+", a, primer.FindMatches(seq, a, cfg.MaxMM, 0, tw))
+	add("forward", "-", a, filterLeftTW(primer.FindMatches(seq, primer.RevComp(a), cfg.MaxMM, 0, 0), tw))
+	add("reverse", "+", b, primer.FindMatches(seq, b, cfg.MaxMM, 0, tw))
+	add("reverse", "-", b, filterLeftTW(primer.FindMatches(seq, primer.RevComp(b), cfg.MaxMM, 0, 0), tw))
+	sort.Strings(out)
+	return out
+}
+
+func TestForEachCompiledSiteMatchesBruteForce(t *testing.T) {
+	randSeq := seqtest.Random(6)
+	random := func(n int) []byte { return []byte(randSeq(n)) }
+	rng := rand.New(rand.NewSource(6))
+	const alphabet = "ACGT"
+
+	for trial := 0; trial < 40; trial++ {
+		fwd, rev := random(14), random(15)
+		seq := random(400)
+		// Plant a few perfect and mutated copies on both strands.
+		for _, pat := range [][]byte{fwd, rev, primer.RevComp(fwd), primer.RevComp(rev)} {
+			at := rng.Intn(len(seq) - len(pat))
+			copy(seq[at:], pat)
+			if rng.Intn(2) == 0 {
+				seq[at+rng.Intn(len(pat))] = alphabet[rng.Intn(len(alphabet))]
+			}
+		}
+		p := primer.Pair{ID: "p", Forward: string(fwd), Reverse: string(rev)}
+
+		for _, cfg := range []Config{
+			{MaxMM: 0},
+			{MaxMM: 1, TerminalWindow: 3},
+			{MaxMM: 2},
+		} {
+			eng := New(cfg)
+			cp := eng.CompilePanel([]primer.Pair{p})
+			var got []string
+			err := eng.ForEachCompiledSite("s", seq, cp, nil, func(s BindingSite) error {
+				got = append(got, fmt.Sprintf("%s%s@%d+%d/%d", s.Primer, s.Strand, s.Start, s.End-s.Start, s.Mismatches))
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+			if want := bruteForceSites(cfg, seq, p); !reflect.DeepEqual(got, want) {
+				t.Fatalf("trial %d cfg %+v:\n got: %v\nwant: %v", trial, cfg, got, want)
+			}
+		}
+	}
+}
+
+func TestForEachCompiledSiteReverseStrandCoordinates(t *testing.T) {
+	fwd := "ACGTACGGATTC"
+	// rc(fwd) with its site mismatched at primer base 1 (second from the 5' end).
+	site := []byte(fwd)
+	site[1] = 'G'
+	seq := append([]byte("TTTT"), primer.RevComp(site)...)
+	seq = append(seq, "TTTT"...)
+
+	eng := New(Config{MaxMM: 1})
+	cp := eng.CompilePanel([]primer.Pair{{ID: "p", Forward: fwd, Reverse: fwd}})
+	var got []BindingSite
+	_ = eng.ForEachCompiledSite("s", seq, cp, nil, func(s BindingSite) error {
+		got = append(got, s)
+		return nil
+	})
+	if len(got) != 1 {
+		t.Fatalf("want one site (identical primers reported once), got %+v", got)
+	}
+	s := got[0]
+	if s.Primer != "forward" || s.Strand != "-" || s.Start != 4 || s.End != 4+len(fwd) {
+		t.Fatalf("unexpected site %+v", s)
+	}
+	if !reflect.DeepEqual(s.MismatchIdx, []int{1}) || s.Site != string(site) {
+		t.Fatalf("site not in primer orientation: %+v", s)
+	}
+	if d := s.ThreePrimeDist(); d != len(fwd)-2 {
+		t.Fatalf("ThreePrimeDist=%d", d)
+	}
+	if n := s.ThreePrimeMismatches(5); n != 0 {
+		t.Fatalf("ThreePrimeMismatches(5)=%d", n)
+	}
+}
+
+func TestForEachCompiledSiteIndexedMatchesAutomaton(t *testing.T) {
+	seq := []byte("TTTACGTACGGATTCAAAAGGTACCATCGGTTTNNACGTACGGATTCAACCCGGTACCATCGGAAA")
+	pairs := []primer.Pair{{ID: "p", Forward: "ACGTACGGATTC", Reverse: "CCGATGGTACC"}}
+	for _, cfg := range []Config{{MaxMM: 1, SeedLen: 8}, {MaxMM: 1, MaxIndels: 1}} {
+		eng := New(cfg)
+		cp := eng.CompilePanel(pairs)
+		collect := func(indexed bool) []BindingSite {
+			var out []BindingSite
+			emit := func(s BindingSite) error { out = append(out, s); return nil }
+			if indexed {
+				_ = eng.ForEachCompiledSiteIndexed("s", seq, cp, nil, naiveLocator{seq: seq, k: 4}, emit)
+			} else {
+				_ = eng.ForEachCompiledSite("s", seq, cp, nil, emit)
+			}
+			return out
+		}
+		want, got := collect(false), collect(true)
+		if len(want) == 0 {
+			t.Fatalf("cfg %+v: expected sites", cfg)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("cfg %+v: indexed sites differ\n got: %+v\nwant: %+v", cfg, got, want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// core/engine/variants.go
+package engine
+
+import (
+	"fmt"
+	"ipcr-core/primer"
+)
+
+// Allele is one alternate allele of a known variant, on the top strand in
+// record coordinates (Pos is 0-based). AF < 0 means the frequency is unknown.
+type Allele struct {
+	ID       string
+	Pos      int
+	Ref, Alt string
+	AF       float64
+}
+
+// Label names a in reports: its VCF ID, or "pos:ref>alt" (0-based pos)
+// when it has none.
+func (a Allele) Label() string {
+	if a.ID != "" {
+		return a.ID
+	}
+	return fmt.Sprintf("%d:%s>%s", a.Pos, a.Ref, a.Alt)
+}
+
+// Variant effects on primer binding.
+const (
+	EffectMismatch = "mismatch" // the alt allele breaks a primer-template pair
+	EffectMatch    = "match"    // the alt allele repairs a reference mismatch
+	EffectNeutral  = "neutral"  // pairing is the same with either allele
+	EffectIndel    = "indel"    // the alt allele shifts the site
+)
+
+// VariantHit is an allele under one of a product's primer sites.
+type VariantHit struct {
+	ID         string  `json:"id,omitempty"`
+	Pos        int     `json:"pos"`
+	Ref        string  `json:"ref"`
+	Alt        string  `json:"alt"`
+	AF         float64 `json:"af"`
+	Site       string  `json:"site"`       // "fwd" (left, FwdPrimer) or "rev" (right, RevPrimer)
+	PrimerPos  int     `json:"primer_pos"` // 0-based primer index (5'→3') of the 3'-most affected base
+	ThreePrime bool    `json:"three_prime"`
+	Effect     string  `json:"effect"`
+}
+
+// VerifyAlleles checks each allele against the primer sites of p (record
+// coordinates, linear products) and returns the hits, left site first.
+// ThreePrime marks hits within the last window primer bases. Gapped sites
+// (Indels) map positions as if ungapped.
+func VerifyAlleles(p Product, alleles []Allele, window int) []VariantHit {
+	type site struct {
+		name       string
+		start, end int
+		primer     string
+		rev        bool // the primer pairs with the bottom strand
+	}
+	left := primer.SiteLen(len(p.FwdPrimer), p.FwdIndels)
+	right := primer.SiteLen(len(p.RevPrimer), p.RevIndels)
+	sites := []site{
+		{"fwd", p.Start, p.Start + left, p.FwdPrimer, false},
+		{"rev", p.End - right, p.End, p.RevPrimer, true},
+	}
+	var out []VariantHit
+	for _, s := range sites {
+		n := len(s.primer)
+		idx := func(q int) int {
+			i := q - s.start
+			if s.rev {
+				i = s.end - 1 - q
+			}
+			return min(max(i, 0), n-1)
+		}
+		for _, a := range alleles {
+			pos, ref, alt := trimAllele(a)
+			if ref == "" && alt == "" {
+				continue // no change
+			}
+			var lo, hi int // affected top-strand positions [lo, hi)
+			if ref == "" {
+				// insertion between pos-1 and pos
+				if pos <= s.start || pos >= s.end {
+					continue
+				}
+				lo, hi = pos-1, pos+1
+			} else {
+				lo, hi = max(pos, s.start), min(pos+len(ref), s.end)
+				if lo >= hi {
+					continue
+				}
+			}
+			h := VariantHit{ID: a.ID, Pos: a.Pos, Ref: a.Ref, Alt: a.Alt, AF: a.AF, Site: s.name, PrimerPos: -1, Effect: EffectNeutral}
+			for q := lo; q < hi; q++ {
+				h.PrimerPos = max(h.PrimerPos, idx(q))
+			}
+			h.ThreePrime = window > 0 && h.PrimerPos >= n-window
+			if len(ref) != len(alt) {
+				h.Effect = EffectIndel
+				out = append(out, h)
+				continue
+			}
+			broken, repaired := false, false
+			for q := lo; q < hi; q++ {
+				r, x := ref[q-pos], alt[q-pos]
+				if r == x {
+					continue
+				}
+				if s.rev {
+					r, x = primer.Complement(r), primer.Complement(x)
+				}
+				pb := s.primer[idx(q)]
+				refOK, altOK := primer.BaseMatch(r, pb), primer.BaseMatch(x, pb)
+				broken = broken || (refOK && !altOK)
+				repaired = repaired || (!refOK && altOK)
+			}
+			switch {
+			case broken:
+				h.Effect = EffectMismatch
+			case repaired:
+				h.Effect = EffectMatch
+			}
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// trimAllele drops the bases ref and alt share at either end (VCF pads
+// indels with an anchor base) and returns the position of what remains.
+func trimAllele(a Allele) (int, string, string) {
+	pos, ref, alt := a.Pos, a.Ref, a.Alt
+	for len(ref) > 0 && len(alt) > 0 && ref[len(ref)-1] == alt[len(alt)-1] {
+		ref, alt = ref[:len(ref)-1], alt[:len(alt)-1]
+	}
+	for len(ref) > 0 && len(alt) > 0 && ref[0] == alt[0] {
+		ref, alt, pos = ref[1:], alt[1:], pos+1
+	}
+	return pos, ref, alt
+}
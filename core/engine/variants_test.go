@@ -0,0 +1,37 @@
+package engine
+
+import "testing"
+
+func TestVerifyAlleles(t *testing.T) {
+	p := Product{Start: 10, End: 60, FwdPrimer: "ACGTACGTAC", RevPrimer: "GGCCAATTGG"}
+	alleles := []Allele{
+		{ID: "rs1", Pos: 19, Ref: "C", Alt: "T", AF: 0.2},  // fwd 3' base
+		{ID: "rs2", Pos: 12, Ref: "A", Alt: "G", AF: -1},   // repairs a fwd mismatch
+		{ID: "rs3", Pos: 50, Ref: "C", Alt: "A", AF: 0.01}, // rev 3' base (bottom strand)
+		{ID: "rs4", Pos: 54, Ref: "AT", Alt: "A", AF: 0.5}, // deletion of 55
+		{ID: "rs5", Pos: 30, Ref: "G", Alt: "A", AF: 0.9},  // insert, not a site
+		{ID: "rs6", Pos: 9, Ref: "G", Alt: "GA", AF: 0.9},  // insertion just before the site
+		{ID: "rs7", Pos: 14, Ref: "C", Alt: "G", AF: 0.1},  // mismatched either way
+	}
+	got := VerifyAlleles(p, alleles, 3)
+	want := []struct {
+		id, site, effect string
+		pos              int
+		three            bool
+	}{
+		{"rs1", "fwd", EffectMismatch, 9, true},
+		{"rs2", "fwd", EffectMatch, 2, false},
+		{"rs7", "fwd", EffectNeutral, 4, false},
+		{"rs3", "rev", EffectMismatch, 9, true},
+		{"rs4", "rev", EffectIndel, 4, false},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d hits, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.ID != w.id || g.Site != w.site || g.Effect != w.effect || g.PrimerPos != w.pos || g.ThreePrime != w.three {
+			t.Errorf("hit %d: got %+v, want %+v", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// core/fasta/ids.go
+package fasta
+
+import (
+	"bufio"
+	"io"
+)
+
+// RecordIDs lists the record IDs of a FASTA file (gzip and "-" as for
+// streaming) without keeping any sequence.
+func RecordIDs(path string) ([]string, error) {
+	rc, err := openReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	br := bufio.NewReaderSize(rc, 64*1024)
+	var ids []string
+	lineStart := true
+	for {
+		line, err := br.ReadSlice('\n')
+		if lineStart && len(line) > 0 && line[0] == '>' {
+			ids = append(ids, parseHeaderID(line[1:]))
+		}
+		lineStart = err != bufio.ErrBufferFull
+		switch {
+		case err == io.EOF:
+			return ids, nil
+		case err != nil && err != bufio.ErrBufferFull:
+			return nil, err
+		}
+	}
+}
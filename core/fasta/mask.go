@@ -0,0 +1,88 @@
+// core/fasta/mask.go
+package fasta
+
+import "math/bits"
+
+// Mask marks the soft-masked (lowercase in the FASTA file) bases of a record,
+// one bit per base of Seq. The zero Mask marks nothing. Slices share storage
+// with the mask they were cut from.
+type Mask struct {
+	bits []uint64
+	off  int // bit index of base 0
+	n    int
+}
+
+// Len is the number of bases the mask covers.
+func (m Mask) Len() int { return m.n }
+
+// Any reports whether at least one base may be masked. It is false for masks
+// built from sequence without lowercase bases.
+func (m Mask) Any() bool { return m.bits != nil }
+
+// Has reports whether base i is masked.
+func (m Mask) Has(i int) bool {
+	if m.bits == nil || i < 0 || i >= m.n {
+		return false
+	}
+	j := m.off + i
+	return m.bits[j>>6]>>(uint(j)&63)&1 == 1
+}
+
+// Count returns the number of masked bases in [start,end).
+func (m Mask) Count(start, end int) int {
+	start, end = max(start, 0), min(end, m.n)
+	if m.bits == nil || start >= end {
+		return 0
+	}
+	n := 0
+	for j := m.off + start; j < m.off+end; {
+		w := m.bits[j>>6] >> (uint(j) & 63)
+		take := min(64-j&63, m.off+end-j)
+		if take < 64 {
+			w &= 1<<uint(take) - 1
+		}
+		n += bits.OnesCount64(w)
+		j += take
+	}
+	return n
+}
+
+// Slice returns the mask of bases [start,end).
+func (m Mask) Slice(start, end int) Mask {
+	return Mask{bits: m.bits, off: m.off + start, n: end - start}
+}
+
+// push appends one base. The mask must own its storage (off == 0); bit
+// storage is only allocated once a masked base is seen.
+func (m *Mask) push(masked bool) {
+	if masked && m.bits == nil {
+		m.bits = make([]uint64, m.n>>6+1, 1024)
+	}
+	if m.bits != nil {
+		if m.n>>6 >= len(m.bits) {
+			m.bits = append(m.bits, 0)
+		}
+		if masked {
+			m.bits[m.n>>6] |= 1 << (uint(m.n) & 63)
+		}
+	}
+	m.n++
+}
+
+// appendMask returns a copy of m followed by o, in fresh storage.
+func appendMask(m, o Mask) Mask {
+	var out Mask
+	if !m.Any() && !o.Any() {
+		out.n = m.n + o.n
+		return out
+	}
+	for _, src := range []Mask{m, o} {
+		for i := 0; i < src.n; i++ {
+			out.push(src.Has(i))
+		}
+	}
+	return out
+}
+
+// clone returns a copy of m in fresh storage.
+func (m Mask) clone() Mask { return appendMask(m, Mask{}) }
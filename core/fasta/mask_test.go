@@ -0,0 +1,111 @@
+package fasta
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// maskString renders a mask as the lowercase/uppercase pattern of seq.
+func maskString(seq []byte, m Mask) string {
+	var b strings.Builder
+	for i, c := range seq {
+		if m.Has(i) {
+			c += 'a' - 'A'
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func TestMaskCountAndSlice(t *testing.T) {
+	rng := rand.New(rand.NewSource(9))
+	var m Mask
+	var ref []bool
+	for i := 0; i < 300; i++ {
+		v := rng.Intn(3) == 0
+		m.push(v)
+		ref = append(ref, v)
+	}
+	for trial := 0; trial < 200; trial++ {
+		a := rng.Intn(len(ref))
+		b := a + rng.Intn(len(ref)-a+1)
+		want := 0
+		for _, v := range ref[a:b] {
+			if v {
+				want++
+			}
+		}
+		if got := m.Count(a, b); got != want {
+			t.Fatalf("Count(%d,%d)=%d want %d", a, b, got, want)
+		}
+		s := m.Slice(a, b)
+		if got := s.Count(0, s.Len()); got != want {
+			t.Fatalf("Slice(%d,%d).Count=%d want %d", a, b, got, want)
+		}
+		if s.Len() > 0 && s.Has(0) != ref[a] {
+			t.Fatalf("Slice(%d,%d).Has(0) mismatch", a, b)
+		}
+	}
+
+	var clean Mask
+	clean.push(false)
+	clean.push(false)
+	if clean.Any() || clean.Len() != 2 || clean.Count(0, 2) != 0 {
+		t.Fatalf("unmasked sequence should not allocate: %+v", clean)
+	}
+}
+
+func TestStreamMaskedChunksPathCtx(t *testing.T) {
+	const src = "ACgtaC\nGTacgTAC\n"
+	fn := filepath.Join(t.TempDir(), "masked.fa")
+	if err := os.WriteFile(fn, []byte(">s\n"+src), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	collect := func(chunk, overlap int) []string {
+		var out []string
+		err := StreamMaskedChunksPathCtx(context.Background(), fn, chunk, overlap, func(r Record) error {
+			out = append(out, r.ID+"="+maskString(r.Seq, r.Mask))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	if got := collect(0, 0); len(got) != 1 || got[0] != "s=ACgtaCGTacgTAC" {
+		t.Fatalf("whole record: %v", got)
+	}
+	want := []string{"s:0-6=ACgtaC", "s:4-10=aCGTac", "s:8-14=acgTAC"}
+	got := collect(6, 2)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("chunks: got %v want %v", got, want)
+	}
+
+	// The plain stream does not track masks.
+	_ = StreamChunksPathCtx(context.Background(), fn, 0, 0, func(r Record) error {
+		if r.Mask.Any() || r.Mask.Len() != 0 {
+			t.Fatalf("unexpected mask %+v", r.Mask)
+		}
+		return nil
+	})
+}
+
+func TestStreamChunksCtxWrapCarriesMask(t *testing.T) {
+	var wrap RecordChunk
+	err := StreamChunksCtx(context.Background(), strings.NewReader(">c\nacGTAcgt\n"), 5, true, func(ch RecordChunk) error {
+		if ch.IsWrap {
+			wrap = ch
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := maskString(wrap.Seq, wrap.Mask); got != "TAcgt" {
+		t.Fatalf("wrap chunk mask %q", got)
+	}
+}
@@ -12,3 +12,21 @@ func appendNormalizedSeqLine(dst, line []byte) []byte {
 	}
 	return dst
 }
+
+// appendMaskedSeqLine is appendNormalizedSeqLine that also records which
+// bases were lowercase (soft-masked) in mask. A nil mask records nothing.
+func appendMaskedSeqLine(dst []byte, mask *Mask, line []byte) []byte {
+	if mask == nil {
+		return appendNormalizedSeqLine(dst, line)
+	}
+	line = bytes.TrimSpace(line)
+	for _, b := range line {
+		lower := b >= 'a' && b <= 'z'
+		if lower {
+			b -= 'a' - 'A'
+		}
+		dst = append(dst, b)
+		mask.push(lower)
+	}
+	return dst
+}
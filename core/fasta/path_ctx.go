@@ -21,6 +21,27 @@ func StreamChunksPathCtx(
 	path string,
 	chunkSize, overlap int,
 	emit func(Record) error,
+) error {
+	return streamChunksPath(ctx, path, chunkSize, overlap, false, emit)
+}
+
+// StreamMaskedChunksPathCtx is StreamChunksPathCtx that also fills Record.Mask
+// with the soft-masked (lowercase) bases of each record or chunk.
+func StreamMaskedChunksPathCtx(
+	ctx context.Context,
+	path string,
+	chunkSize, overlap int,
+	emit func(Record) error,
+) error {
+	return streamChunksPath(ctx, path, chunkSize, overlap, true, emit)
+}
+
+func streamChunksPath(
+	ctx context.Context,
+	path string,
+	chunkSize, overlap int,
+	keepMask bool,
+	emit func(Record) error,
 ) error {
 	if overlap < 0 {
 		overlap = 0
@@ -32,25 +53,30 @@ func StreamChunksPathCtx(
 	defer func() { _ = rc.Close() }()
 
 	if chunkSize <= 0 || overlap >= chunkSize {
-		return streamWholeRecords(ctx, rc, emit)
+		return streamWholeRecords(ctx, rc, keepMask, emit)
 	}
-	return streamRollingChunks(ctx, rc, chunkSize, overlap, emit)
+	return streamRollingChunks(ctx, rc, chunkSize, overlap, keepMask, emit)
 }
 
 func streamWholeRecords(ctx context.Context, r interface {
 	Read([]byte) (int, error)
-}, emit func(Record) error,
+}, keepMask bool, emit func(Record) error,
 ) error {
 	var (
-		id  string
-		seq = make([]byte, 0, 1<<20)
+		id   string
+		seq  = make([]byte, 0, 1<<20)
+		mask *Mask
 	)
 
 	flush := func() error {
 		if id == "" {
 			return nil
 		}
-		if err := emit(Record{ID: id, Seq: append([]byte(nil), seq...)}); err != nil {
+		rec := Record{ID: id, Seq: append([]byte(nil), seq...)}
+		if mask != nil {
+			rec.Mask = *mask
+		}
+		if err := emit(rec); err != nil {
 			return err
 		}
 		return nil
@@ -64,13 +90,16 @@ func streamWholeRecords(ctx context.Context, r interface {
 			}
 			id = parseHeaderID(header)
 			seq = seq[:0]
+			if keepMask {
+				mask = &Mask{} // handed over with the record, never reused
+			}
 			return nil
 		},
 		func(line []byte) error {
 			if id == "" {
 				return nil
 			}
-			seq = appendNormalizedSeqLine(seq, line)
+			seq = appendMaskedSeqLine(seq, mask, line)
 			return nil
 		},
 	)
@@ -82,16 +111,17 @@ func streamWholeRecords(ctx context.Context, r interface {
 
 func streamRollingChunks(ctx context.Context, r interface {
 	Read([]byte) (int, error)
-}, chunkSize, overlap int, emit func(Record) error,
+}, chunkSize, overlap int, keepMask bool, emit func(Record) error,
 ) error {
 	step := chunkSize - overlap
 	if step <= 0 {
-		return streamWholeRecords(ctx, r, emit)
+		return streamWholeRecords(ctx, r, keepMask, emit)
 	}
 
 	var (
 		id             string
 		window         = make([]byte, 0, chunkSize+1)
+		mask           *Mask // parallel to window when keepMask
 		windowStart    int
 		totalLen       int
 		lastEmittedEnd int
@@ -101,6 +131,9 @@ func streamRollingChunks(ctx context.Context, r interface {
 	reset := func(newID string) {
 		id = newID
 		window = window[:0]
+		if keepMask {
+			mask = &Mask{}
+		}
 		windowStart = 0
 		totalLen = 0
 		lastEmittedEnd = 0
@@ -114,7 +147,11 @@ func streamRollingChunks(ctx context.Context, r interface {
 		default:
 		}
 		chID := fmt.Sprintf("%s:%d-%d", id, start, end)
-		if err := emit(Record{ID: chID, Seq: append([]byte(nil), seq...)}); err != nil {
+		rec := Record{ID: chID, Seq: append([]byte(nil), seq...)}
+		if mask != nil {
+			rec.Mask = mask.Slice(0, len(seq)).clone()
+		}
+		if err := emit(rec); err != nil {
 			return err
 		}
 		lastEmittedEnd = end
@@ -127,7 +164,11 @@ func streamRollingChunks(ctx context.Context, r interface {
 			return nil
 		}
 		if !emittedChunk {
-			if err := emit(Record{ID: id, Seq: append([]byte(nil), window...)}); err != nil {
+			rec := Record{ID: id, Seq: append([]byte(nil), window...)}
+			if mask != nil {
+				rec.Mask = *mask
+			}
+			if err := emit(rec); err != nil {
 				return err
 			}
 			return nil
@@ -143,7 +184,7 @@ func streamRollingChunks(ctx context.Context, r interface {
 			return nil
 		}
 		before := len(window)
-		window = appendNormalizedSeqLine(window, line)
+		window = appendMaskedSeqLine(window, mask, line)
 		totalLen += len(window) - before
 
 		for len(window) > chunkSize {
@@ -156,6 +197,9 @@ func streamRollingChunks(ctx context.Context, r interface {
 				copy(window, window[step:])
 				window = window[:len(window)-step]
 			}
+			if mask != nil {
+				*mask = mask.Slice(min(step, mask.n), mask.n).clone()
+			}
 			windowStart += step
 		}
 		return nil
@@ -7,8 +7,9 @@ import (
 
 // Record represents a parsed FASTA sequence (or a chunk of one).
 type Record struct {
-	ID  string
-	Seq []byte
+	ID   string
+	Seq  []byte
+	Mask Mask // soft-masked bases; only filled by the masked streaming functions
 }
 
 // StreamChunksCtxPath is the ctx-aware channel wrapper around StreamChunksPathCtx.
@@ -133,3 +133,16 @@ func TestStreamChunksPathCtx_ShortRecordKeepsBaseID(t *testing.T) {
 		t.Fatalf("unexpected chunks: %+v", got)
 	}
 }
+
+func TestRecordIDs(t *testing.T) {
+	gzPath := writeGz(t, plain+">seq3 description\n"+strings.Repeat("A", 100000)+"\n>seq4")
+	defer func() { _ = os.Remove(gzPath) }()
+
+	ids, err := RecordIDs(gzPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(ids, ",") != "seq1,seq2,seq3,seq4" {
+		t.Fatalf("ids = %v", ids)
+	}
+}
@@ -13,6 +13,7 @@ type RecordChunk struct {
 	RecordID string
 	Offset   int
 	Seq      []byte
+	Mask     Mask // soft-masked (lowercase) bases of Seq
 	IsWrap   bool
 	IsLast   bool
 }
@@ -25,8 +26,9 @@ type RecordChunk struct {
 // records are not limited by Scanner's token size.
 func StreamChunksCtx(ctx context.Context, r io.Reader, chunkSize int, circular bool, emit func(RecordChunk) error) error {
 	var (
-		id  string
-		seq = make([]byte, 0, 1<<20)
+		id   string
+		seq  = make([]byte, 0, 1<<20)
+		mask = &Mask{}
 	)
 
 	flush := func() error {
@@ -34,7 +36,7 @@ func StreamChunksCtx(ctx context.Context, r io.Reader, chunkSize int, circular b
 			return nil
 		}
 		if chunkSize <= 0 || chunkSize >= len(seq) {
-			if err := emit(RecordChunk{RecordID: id, Offset: 0, Seq: append([]byte(nil), seq...), IsWrap: false, IsLast: true}); err != nil {
+			if err := emit(RecordChunk{RecordID: id, Offset: 0, Seq: append([]byte(nil), seq...), Mask: *mask, IsWrap: false, IsLast: true}); err != nil {
 				return err
 			}
 			return nil
@@ -48,6 +50,7 @@ func StreamChunksCtx(ctx context.Context, r io.Reader, chunkSize int, circular b
 				RecordID: id,
 				Offset:   off,
 				Seq:      append([]byte(nil), seq[off:end]...),
+				Mask:     mask.Slice(off, end),
 				IsWrap:   false,
 				IsLast:   false,
 			}
@@ -71,6 +74,7 @@ func StreamChunksCtx(ctx context.Context, r io.Reader, chunkSize int, circular b
 					RecordID: id,
 					Offset:   0,
 					Seq:      wrap,
+					Mask:     appendMask(*mask, mask.Slice(0, min(repeat, len(seq)))),
 					IsWrap:   true,
 					IsLast:   true,
 				}); err != nil {
@@ -83,6 +87,7 @@ func StreamChunksCtx(ctx context.Context, r io.Reader, chunkSize int, circular b
 					RecordID: id,
 					Offset:   start,
 					Seq:      wrap,
+					Mask:     appendMask(mask.Slice(start, len(seq)), mask.Slice(0, chunkSize-(len(seq)-start))),
 					IsWrap:   true,
 					IsLast:   true,
 				}); err != nil {
@@ -101,13 +106,14 @@ func StreamChunksCtx(ctx context.Context, r io.Reader, chunkSize int, circular b
 			}
 			id = parseHeaderID(header)
 			seq = seq[:0]
+			mask = &Mask{}
 			return nil
 		},
 		func(line []byte) error {
 			if id == "" {
 				return nil
 			}
-			seq = appendNormalizedSeqLine(seq, line)
+			seq = appendMaskedSeqLine(seq, mask, line)
 			return nil
 		},
 	)
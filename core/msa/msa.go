@@ -0,0 +1,222 @@
+// core/msa/msa.go
+
+// Package msa reads multiple sequence alignments: aligned FASTA, Clustal
+// and Stockholm.
+package msa
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Alignment formats.
+const (
+	FormatAuto      = "auto"
+	FormatFASTA     = "fasta"
+	FormatClustal   = "clustal"
+	FormatStockholm = "stockholm"
+)
+
+// Alignment is a set of equal-length rows. Residues are uppercase with U
+// read as T; every gap character ('-', '.', '~') is stored as '-'.
+type Alignment struct {
+	Names []string
+	Rows  [][]byte
+}
+
+// Columns returns the alignment width.
+func (a *Alignment) Columns() int {
+	if len(a.Rows) == 0 {
+		return 0
+	}
+	return len(a.Rows[0])
+}
+
+// Load reads an alignment from path ("-" for standard input; gzip when it
+// ends in ".gz"). format is one of the Format constants.
+func Load(path, format string) (*Alignment, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+		if strings.HasSuffix(path, ".gz") {
+			gr, err := gzip.NewReader(f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			defer func() { _ = gr.Close() }()
+			r = gr
+		}
+	}
+	a, err := Read(r, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return a, nil
+}
+
+// Read parses an alignment. FormatAuto picks the format from the first
+// non-blank line: '>' for FASTA, "CLUSTAL" (or MUSCLE's "MUSCLE") for
+// Clustal and "# STOCKHOLM" for Stockholm.
+func Read(r io.Reader, format string) (*Alignment, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, strings.TrimRight(sc.Text(), "\r"))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if format == FormatAuto {
+		format = detect(lines)
+	}
+	var (
+		a   *Alignment
+		err error
+	)
+	switch format {
+	case FormatFASTA:
+		a, err = readFASTA(lines)
+	case FormatClustal:
+		a, err = readBlocks(lines, func(s string) bool { return s[0] == ' ' || s[0] == '\t' })
+	case FormatStockholm:
+		a, err = readStockholm(lines)
+	case "":
+		return nil, errors.New("unrecognized alignment format (want aligned FASTA, Clustal or Stockholm)")
+	default:
+		return nil, fmt.Errorf("unknown alignment format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, a.check()
+}
+
+func detect(lines []string) string {
+	for _, l := range lines {
+		s := strings.TrimSpace(l)
+		switch {
+		case s == "":
+			continue
+		case s[0] == '>':
+			return FormatFASTA
+		case strings.HasPrefix(s, "CLUSTAL") || strings.HasPrefix(s, "MUSCLE"):
+			return FormatClustal
+		case strings.HasPrefix(s, "# STOCKHOLM"):
+			return FormatStockholm
+		}
+		return ""
+	}
+	return ""
+}
+
+func readFASTA(lines []string) (*Alignment, error) {
+	a := &Alignment{}
+	for i, l := range lines {
+		s := strings.TrimSpace(l)
+		switch {
+		case s == "" || s[0] == ';':
+		case s[0] == '>':
+			name := strings.TrimSpace(s[1:])
+			if f := strings.Fields(name); len(f) > 0 {
+				name = f[0]
+			}
+			a.Names = append(a.Names, name)
+			a.Rows = append(a.Rows, nil)
+		case len(a.Rows) == 0:
+			return nil, fmt.Errorf("line %d: sequence before the first '>' header", i+1)
+		default:
+			n := len(a.Rows) - 1
+			a.Rows[n] = appendResidues(a.Rows[n], s)
+		}
+	}
+	return a, nil
+}
+
+// readBlocks reads interleaved "name residues [count]" lines, appending
+// each name's residues in first-seen order. The header line and lines
+// skip reports true for are ignored.
+func readBlocks(lines []string, skip func(string) bool) (*Alignment, error) {
+	a := &Alignment{}
+	idx := make(map[string]int)
+	header := true
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" || skip(l) {
+			continue
+		}
+		if header {
+			header = false
+			continue
+		}
+		f := strings.Fields(l)
+		if len(f) < 2 {
+			continue // conservation line of a block without leading spaces
+		}
+		i, ok := idx[f[0]]
+		if !ok {
+			i = len(a.Rows)
+			idx[f[0]] = i
+			a.Names = append(a.Names, f[0])
+			a.Rows = append(a.Rows, nil)
+		}
+		a.Rows[i] = appendResidues(a.Rows[i], f[1])
+	}
+	return a, nil
+}
+
+// readStockholm reads the first alignment of a Stockholm file; markup
+// ("#=GF", "#=GC", ...) is ignored.
+func readStockholm(lines []string) (*Alignment, error) {
+	for i, l := range lines {
+		if strings.TrimSpace(l) == "//" {
+			lines = lines[:i]
+			break
+		}
+	}
+	// readBlocks drops the first kept line as a header, so keep the
+	// "# STOCKHOLM" line for it.
+	return readBlocks(lines, func(s string) bool {
+		return s[0] == '#' && !strings.HasPrefix(s, "# STOCKHOLM")
+	})
+}
+
+func appendResidues(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '-' || c == '.' || c == '~':
+			c = '-'
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c == ' ' || c == '\t' || (c >= '0' && c <= '9'):
+			continue
+		}
+		if c == 'U' {
+			c = 'T'
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+func (a *Alignment) check() error {
+	if len(a.Rows) == 0 {
+		return errors.New("no aligned sequences")
+	}
+	for i, r := range a.Rows {
+		if len(r) != len(a.Rows[0]) {
+			return fmt.Errorf("sequence %q is %d columns wide, %q is %d; not an alignment", a.Names[i], len(r), a.Names[0], len(a.Rows[0]))
+		}
+	}
+	return nil
+}
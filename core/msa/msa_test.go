@@ -0,0 +1,45 @@
+package msa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadFormats(t *testing.T) {
+	inputs := map[string]string{
+		FormatFASTA: ">s1 first\nacgu-ACG\nT\n>s2\nACG--ACGA\n",
+		FormatClustal: "CLUSTAL W (1.83) multiple sequence alignment\n\n" +
+			"s1      ACGT-A 5\ns2      ACG--A 4\n        *** .*\n\n" +
+			"s1      CGT 8\ns2      CGA 7\n",
+		FormatStockholm: "# STOCKHOLM 1.0\n#=GF ID test\ns1 ACGT.A\ns2 ACG..A\n#=GC SS_cons ......\n\n" +
+			"s1 CGT\ns2 CGA\n//\n",
+	}
+	for format, in := range inputs {
+		for _, f := range []string{format, FormatAuto} {
+			a, err := Read(strings.NewReader(in), f)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", format, f, err)
+			}
+			if strings.Join(a.Names, ",") != "s1,s2" || string(a.Rows[0]) != "ACGT-ACGT" || string(a.Rows[1]) != "ACG--ACGA" || a.Columns() != 9 {
+				t.Fatalf("%s/%s: %q %q", format, f, a.Names, a.Rows)
+			}
+		}
+	}
+}
+
+func TestReadRejectsBadInput(t *testing.T) {
+	for name, in := range map[string]string{
+		"ragged":   ">s1\nACGT\n>s2\nACG\n",
+		"unknown":  "id\tseq\n",
+		"empty":    "",
+		"headless": "ACGT\n>s1\nACGT\n",
+	} {
+		f := FormatAuto
+		if name == "headless" {
+			f = FormatFASTA
+		}
+		if _, err := Read(strings.NewReader(in), f); err == nil {
+			t.Fatalf("%s accepted", name)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// core/multiplex/dimers.go
+
+// Package multiplex analyses primer pools: pairwise dimer matrices over every
+// oligo of a panel and assay-to-pool partitioning that keeps interacting
+// assays apart.
+package multiplex
+
+import (
+	"fmt"
+	"ipcr-core/primer"
+	"ipcr-core/thermo"
+)
+
+// Oligo is one panel oligo: a primer or probe of an assay.
+type Oligo struct {
+	ID    string // "<assay>.fwd", "<assay>.rev" or the probe name
+	Assay string
+	Role  string // "fwd" | "rev" | "probe"
+	Seq   string // 5'→3'
+}
+
+// Oligos lists the primers and probes of pairs in panel order. A primer
+// shared by several rows is listed once per row so the matrix stays aligned
+// with assays.
+func Oligos(pairs []primer.Pair) []Oligo {
+	var out []Oligo
+	for _, p := range pairs {
+		out = append(out,
+			Oligo{ID: p.ID + ".fwd", Assay: p.ID, Role: "fwd", Seq: p.Forward},
+			Oligo{ID: p.ID + ".rev", Assay: p.ID, Role: "rev", Seq: p.Reverse},
+		)
+		for _, pr := range p.Probes {
+			out = append(out, Oligo{ID: pr.Name, Assay: p.ID, Role: "probe", Seq: pr.Seq})
+		}
+	}
+	return out
+}
+
+// Dimer is the most stable duplex found between two oligos (or an oligo and
+// itself on the diagonal). Found is false when no stem reaches the minimum.
+type Dimer struct {
+	Found        bool
+	DeltaGKcal   float64 // at the annealing temperature
+	TmC          float64
+	ThreePrime   bool // a 3' end sits in the stem (extensible dimer)
+	BothThreeEnd bool
+	AVariant     string // IUPAC expansion that formed the dimer (A/C/G/T oligos: the oligo)
+	BVariant     string
+}
+
+// Options configure the dimer scan.
+type Options struct {
+	Structure thermo.StructureOptions
+	// ThresholdKcal is the ΔG at or below which a dimer counts toward the
+	// burden; 0 means "stable at the evaluation temperature" (dimer Tm above
+	// it). ThreePrimeWeight scales the burden of 3'-anchored dimers (0 means 1).
+	ThresholdKcal    float64
+	ThreePrimeWeight float64
+	// MaxExpansions caps IUPAC expansion per oligo (0 = 64).
+	MaxExpansions int
+}
+
+// DefaultTempC is the temperature pool tools score dimers at unless told
+// otherwise: primer dimers form while reactions are set up, well below the
+// annealing temperature.
+const DefaultTempC = 37
+
+// DefaultOptions counts every dimer stable at cond.AnnealC, with 3'-anchored
+// dimers weighted twice.
+func DefaultOptions(cond thermo.Conditions) Options {
+	return Options{
+		Structure:        thermo.DefaultStructureOptions(cond),
+		ThresholdKcal:    0,
+		ThreePrimeWeight: 2,
+		MaxExpansions:    64,
+	}
+}
+
+// Burden is how far d falls below the threshold, weighted for 3' anchoring;
+// zero for absent or weaker dimers.
+func (o Options) Burden(d Dimer) float64 {
+	if !d.Found || d.DeltaGKcal > o.ThresholdKcal {
+		return 0
+	}
+	b := o.ThresholdKcal - d.DeltaGKcal
+	if d.ThreePrime {
+		w := o.ThreePrimeWeight
+		if w <= 0 {
+			w = 1
+		}
+		b *= w
+	}
+	return b
+}
+
+// Matrix is the symmetric N×N dimer matrix of a pool. Cells[i][i] holds the
+// self-dimer of oligo i.
+type Matrix struct {
+	Oligos []Oligo
+	Cells  [][]Dimer
+}
+
+// Analyze evaluates BestSelfDimerV2 on the diagonal and BestCrossDimerV2 for
+// every other oligo combination. Degenerate oligos are expanded and the most
+// stable variant combination is kept.
+func Analyze(oligos []Oligo, opt Options) (Matrix, error) {
+	maxExp := opt.MaxExpansions
+	if maxExp <= 0 {
+		maxExp = 64
+	}
+	variants := make([][]string, len(oligos))
+	for i, o := range oligos {
+		v, _, err := thermo.ExpandIUPAC(o.Seq, maxExp)
+		if err != nil {
+			return Matrix{}, fmt.Errorf("%s: %w", o.ID, err)
+		}
+		variants[i] = v
+	}
+
+	m := Matrix{Oligos: oligos, Cells: make([][]Dimer, len(oligos))}
+	for i := range m.Cells {
+		m.Cells[i] = make([]Dimer, len(oligos))
+	}
+	for i := range oligos {
+		for j := i; j < len(oligos); j++ {
+			var best Dimer
+			for _, a := range variants[i] {
+				for _, b := range variants[j] {
+					var res thermo.StructureResult
+					var ok bool
+					var err error
+					if i == j {
+						if a != b {
+							continue
+						}
+						res, ok, err = thermo.BestSelfDimerV2(a, opt.Structure)
+					} else {
+						res, ok, err = thermo.BestCrossDimerV2(a, b, opt.Structure)
+					}
+					if err != nil {
+						return Matrix{}, fmt.Errorf("%s × %s: %w", oligos[i].ID, oligos[j].ID, err)
+					}
+					if ok && (!best.Found || res.DeltaGAtAnnealKcal < best.DeltaGKcal) {
+						best = Dimer{
+							Found: true, DeltaGKcal: res.DeltaGAtAnnealKcal, TmC: res.TmC,
+							ThreePrime: res.ThreePrimeAnchored, BothThreeEnd: res.BothThreePrimeAnchor,
+							AVariant: a, BVariant: b,
+						}
+					}
+				}
+			}
+			m.Cells[i][j] = best
+			if i != j {
+				best.AVariant, best.BVariant = best.BVariant, best.AVariant
+				m.Cells[j][i] = best
+			}
+		}
+	}
+	return m, nil
+}
+
+// AssayBurden sums the dimer burden between the oligos of each pair of
+// assays (cross-assay entries only; the diagonal holds each assay's own
+// self/cross burden, which no pool split can remove).
+func (m Matrix) AssayBurden(assays []string, opt Options) [][]float64 {
+	idx := make(map[string]int, len(assays))
+	for i, a := range assays {
+		idx[a] = i
+	}
+	out := make([][]float64, len(assays))
+	for i := range out {
+		out[i] = make([]float64, len(assays))
+	}
+	for i, oi := range m.Oligos {
+		for j := i; j < len(m.Oligos); j++ {
+			b := opt.Burden(m.Cells[i][j])
+			if b == 0 {
+				continue
+			}
+			ai, aj := idx[oi.Assay], idx[m.Oligos[j].Assay]
+			out[ai][aj] += b
+			if ai != aj {
+				out[aj][ai] += b
+			}
+		}
+	}
+	return out
+}
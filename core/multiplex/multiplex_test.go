@@ -0,0 +1,72 @@
+package multiplex
+
+import (
+	"ipcr-core/primer"
+	"ipcr-core/thermo"
+	"testing"
+)
+
+func TestAnalyzeFindsThreePrimeCrossDimer(t *testing.T) {
+	// B's 3' end is the reverse complement of A's 3' end.
+	pairs := []primer.Pair{
+		{ID: "A", Forward: "TTAGCCTAAGTCCGATCGGC", Reverse: "ACTTGACTTAGCATCAGTCA"},
+		{ID: "B", Forward: "CATTCATGCCGATCGGACTTA", Reverse: "TGTATCATCTAATGCTCAACTT"},
+	}
+	cond := thermo.DefaultConditions()
+	cond.AnnealC = 37
+	opt := DefaultOptions(cond)
+	m, err := Analyze(Oligos(pairs), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Cells) != 4 || m.Oligos[2].ID != "B.fwd" {
+		t.Fatalf("unexpected oligos %+v", m.Oligos)
+	}
+	d := m.Cells[0][2]
+	if !d.Found || !d.ThreePrime || d.DeltaGKcal > opt.ThresholdKcal {
+		t.Fatalf("A.fwd × B.fwd should be a strong 3'-anchored dimer: %+v", d)
+	}
+	if m.Cells[2][0].DeltaGKcal != d.DeltaGKcal {
+		t.Fatal("matrix should be symmetric")
+	}
+	burden := m.AssayBurden([]string{"A", "B"}, opt)
+	if burden[0][1] <= 0 || burden[0][1] != burden[1][0] {
+		t.Fatalf("assay burden %v", burden)
+	}
+}
+
+func TestAnalyzeExpandsDegenerateOligos(t *testing.T) {
+	oligos := []Oligo{
+		{ID: "x", Seq: "TTAGCCTAAGTCCGATCGGN"},
+		{ID: "y", Seq: "CATTCATGCCGATCGGACTTA"},
+	}
+	m, err := Analyze(oligos, DefaultOptions(thermo.DefaultConditions()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := m.Cells[0][1]; !d.Found || d.AVariant == oligos[0].Seq {
+		t.Fatalf("want a concrete variant, got %+v", d)
+	}
+}
+
+func TestPartitionSeparatesInteractingItems(t *testing.T) {
+	// 0-1 and 2-3 interact strongly; 0-2 weakly.
+	cost := [][]float64{
+		{0, 9, 1, 0},
+		{9, 0, 0, 0},
+		{1, 0, 0, 8},
+		{0, 0, 8, 0},
+	}
+	pool, rest := Partition(cost, 2, 0)
+	if pool[0] == pool[1] || pool[2] == pool[3] || rest != 0 {
+		t.Fatalf("pools %v cost %v", pool, rest)
+	}
+	if pool, rest := Partition(cost, 1, 0); rest != 18 || pool[3] != 0 {
+		t.Fatalf("single pool: %v %v", pool, rest)
+	}
+	// Pool size 3 lets the greedy choice stand but still splits the pairs.
+	pool, _ = Partition(cost, 2, 3)
+	if pool[0] == pool[1] || pool[2] == pool[3] {
+		t.Fatalf("pools %v", pool)
+	}
+}
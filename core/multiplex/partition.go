@@ -0,0 +1,119 @@
+// core/multiplex/partition.go
+package multiplex
+
+import "sort"
+
+// Partition assigns n items to k pools so that the summed cost between items
+// sharing a pool is small. cost is a symmetric n×n matrix; the diagonal is
+// ignored. maxSize caps pool size (0 = ceil(n/k), i.e. balanced pools).
+// It returns each item's pool (0-based) and the remaining in-pool cost.
+//
+// The search is a greedy placement (most-interacting items first) refined
+// by single moves and pairwise swaps until no step lowers the cost; it is
+// deterministic for a given input.
+func Partition(cost [][]float64, k, maxSize int) ([]int, float64) {
+	n := len(cost)
+	pool := make([]int, n)
+	if n == 0 || k <= 1 {
+		return pool, InPoolCost(cost, pool)
+	}
+	if maxSize <= 0 {
+		maxSize = (n + k - 1) / k
+	}
+	if maxSize*k < n {
+		maxSize = (n + k - 1) / k
+	}
+
+	total := make([]float64, n)
+	order := make([]int, n)
+	for i := range cost {
+		order[i] = i
+		for j, c := range cost[i] {
+			if i != j {
+				total[i] += c
+			}
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return total[order[a]] > total[order[b]] })
+
+	size := make([]int, k)
+	for i := range pool {
+		pool[i] = -1
+	}
+	// toPool returns the cost between item i and the members of pool p.
+	toPool := func(i, p int) float64 {
+		s := 0.0
+		for j, q := range pool {
+			if q == p && j != i {
+				s += cost[i][j]
+			}
+		}
+		return s
+	}
+	for _, i := range order {
+		best := -1
+		bestCost := 0.0
+		for p := 0; p < k; p++ {
+			if size[p] >= maxSize {
+				continue
+			}
+			c := toPool(i, p)
+			if best < 0 || c < bestCost || (c == bestCost && size[p] < size[best]) {
+				best, bestCost = p, c
+			}
+		}
+		pool[i] = best
+		size[best]++
+	}
+
+	const eps = 1e-9
+	for iter := 0; iter < 100*n; iter++ {
+		improved := false
+		for i := 0; i < n; i++ {
+			cur := toPool(i, pool[i])
+			for p := 0; p < k; p++ {
+				if p == pool[i] || size[p] >= maxSize {
+					continue
+				}
+				if toPool(i, p) < cur-eps {
+					size[pool[i]]--
+					pool[i] = p
+					size[p]++
+					cur = toPool(i, p)
+					improved = true
+				}
+			}
+		}
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				pi, pj := pool[i], pool[j]
+				if pi == pj {
+					continue
+				}
+				before := toPool(i, pi) + toPool(j, pj)
+				after := toPool(i, pj) - cost[i][j] + toPool(j, pi) - cost[j][i]
+				if after < before-eps {
+					pool[i], pool[j] = pj, pi
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return pool, InPoolCost(cost, pool)
+}
+
+// InPoolCost sums cost[i][j] over item pairs i<j sharing a pool.
+func InPoolCost(cost [][]float64, pool []int) float64 {
+	s := 0.0
+	for i := range cost {
+		for j := i + 1; j < len(cost); j++ {
+			if pool[i] == pool[j] {
+				s += cost[i][j]
+			}
+		}
+	}
+	return s
+}
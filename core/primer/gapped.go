@@ -0,0 +1,341 @@
+// core/primer/gapped.go
+package primer
+
+import "sort"
+
+// Indel is one gap in a primer-to-template alignment. Pos is a 0-based primer
+// index (5'→3' in the pattern that was aligned). For an insertion (Ins=true)
+// the template carries one extra base immediately before primer base Pos; for
+// a deletion (Ins=false) primer base Pos has no template partner.
+type Indel struct {
+	Pos int  `json:"pos"`
+	Ins bool `json:"ins,omitempty"`
+}
+
+// FlipIndels maps indel positions from a reverse-complemented pattern of
+// length n back onto the original primer (5'→3').
+func FlipIndels(n int, in []Indel) []Indel {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]Indel, len(in))
+	for i, d := range in {
+		// A deletion of rc base j is primer base n-1-j. An insertion before rc
+		// base j sits between primer bases n-j and n-1-j, i.e. before n-j.
+		pos := n - 1 - d.Pos
+		if d.Ins {
+			pos = n - d.Pos
+		}
+		out[len(in)-1-i] = Indel{Pos: pos, Ins: d.Ins}
+	}
+	return out
+}
+
+// SiteLen returns the template span covered by a primer of length n aligned
+// with the given indels.
+func SiteLen(n int, indels []Indel) int {
+	for _, d := range indels {
+		if d.Ins {
+			n++
+		} else {
+			n--
+		}
+	}
+	return n
+}
+
+const (
+	gapOpMatch byte = iota + 1
+	gapOpDel
+	gapOpIns
+)
+
+// AlignGappedAt aligns pat against seq anchored at one template coordinate and
+// lets the other end float by up to maxIndels bases. With anchorRight=false
+// the anchor is the first template base of the site (forward orientations);
+// with anchorRight=true the anchor is the exclusive end of the site (reverse-
+// complement orientations, where the primer 5' end is on the right).
+//
+// Mismatches (IUPAC-aware) and indels are budgeted separately. Gaps are only
+// allowed strictly inside the primer, and not inside a terminal homopolymer
+// run either, where a gap is the same as moving the site end. Neither
+// mismatches nor gaps may touch the protected windows pat[0:leftTW] and
+// pat[len(pat)-rightTW:]. Among valid alignments the one with the fewest
+// edits wins, then the fewest indels, then the site length closest to
+// len(pat).
+func AlignGappedAt(seq []byte, anchor int, pat []byte, maxMM, maxIndels, leftTW, rightTW int, anchorRight bool) (Match, bool) {
+	n := len(pat)
+	if n == 0 || maxMM < 0 || maxIndels < 0 {
+		return Match{}, false
+	}
+	if anchorRight {
+		if anchor > len(seq) || anchor-(n-maxIndels) < 0 {
+			return Match{}, false
+		}
+	} else if anchor < 0 || anchor+n-maxIndels > len(seq) {
+		return Match{}, false
+	}
+
+	// Template and pattern accessors in alignment order. Right-anchored
+	// alignments run right-to-left so both cases share one left-anchored DP.
+	avail := len(seq) - anchor
+	if anchorRight {
+		avail = anchor
+	}
+	tmpl := func(i int) byte {
+		if anchorRight {
+			return seq[anchor-1-i]
+		}
+		return seq[anchor+i]
+	}
+	patAt := func(j int) byte {
+		if anchorRight {
+			return pat[n-1-j]
+		}
+		return pat[j]
+	}
+	protected := func(j int) bool {
+		orig := j
+		if anchorRight {
+			orig = n - 1 - j
+		}
+		return orig < leftTW || orig >= n-rightTW
+	}
+
+	// Terminal runs of one base (alignment order): a gap inside them only
+	// moves the end of the site.
+	lead, trail := 1, 1
+	for lead < n && patAt(lead) == patAt(0) {
+		lead++
+	}
+	for trail < n && patAt(n-1-trail) == patAt(n-1) {
+		trail++
+	}
+
+	// dp[j][i-j+K][d] = fewest mismatches after consuming j primer bases and i
+	// template bases using exactly d indels (-1 = unreachable).
+	band := 2*maxIndels + 1
+	width := band * (maxIndels + 1)
+	cells := (n + 1) * width
+	mm := make([]int16, cells)
+	op := make([]byte, cells)
+	for k := range mm {
+		mm[k] = -1
+	}
+	idx := func(j, i, d int) int { return j*width + (i-j+maxIndels)*(maxIndels+1) + d }
+	relax := func(j, i, d int, v int16, how byte) {
+		k := idx(j, i, d)
+		if mm[k] < 0 || v < mm[k] {
+			mm[k] = v
+			op[k] = how
+		}
+	}
+	mm[idx(0, 0, 0)] = 0
+
+	for j := 0; j < n; j++ {
+		for off := -maxIndels; off <= maxIndels; off++ {
+			i := j + off
+			if i < 0 || i > avail {
+				continue
+			}
+			for d := 0; d <= maxIndels; d++ {
+				cur := mm[idx(j, i, d)]
+				if cur < 0 {
+					continue
+				}
+				pj := patAt(j)
+				prot := protected(j)
+
+				// Match or substitution.
+				if i < avail {
+					v := cur
+					if !BaseMatch(tmpl(i), pj) {
+						v++
+					}
+					if (v == cur || !prot) && int(v) <= maxMM {
+						relax(j+1, i+1, d, v, gapOpMatch)
+					}
+				}
+				if d == maxIndels || j == 0 || prot {
+					continue
+				}
+				// Deletion: primer base j unpaired (never a terminal base).
+				if j >= lead && j < n-trail && off-1 >= -maxIndels {
+					relax(j+1, i, d+1, cur, gapOpDel)
+				}
+				// Insertion: extra template base before primer base j, only
+				// when the preceding primer base is not protected either and
+				// the base does not just lengthen a terminal run.
+				if i < avail && off+1 <= maxIndels && !protected(j-1) &&
+					!(j <= lead && BaseMatch(tmpl(i), patAt(0))) && !(j >= n-trail && BaseMatch(tmpl(i), patAt(n-1))) {
+					relax(j, i+1, d+1, cur, gapOpIns)
+				}
+			}
+		}
+	}
+
+	// Pick the best terminal state.
+	bestI, bestD := -1, -1
+	var bestMM int16
+	for off := -maxIndels; off <= maxIndels; off++ {
+		i := n + off
+		if i < 0 || i > avail {
+			continue
+		}
+		for d := 0; d <= maxIndels; d++ {
+			k := idx(n, i, d)
+			if mm[k] < 0 || op[k] != gapOpMatch {
+				continue
+			}
+			if bestI < 0 || gappedBetter(int(mm[k]), d, i, int(bestMM), bestD, bestI, n) {
+				bestI, bestD, bestMM = i, d, mm[k]
+			}
+		}
+	}
+	if bestI < 0 {
+		return Match{}, false
+	}
+
+	// Trace back into alignment-order edits, then map onto pattern indexes.
+	var mIdx []int
+	var indels []Indel
+	for j, i, d := n, bestI, bestD; j > 0 || i > 0; {
+		k := idx(j, i, d)
+		switch op[k] {
+		case gapOpMatch:
+			j--
+			i--
+			if !BaseMatch(tmpl(i), patAt(j)) {
+				mIdx = append(mIdx, j)
+			}
+		case gapOpDel:
+			j--
+			d--
+			indels = append(indels, Indel{Pos: j})
+		case gapOpIns:
+			i--
+			d--
+			indels = append(indels, Indel{Pos: j, Ins: true})
+		default:
+			return Match{}, false
+		}
+	}
+
+	// Traceback yields descending alignment order; restore ascending pattern
+	// order (right-anchored alignments ran over the reversed pattern).
+	if anchorRight {
+		for k := range mIdx {
+			mIdx[k] = n - 1 - mIdx[k]
+		}
+		indels = FlipIndels(n, reverseIndels(indels))
+	} else {
+		reverseInts(mIdx)
+		indels = reverseIndels(indels)
+	}
+
+	pos := anchor
+	if anchorRight {
+		pos = anchor - bestI
+	}
+	return Match{Pos: pos, Mismatches: int(bestMM), Length: bestI, MismatchIdx: mIdx, Indels: indels}, true
+}
+
+func gappedBetter(mm, d, i, bestMM, bestD, bestI, n int) bool {
+	if mm+d != bestMM+bestD {
+		return mm+d < bestMM+bestD
+	}
+	if d != bestD {
+		return d < bestD
+	}
+	di, bi := absInt(i-n), absInt(bestI-n)
+	if di != bi {
+		return di < bi
+	}
+	return i < bestI
+}
+
+// BestGappedMatches keeps, among matches of one primer orientation (length n)
+// whose anchors lie within maxIndels of each other, only the best alignment
+// (as AlignGappedAt ranks them; the first anchor on ties). A site re-aligned
+// from a neighbouring anchor with compensating gaps is the same binding, not
+// another one. ms is reordered by anchor and filtered in place.
+func BestGappedMatches(ms []Match, n, maxIndels int, anchorRight bool) []Match {
+	anchor := func(m Match) int {
+		if anchorRight {
+			return m.Pos + m.Length
+		}
+		return m.Pos
+	}
+	better := func(a, b Match) bool {
+		return gappedBetter(a.Mismatches, len(a.Indels), a.Length, b.Mismatches, len(b.Indels), b.Length, n)
+	}
+	sort.SliceStable(ms, func(i, j int) bool { return anchor(ms[i]) < anchor(ms[j]) })
+	keep := make([]bool, len(ms))
+	for k, m := range ms {
+		keep[k] = true
+		for o := k - 1; o >= 0 && anchor(ms[o]) >= anchor(m)-maxIndels; o-- {
+			if !better(m, ms[o]) {
+				keep[k] = false
+				break
+			}
+		}
+		for o := k + 1; keep[k] && o < len(ms) && anchor(ms[o]) <= anchor(m)+maxIndels; o++ {
+			if better(ms[o], m) {
+				keep[k] = false
+			}
+		}
+	}
+	out := ms[:0]
+	for k, m := range ms {
+		if keep[k] {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// FindGappedMatches is the exhaustive counterpart of AlignGappedAt: it tries
+// every anchor on seq and returns the best matches (BestGappedMatches) ordered
+// by anchor. capHits == 0 means unlimited.
+func FindGappedMatches(seq, pat []byte, maxMM, maxIndels, capHits, leftTW, rightTW int, anchorRight bool) []Match {
+	n := len(pat)
+	if n == 0 || len(seq) < n-maxIndels {
+		return nil
+	}
+	out := make([]Match, 0, 8)
+	lo, hi := 0, len(seq)-(n-maxIndels)
+	if anchorRight {
+		lo, hi = n-maxIndels, len(seq)
+	}
+	for anchor := lo; anchor <= hi; anchor++ {
+		m, ok := AlignGappedAt(seq, anchor, pat, maxMM, maxIndels, leftTW, rightTW, anchorRight)
+		if !ok {
+			continue
+		}
+		out = append(out, m)
+		if capHits > 0 && len(out) >= capHits {
+			break
+		}
+	}
+	return BestGappedMatches(out, n, maxIndels, anchorRight)
+}
+
+func reverseInts(v []int) {
+	for i, j := 0, len(v)-1; i < j; i, j = i+1, j-1 {
+		v[i], v[j] = v[j], v[i]
+	}
+}
+
+func reverseIndels(v []Indel) []Indel {
+	for i, j := 0, len(v)-1; i < j; i, j = i+1, j-1 {
+		v[i], v[j] = v[j], v[i]
+	}
+	return v
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
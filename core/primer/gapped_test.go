@@ -0,0 +1,81 @@
+package primer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAlignGappedAtDeletionAndInsertion(t *testing.T) {
+	pat := []byte("ACGTTGCA")
+
+	// Template lacks primer base 5 (the TT pair would make base 3/4 ambiguous).
+	del := []byte("ACGTTCA" + "GGG")
+	m, ok := AlignGappedAt(del, 0, pat, 0, 1, 0, 0, false)
+	if !ok {
+		t.Fatalf("expected gapped match for deletion")
+	}
+	if m.Length != 7 || m.Mismatches != 0 || !reflect.DeepEqual(m.Indels, []Indel{{Pos: 5}}) {
+		t.Fatalf("deletion match = %+v", m)
+	}
+
+	// Template carries an extra 'T' before primer base 6 ('C').
+	ins := []byte("ACGTTGTCA")
+	m, ok = AlignGappedAt(ins, 0, pat, 0, 1, 0, 0, false)
+	if !ok {
+		t.Fatalf("expected gapped match for insertion")
+	}
+	if m.Length != 9 || !reflect.DeepEqual(m.Indels, []Indel{{Pos: 6, Ins: true}}) {
+		t.Fatalf("insertion match = %+v", m)
+	}
+
+	if _, ok := AlignGappedAt(ins, 0, pat, 0, 0, 0, 0, false); ok {
+		t.Fatalf("insertion must not match without an indel budget")
+	}
+	// The gap sits inside a 3-base terminal window.
+	if _, ok := AlignGappedAt(ins, 0, pat, 0, 1, 0, 3, false); ok {
+		t.Fatalf("indel inside the terminal window must be rejected")
+	}
+}
+
+func TestAlignGappedAtAnchorRightMapsPatternCoordinates(t *testing.T) {
+	pat := []byte("ACGTTGCA")
+	seq := []byte("TT" + "ACGTTGTCA")
+
+	m, ok := AlignGappedAt(seq, len(seq), pat, 0, 1, 0, 0, true)
+	if !ok {
+		t.Fatalf("expected right-anchored gapped match")
+	}
+	if m.Pos != 2 || m.Length != 9 || !reflect.DeepEqual(m.Indels, []Indel{{Pos: 6, Ins: true}}) {
+		t.Fatalf("right-anchored match = %+v", m)
+	}
+}
+
+func TestFindGappedMatchesReportsEachSiteOnce(t *testing.T) {
+	// Terminal runs let neighbouring anchors re-align the same site with a
+	// gap that only moves one of its ends.
+	pat := []byte("AACGTTGCAATCC")
+	seq := []byte("GTGAT" + string(pat) + "GATTG")
+	for _, right := range []bool{false, true} {
+		p := pat
+		if right {
+			p = RevComp(pat)
+			seq = RevComp(seq)
+		}
+		got := FindGappedMatches(seq, p, 0, 1, 0, 0, 0, right)
+		if len(got) != 1 || got[0].Pos != 5 || got[0].Length != len(pat) || len(got[0].Indels) != 0 {
+			t.Fatalf("anchorRight=%v: matches = %+v, want one exact site at 5", right, got)
+		}
+	}
+}
+
+func TestFlipIndels(t *testing.T) {
+	in := []Indel{{Pos: 1}, {Pos: 5, Ins: true}}
+	got := FlipIndels(8, in)
+	want := []Indel{{Pos: 3, Ins: true}, {Pos: 6}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FlipIndels = %+v, want %+v", got, want)
+	}
+	if SiteLen(8, in) != 8 {
+		t.Fatalf("SiteLen with one insertion and one deletion should equal primer length")
+	}
+}
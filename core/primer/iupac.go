@@ -65,3 +65,14 @@ func BaseMatch(g, p byte) bool {
 	}
 	return iupacMask[p]&iupacMask[g] != 0
 }
+
+// Mask returns the base mask (A=1, C=2, G=4, T=8) of an IUPAC code, or 0
+// for any other byte (gaps included).
+func Mask(b byte) byte { return iupacMask[b] }
+
+// codeOf is the inverse of iupacMask over the uppercase codes.
+var codeOf = [16]byte{0, 'A', 'C', 'M', 'G', 'R', 'S', 'V', 'T', 'W', 'Y', 'H', 'K', 'D', 'B', 'N'}
+
+// Code returns the uppercase IUPAC code covering the bases in mask, or 0
+// for an empty mask.
+func Code(mask byte) byte { return codeOf[mask&0xF] }
@@ -25,3 +25,14 @@ func TestBaseMatch(t *testing.T) {
 }
 
 // ===
+
+func TestCodeInvertsMask(t *testing.T) {
+	for _, c := range []byte("ACGTRYSWKMBDHVN") {
+		if got := Code(Mask(c)); got != c {
+			t.Errorf("Code(Mask(%q)) = %q", c, got)
+		}
+	}
+	if Code(Mask('g')|Mask('A')) != 'R' || Code(0) != 0 || Mask('-') != 0 {
+		t.Fatal("Code/Mask edge cases")
+	}
+}
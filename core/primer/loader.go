@@ -4,53 +4,68 @@ package primer
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 )
 
+// LoadTSV reads a primer panel. Two layouts are accepted:
+//
+//   - plain: whitespace-separated "id fwd rev [min] [max]" rows;
+//   - extended: a header row starting with "id" names tab-separated columns
+//     (see panelColumns). Empty or "-" cells leave the run setting in place.
+//     The probe column holds ';'-separated "[NAME=]SEQ[@CHANNEL]" entries.
+//
+// Blank lines and lines starting with '#' are ignored in both layouts, except
+// that a first line of "#id\t..." is taken as the header.
 func LoadTSV(path string) ([]Pair, error) {
 	fh, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = fh.Close() }()
+	return ReadTSV(fh, path)
+}
 
-	var list []Pair
-	sc := bufio.NewScanner(fh)
+// ReadTSV reads a primer panel from r (see LoadTSV); errors are prefixed
+// with name and the line number.
+func ReadTSV(r io.Reader, name string) ([]Pair, error) {
+	var (
+		err  error
+		list []Pair
+		cols []string // extended layout when non-nil
+		seen bool     // first content line handled
+	)
+	sc := bufio.NewScanner(r)
 	ln := 0
 	for sc.Scan() {
 		ln++
-		line := strings.TrimSpace(sc.Text())
-		if line == "" || line[0] == '#' {
+		raw := sc.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" {
 			continue
 		}
-		f := strings.Fields(line)
-		// Accept 3 (id fwd rev), 4 (… min), or 5 (… min max) fields.
-		if len(f) < 3 || len(f) > 5 {
-			return nil, fmt.Errorf("%s:%d bad field count", path, ln)
-		}
-		fwd, err := Validate(f[1])
-		if err != nil {
-			return nil, fmt.Errorf("%s:%d forward primer: %v", path, ln, err)
-		}
-		rev, err := Validate(f[2])
-		if err != nil {
-			return nil, fmt.Errorf("%s:%d reverse primer: %v", path, ln, err)
+		if !seen {
+			seen = true
+			if h, ok := panelHeader(line); ok {
+				if cols, err = checkPanelHeader(h); err != nil {
+					return nil, fmt.Errorf("%s:%d %v", name, ln, err)
+				}
+				continue
+			}
 		}
-		p := Pair{
-			ID:      f[0],
-			Forward: fwd,
-			Reverse: rev,
+		if line[0] == '#' {
+			continue
 		}
-		if len(f) >= 4 {
-			if _, err := fmt.Sscan(f[3], &p.MinProduct); err != nil {
-				return nil, fmt.Errorf("%s:%d bad min: %v", path, ln, err)
-			}
+		var p Pair
+		if cols != nil {
+			p, err = parsePanelRow(cols, strings.Split(strings.TrimRight(raw, "\r\n"), "\t"))
+		} else {
+			p, err = parsePlainRow(strings.Fields(line))
 		}
-		if len(f) == 5 {
-			if _, err := fmt.Sscan(f[4], &p.MaxProduct); err != nil {
-				return nil, fmt.Errorf("%s:%d bad max: %v", path, ln, err)
-			}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d %v", name, ln, err)
 		}
 		list = append(list, p)
 	}
@@ -59,3 +74,204 @@ func LoadTSV(path string) ([]Pair, error) {
 	}
 	return list, nil
 }
+
+func parsePlainRow(f []string) (Pair, error) {
+	// Accept 3 (id fwd rev), 4 (… min), or 5 (… min max) fields.
+	if len(f) < 3 || len(f) > 5 {
+		return Pair{}, fmt.Errorf("bad field count")
+	}
+	fwd, err := Validate(f[1])
+	if err != nil {
+		return Pair{}, fmt.Errorf("forward primer: %v", err)
+	}
+	rev, err := Validate(f[2])
+	if err != nil {
+		return Pair{}, fmt.Errorf("reverse primer: %v", err)
+	}
+	p := Pair{
+		ID:      f[0],
+		Forward: fwd,
+		Reverse: rev,
+	}
+	if len(f) >= 4 {
+		if _, err := fmt.Sscan(f[3], &p.MinProduct); err != nil {
+			return Pair{}, fmt.Errorf("bad min: %v", err)
+		}
+	}
+	if len(f) == 5 {
+		if _, err := fmt.Sscan(f[4], &p.MaxProduct); err != nil {
+			return Pair{}, fmt.Errorf("bad max: %v", err)
+		}
+	}
+	return p, nil
+}
+
+// panelColumns maps accepted extended-panel header names (and aliases) to
+// their canonical column.
+var panelColumns = map[string]string{
+	"id":              "id",
+	"fwd":             "fwd",
+	"forward":         "fwd",
+	"rev":             "rev",
+	"reverse":         "rev",
+	"min":             "min",
+	"min_len":         "min",
+	"max":             "max",
+	"max_len":         "max",
+	"max_mm":          "max_mm",
+	"max_mm_fwd":      "max_mm_fwd",
+	"max_mm_rev":      "max_mm_rev",
+	"terminal_window": "terminal_window",
+	"circular":        "circular",
+	"probe":           "probe",
+	"probes":          "probe",
+	"notes":           "notes",
+}
+
+// panelHeader reports whether line is an extended-panel header and returns
+// its cells.
+func panelHeader(line string) ([]string, bool) {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+	cells := strings.Split(line, "\t")
+	if !strings.EqualFold(strings.TrimSpace(cells[0]), "id") || len(cells) < 3 {
+		return nil, false
+	}
+	return cells, true
+}
+
+func checkPanelHeader(cells []string) ([]string, error) {
+	cols := make([]string, len(cells))
+	have := make(map[string]bool, len(cells))
+	for i, c := range cells {
+		name, ok := panelColumns[strings.ToLower(strings.TrimSpace(c))]
+		if !ok {
+			return nil, fmt.Errorf("unknown panel column %q", strings.TrimSpace(c))
+		}
+		if have[name] {
+			return nil, fmt.Errorf("duplicate panel column %q", name)
+		}
+		have[name] = true
+		cols[i] = name
+	}
+	if !have["fwd"] || !have["rev"] {
+		return nil, fmt.Errorf("panel header needs id, fwd and rev columns")
+	}
+	return cols, nil
+}
+
+func parsePanelRow(cols, cells []string) (Pair, error) {
+	if len(cells) > len(cols) {
+		return Pair{}, fmt.Errorf("%d cells for %d header columns", len(cells), len(cols))
+	}
+	var p Pair
+	intCell := func(name, v string) (*int, error) {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("bad %s %q", name, v)
+		}
+		return &n, nil
+	}
+	for i, v := range cells {
+		v = strings.TrimSpace(v)
+		if v == "" || v == "-" {
+			continue
+		}
+		var err error
+		switch cols[i] {
+		case "id":
+			p.ID = v
+		case "fwd":
+			if p.Forward, err = Validate(v); err != nil {
+				return Pair{}, fmt.Errorf("forward primer: %v", err)
+			}
+		case "rev":
+			if p.Reverse, err = Validate(v); err != nil {
+				return Pair{}, fmt.Errorf("reverse primer: %v", err)
+			}
+		case "min", "max":
+			n, err := intCell(cols[i], v)
+			if err != nil {
+				return Pair{}, err
+			}
+			if cols[i] == "min" {
+				p.MinProduct = *n
+			} else {
+				p.MaxProduct = *n
+			}
+		case "max_mm":
+			n, err := intCell(cols[i], v)
+			if err != nil {
+				return Pair{}, err
+			}
+			// max_mm_fwd/max_mm_rev win regardless of column order.
+			if p.MaxMMFwd == nil {
+				p.MaxMMFwd = n
+			}
+			if p.MaxMMRev == nil {
+				p.MaxMMRev = n
+			}
+		case "max_mm_fwd":
+			if p.MaxMMFwd, err = intCell(cols[i], v); err != nil {
+				return Pair{}, err
+			}
+		case "max_mm_rev":
+			if p.MaxMMRev, err = intCell(cols[i], v); err != nil {
+				return Pair{}, err
+			}
+		case "terminal_window":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return Pair{}, fmt.Errorf("bad terminal_window %q", v)
+			}
+			n = max(n, 0) // as on the command line, N<1 disables
+			p.TerminalWindow = &n
+		case "circular":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return Pair{}, fmt.Errorf("bad circular %q (want true/false)", v)
+			}
+			p.Circular = &b
+		case "probe":
+			if p.Probes, err = parseProbes(v); err != nil {
+				return Pair{}, err
+			}
+		case "notes":
+			p.Notes = v
+		}
+	}
+	if p.ID == "" || p.Forward == "" || p.Reverse == "" {
+		return Pair{}, fmt.Errorf("id, fwd and rev are required")
+	}
+	for i := range p.Probes {
+		if p.Probes[i].Name == "" {
+			p.Probes[i].Name = fmt.Sprintf("%s.p%d", p.ID, i+1)
+		}
+	}
+	return p, nil
+}
+
+// parseProbes reads a probe cell: ';'-separated "[NAME=]SEQ[@CHANNEL]"
+// entries. Unnamed probes are named by parsePanelRow once the ID is known.
+func parseProbes(cell string) ([]Probe, error) {
+	var out []Probe
+	for _, ent := range strings.Split(cell, ";") {
+		ent = strings.TrimSpace(ent)
+		if ent == "" {
+			continue
+		}
+		var pr Probe
+		if name, rest, ok := strings.Cut(ent, "="); ok {
+			pr.Name, ent = strings.TrimSpace(name), rest
+		}
+		if seq, ch, ok := strings.Cut(ent, "@"); ok {
+			ent, pr.Channel = seq, strings.TrimSpace(ch)
+		}
+		seq, err := Validate(strings.TrimSpace(ent))
+		if err != nil {
+			return nil, fmt.Errorf("probe %d: %v", len(out)+1, err)
+		}
+		pr.Seq = seq
+		out = append(out, pr)
+	}
+	return out, nil
+}
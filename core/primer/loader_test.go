@@ -68,3 +68,101 @@ func TestLoadTSVRejectsInvalidPrimerBase(t *testing.T) {
 		t.Fatal("expected invalid primer error")
 	}
 }
+
+func TestLoadTSVExtendedHeader(t *testing.T) {
+	tmp := "tmp_primers_extended.tsv"
+	body := "#id\tforward\treverse\tmax_len\tmax_mm\tmax_mm_rev\tterminal_window\tcircular\tprobe\tnotes\n" +
+		"p1\tACG\tTTA\t500\t2\t1\t0\ttrue\tacgt\tplasmid check\n" +
+		"# skipped\n" +
+		"p2\tGGA\tCCA\t-\t\t\t\n"
+	if err := os.WriteFile(tmp, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Remove(tmp) }()
+
+	ps, err := LoadTSV(tmp)
+	if err != nil || len(ps) != 2 {
+		t.Fatalf("LoadTSV extended: %+v %v", ps, err)
+	}
+	p := ps[0]
+	if p.ID != "p1" || p.MaxProduct != 500 || *p.MaxMMFwd != 2 || *p.MaxMMRev != 1 ||
+		*p.TerminalWindow != 0 || !*p.Circular || len(p.Probes) != 1 || p.Probes[0] != (Probe{Name: "p1.p1", Seq: "ACGT"}) || p.Notes != "plasmid check" {
+		t.Fatalf("unexpected row: %+v", p)
+	}
+	if q := ps[1]; q.MaxProduct != 0 || q.MaxMMFwd != nil || q.TerminalWindow != nil || q.Circular != nil {
+		t.Fatalf("empty cells should leave settings unset: %+v", q)
+	}
+}
+
+func TestLoadTSVExtendedHeaderErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"unknown column":   "id\tfwd\trev\tcolour\np1\tACG\tTTA\tred\n",
+		"duplicate column": "id\tfwd\trev\tforward\np1\tACG\tTTA\tACG\n",
+		"missing rev":      "id\tfwd\tnotes\np1\tACG\tx\n",
+		"bad max_mm":       "id\tfwd\trev\tmax_mm\np1\tACG\tTTA\t-1\n",
+		"bad circular":     "id\tfwd\trev\tcircular\np1\tACG\tTTA\tmaybe\n",
+		"bad probe":        "id\tfwd\trev\tprobes\np1\tACG\tTTA\tFAM=ACXT@FAM\n",
+		"extra cells":      "id\tfwd\trev\np1\tACG\tTTA\t7\n",
+	} {
+		tmp := "tmp_primers_bad_header.tsv"
+		if err := os.WriteFile(tmp, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadTSV(tmp)
+		_ = os.Remove(tmp)
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadTSVExtendedProbesWithChannels(t *testing.T) {
+	tmp := "tmp_primers_probes.tsv"
+	body := "id\tfwd\trev\tprobes\n" +
+		"a1\tACG\tTTA\ttaqA=acgtac@FAM; ggtacc@HEX\n"
+	if err := os.WriteFile(tmp, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Remove(tmp) }()
+
+	ps, err := LoadTSV(tmp)
+	if err != nil || len(ps) != 1 {
+		t.Fatalf("LoadTSV: %+v %v", ps, err)
+	}
+	want := []Probe{{Name: "taqA", Seq: "ACGTAC", Channel: "FAM"}, {Name: "a1.p2", Seq: "GGTACC", Channel: "HEX"}}
+	if got := ps[0].Probes; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("probes: got %+v want %+v", got, want)
+	}
+}
+
+func TestWriteTSVRoundTrips(t *testing.T) {
+	two, yes := 2, true
+	in := []Pair{
+		{ID: "a1", Forward: "ACG", Reverse: "TTA", MaxProduct: 500, MaxMMRev: &two, Circular: &yes,
+			Probes: []Probe{{Name: "taqA", Seq: "ACGTAC", Channel: "FAM"}}, Notes: "tab\there"},
+		{ID: "a2", Forward: "GGA", Reverse: "CCA", MinProduct: 50},
+	}
+	tmp := "tmp_primers_written.tsv"
+	fh, err := os.Create(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Remove(tmp) }()
+	if err := WriteTSV(fh, in); err != nil {
+		t.Fatal(err)
+	}
+	_ = fh.Close()
+
+	ps, err := LoadTSV(tmp)
+	if err != nil || len(ps) != 2 {
+		t.Fatalf("LoadTSV: %+v %v", ps, err)
+	}
+	p, q := ps[0], ps[1]
+	if p.MaxProduct != 500 || p.MaxMMFwd != nil || *p.MaxMMRev != 2 || !*p.Circular ||
+		len(p.Probes) != 1 || p.Probes[0] != in[0].Probes[0] || p.Notes != "tab here" {
+		t.Fatalf("unexpected row: %+v", p)
+	}
+	if q.MinProduct != 50 || q.MaxMMRev != nil || q.Circular != nil || q.Probes != nil || q.Notes != "" {
+		t.Fatalf("unset cells should stay unset: %+v", q)
+	}
+}
@@ -8,8 +8,9 @@ import "bytes"
 type Match struct {
 	Pos         int
 	Mismatches  int
-	Length      int
-	MismatchIdx []int // 0‑based positions in primer (5'→3') that mismatched
+	Length      int     // template span; differs from the primer length only when Indels is set
+	MismatchIdx []int   // 0‑based positions in primer (5'→3') that mismatched
+	Indels      []Indel // gapped matches only (see AlignGappedAt)
 }
 
 /* ---------------------- helpers -------------------- */
@@ -3,10 +3,27 @@ package primer
 
 type Pair struct {
 	ID         string
-	Forward    string // Primer A sequence (5'→3', binds forward strand)
-	Reverse    string // Primer B sequence (5'→3', binds reverse strand)
+	Forward    string // Primer A sequence (5'→3', binds forward strand)
+	Reverse    string // Primer B sequence (5'→3', binds reverse strand)
 	MinProduct int
 	MaxProduct int
+
+	// Per-pair overrides of run-wide settings, set by header-driven panel
+	// TSVs (see LoadTSV). nil means "use the run setting".
+	MaxMMFwd       *int  // max mismatches for Forward
+	MaxMMRev       *int  // max mismatches for Reverse
+	TerminalWindow *int  // 3' terminal window (0 disables)
+	Circular       *bool // treat templates as circular for this pair
+
+	Probes []Probe // optional internal oligos for probe-aware tools
+	Notes  string  // free text, carried along for reference
+}
+
+// Probe is an internal oligo listed on a panel row.
+type Probe struct {
+	Name    string // defaults to "<pair id>.p<N>"
+	Seq     string // 5'→3'
+	Channel string // reporter dye / detection channel; may be empty
 }
 
 // ===
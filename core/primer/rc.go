@@ -23,6 +23,10 @@ func init() {
 	complement['N'] = 'N'
 }
 
+// Complement returns the complement of a normalized uppercase IUPAC base,
+// or 0 for any other byte.
+func Complement(b byte) byte { return complement[b] }
+
 // RevComp returns the reverse-complement of a normalized uppercase IUPAC DNA
 // sequence. Unknown characters fail loudly instead of being converted to N.
 func RevComp(seq []byte) []byte {
@@ -0,0 +1,80 @@
+// core/primer/writer.go
+package primer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteTSV writes pairs as an extended panel that LoadTSV reads back. Only
+// optional columns that some pair sets are written.
+func WriteTSV(w io.Writer, pairs []Pair) error {
+	type column struct {
+		name string
+		cell func(Pair) string
+		used func(Pair) bool
+	}
+	intPtr := func(v *int) string {
+		if v == nil {
+			return "-"
+		}
+		return strconv.Itoa(*v)
+	}
+	optional := []column{
+		{"max_mm_fwd", func(p Pair) string { return intPtr(p.MaxMMFwd) }, func(p Pair) bool { return p.MaxMMFwd != nil }},
+		{"max_mm_rev", func(p Pair) string { return intPtr(p.MaxMMRev) }, func(p Pair) bool { return p.MaxMMRev != nil }},
+		{"terminal_window", func(p Pair) string { return intPtr(p.TerminalWindow) }, func(p Pair) bool { return p.TerminalWindow != nil }},
+		{"circular", func(p Pair) string {
+			if p.Circular == nil {
+				return "-"
+			}
+			return strconv.FormatBool(*p.Circular)
+		}, func(p Pair) bool { return p.Circular != nil }},
+		{"probes", func(p Pair) string {
+			if len(p.Probes) == 0 {
+				return "-"
+			}
+			ents := make([]string, len(p.Probes))
+			for i, pr := range p.Probes {
+				ents[i] = pr.Name + "=" + pr.Seq
+				if pr.Channel != "" {
+					ents[i] += "@" + pr.Channel
+				}
+			}
+			return strings.Join(ents, ";")
+		}, func(p Pair) bool { return len(p.Probes) > 0 }},
+		{"notes", func(p Pair) string {
+			if p.Notes == "" {
+				return "-"
+			}
+			return strings.ReplaceAll(p.Notes, "\t", " ")
+		}, func(p Pair) bool { return p.Notes != "" }},
+	}
+	var cols []column
+	for _, c := range optional {
+		for _, p := range pairs {
+			if c.used(p) {
+				cols = append(cols, c)
+				break
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("id\tfwd\trev\tmin\tmax")
+	for _, c := range cols {
+		b.WriteString("\t" + c.name)
+	}
+	b.WriteByte('\n')
+	for _, p := range pairs {
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%d\t%d", p.ID, p.Forward, p.Reverse, p.MinProduct, p.MaxProduct)
+		for _, c := range cols {
+			b.WriteString("\t" + c.cell(p))
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
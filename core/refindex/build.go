@@ -0,0 +1,205 @@
+// core/refindex/build.go
+package refindex
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"ipcr-core/fasta"
+	"slices"
+)
+
+// BuildStats summarizes one index build.
+type BuildStats struct {
+	Records int
+	Bases   int64
+	Kmers   int64
+}
+
+type dirEntry struct {
+	sourceFile string
+	id         string
+	offset     uint64
+	length     uint64
+}
+
+type countingWriter struct {
+	w *bufio.Writer
+	n uint64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += uint64(n)
+	return n, err
+}
+
+func (c *countingWriter) u32(v uint32) error {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	_, err := c.Write(b[:])
+	return err
+}
+
+func (c *countingWriter) u64(v uint64) error {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	_, err := c.Write(b[:])
+	return err
+}
+
+func (c *countingWriter) str(s string) error {
+	if err := c.u32(uint32(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(c, s)
+	return err
+}
+
+// Build reads every FASTA path (gzip and "-" are handled by the fasta layer)
+// and writes an index with k-mer length k to out. Records are normalized the
+// same way the streaming scanner normalizes them, and source paths are stored
+// verbatim so indexed runs report the same source_file values.
+func Build(ctx context.Context, out io.Writer, paths []string, k int) (BuildStats, error) {
+	var st BuildStats
+	if k < MinK || k > MaxK {
+		return st, fmt.Errorf("refindex: k must be between %d and %d", MinK, MaxK)
+	}
+
+	cw := &countingWriter{w: bufio.NewWriterSize(out, 1<<20)}
+	if _, err := cw.Write(magic[:]); err != nil {
+		return st, err
+	}
+	if err := cw.u32(formatVersion); err != nil {
+		return st, err
+	}
+	if err := cw.u32(uint32(k)); err != nil {
+		return st, err
+	}
+
+	var dir []dirEntry
+	for _, path := range paths {
+		err := fasta.StreamChunksPathCtx(ctx, path, 0, 0, func(rec fasta.Record) error {
+			if uint64(len(rec.Seq)) > uint64(^uint32(0)) {
+				return fmt.Errorf("refindex: %s: record %q exceeds 4 Gbp", path, rec.ID)
+			}
+			dir = append(dir, dirEntry{sourceFile: path, id: rec.ID, offset: cw.n, length: uint64(len(rec.Seq))})
+			nk, err := writeRecord(cw, rec.Seq, k)
+			if err != nil {
+				return err
+			}
+			st.Records++
+			st.Bases += int64(len(rec.Seq))
+			st.Kmers += nk
+			return nil
+		})
+		if err != nil {
+			return st, err
+		}
+	}
+
+	dirOffset := cw.n
+	if err := cw.u32(uint32(len(dir))); err != nil {
+		return st, err
+	}
+	for _, d := range dir {
+		if err := cw.str(d.sourceFile); err != nil {
+			return st, err
+		}
+		if err := cw.str(d.id); err != nil {
+			return st, err
+		}
+		if err := cw.u64(d.offset); err != nil {
+			return st, err
+		}
+		if err := cw.u64(d.length); err != nil {
+			return st, err
+		}
+	}
+	if err := cw.u64(dirOffset); err != nil {
+		return st, err
+	}
+	if _, err := cw.Write(magic[:]); err != nil {
+		return st, err
+	}
+	return st, cw.w.Flush()
+}
+
+func writeRecord(cw *countingWriter, seq []byte, k int) (int64, error) {
+	// Non-ACGT runs.
+	var runs []run
+	for i := 0; i < len(seq); {
+		if base2bit[seq[i]] >= 0 {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(seq) && seq[j] == seq[i] {
+			j++
+		}
+		runs = append(runs, run{pos: uint64(i), len: uint64(j - i), b: seq[i]})
+		i = j
+	}
+	if err := cw.u32(uint32(len(runs))); err != nil {
+		return 0, err
+	}
+	for _, r := range runs {
+		if err := cw.u64(r.pos); err != nil {
+			return 0, err
+		}
+		if err := cw.u64(r.len); err != nil {
+			return 0, err
+		}
+		if _, err := cw.Write([]byte{r.b}); err != nil {
+			return 0, err
+		}
+	}
+
+	// Packed bases (non-ACGT positions pack as A and are restored from runs).
+	packed := make([]byte, (len(seq)+3)/4)
+	for i, b := range seq {
+		if v := base2bit[b]; v > 0 {
+			packed[i>>2] |= byte(v) << (uint(i&3) * 2)
+		}
+	}
+	if _, err := cw.Write(packed); err != nil {
+		return 0, err
+	}
+
+	// Sorted (k-mer code, position) table over A/C/G/T-only k-mers.
+	keys := make([]uint64, 0, len(seq))
+	var code uint64
+	mask := uint64(1)<<(2*uint(k)) - 1
+	valid := 0
+	for i, b := range seq {
+		v := base2bit[b]
+		if v < 0 {
+			valid = 0
+			code = 0
+			continue
+		}
+		code = ((code << 2) | uint64(v)) & mask
+		valid++
+		if valid >= k {
+			keys = append(keys, code<<32|uint64(i-k+1))
+		}
+	}
+	slices.Sort(keys)
+
+	if err := cw.u64(uint64(len(keys))); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 4*4096)
+	for start := 0; start < len(keys); start += 4096 {
+		end := min(start+4096, len(keys))
+		for i, key := range keys[start:end] {
+			binary.LittleEndian.PutUint32(buf[4*i:], uint32(key))
+		}
+		if _, err := cw.Write(buf[:4*(end-start)]); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(keys)), nil
+}
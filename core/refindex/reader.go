@@ -0,0 +1,244 @@
+// core/refindex/reader.go
+package refindex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// RecordInfo describes one indexed FASTA record.
+type RecordInfo struct {
+	SourceFile string // FASTA path as given to Build
+	ID         string
+	Len        int
+
+	offset int64
+}
+
+// Index is an open reference index. Records are loaded on demand, one at a
+// time, so memory scales with the largest record rather than the collection.
+type Index struct {
+	f       *os.File
+	K       int
+	Records []RecordInfo
+}
+
+// Open reads the header and record directory of an index file.
+func Open(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	ix, err := readDirectory(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	ix.f = f
+	return ix, nil
+}
+
+// Close releases the underlying file.
+func (ix *Index) Close() error { return ix.f.Close() }
+
+func readDirectory(f *os.File) (*Index, error) {
+	var hdr [16]byte
+	if _, err := io.ReadFull(f, hdr[:]); err != nil {
+		return nil, ErrFormat
+	}
+	if !bytes.Equal(hdr[:8], magic[:]) || binary.LittleEndian.Uint32(hdr[8:]) != formatVersion {
+		return nil, ErrFormat
+	}
+	k := int(binary.LittleEndian.Uint32(hdr[12:]))
+	if k < MinK || k > MaxK {
+		return nil, ErrFormat
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	var tr [16]byte
+	if fi.Size() < int64(len(hdr)+len(tr)) {
+		return nil, ErrFormat
+	}
+	if _, err := f.ReadAt(tr[:], fi.Size()-int64(len(tr))); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(tr[8:], magic[:]) {
+		return nil, ErrFormat
+	}
+	dirOffset := int64(binary.LittleEndian.Uint64(tr[:8]))
+	dirLen := fi.Size() - int64(len(tr)) - dirOffset
+	if dirOffset < int64(len(hdr)) || dirLen < 4 {
+		return nil, ErrFormat
+	}
+	dir := make([]byte, dirLen)
+	if _, err := f.ReadAt(dir, dirOffset); err != nil {
+		return nil, err
+	}
+
+	r := &byteReader{b: dir}
+	n := r.u32()
+	ix := &Index{K: k, Records: make([]RecordInfo, 0, n)}
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		info := RecordInfo{SourceFile: r.str(), ID: r.str()}
+		info.offset = int64(r.u64())
+		info.Len = int(r.u64())
+		ix.Records = append(ix.Records, info)
+	}
+	if r.err != nil {
+		return nil, ErrFormat
+	}
+	return ix, nil
+}
+
+// Record is one loaded record: its decoded sequence plus the k-mer table.
+// It implements engine.SeedLocator.
+type Record struct {
+	Info RecordInfo
+	Seq  []byte
+
+	k   int
+	pos []uint32
+}
+
+// Load decodes record i.
+func (ix *Index) Load(i int) (*Record, error) {
+	if i < 0 || i >= len(ix.Records) {
+		return nil, fmt.Errorf("refindex: record %d out of range", i)
+	}
+	info := ix.Records[i]
+	sr := io.NewSectionReader(ix.f, info.offset, 1<<62)
+	rd := func(p []byte) error {
+		_, err := io.ReadFull(sr, p)
+		return err
+	}
+	var b8 [8]byte
+
+	if err := rd(b8[:4]); err != nil {
+		return nil, err
+	}
+	runs := make([]run, binary.LittleEndian.Uint32(b8[:4]))
+	var rb [17]byte
+	for j := range runs {
+		if err := rd(rb[:]); err != nil {
+			return nil, err
+		}
+		runs[j] = run{pos: binary.LittleEndian.Uint64(rb[:8]), len: binary.LittleEndian.Uint64(rb[8:16]), b: rb[16]}
+	}
+
+	packed := make([]byte, (info.Len+3)/4)
+	if err := rd(packed); err != nil {
+		return nil, err
+	}
+	seq := make([]byte, info.Len)
+	for j := range seq {
+		seq[j] = bit2base[(packed[j>>2]>>(uint(j&3)*2))&3]
+	}
+	for _, r := range runs {
+		if r.pos+r.len > uint64(len(seq)) {
+			return nil, ErrFormat
+		}
+		for j := r.pos; j < r.pos+r.len; j++ {
+			seq[j] = r.b
+		}
+	}
+
+	if err := rd(b8[:]); err != nil {
+		return nil, err
+	}
+	nPos := binary.LittleEndian.Uint64(b8[:])
+	if nPos > uint64(info.Len) {
+		return nil, ErrFormat
+	}
+	raw := make([]byte, 4*nPos)
+	if err := rd(raw); err != nil {
+		return nil, err
+	}
+	pos := make([]uint32, nPos)
+	for j := range pos {
+		pos[j] = binary.LittleEndian.Uint32(raw[4*j:])
+	}
+	return &Record{Info: info, Seq: seq, k: ix.K, pos: pos}, nil
+}
+
+// MinSeedLen is the shortest pattern ForEachOccurrence can look up.
+func (r *Record) MinSeedLen() int { return r.k }
+
+// ForEachOccurrence calls fn with the start of every exact occurrence of the
+// A/C/G/T pattern pat (len(pat) >= MinSeedLen), in ascending position order.
+func (r *Record) ForEachOccurrence(pat []byte, fn func(start int)) {
+	if len(pat) < r.k || fn == nil {
+		return
+	}
+	want, ok := kmerCode(pat, r.k)
+	if !ok {
+		return
+	}
+	for _, b := range pat[r.k:] {
+		if base2bit[b] < 0 {
+			return
+		}
+	}
+	lo := sort.Search(len(r.pos), func(i int) bool {
+		c, _ := kmerCode(r.Seq[r.pos[i]:], r.k)
+		return c >= want
+	})
+	for i := lo; i < len(r.pos); i++ {
+		p := int(r.pos[i])
+		if c, _ := kmerCode(r.Seq[p:], r.k); c != want {
+			break
+		}
+		if p+len(pat) <= len(r.Seq) && bytes.Equal(r.Seq[p+r.k:p+len(pat)], pat[r.k:]) {
+			fn(p)
+		}
+	}
+}
+
+func kmerCode(s []byte, k int) (uint64, bool) {
+	var code uint64
+	for _, b := range s[:k] {
+		v := base2bit[b]
+		if v < 0 {
+			return 0, false
+		}
+		code = code<<2 | uint64(v)
+	}
+	return code, true
+}
+
+type byteReader struct {
+	b   []byte
+	err error
+}
+
+func (r *byteReader) take(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.b) {
+		r.err = ErrFormat
+		return nil
+	}
+	out := r.b[:n]
+	r.b = r.b[n:]
+	return out
+}
+
+func (r *byteReader) u32() uint32 {
+	if b := r.take(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *byteReader) u64() uint64 {
+	if b := r.take(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *byteReader) str() string { return string(r.take(int(r.u32()))) }
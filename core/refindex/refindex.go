@@ -0,0 +1,51 @@
+// core/refindex/refindex.go
+
+// Package refindex stores FASTA references as packed 2-bit sequence plus a
+// sorted k-mer position table, so repeated panel scans can skip FASTA parsing
+// and look seeds up directly instead of running a linear automaton pass.
+//
+// File layout (little-endian):
+//
+//	header    "IPCRIDX\x01" | u32 format version | u32 k
+//	records   per record: u32 nRuns, runs (u64 pos, u64 len, u8 byte) for
+//	          non-ACGT bytes; ceil(len/4) packed bases; u64 nPos; u32 positions
+//	          sorted by (k-mer code, position)
+//	directory u32 nRecords, per record: u32+source file, u32+ID, u64 offset,
+//	          u64 length
+//	trailer   u64 directory offset | "IPCRIDX\x01"
+package refindex
+
+import "errors"
+
+const (
+	// DefaultK is the k-mer length used by `ipcr index build` unless overridden.
+	DefaultK = 12
+	// MinK and MaxK bound the k-mer length; codes must fit in 32 bits.
+	MinK = 4
+	MaxK = 16
+
+	formatVersion = 1
+)
+
+var magic = [8]byte{'I', 'P', 'C', 'R', 'I', 'D', 'X', 1}
+
+// ErrFormat reports a file that is not a readable ipcr reference index.
+var ErrFormat = errors.New("refindex: not an ipcr reference index (or unsupported version)")
+
+var base2bit = func() [256]int8 {
+	var t [256]int8
+	for i := range t {
+		t[i] = -1
+	}
+	t['A'], t['C'], t['G'], t['T'] = 0, 1, 2, 3
+	return t
+}()
+
+var bit2base = [4]byte{'A', 'C', 'G', 'T'}
+
+// run is a stretch of identical non-ACGT bytes (N, IUPAC codes, gaps…) that
+// cannot be represented in the 2-bit sequence.
+type run struct {
+	pos, len uint64
+	b        byte
+}
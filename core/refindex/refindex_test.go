@@ -0,0 +1,90 @@
+// core/refindex/refindex_test.go
+package refindex
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func buildTestIndex(t *testing.T, fasta string, k int) *Index {
+	t.Helper()
+	dir := t.TempDir()
+	fa := filepath.Join(dir, "ref.fa")
+	if err := os.WriteFile(fa, []byte(fasta), 0o644); err != nil {
+		t.Fatalf("write fasta: %v", err)
+	}
+	out := filepath.Join(dir, "ref.ipcridx")
+	fh, err := os.Create(out)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	st, err := Build(context.Background(), fh, []string{fa}, k)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if err := fh.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if st.Records != 2 {
+		t.Fatalf("records = %d, want 2", st.Records)
+	}
+	ix, err := Open(out)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = ix.Close() })
+	if ix.Records[0].SourceFile != fa {
+		t.Fatalf("source file = %q, want %q", ix.Records[0].SourceFile, fa)
+	}
+	return ix
+}
+
+func TestBuildLoadRoundTrip(t *testing.T) {
+	ix := buildTestIndex(t, ">chr1 desc\nacgtNNNNacgtRY\nACGTACGTTT\n>chr2\nGGGCCCAAATTT\n", 4)
+
+	wantSeqs := []string{"ACGTNNNNACGTRYACGTACGTTT", "GGGCCCAAATTT"}
+	wantIDs := []string{"chr1", "chr2"}
+	for i := range ix.Records {
+		rec, err := ix.Load(i)
+		if err != nil {
+			t.Fatalf("load %d: %v", i, err)
+		}
+		if rec.Info.ID != wantIDs[i] || string(rec.Seq) != wantSeqs[i] {
+			t.Fatalf("record %d = %q %q", i, rec.Info.ID, rec.Seq)
+		}
+	}
+}
+
+func TestForEachOccurrenceMatchesNaiveSearch(t *testing.T) {
+	ix := buildTestIndex(t, ">a\nACGTACGTNACGTACGGACGTACGT\n>b\nTTTT\n", 4)
+	rec, err := ix.Load(0)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	for _, pat := range []string{"ACGT", "ACGTACG", "CGTACGG", "TTTT", "ACGTN"} {
+		var got, want []int
+		rec.ForEachOccurrence([]byte(pat), func(start int) { got = append(got, start) })
+		for i := 0; i+len(pat) <= len(rec.Seq); i++ {
+			if bytes.Equal(rec.Seq[i:i+len(pat)], []byte(pat)) && !bytes.ContainsAny([]byte(pat), "N") {
+				want = append(want, i)
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: got %v want %v", pat, got, want)
+		}
+	}
+}
+
+func TestOpenRejectsNonIndex(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "x.fa")
+	if err := os.WriteFile(fn, []byte(">x\nACGTACGTACGTACGTACGTACGT\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(fn); err == nil {
+		t.Fatalf("expected format error")
+	}
+}
@@ -0,0 +1,203 @@
+// core/rt/gtf.go
+package rt
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"ipcr-core/primer"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Exon is a genomic interval [Start, End), 0-based.
+type Exon struct{ Start, End int }
+
+// Transcript is a spliced transcript model. Exons are in transcript order
+// (5'→3'), so on the minus strand they run from high to low coordinates.
+type Transcript struct {
+	ID     string
+	GeneID string
+	Gene   string // gene_name when given, else GeneID
+	Chrom  string
+	Strand byte // '+' or '-'
+	Exons  []Exon
+}
+
+// Len is the spliced transcript length.
+func (t Transcript) Len() int {
+	n := 0
+	for _, e := range t.Exons {
+		n += e.End - e.Start
+	}
+	return n
+}
+
+// Junctions lists exon-exon junctions as transcript positions: a junction
+// at j lies between transcript bases j-1 and j.
+func (t Transcript) Junctions() []int {
+	var out []int
+	pos := 0
+	for _, e := range t.Exons[:max(0, len(t.Exons)-1)] {
+		pos += e.End - e.Start
+		out = append(out, pos)
+	}
+	return out
+}
+
+// Genomic maps transcript position i to its genomic coordinate.
+func (t Transcript) Genomic(i int) int {
+	for _, e := range t.Exons {
+		n := e.End - e.Start
+		if i < n {
+			if t.Strand == '-' {
+				return e.End - 1 - i
+			}
+			return e.Start + i
+		}
+		i -= n
+	}
+	return -1
+}
+
+// GenomicSpan is the genomic length covered by transcript interval
+// [start, end), introns included.
+func (t Transcript) GenomicSpan(start, end int) int {
+	if end <= start {
+		return 0
+	}
+	a, b := t.Genomic(start), t.Genomic(end-1)
+	if a < 0 || b < 0 {
+		return 0
+	}
+	if a > b {
+		a, b = b, a
+	}
+	return b - a + 1
+}
+
+// Splice returns the transcript sequence (sense strand, 5'→3') cut from
+// its chromosome.
+func (t Transcript) Splice(chrom []byte) ([]byte, error) {
+	out := make([]byte, 0, t.Len())
+	for _, e := range t.Exons {
+		if e.End > len(chrom) {
+			return nil, fmt.Errorf("transcript %s: exon %d-%d beyond %s (%d bp)", t.ID, e.Start+1, e.End, t.Chrom, len(chrom))
+		}
+		ex := chrom[e.Start:e.End]
+		if t.Strand == '-' {
+			rc, err := primer.RevCompStrict(ex)
+			if err != nil {
+				return nil, fmt.Errorf("transcript %s: %w", t.ID, err)
+			}
+			ex = rc
+		}
+		out = append(out, ex...)
+	}
+	return out, nil
+}
+
+// LoadGTF reads a GTF file from path (gzip when it ends in ".gz").
+func LoadGTF(path string) ([]Transcript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		defer func() { _ = gr.Close() }()
+		r = gr
+	}
+	ts, err := ReadGTF(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ts, nil
+}
+
+// ReadGTF reads the exon features of a GTF file into transcripts, in order
+// of first appearance. Other features and comment lines are ignored.
+func ReadGTF(r io.Reader) ([]Transcript, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	byID := make(map[string]int)
+	var out []Transcript
+	line := 0
+	for sc.Scan() {
+		line++
+		txt := strings.TrimRight(sc.Text(), "\r")
+		if txt == "" || strings.HasPrefix(txt, "#") {
+			continue
+		}
+		f := strings.Split(txt, "\t")
+		if len(f) < 9 {
+			return nil, fmt.Errorf("line %d: want 9 tab-separated columns, got %d", line, len(f))
+		}
+		if f[2] != "exon" {
+			continue
+		}
+		start, err1 := strconv.Atoi(f[3])
+		end, err2 := strconv.Atoi(f[4])
+		if err1 != nil || err2 != nil || start < 1 || end < start {
+			return nil, fmt.Errorf("line %d: bad exon coordinates %q-%q", line, f[3], f[4])
+		}
+		if f[6] != "+" && f[6] != "-" {
+			return nil, fmt.Errorf("line %d: exon strand must be + or -, got %q", line, f[6])
+		}
+		attr := parseAttributes(f[8])
+		id := attr["transcript_id"]
+		if id == "" {
+			return nil, fmt.Errorf("line %d: exon without transcript_id", line)
+		}
+		i, ok := byID[id]
+		if !ok {
+			gene := attr["gene_name"]
+			if gene == "" {
+				gene = attr["gene_id"]
+			}
+			i = len(out)
+			byID[id] = i
+			out = append(out, Transcript{ID: id, GeneID: attr["gene_id"], Gene: gene, Chrom: f[0], Strand: f[6][0]})
+		}
+		t := &out[i]
+		if t.Chrom != f[0] || t.Strand != f[6][0] {
+			return nil, fmt.Errorf("line %d: transcript %s changes chromosome or strand", line, id)
+		}
+		t.Exons = append(t.Exons, Exon{Start: start - 1, End: end})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for i := range out {
+		t := &out[i]
+		sort.Slice(t.Exons, func(a, b int) bool {
+			if t.Strand == '-' {
+				return t.Exons[a].Start > t.Exons[b].Start
+			}
+			return t.Exons[a].Start < t.Exons[b].Start
+		})
+	}
+	return out, nil
+}
+
+// parseAttributes reads GTF attributes (`key "value"; key "value";`).
+func parseAttributes(s string) map[string]string {
+	out := make(map[string]string)
+	for _, kv := range strings.Split(s, ";") {
+		kv = strings.TrimSpace(kv)
+		k, v, ok := strings.Cut(kv, " ")
+		if !ok {
+			continue
+		}
+		out[k] = strings.Trim(strings.TrimSpace(v), `"`)
+	}
+	return out
+}
@@ -0,0 +1,122 @@
+// core/rt/rt.go
+
+// Package rt models reverse transcription for RT-PCR: which parts of an RNA
+// template end up in first-strand cDNA for a given RT primer, and spliced
+// transcript models (GTF exons) that map transcript positions to the genome.
+package rt
+
+import (
+	"errors"
+	"fmt"
+	"ipcr-core/primer"
+	"sort"
+	"strings"
+)
+
+// Kind is the RT priming strategy.
+type Kind string
+
+const (
+	OligoDT  Kind = "oligo-dt" // primes on the poly(A) tail at the 3' end
+	Random   Kind = "random"   // random hexamers prime along the whole RNA
+	Specific Kind = "specific" // a gene-specific primer, antisense to the RNA
+)
+
+// Priming describes first-strand cDNA synthesis.
+type Priming struct {
+	Kind   Kind
+	Primer []byte // Specific only, 5'→3'
+
+	// MaxMM and TerminalWindow bound gene-specific primer binding as for PCR
+	// primers (mismatches never allowed in the last TerminalWindow bases).
+	MaxMM          int
+	TerminalWindow int
+
+	// MaxLen caps the cDNA length (reverse transcriptase processivity);
+	// 0 = the RT reaches the 5' end of the RNA. Random priming ignores it.
+	MaxLen int
+}
+
+// ParsePriming reads "oligo-dt", "random" or a gene-specific primer sequence.
+func ParsePriming(s string) (Priming, error) {
+	switch v := strings.ToLower(strings.TrimSpace(s)); v {
+	case "":
+		return Priming{}, errors.New("empty RT primer")
+	case string(OligoDT), "oligodt":
+		return Priming{Kind: OligoDT}, nil
+	case string(Random), "random-hexamer", "hexamer":
+		return Priming{Kind: Random}, nil
+	}
+	seq, err := primer.Validate(s)
+	if err != nil {
+		return Priming{}, fmt.Errorf("RT primer: %w", err)
+	}
+	return Priming{Kind: Specific, Primer: []byte(seq)}, nil
+}
+
+// Segment is a half-open interval [Start, End) of the RNA covered by cDNA.
+type Segment struct{ Start, End int }
+
+// Segments returns the parts of rna (sense strand, 5'→3', DNA alphabet)
+// copied into first-strand cDNA, merged and sorted. cDNA runs from the RT
+// primer's 3' end toward the 5' end of the RNA, so a gene-specific primer
+// binding rna[a:b] yields [b-MaxLen, b) clipped at 0.
+func (p Priming) Segments(rna []byte) ([]Segment, error) {
+	n := len(rna)
+	if n == 0 {
+		return nil, nil
+	}
+	from := func(end int) Segment {
+		s := Segment{End: end}
+		if p.MaxLen > 0 {
+			s.Start = max(0, end-p.MaxLen)
+		}
+		return s
+	}
+	switch p.Kind {
+	case OligoDT:
+		return []Segment{from(n)}, nil
+	case Random:
+		return []Segment{{0, n}}, nil
+	}
+	// The primer anneals antisense: it matches the reverse complement of rna.
+	rc, err := primer.RevCompStrict(rna)
+	if err != nil {
+		return nil, err
+	}
+	var out []Segment
+	for _, m := range primer.FindMatches(rc, p.Primer, p.MaxMM, 0, p.TerminalWindow) {
+		out = append(out, from(n-m.Pos))
+	}
+	return merge(out), nil
+}
+
+func merge(segs []Segment) []Segment {
+	if len(segs) < 2 {
+		return segs
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].Start < segs[j].Start })
+	out := segs[:1]
+	for _, s := range segs[1:] {
+		last := &out[len(out)-1]
+		if s.Start <= last.End {
+			last.End = max(last.End, s.End)
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// ToDNA rewrites RNA bases (U/u) as T/t in place and returns seq.
+func ToDNA(seq []byte) []byte {
+	for i, b := range seq {
+		switch b {
+		case 'U':
+			seq[i] = 'T'
+		case 'u':
+			seq[i] = 't'
+		}
+	}
+	return seq
+}
@@ -0,0 +1,80 @@
+package rt
+
+import (
+	"ipcr-core/primer"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSegments(t *testing.T) {
+	rna := []byte(strings.Repeat("ACGT", 10) + "GGATCCTTAGCAATCGCA" + strings.Repeat("TTGA", 10)) // 98 nt
+	gsp := primer.RevComp([]byte("GGATCCTTAGCAATCGCA"))                                           // binds 40..58
+	cases := []struct {
+		name string
+		p    Priming
+		want []Segment
+	}{
+		{"oligo-dT", Priming{Kind: OligoDT}, []Segment{{0, 98}}},
+		{"oligo-dT processivity", Priming{Kind: OligoDT, MaxLen: 30}, []Segment{{68, 98}}},
+		{"random", Priming{Kind: Random, MaxLen: 30}, []Segment{{0, 98}}},
+		{"specific", Priming{Kind: Specific, Primer: gsp, MaxLen: 50}, []Segment{{8, 58}}},
+		{"specific, sense strand does not prime", Priming{Kind: Specific, Primer: []byte("GGATCCTTAGCAATCGCA")}, nil},
+	}
+	for _, c := range cases {
+		got, err := c.p.Segments(rna)
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v (%v), want %v", c.name, got, err, c.want)
+		}
+	}
+
+	p, err := ParsePriming("Oligo-dT")
+	if err != nil || p.Kind != OligoDT {
+		t.Fatalf("ParsePriming: %+v %v", p, err)
+	}
+	if p, err = ParsePriming("acgtn"); err != nil || p.Kind != Specific || string(p.Primer) != "ACGTN" {
+		t.Fatalf("ParsePriming sequence: %+v %v", p, err)
+	}
+	if string(ToDNA([]byte("ACGUu"))) != "ACGTt" {
+		t.Fatal("ToDNA should rewrite U as T")
+	}
+}
+
+func TestGTFSpliceAndJunctions(t *testing.T) {
+	gtf := "#comment\n" +
+		"chr1\tt\tgene\t1\t100\t.\t+\t.\tgene_id \"g1\";\n" +
+		"chr1\tt\texon\t21\t30\t.\t+\t.\tgene_id \"g1\"; transcript_id \"t1\"; gene_name \"ABC\";\n" +
+		"chr1\tt\texon\t1\t10\t.\t+\t.\tgene_id \"g1\"; transcript_id \"t1\"; gene_name \"ABC\";\n" +
+		"chr1\tt\texon\t41\t45\t.\t-\t.\tgene_id \"g2\"; transcript_id \"t2\";\n" +
+		"chr1\tt\texon\t51\t55\t.\t-\t.\tgene_id \"g2\"; transcript_id \"t2\";\n"
+	ts, err := ReadGTF(strings.NewReader(gtf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 2 || ts[0].Gene != "ABC" || ts[1].Gene != "g2" {
+		t.Fatalf("transcripts: %+v", ts)
+	}
+	chrom := []byte(strings.Repeat("A", 10) + strings.Repeat("C", 10) + strings.Repeat("G", 10) +
+		strings.Repeat("T", 10) + "AAAAC" + "TTTTT" + "GGGGT" + strings.Repeat("A", 45))
+
+	t1 := ts[0]
+	if s, _ := t1.Splice(chrom); string(s) != strings.Repeat("A", 10)+strings.Repeat("G", 10) {
+		t.Fatalf("t1 splice %s", s)
+	}
+	if !reflect.DeepEqual(t1.Junctions(), []int{10}) || t1.Genomic(10) != 20 || t1.GenomicSpan(5, 15) != 20 {
+		t.Fatalf("t1 junctions %v genomic %d span %d", t1.Junctions(), t1.Genomic(10), t1.GenomicSpan(5, 15))
+	}
+
+	// Minus strand: exons in transcript order from high to low coordinates.
+	t2 := ts[1]
+	if s, _ := t2.Splice(chrom); string(s) != "ACCCC"+"GTTTT" {
+		t.Fatalf("t2 splice %s", s)
+	}
+	if t2.Exons[0].Start != 50 || t2.Genomic(0) != 54 || t2.Genomic(5) != 44 || t2.GenomicSpan(0, 10) != 15 {
+		t.Fatalf("t2 mapping: %+v", t2)
+	}
+
+	if _, err := ReadGTF(strings.NewReader("chr1\tt\texon\t5\t1\t.\t+\t.\ttranscript_id \"x\";\n")); err == nil {
+		t.Fatal("expected an error for reversed exon coordinates")
+	}
+}
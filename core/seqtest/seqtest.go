@@ -0,0 +1,20 @@
+// core/seqtest/seqtest.go
+
+// Package seqtest builds reproducible DNA fixtures for tests.
+package seqtest
+
+import "math/rand"
+
+// Random returns a generator of random A/C/G/T sequences. The same seed
+// yields the same sequences in the same order, so fixtures built from it
+// (and the coordinates tests assert) are stable.
+func Random(seed int64) func(n int) string {
+	rng := rand.New(rand.NewSource(seed))
+	return func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = "ACGT"[rng.Intn(4)]
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,157 @@
+// core/vcf/vcf.go
+
+// Package vcf reads known variants from VCF files for primer-site checks.
+package vcf
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Set holds alleles sorted by position per record (CHROM).
+type Set struct {
+	byChrom map[string][]engine.Allele
+	maxRef  int // longest REF, bounds the overlap search
+}
+
+// Load reads a VCF file from path (gzip/bgzip when it ends in ".gz").
+func Load(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		defer func() { _ = gr.Close() }()
+		r = gr
+	}
+	s, err := Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Read parses VCF records, one Allele per ALT. Symbolic and breakend ALTs
+// ("<DEL>", "*", "A[chr2:5[") are skipped. AF comes from the INFO AF field
+// (one value per ALT); it is -1 when absent.
+func Read(r io.Reader) (*Set, error) {
+	s := &Set{byChrom: make(map[string][]engine.Allele)}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		txt := strings.TrimRight(sc.Text(), "\r")
+		if txt == "" || strings.HasPrefix(txt, "#") {
+			continue
+		}
+		f := strings.SplitN(txt, "\t", 9)
+		if len(f) < 8 {
+			return nil, fmt.Errorf("line %d: want at least 8 tab-separated columns, got %d", line, len(f))
+		}
+		pos, err := strconv.Atoi(f[1])
+		if err != nil || pos < 1 {
+			return nil, fmt.Errorf("line %d: bad POS %q", line, f[1])
+		}
+		ref := strings.ToUpper(f[3])
+		if !isBases(ref) {
+			return nil, fmt.Errorf("line %d: bad REF %q", line, f[3])
+		}
+		id := f[2]
+		if id == "." {
+			id = ""
+		}
+		alts := strings.Split(strings.ToUpper(f[4]), ",")
+		afs := infoAF(f[7])
+		for i, alt := range alts {
+			if !isBases(alt) {
+				continue
+			}
+			af := -1.0
+			if i < len(afs) {
+				af = afs[i]
+			}
+			s.byChrom[f[0]] = append(s.byChrom[f[0]], engine.Allele{ID: id, Pos: pos - 1, Ref: ref, Alt: alt, AF: af})
+			s.maxRef = max(s.maxRef, len(ref))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for _, list := range s.byChrom {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Pos < list[j].Pos })
+	}
+	return s, nil
+}
+
+// Len is the number of alleles in s.
+func (s *Set) Len() int {
+	n := 0
+	for _, list := range s.byChrom {
+		n += len(list)
+	}
+	return n
+}
+
+// Overlap returns the alleles on chrom whose REF overlaps [start, end).
+func (s *Set) Overlap(chrom string, start, end int) []engine.Allele {
+	if s == nil {
+		return nil
+	}
+	list := s.byChrom[chrom]
+	i := sort.Search(len(list), func(i int) bool { return list[i].Pos >= start-s.maxRef })
+	var out []engine.Allele
+	for ; i < len(list) && list[i].Pos < end; i++ {
+		if list[i].Pos+len(list[i].Ref) > start {
+			out = append(out, list[i])
+		}
+	}
+	return out
+}
+
+// infoAF parses the AF values of an INFO column; missing values are -1.
+func infoAF(info string) []float64 {
+	for _, kv := range strings.Split(info, ";") {
+		v, ok := strings.CutPrefix(kv, "AF=")
+		if !ok {
+			continue
+		}
+		var out []float64
+		for _, x := range strings.Split(v, ",") {
+			af, err := strconv.ParseFloat(x, 64)
+			if err != nil {
+				af = -1
+			}
+			out = append(out, af)
+		}
+		return out
+	}
+	return nil
+}
+
+func isBases(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case 'A', 'C', 'G', 'T', 'N':
+		default:
+			return false
+		}
+	}
+	return true
+}
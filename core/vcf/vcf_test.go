@@ -0,0 +1,38 @@
+package vcf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadAndOverlap(t *testing.T) {
+	data := "##fileformat=VCFv4.2\n" +
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n" +
+		"chr1\t20\trs2\tA\tG,T\t.\tPASS\tDP=10;AF=0.25,0.5\n" +
+		"chr1\t5\trs1\tc\tt\t.\tPASS\t.\n" +
+		"chr1\t30\t.\tACGT\tA\t.\tPASS\tAF=0.01\n" +
+		"chr1\t40\tsv1\tA\t<DEL>\t.\tPASS\t.\n" +
+		"chr2\t1\trs9\tG\tA\t.\tPASS\tAF=1\n"
+	s, err := Read(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 5 {
+		t.Fatalf("want 5 alleles (symbolic ALT skipped), got %d", s.Len())
+	}
+	got := s.Overlap("chr1", 0, 20)
+	if len(got) != 3 || got[0].ID != "rs1" || got[0].Pos != 4 || got[0].Ref != "C" || got[0].AF != -1 ||
+		got[1].Alt != "G" || got[1].AF != 0.25 || got[2].Alt != "T" || got[2].AF != 0.5 {
+		t.Fatalf("Overlap [0,20): %+v", got)
+	}
+	// The deletion's REF (29..32) reaches into [31, 35).
+	if got := s.Overlap("chr1", 31, 35); len(got) != 1 || got[0].Pos != 29 || got[0].ID != "" {
+		t.Fatalf("Overlap [31,35): %+v", got)
+	}
+	if got := s.Overlap("chr1", 33, 100); len(got) != 0 {
+		t.Fatalf("Overlap [33,100): %+v", got)
+	}
+	if _, err := Read(strings.NewReader("chr1\tx\t.\tA\tG\t.\t.\t.\n")); err == nil {
+		t.Fatal("expected an error for a bad POS")
+	}
+}
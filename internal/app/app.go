@@ -10,20 +10,47 @@ import (
 	"io"
 	"ipcr-core/engine"
 	"ipcr-core/primer"
+	"ipcr-core/vcf"
 	"ipcr/internal/appcore"
 	"ipcr/internal/cli"
 	"ipcr/internal/clibase"
+	"ipcr/internal/cmdutil"
 	"ipcr/internal/common"
+	"ipcr/internal/coverage"
+	"ipcr/internal/diffapp"
+	"ipcr/internal/indexapp"
+	"ipcr/internal/manifest"
+	"ipcr/internal/msaapp"
+	"ipcr/internal/pipeline"
 	"ipcr/internal/runutil"
+	"ipcr/internal/serveapp"
+	"ipcr/internal/sitesapp"
 	"ipcr/internal/version"
 	"ipcr/internal/visitors"
 	"ipcr/internal/writers"
+	"path/filepath"
 )
 
 func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer) int {
 	outw := bufio.NewWriter(stdout)
 	defer func() { _ = outw.Flush() }()
 
+	if len(argv) > 0 && argv[0] == "index" {
+		return indexapp.RunContext(parent, argv[1:], stdout, stderr)
+	}
+	if len(argv) > 0 && argv[0] == "sites" {
+		return sitesapp.RunContext(parent, argv[1:], stdout, stderr)
+	}
+	if len(argv) > 0 && argv[0] == "serve" {
+		return serveapp.RunContext(parent, argv[1:], stdout, stderr)
+	}
+	if len(argv) > 0 && argv[0] == "diff" {
+		return diffapp.RunContext(parent, argv[1:], stdout, stderr)
+	}
+	if len(argv) > 0 && argv[0] == "msa" {
+		return msaapp.RunContext(parent, argv[1:], stdout, stderr)
+	}
+
 	fs := cli.NewFlagSet("ipcr")
 	fs.SetOutput(io.Discard)
 
@@ -87,6 +114,40 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 		return 0
 	}
 
+	if opts.Replay != "" {
+		path, out := opts.Replay, opts.Manifest
+		prev, err := manifest.Read(path, "ipcr")
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		// Inputs are read from the directory of the recorded run; the new
+		// manifest still goes where this command line says.
+		if out != "" {
+			if out, err = filepath.Abs(out); err != nil {
+				_, _ = fmt.Fprintln(stderr, err)
+				return 2
+			}
+		}
+		leave, err := manifest.Enter(prev)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "--replay %s: %v\n", path, err)
+			return 2
+		}
+		defer leave()
+		argv = manifest.Args(prev)
+		fs = cli.NewFlagSet("ipcr")
+		fs.SetOutput(io.Discard)
+		if opts, err = cli.ParseArgs(fs, argv); err != nil {
+			_, _ = fmt.Fprintf(stderr, "--replay %s: %v\n", path, err)
+			return 2
+		}
+		opts.Manifest = out
+		for _, w := range manifest.Check(prev) {
+			cmdutil.Warnf(stderr, opts.Quiet, "%s", w)
+		}
+	}
+
 	var pairs []primer.Pair
 	if opts.PrimerFile != "" {
 		pairs, err = primer.LoadTSV(opts.PrimerFile)
@@ -97,20 +158,66 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 	} else {
 		pairs = []primer.Pair{{ID: "manual", Forward: opts.Fwd, Reverse: opts.Rev, MinProduct: opts.MinLen, MaxProduct: opts.MaxLen}}
 	}
+	panel := pairs // self pairs are single-primer side reactions, not assays to report on
 	if opts.Self {
 		pairs = common.AddSelfPairs(pairs)
 	}
 
 	termWin := runutil.EffectiveTerminalWindow(opts.TerminalWindow)
 	coreOpts := appcore.Options{
-		SeqFiles: opts.SeqFiles, MaxMM: opts.Mismatches, TerminalWindow: termWin,
+		SeqFiles: opts.SeqFiles, Index: opts.Index, Regions: opts.Regions, ExcludeRegions: opts.ExcludeRegions,
+		MaskPolicy: opts.MaskPolicy, Bisulfite: opts.Bisulfite, MaxMM: opts.Mismatches, MaxIndels: opts.MaxIndels, TerminalWindow: termWin,
 		MinLen: opts.MinLen, MaxLen: opts.MaxLen, HitCap: opts.HitCap, SeedLength: opts.SeedLength,
 		Circular: opts.Circular, Threads: opts.Threads, ChunkSize: opts.ChunkSize,
 		DedupeCap: opts.DedupeCap,
 		Quiet:     opts.Quiet, NoMatchExitCode: opts.NoMatchExitCode,
 	}
-	writer := appcore.NewProductWriterFactory(opts.Output, opts.Sort, opts.Header, opts.Pretty, opts.Products, false, false)
-	return appcore.Run[engine.Product](parent, stdout, stderr, coreOpts, pairs, visitors.PassThrough{}.Visit, writer)
+	products := appcore.NewProductWriterFactory(opts.Output, opts.Sort, opts.Header, opts.Pretty, opts.Products, false, false)
+	products.Columns = writers.TextColumns{Indels: opts.MaxIndels > 0, Bisulfite: opts.Bisulfite, Variants: opts.VCF != ""}
+	var writer appcore.WriterFactory[engine.Product] = products
+	if opts.Summary {
+		sum, err := appcore.NewSummary(coreOpts, opts.AssemblyMap, panel)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		writer = appcore.NewSummaryWriterFactory(opts.Output, opts.Header, sum, func(p engine.Product) engine.Product { return p })
+	}
+	if opts.Coverage {
+		rep := coverage.New(panel, opts.CoverageTop)
+		coreOpts.Ungap = true
+		coreOpts.OnInput = func(in pipeline.Input) { rep.Seen(in.SourceFile, in.Rec.ID) }
+		writer = appcore.CoverageWriterFactory{Format: opts.Output, Header: opts.Header, Report: rep}
+	}
+	visit := visitors.PassThrough{}.Visit
+	if opts.VCF != "" {
+		set, err := vcf.Load(opts.VCF)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		visit = visitors.Variants{Set: set, Window: termWin, FailAF: opts.VCFFailAF}.Visit
+		if !opts.Summary && !opts.Coverage {
+			// Allele-only products are listed, not counted as reference hits.
+			coreOpts.Alleles = set.Overlap
+		}
+	}
+	var rec *manifest.Recorder
+	if opts.Manifest != "" {
+		rec = manifest.New("ipcr", argv, fs, pairs)
+		rec.CommonInputs(&opts)
+		visit = rec.Visit(visit)
+	}
+	code := appcore.Run[engine.Product](parent, stdout, stderr, coreOpts, pairs, visit, writer)
+	if rec != nil {
+		if err := rec.Write(opts.Manifest, code); err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			if code == 0 {
+				return 3
+			}
+		}
+	}
+	return code
 }
 
 func Run(argv []string, stdout, stderr io.Writer) int {
@@ -11,15 +11,45 @@ import (
 	"ipcr-core/primer"
 	"ipcr/internal/cmdutil"
 	"ipcr/internal/pipeline"
+	"ipcr/internal/regions"
 	"ipcr/internal/runutil"
 	"ipcr/internal/writers"
 	"runtime"
+	"sort"
 )
 
 type Options struct {
 	SeqFiles []string
+	Index    string // prebuilt reference index; replaces SeqFiles when set
+
+	// Source replaces SeqFiles/Index with a custom input (e.g. ipcr-rt's
+	// cDNA templates); records are scanned whole.
+	Source pipeline.Source
+
+	Regions        string // BED: scan only these intervals
+	ExcludeRegions string // BED: skip these intervals
+	MaskPolicy     string // --mask-policy; soft-masking needs FASTA input
+
+	// Bisulfite scans the four bisulfite-converted templates of every
+	// record instead of the record itself.
+	Bisulfite bool
+
+	// Ungap strips alignment gaps from every record (aligned FASTA input);
+	// records are scanned whole.
+	Ungap bool
+
+	// OnInput, when set, sees every input before it is scanned (e.g. to
+	// count the sequences of a coverage report).
+	OnInput func(pipeline.Input)
+
+	// Alleles, when set, also scans every known alternate allele near a
+	// primer site in its own haplotype window, reporting the products that
+	// form only with it (pipeline.AlleleSource). Products need a maximum
+	// length for this; without one the alleles are not rescanned.
+	Alleles pipeline.AlleleLookup
 
 	MaxMM          int
+	MaxIndels      int
 	TerminalWindow int
 	MinLen         int
 	MaxLen         int
@@ -31,6 +61,9 @@ type Options struct {
 	ChunkSize int
 	DedupeCap int // NEW
 
+	// Panels, when set, supplies compiled primer panels across scans.
+	Panels *PanelCache
+
 	Quiet           bool
 	NoMatchExitCode int
 }
@@ -65,16 +98,18 @@ func effectiveMaxProductLen(globalMaxLen int, pairs []primer.Pair) int {
 	return effective
 }
 
-func Run[T any](
-	parent context.Context,
-	stdout, stderr io.Writer,
-	o Options,
-	pairs []primer.Pair,
-	visit VisitorFunc[T],
-	wf WriterFactory[T],
-) int {
-	outw := bufio.NewWriter(stdout)
+// Scan is a validated scan of Options over a primer panel, ready to stream.
+type Scan struct {
+	seqFiles []string
+	pairs    []primer.Pair
+	cfg      pipeline.Config
+	sim      pipeline.Simulator
+}
 
+// NewScan checks o against pairs and prepares the pipeline and engine.
+// needSites and needSeq are what the consumer of the products requires.
+// Adjustments that do not stop the scan (chunking disabled, ...) go to warn.
+func NewScan(o Options, pairs []primer.Pair, needSites, needSeq bool, warn func(string)) (*Scan, error) {
 	// longest primer
 	maxPLen := 0
 	for _, pr := range pairs {
@@ -87,17 +122,46 @@ func Run[T any](
 	}
 	effectiveMaxLen := effectiveMaxProductLen(o.MaxLen, pairs)
 	if effectiveMaxLen > 0 && effectiveMaxLen < maxPLen {
-		_, _ = fmt.Fprintf(stderr, "error: effective maximum product length (%d) is smaller than the longest primer length (%d)\n", effectiveMaxLen, maxPLen)
-		return 2
+		return nil, fmt.Errorf("effective maximum product length (%d) is smaller than the longest primer length (%d)", effectiveMaxLen, maxPLen)
 	}
 	if o.MinLen > 0 && o.MaxLen > 0 && o.MinLen > o.MaxLen {
-		_, _ = fmt.Fprintf(stderr, "error: --min-length (%d) exceeds --max-length (%d)\n", o.MinLen, o.MaxLen)
-		return 2
+		return nil, fmt.Errorf("--min-length (%d) exceeds --max-length (%d)", o.MinLen, o.MaxLen)
+	}
+
+	// Panel rows may switch individual pairs to circular templates, which
+	// rules out chunking (and region slicing) just like --circular.
+	circular := o.Circular
+	for _, pr := range pairs {
+		if pr.Circular != nil && *pr.Circular {
+			circular = true
+		}
+	}
+	if circular && !o.Circular && (o.Regions != "" || o.ExcludeRegions != "") {
+		return nil, errors.New("--regions/--exclude-regions cannot be combined with circular panel pairs")
 	}
 
-	chunkSize, overlap, warns := runutil.ValidateChunking(o.Circular, o.ChunkSize, effectiveMaxLen, maxPLen)
+	chunkSize, overlap, warns := runutil.ValidateChunking(circular, o.ChunkSize, effectiveMaxLen, maxPLen)
 	for _, w := range warns {
-		cmdutil.Warnf(stderr, o.Quiet, "%s", w)
+		warn(w)
+	}
+	if o.Index != "" && chunkSize > 0 {
+		warn("--chunk-size is ignored with --index; records are scanned whole")
+		chunkSize, overlap = 0, 0
+	}
+	if o.Bisulfite && chunkSize > 0 {
+		warn("--chunk-size is ignored with --bisulfite; records are scanned whole")
+		chunkSize, overlap = 0, 0
+	}
+	if o.Ungap && chunkSize > 0 {
+		warn("--chunk-size is ignored with --coverage; records are scanned whole")
+		chunkSize, overlap = 0, 0
+	}
+	if o.Source != nil {
+		chunkSize, overlap = 0, 0
+	}
+	if o.Alleles != nil && effectiveMaxLen <= 0 {
+		warn("--vcf: alternate alleles are not rescanned for products without a maximum length; set --max-length")
+		o.Alleles = nil
 	}
 
 	thr := o.Threads
@@ -105,45 +169,86 @@ func Run[T any](
 		thr = runtime.NumCPU()
 	}
 
-	sim := engine.New(engine.Config{
+	ecfg := engine.Config{
 		MaxMM:          o.MaxMM,
 		TerminalWindow: o.TerminalWindow,
 		MinLen:         o.MinLen,
 		MaxLen:         o.MaxLen,
 		HitCap:         o.HitCap,
-		NeedSites:      wf.NeedSites(),
+		NeedSites:      needSites,
 		SeedLen:        o.SeedLength,
 		Circular:       o.Circular,
+		MaxIndels:      o.MaxIndels,
+	}
+	eng := engine.New(ecfg)
+	var sites pipeline.SiteFinder
+	if o.Alleles != nil {
+		sites = primerSites(eng, pairs)
+	}
+	source, err := o.source(chunkSize, overlap, effectiveMaxLen+o.MaxIndels, sites)
+	if err != nil {
+		return nil, err
+	}
+	var sim pipeline.Simulator = eng
+	if o.Panels != nil {
+		sim = cachedPanel{Engine: eng, cp: o.Panels.compile(eng, ecfg, pairs)}
+	}
+
+	return &Scan{
+		seqFiles: o.SeqFiles,
+		pairs:    pairs,
+		cfg: pipeline.Config{
+			Threads:    thr,
+			ChunkSize:  chunkSize,
+			Overlap:    overlap,
+			Circular:   circular,
+			NeedSeq:    needSeq,
+			DedupCap:   o.DedupeCap, // NEW
+			Source:     source,
+			MaskPolicy: o.maskPolicy(),
+		},
+		sim: sim,
+	}, nil
+}
+
+// Stream runs s, passing every product visit keeps on to send, and returns
+// how many were sent. The first error from visit or send is returned; once
+// ctx is cancelled the scan stops and returns ctx.Err().
+func Stream[T any](ctx context.Context, s *Scan, visit VisitorFunc[T], send func(T) error) (int, error) {
+	return cmdutil.RunStream[T](ctx, s.cfg, s.seqFiles, s.pairs, s.sim, visit, send)
+}
+
+func Run[T any](
+	parent context.Context,
+	stdout, stderr io.Writer,
+	o Options,
+	pairs []primer.Pair,
+	visit VisitorFunc[T],
+	wf WriterFactory[T],
+) int {
+	outw := bufio.NewWriter(stdout)
+
+	scan, err := NewScan(o, pairs, wf.NeedSites(), wf.NeedSeq(), func(w string) {
+		cmdutil.Warnf(stderr, o.Quiet, "%s", w)
 	})
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
 
-	inCh, writeErr := wf.Start(outw, thr*4)
+	inCh, writeErr := wf.Start(outw, scan.cfg.Threads*4)
 
 	ctx, cancel := context.WithCancel(parent)
 	defer cancel()
 
-	total, perr := cmdutil.RunStream[T](
-		ctx,
-		pipeline.Config{
-			Threads:   thr,
-			ChunkSize: chunkSize,
-			Overlap:   overlap,
-			Circular:  o.Circular,
-			NeedSeq:   wf.NeedSeq(),
-			DedupCap:  o.DedupeCap, // NEW
-		},
-		o.SeqFiles,
-		pairs,
-		sim,
-		visit,
-		func(x T) error {
-			select {
-			case inCh <- x:
-				return nil
-			case <-ctx.Done():
-				return ctx.Err()
-			}
-		},
-	)
+	total, perr := Stream(ctx, scan, visit, func(x T) error {
+		select {
+		case inCh <- x:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
 
 	close(inCh)
 
@@ -172,3 +277,96 @@ func Run[T any](
 	}
 	return 0
 }
+
+// source picks the scan input for o (see scanSource), shown to OnInput,
+// with the haplotype windows of Alleles (flank bases either side, near
+// sites) and bisulfite-converted with --bisulfite. A nil Source lets the
+// pipeline stream the FASTA files itself.
+func (o Options) source(chunkSize, overlap, flank int, sites pipeline.SiteFinder) (pipeline.Source, error) {
+	src, err := o.scanSource(chunkSize, overlap)
+	if err != nil || (!o.Bisulfite && o.OnInput == nil && o.Alleles == nil) {
+		return src, err
+	}
+	if src == nil {
+		src = pipeline.FASTASource(o.SeqFiles, chunkSize, overlap)
+	}
+	if o.OnInput != nil {
+		scan := src
+		src = func(ctx context.Context, emit func(pipeline.Input) error) error {
+			return scan(ctx, func(in pipeline.Input) error {
+				o.OnInput(in)
+				return emit(in)
+			})
+		}
+	}
+	if o.Alleles != nil {
+		src = pipeline.AlleleSource(src, o.Alleles, flank, sites)
+	}
+	if o.Bisulfite {
+		src = pipeline.BisulfiteSource(src)
+	}
+	return src, nil
+}
+
+// primerSites finds where the primers of pairs bind, so that only alleles
+// near a primer site are rescanned.
+func primerSites(eng *engine.Engine, pairs []primer.Pair) pipeline.SiteFinder {
+	cp := eng.CompilePanel(pairs)
+	return func(seq []byte) []int {
+		var starts []int
+		_ = eng.ForEachCompiledSite("", seq, cp, nil, func(s engine.BindingSite) error {
+			starts = append(starts, s.Start)
+			return nil
+		})
+		sort.Ints(starts)
+		return starts
+	}
+}
+
+// scanSource picks the reference index or the (chunked, optionally
+// soft-mask aware) FASTA files, ungapped with Ungap and restricted to
+// --regions/--exclude-regions when given.
+func (o Options) scanSource(chunkSize, overlap int) (pipeline.Source, error) {
+	var src pipeline.Source
+	switch {
+	case o.Source != nil:
+		src = o.Source
+	case o.Index != "":
+		src = pipeline.IndexSource(o.Index)
+	case o.maskPolicy() != pipeline.MaskIgnore:
+		src = pipeline.MaskedFASTASource(o.SeqFiles, chunkSize, overlap)
+	}
+	if o.Ungap {
+		if src == nil {
+			src = pipeline.FASTASource(o.SeqFiles, chunkSize, overlap)
+		}
+		src = pipeline.UngapSource(src)
+	}
+	if o.Regions == "" && o.ExcludeRegions == "" {
+		return src, nil
+	}
+	var include, exclude *regions.Set
+	var err error
+	if o.Regions != "" {
+		if include, err = regions.Load(o.Regions); err != nil {
+			return nil, err
+		}
+	}
+	if o.ExcludeRegions != "" {
+		if exclude, err = regions.Load(o.ExcludeRegions); err != nil {
+			return nil, err
+		}
+	}
+	if src == nil {
+		src = pipeline.FASTASource(o.SeqFiles, chunkSize, overlap)
+	}
+	return pipeline.RegionSource(src, include, exclude), nil
+}
+
+func (o Options) maskPolicy() pipeline.MaskPolicy {
+	mp, err := pipeline.ParseMaskPolicy(o.MaskPolicy)
+	if err != nil {
+		return pipeline.MaskIgnore // rejected by CLI validation
+	}
+	return mp
+}
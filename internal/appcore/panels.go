@@ -0,0 +1,89 @@
+// internal/appcore/panels.go
+package appcore
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"sync"
+)
+
+// PanelCache keeps compiled primer panels across scans, so a long-running
+// process (ipcr serve) compiles each panel once per engine configuration.
+// It is safe for concurrent use; the least recently used panel is dropped
+// once more than Max are held.
+type PanelCache struct {
+	Max int
+
+	mu     sync.Mutex
+	m      map[[32]byte]*engine.CompiledPanel
+	order  [][32]byte // least recently used first
+	hits   int
+	misses int
+}
+
+// NewPanelCache holds up to n compiled panels (n < 1 means 1).
+func NewPanelCache(n int) *PanelCache {
+	return &PanelCache{Max: n, m: make(map[[32]byte]*engine.CompiledPanel)}
+}
+
+// Stats reports cache hits and misses so far.
+func (c *PanelCache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// compile returns the panel e compiles for pairs under cfg, from the cache
+// when an identical panel was compiled before.
+func (c *PanelCache) compile(e *engine.Engine, cfg engine.Config, pairs []primer.Pair) *engine.CompiledPanel {
+	b, _ := json.Marshal(struct {
+		Cfg   engine.Config
+		Pairs []primer.Pair
+	}{cfg, pairs})
+	key := sha256.Sum256(b)
+
+	c.mu.Lock()
+	if cp, ok := c.m[key]; ok {
+		c.hits++
+		c.touch(key)
+		c.mu.Unlock()
+		return cp
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	cp := e.CompilePanel(pairs) // outside the lock: large panels take a while
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev, ok := c.m[key]; ok {
+		return prev // compiled concurrently
+	}
+	c.m[key] = cp
+	c.order = append(c.order, key)
+	for len(c.order) > max(1, c.Max) {
+		delete(c.m, c.order[0])
+		c.order = c.order[1:]
+	}
+	return cp
+}
+
+func (c *PanelCache) touch(key [32]byte) {
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(append(c.order[:i:i], c.order[i+1:]...), key)
+			return
+		}
+	}
+}
+
+// cachedPanel is the engine with its panel compiled up front; the pipeline
+// takes it in place of compiling its own.
+type cachedPanel struct {
+	*engine.Engine
+	cp *engine.CompiledPanel
+}
+
+func (c cachedPanel) CompilePanel([]primer.Pair) *engine.CompiledPanel { return c.cp }
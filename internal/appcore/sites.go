@@ -0,0 +1,169 @@
+// internal/appcore/sites.go
+package appcore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"ipcr/internal/cmdutil"
+	"ipcr/internal/output"
+	"ipcr/internal/pipeline"
+	"ipcr/internal/siteoutput"
+	"ipcr/internal/writers"
+	"runtime"
+)
+
+// SiteWriterFactory writes single-primer binding sites (`ipcr sites`).
+// Unsorted text and JSONL stream; JSON and --sort buffer the whole run.
+type SiteWriterFactory struct {
+	Format string
+	Sort   bool
+	Header bool
+	Window int // 3' window for three_prime_mm
+}
+
+func (w SiteWriterFactory) Start(out io.Writer, bufSize int) (chan<- engine.BindingSite, <-chan error) {
+	in := make(chan engine.BindingSite, bufSize)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		if w.Sort || w.Format == output.FormatJSON {
+			var list []engine.BindingSite
+			for s := range in {
+				list = append(list, s)
+			}
+			if w.Sort {
+				siteoutput.Sort(list)
+			}
+			switch w.Format {
+			case output.FormatJSON:
+				errc <- siteoutput.WriteJSON(out, list, w.Window)
+			case output.FormatJSONL:
+				errc <- siteoutput.WriteJSONL(out, list, w.Window)
+			default:
+				errc <- siteoutput.WriteTSV(out, list, w.Header, w.Window)
+			}
+			return
+		}
+
+		var err error
+		enc := json.NewEncoder(out)
+		if w.Format == output.FormatText && w.Header {
+			_, err = io.WriteString(out, siteoutput.TSVHeader+"\n")
+		}
+		for s := range in {
+			if err != nil {
+				continue // drain so the pipeline can finish
+			}
+			if w.Format == output.FormatJSONL {
+				err = enc.Encode(siteoutput.ToAPI(s, w.Window))
+			} else {
+				_, err = io.WriteString(out, siteoutput.FormatRowTSV(s, w.Window)+"\n")
+			}
+		}
+		errc <- err
+	}()
+	return in, errc
+}
+
+// RunSites scans for single-primer binding sites and writes them with wf.
+// Product-length options do not apply; exit codes follow Run.
+func RunSites(parent context.Context, stdout, stderr io.Writer, o Options, pairs []primer.Pair, wf SiteWriterFactory) int {
+	outw := bufio.NewWriter(stdout)
+
+	// A chunk overlap of one site span keeps every site whole in some chunk.
+	span := 0
+	for _, pr := range pairs {
+		span = max(span, len(pr.Forward)+o.MaxIndels, len(pr.Reverse)+o.MaxIndels)
+	}
+	chunkSize, overlap := o.ChunkSize, span-1
+	if chunkSize > 0 && o.Index != "" {
+		cmdutil.Warnf(stderr, o.Quiet, "--chunk-size is ignored with --index; records are scanned whole")
+		chunkSize = 0
+	}
+	if chunkSize > 0 && chunkSize <= span {
+		cmdutil.Warnf(stderr, o.Quiet, "chunk-size (%d) <= longest primer site (%d): disabling chunking", chunkSize, span)
+		chunkSize = 0
+	}
+	if chunkSize <= 0 {
+		overlap = 0
+	}
+	o.Alleles = nil // binding sites are reported on the reference only
+	source, err := o.source(chunkSize, overlap, 0, nil)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	thr := o.Threads
+	if thr <= 0 {
+		thr = runtime.NumCPU()
+	}
+
+	sim := engine.New(engine.Config{
+		MaxMM:          o.MaxMM,
+		TerminalWindow: o.TerminalWindow,
+		HitCap:         o.HitCap,
+		SeedLen:        o.SeedLength,
+		MaxIndels:      o.MaxIndels,
+	})
+
+	inCh, writeErr := wf.Start(outw, thr*4)
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	total := 0
+	perr := pipeline.ForEachSite(ctx,
+		pipeline.Config{
+			Threads:    thr,
+			ChunkSize:  chunkSize,
+			Overlap:    overlap,
+			DedupCap:   o.DedupeCap,
+			Source:     source,
+			MaskPolicy: o.maskPolicy(),
+		},
+		o.SeqFiles, pairs, sim,
+		func(s engine.BindingSite) error {
+			select {
+			case inCh <- s:
+				total++
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	)
+
+	close(inCh)
+
+	if werr := <-writeErr; writers.IsBrokenPipe(werr) {
+		return 0
+	} else if werr != nil {
+		_, _ = fmt.Fprintln(stderr, werr)
+		return 3
+	}
+	if e := outw.Flush(); writers.IsBrokenPipe(e) {
+		return 0
+	} else if e != nil {
+		_, _ = fmt.Fprintln(stderr, e)
+		return 3
+	}
+
+	if perr != nil {
+		if errors.Is(perr, context.Canceled) {
+			return 130
+		}
+		_, _ = fmt.Fprintln(stderr, perr)
+		return 3
+	}
+	if total == 0 {
+		return o.NoMatchExitCode
+	}
+	return 0
+}
@@ -3,9 +3,16 @@ package appcore
 import (
 	"io"
 	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"ipcr-core/refindex"
+	"ipcr/internal/assembly"
+	"ipcr/internal/coverage"
+	"ipcr/internal/multiplexoutput"
 	"ipcr/internal/nestedoutput"
 	"ipcr/internal/output"
+	"ipcr/internal/pretty"
 	"ipcr/internal/probeoutput"
+	"ipcr/internal/rtoutput"
 	"ipcr/internal/writers"
 )
 
@@ -19,6 +26,7 @@ type ProductWriterFactory struct {
 	Products     bool
 	IncludeScore bool
 	RankByScore  bool
+	Columns      writers.TextColumns
 }
 
 func NewProductWriterFactory(format string, sort, header, pretty, products bool, includeScore bool, rankByScore bool) ProductWriterFactory {
@@ -34,7 +42,7 @@ func NewProductWriterFactory(format string, sort, header, pretty, products bool,
 }
 
 func (w ProductWriterFactory) NeedSites() bool {
-	return w.Format == output.FormatText && w.Pretty
+	return (w.Format == output.FormatText && w.Pretty) || w.Format == output.FormatSAM
 }
 
 func (w ProductWriterFactory) NeedSeq() bool {
@@ -51,7 +59,7 @@ func (w ProductWriterFactory) NeedSeq() bool {
 }
 
 func (w ProductWriterFactory) Start(out io.Writer, bufSize int) (chan<- engine.Product, <-chan error) {
-	return writers.StartProductWriter(out, w.Format, w.Sort, w.Header, w.Pretty, w.IncludeScore, w.RankByScore, bufSize)
+	return writers.StartProductWriterWithColumns(out, w.Format, w.Sort, w.Header, w.Pretty, w.IncludeScore, w.RankByScore, false, pretty.DefaultOptions, w.Columns, bufSize)
 }
 
 // ---------------- Annotated writer ----------------
@@ -67,13 +75,99 @@ func NewAnnotatedWriterFactory(format string, sort, header, pretty bool) Annotat
 	return AnnotatedWriterFactory{Format: format, Sort: sort, Header: header, Pretty: pretty}
 }
 
-func (w AnnotatedWriterFactory) NeedSites() bool { return w.Pretty }
+func (w AnnotatedWriterFactory) NeedSites() bool { return w.Pretty || w.Format == output.FormatSAM }
 func (w AnnotatedWriterFactory) NeedSeq() bool   { return true } // probe overlay requires sequence
 
 func (w AnnotatedWriterFactory) Start(out io.Writer, bufSize int) (chan<- probeoutput.AnnotatedProduct, <-chan error) {
 	return writers.StartAnnotatedWriter(out, w.Format, w.Sort, w.Header, w.Pretty, bufSize)
 }
 
+// ---------------- Probed (multiplex) writer ----------------
+
+// ProbedWriterFactory writes multiplex products with their panel probe hits
+// and feeds Channels, when set, for the channel collision report.
+type ProbedWriterFactory struct {
+	Format   string
+	Sort     bool
+	Header   bool
+	Pretty   bool
+	Channels *multiplexoutput.Channels
+}
+
+func (w ProbedWriterFactory) NeedSites() bool { return w.Pretty }
+func (w ProbedWriterFactory) NeedSeq() bool   { return true } // probe overlay scans the amplicon
+
+func (w ProbedWriterFactory) Start(out io.Writer, bufSize int) (chan<- multiplexoutput.ProbedProduct, <-chan error) {
+	return writers.StartProbedWriter(out, w.Format, w.Sort, w.Header, w.Pretty, w.Channels, bufSize)
+}
+
+// ---------------- RT writer ----------------
+
+// RTWriterFactory writes ipcr-rt products with their template annotations.
+type RTWriterFactory struct {
+	Format   string
+	Sort     bool
+	Header   bool
+	Pretty   bool
+	Products bool
+}
+
+func (w RTWriterFactory) NeedSites() bool { return w.Pretty }
+func (w RTWriterFactory) NeedSeq() bool   { return w.Products || w.Pretty }
+
+func (w RTWriterFactory) Start(out io.Writer, bufSize int) (chan<- rtoutput.RTProduct, <-chan error) {
+	return writers.StartRTWriter(out, w.Format, w.Sort, w.Header, w.Pretty, bufSize)
+}
+
+// ---------------- Gel writer ----------------
+
+// GelWriterFactory replaces per-product output with per-template band lists
+// and resolvability flags (ipcr-multiplex --gel).
+type GelWriterFactory struct {
+	Format string
+	Header bool
+	Pretty bool
+	Gel    *multiplexoutput.Gel
+}
+
+func (w GelWriterFactory) NeedSites() bool { return false }
+func (w GelWriterFactory) NeedSeq() bool   { return false }
+
+func (w GelWriterFactory) Start(out io.Writer, bufSize int) (chan<- engine.Product, <-chan error) {
+	in := make(chan engine.Product, bufSize)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		for p := range in {
+			w.Gel.Add(p)
+		}
+		errc <- multiplexoutput.WriteGel(out, w.Format, w.Gel.Lanes(), w.Header, w.Pretty)
+	}()
+	return in, errc
+}
+
+// PartitionWriterFactory feeds every product to a Crossings tally and writes
+// nothing; ipcr-multiplex --partition reports once the scan is done.
+type PartitionWriterFactory struct {
+	Crossings *multiplexoutput.Crossings
+}
+
+func (w PartitionWriterFactory) NeedSites() bool { return false }
+func (w PartitionWriterFactory) NeedSeq() bool   { return false }
+
+func (w PartitionWriterFactory) Start(_ io.Writer, bufSize int) (chan<- engine.Product, <-chan error) {
+	in := make(chan engine.Product, bufSize)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		for p := range in {
+			w.Crossings.Add(p)
+		}
+		errc <- nil
+	}()
+	return in, errc
+}
+
 // ---------------- Nested writer ----------------
 
 type NestedWriterFactory struct {
@@ -81,15 +175,133 @@ type NestedWriterFactory struct {
 	Sort   bool
 	Header bool
 	Pretty bool
+	Rounds bool // one text row per inner product
+	Scores bool // products carry thermo scores of both rounds
+	Rank   bool // with Scores and Sort, order by nested score
 }
 
 func NewNestedWriterFactory(format string, sort, header, pretty bool) NestedWriterFactory {
 	return NestedWriterFactory{Format: format, Sort: sort, Header: header, Pretty: pretty}
 }
 
-func (w NestedWriterFactory) NeedSites() bool { return w.Pretty }
+func (w NestedWriterFactory) NeedSites() bool { return w.Pretty || w.Format == output.FormatSAM }
 func (w NestedWriterFactory) NeedSeq() bool   { return true }
 
 func (w NestedWriterFactory) Start(out io.Writer, bufSize int) (chan<- nestedoutput.NestedProduct, <-chan error) {
+	if w.Scores {
+		return writers.StartNestedScoredWriter(out, w.Format, w.Sort, w.Header, w.Pretty, w.Rounds, w.Rank, bufSize)
+	}
+	if w.Rounds {
+		return writers.StartNestedRoundsWriter(out, w.Format, w.Sort, w.Header, w.Pretty, bufSize)
+	}
 	return writers.StartNestedWriterWithPretty(out, w.Format, w.Sort, w.Header, w.Pretty, bufSize)
 }
+
+// ---------------- Summary writer ----------------
+
+// SummaryWriterFactory replaces per-product output with a genome × pair
+// summary (--summary). Product extracts the amplicon that is counted.
+type SummaryWriterFactory[T any] struct {
+	Format  string
+	Header  bool
+	Summary *assembly.Summary
+	Product func(T) engine.Product
+	Seq     bool // the visitor needs amplicon sequences (probe/inner scans)
+}
+
+func NewSummaryWriterFactory[T any](format string, header bool, s *assembly.Summary, product func(T) engine.Product) SummaryWriterFactory[T] {
+	return SummaryWriterFactory[T]{Format: format, Header: header, Summary: s, Product: product}
+}
+
+func (w SummaryWriterFactory[T]) NeedSites() bool { return false }
+func (w SummaryWriterFactory[T]) NeedSeq() bool   { return w.Seq }
+
+func (w SummaryWriterFactory[T]) Start(out io.Writer, bufSize int) (chan<- T, <-chan error) {
+	in := make(chan T, bufSize)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		for x := range in {
+			w.Summary.Add(w.Product(x))
+		}
+		rows := w.Summary.Rows()
+		var err error
+		switch w.Format {
+		case output.FormatJSON:
+			err = assembly.WriteJSON(out, rows)
+		case output.FormatJSONL:
+			err = assembly.WriteJSONL(out, rows)
+		default:
+			err = assembly.WriteTSV(out, rows, w.Header)
+		}
+		errc <- err
+	}()
+	return in, errc
+}
+
+// ---------------- Coverage writer ----------------
+
+// CoverageWriterFactory replaces per-product output with the population
+// coverage report (--coverage). Run must feed Report the scanned sequences
+// (Options.OnInput) for the amplified share to be meaningful.
+type CoverageWriterFactory struct {
+	Format string
+	Header bool
+	Report *coverage.Report
+}
+
+func (w CoverageWriterFactory) NeedSites() bool { return true }
+func (w CoverageWriterFactory) NeedSeq() bool   { return false }
+
+func (w CoverageWriterFactory) Start(out io.Writer, bufSize int) (chan<- engine.Product, <-chan error) {
+	in := make(chan engine.Product, bufSize)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		for p := range in {
+			w.Report.Add(p)
+		}
+		rows := w.Report.Rows()
+		var err error
+		switch w.Format {
+		case output.FormatJSON:
+			err = coverage.WriteJSON(out, rows)
+		case output.FormatJSONL:
+			err = coverage.WriteJSONL(out, rows)
+		default:
+			err = coverage.WriteTSV(out, rows, w.Header)
+		}
+		errc <- err
+	}()
+	return in, errc
+}
+
+// NewSummary prepares the --summary accumulator. Genomes come from the
+// assembly map when one is given, otherwise from the run's source files
+// (read from the index directory when scanning --index).
+func NewSummary(o Options, assemblyMap string, pairs []primer.Pair) (*assembly.Summary, error) {
+	var m *assembly.Map
+	if assemblyMap != "" {
+		var err error
+		if m, err = assembly.Load(assemblyMap); err != nil {
+			return nil, err
+		}
+	}
+	sources := o.SeqFiles
+	if o.Index != "" {
+		ix, err := refindex.Open(o.Index)
+		if err != nil {
+			return nil, err
+		}
+		sources = nil
+		for _, r := range ix.Records {
+			sources = append(sources, r.SourceFile)
+		}
+		_ = ix.Close()
+	}
+	ids := make([]string, len(pairs))
+	for i, p := range pairs {
+		ids[i] = p.ID
+	}
+	return assembly.NewSummary(assembly.NewResolver(m, sources), ids), nil
+}
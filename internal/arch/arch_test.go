@@ -57,6 +57,21 @@ func TestImportBoundaries(t *testing.T) {
 			"ipcr/internal/cli", "ipcr/internal/probecli", "ipcr/internal/nestedcli",
 			"ipcr/internal/pipeline", "ipcr/cmd/",
 		},
+		"ipcr/internal/multiplexoutput": {
+			"ipcr/internal/appcore", "ipcr/internal/app",
+			"ipcr/internal/cli", "ipcr/internal/probecli", "ipcr/internal/nestedcli",
+			"ipcr/internal/pipeline", "ipcr/cmd/",
+		},
+		"ipcr/internal/siteoutput": {
+			"ipcr/internal/appcore", "ipcr/internal/app",
+			"ipcr/internal/cli", "ipcr/internal/probecli", "ipcr/internal/nestedcli",
+			"ipcr/internal/pipeline", "ipcr/cmd/",
+		},
+		"ipcr/internal/rtoutput": {
+			"ipcr/internal/appcore", "ipcr/internal/app",
+			"ipcr/internal/cli", "ipcr/internal/probecli", "ipcr/internal/nestedcli",
+			"ipcr/internal/pipeline", "ipcr/cmd/",
+		},
 		"ipcr/internal/pretty": {
 			"ipcr/internal/appcore", "ipcr/internal/app",
 			"ipcr/internal/cli", "ipcr/internal/probecli", "ipcr/internal/nestedcli",
@@ -76,6 +91,11 @@ func TestImportBoundaries(t *testing.T) {
 			continue
 		}
 		imp := p.ImportPath
+		for _, dep := range p.Imports {
+			if dep == "ipcr-core/seqtest" {
+				violations = append(violations, imp+" → "+dep+" (test fixtures; tests only)")
+			}
+		}
 		for prefix, forbidden := range bans {
 			if !strings.HasPrefix(imp, prefix) {
 				continue
@@ -0,0 +1,59 @@
+package assembly
+
+import (
+	"bytes"
+	"ipcr-core/engine"
+	"strings"
+	"testing"
+)
+
+func TestParseAndResolve(t *testing.T) {
+	m, err := Parse(strings.NewReader("# file or record\tgenome\ngA.fa\tGA\n/data/gB.fa.gz\tGB\nplasmid1\tGA\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Genomes(); len(got) != 2 || got[0] != "GA" || got[1] != "GB" {
+		t.Fatalf("genomes = %v", got)
+	}
+	r := NewResolver(m, nil)
+	cases := []struct{ file, id, want string }{
+		{"/x/gA.fa", "contig1", "GA"},             // base name
+		{"/data/gB.fa.gz", "contig9", "GB"},       // exact path
+		{"other.fa", "plasmid1", "GA"},            // record ID wins
+		{"unmapped.fa", "contig1", "unmapped.fa"}, // fallback to source file
+	}
+	for _, c := range cases {
+		if got := r.Genome(c.file, c.id); got != c.want {
+			t.Errorf("Genome(%q,%q) = %q, want %q", c.file, c.id, got, c.want)
+		}
+	}
+}
+
+func TestParseRejectsConflicts(t *testing.T) {
+	if _, err := Parse(strings.NewReader("a.fa\tG1\na.fa\tG2\n")); err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if _, err := Parse(strings.NewReader("a.fa\n")); err == nil {
+		t.Fatal("expected column error")
+	}
+}
+
+func TestSummaryRowsIncludeMisses(t *testing.T) {
+	s := NewSummary(NewResolver(nil, []string{"a.fa", "b.fa"}), []string{"P1", "P2"})
+	s.Add(engine.Product{ExperimentID: "P1", SourceFile: "a.fa", Length: 120, FwdMM: 1, RevMM: 1})
+	s.Add(engine.Product{ExperimentID: "P1", SourceFile: "a.fa", Length: 90})
+	s.Add(engine.Product{ExperimentID: "P1", SourceFile: "a.fa", Length: 120, RevMM: 1})
+
+	var buf bytes.Buffer
+	if err := WriteTSV(&buf, s.Rows(), true); err != nil {
+		t.Fatal(err)
+	}
+	want := SummaryTSVHeader + "\n" +
+		"a.fa\tP1\tyes\t3\t90,120\t0\n" +
+		"a.fa\tP2\tno\t0\t-\t-\n" +
+		"b.fa\tP1\tno\t0\t-\t-\n" +
+		"b.fa\tP2\tno\t0\t-\t-\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
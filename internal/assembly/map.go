@@ -0,0 +1,148 @@
+// internal/assembly/map.go
+
+// Package assembly groups scanned FASTA records into genomes (assemblies) and
+// builds per-genome, per-primer-pair summaries of the products found.
+package assembly
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Map assigns FASTA files or individual records to genome IDs. It is loaded
+// from a two-column TSV: key (file path, file name or record ID) and genome ID.
+type Map struct {
+	byKey   map[string]string
+	genomes []string // first-seen order
+}
+
+// Load reads an assembly map TSV from path.
+func Load(path string) (*Map, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	m, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Parse reads an assembly map. Blank lines and lines starting with '#' are
+// ignored; a key may only be assigned to one genome.
+func Parse(r io.Reader) (*Map, error) {
+	m := &Map{byKey: make(map[string]string)}
+	seen := make(map[string]bool)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		txt := strings.TrimSpace(sc.Text())
+		if txt == "" || strings.HasPrefix(txt, "#") {
+			continue
+		}
+		cols := strings.Split(txt, "\t")
+		if len(cols) < 2 {
+			return nil, fmt.Errorf("line %d: want <file|record>\\t<genome_id>", line)
+		}
+		key, genome := strings.TrimSpace(cols[0]), strings.TrimSpace(cols[1])
+		if key == "" || genome == "" {
+			return nil, fmt.Errorf("line %d: empty key or genome ID", line)
+		}
+		if prev, ok := m.byKey[key]; ok && prev != genome {
+			return nil, fmt.Errorf("line %d: %q already assigned to genome %q", line, key, prev)
+		}
+		m.byKey[key] = genome
+		if !seen[genome] {
+			seen[genome] = true
+			m.genomes = append(m.genomes, genome)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Genomes lists the genome IDs in the order they first appear in the map.
+func (m *Map) Genomes() []string { return append([]string(nil), m.genomes...) }
+
+// Resolver names the genome a product belongs to.
+type Resolver struct {
+	m       *Map
+	genomes []string
+}
+
+// NewResolver builds a resolver over an optional map. Without a map every
+// source file is its own genome. sources are the inputs of the run and seed
+// the genome list so that genomes without any product still get rows.
+func NewResolver(m *Map, sources []string) *Resolver {
+	r := &Resolver{m: m}
+	if m != nil {
+		r.genomes = m.Genomes()
+		return r
+	}
+	seen := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		if !seen[s] {
+			seen[s] = true
+			r.genomes = append(r.genomes, s)
+		}
+	}
+	return r
+}
+
+// Genome returns the genome ID for a record. Lookup order is record ID, the
+// source path as given, then its base name; unmapped records fall back to
+// their source file.
+func (r *Resolver) Genome(sourceFile, seqID string) string {
+	if r.m != nil {
+		if g, ok := r.m.byKey[seqID]; ok {
+			return g
+		}
+		if g, ok := r.m.byKey[sourceFile]; ok {
+			return g
+		}
+		if g, ok := r.m.byKey[filepath.Base(sourceFile)]; ok {
+			return g
+		}
+	}
+	return sourceFile
+}
+
+// FileGenome returns the genome a whole input file belongs to. Without a map
+// that is the file itself; with a map the file (or its base name) must be
+// listed, otherwise genomes are only known per record.
+func (r *Resolver) FileGenome(sourceFile string) (string, bool) {
+	if r.m == nil {
+		return sourceFile, true
+	}
+	if g, ok := r.m.byKey[sourceFile]; ok {
+		return g, true
+	}
+	g, ok := r.m.byKey[filepath.Base(sourceFile)]
+	return g, ok
+}
+
+// Mapped reports whether genomes come from an assembly map.
+func (r *Resolver) Mapped() bool { return r.m != nil }
+
+// Genomes lists the known genome IDs in report order.
+func (r *Resolver) Genomes() []string { return append([]string(nil), r.genomes...) }
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
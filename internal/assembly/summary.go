@@ -0,0 +1,139 @@
+// internal/assembly/summary.go
+package assembly
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr/internal/jsonutil"
+	"ipcr/pkg/api"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SummaryTSVHeader is the header row of the text summary report.
+const SummaryTSVHeader = "genome\texperiment_id\thit\tproducts\tlengths\tmin_mm"
+
+type cellKey struct{ genome, pair string }
+
+type cell struct {
+	products int
+	lengths  map[int]bool
+	minMM    int
+}
+
+// Summary accumulates products into genome × pair cells.
+type Summary struct {
+	r     *Resolver
+	pairs []string
+	cells map[cellKey]*cell
+}
+
+// NewSummary reports every genome known to r against every pair ID.
+func NewSummary(r *Resolver, pairIDs []string) *Summary {
+	return &Summary{r: r, pairs: pairIDs, cells: make(map[cellKey]*cell)}
+}
+
+// Add records one product.
+func (s *Summary) Add(p engine.Product) {
+	k := cellKey{genome: s.r.Genome(p.SourceFile, p.SequenceID), pair: p.ExperimentID}
+	c := s.cells[k]
+	if c == nil {
+		c = &cell{lengths: make(map[int]bool), minMM: p.FwdMM + p.RevMM}
+		s.cells[k] = c
+	}
+	c.products++
+	c.lengths[p.Length] = true
+	c.minMM = min(c.minMM, p.FwdMM+p.RevMM)
+}
+
+// Rows returns one row per genome and pair: known genomes first (in resolver
+// order) followed by any other genome seen in products, sorted; pairs in
+// panel order followed by any other experiment ID seen, sorted.
+func (s *Summary) Rows() []api.GenomeSummaryV1 {
+	genomes := s.r.Genomes()
+	pairs := append([]string(nil), s.pairs...)
+	knownG := make(map[string]bool, len(genomes))
+	for _, g := range genomes {
+		knownG[g] = true
+	}
+	knownP := make(map[string]bool, len(pairs))
+	for _, p := range pairs {
+		knownP[p] = true
+	}
+	extraG, extraP := map[string]bool{}, map[string]bool{}
+	for k := range s.cells {
+		if !knownG[k.genome] {
+			extraG[k.genome] = true
+		}
+		if !knownP[k.pair] {
+			extraP[k.pair] = true
+		}
+	}
+	genomes = append(genomes, sortedKeys(extraG)...)
+	pairs = append(pairs, sortedKeys(extraP)...)
+
+	rows := make([]api.GenomeSummaryV1, 0, len(genomes)*len(pairs))
+	for _, g := range genomes {
+		for _, p := range pairs {
+			row := api.GenomeSummaryV1{Genome: g, ExperimentID: p, Lengths: []int{}}
+			if c := s.cells[cellKey{genome: g, pair: p}]; c != nil {
+				row.Hit = true
+				row.Products = c.products
+				for l := range c.lengths {
+					row.Lengths = append(row.Lengths, l)
+				}
+				sort.Ints(row.Lengths)
+				mm := c.minMM
+				row.MinMismatches = &mm
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// WriteTSV writes rows as text. Lengths are comma-separated; misses show "-".
+func WriteTSV(w io.Writer, rows []api.GenomeSummaryV1, header bool) error {
+	if header {
+		if _, err := io.WriteString(w, SummaryTSVHeader+"\n"); err != nil {
+			return err
+		}
+	}
+	for _, r := range rows {
+		hit, lens, mm := "no", "-", "-"
+		if r.Hit {
+			hit = "yes"
+			parts := make([]string, len(r.Lengths))
+			for i, l := range r.Lengths {
+				parts[i] = strconv.Itoa(l)
+			}
+			lens = strings.Join(parts, ",")
+		}
+		if r.MinMismatches != nil {
+			mm = strconv.Itoa(*r.MinMismatches)
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", r.Genome, r.ExperimentID, hit, r.Products, lens, mm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes rows as an indented JSON array.
+func WriteJSON(w io.Writer, rows []api.GenomeSummaryV1) error {
+	return jsonutil.EncodePretty(w, rows)
+}
+
+// WriteJSONL writes one JSON object per row.
+func WriteJSONL(w io.Writer, rows []api.GenomeSummaryV1) error {
+	enc := json.NewEncoder(w)
+	for _, r := range rows {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
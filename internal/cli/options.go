@@ -16,6 +16,21 @@ func NewFlagSet(name string) *flag.FlagSet {
 		_, _ = fmt.Fprintln(out, "Usage:")
 		_, _ = fmt.Fprintf(out, "  %s [options] --forward AAA --reverse TTT --sequences ref.fa\n", name)
 		_, _ = fmt.Fprintf(out, "  %s [options] --forward AAA --reverse TTT ref*.fa gz/*.fa.gz\n", name)
+
+		_, _ = fmt.Fprintln(out, "\nBisulfite:")
+		_, _ = fmt.Fprintf(out, "      --bisulfite             Scan C→T top and G→A bottom strands, CpG methylated and not (text/json/jsonl) [%s]\n", def("bisulfite"))
+
+		_, _ = fmt.Fprintln(out, "\nVariants:")
+		_, _ = fmt.Fprintln(out, "      --vcf string            Known variants (.vcf/.vcf.gz) reported under primer sites (text/json/jsonl)")
+		_, _ = fmt.Fprintf(out, "      --vcf-fail-af float     Drop products with a variant of AF ≥ this in a primer 3' window (<0 off) [%s]\n", def("vcf-fail-af"))
+
+		_, _ = fmt.Fprintln(out, "\nCoverage:")
+		_, _ = fmt.Fprintf(out, "      --coverage              Per-pair %% amplified, primer mismatch spectra and site variants (aligned FASTA ok) [%s]\n", def("coverage"))
+		_, _ = fmt.Fprintf(out, "      --coverage-top int      Site variants listed per primer (0=all) [%s]\n", def("coverage-top"))
+
+		_, _ = fmt.Fprintln(out, "\nProvenance:")
+		_, _ = fmt.Fprintln(out, "      --manifest file         Write options, input checksums, versions and per-pair counts as JSON")
+		_, _ = fmt.Fprintln(out, "      --replay file           Rerun with the options and inputs of a manifest")
 	})
 	return fs
 }
@@ -36,19 +51,6 @@ func examplesFor(name string) func(io.Writer) {
 			_, _ = fmt.Fprintln(out, "    --pretty \\")
 			_, _ = fmt.Fprintln(out, "    Escherichia-coli.fna.gz")
 		}
-	case "ipcr-multiplex":
-		return func(out io.Writer) {
-			_, _ = fmt.Fprintln(out, "Multiplex PCR: amplification with primer pools.")
-			_, _ = fmt.Fprintln(out, "Build primer pools from repeated -f/-r flags or a --primers TSV.")
-			_, _ = fmt.Fprintln(out, "Use --self to include A×rc(A) and B×rc(B) self-pairs.")
-			_, _ = fmt.Fprintln(out, "\nExample:")
-			_, _ = fmt.Fprintln(out, "  ipcr-multiplex \\")
-			_, _ = fmt.Fprintln(out, "    --primers multiplex-pcr-assay.tsv \\")
-			_, _ = fmt.Fprintln(out, "    --output jsonl \\")
-			_, _ = fmt.Fprintln(out, "    --products \\")
-			_, _ = fmt.Fprintln(out, "    --sort \\")
-			_, _ = fmt.Fprintln(out, "    Salmonella-Typhimurium.fna.gz")
-		}
 	default:
 		return nil
 	}
@@ -65,6 +67,10 @@ func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
 	var showExamples bool
 
 	noHeader := clibase.Register(fs, &o)
+	clibase.RegisterBisulfite(fs, &o)
+	clibase.RegisterVCF(fs, &o)
+	clibase.RegisterCoverage(fs, &o)
+	clibase.RegisterManifest(fs, &o)
 	fs.BoolVar(&help, "h", false, "show this help [false]")
 	fs.BoolVar(&showExamples, "examples", false, "show quickstart examples and exit [false]")
 
@@ -81,8 +87,17 @@ func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
 	if o.Version {
 		return o, nil
 	}
+	if o.Replay != "" {
+		return o, clibase.ValidateReplay(fs, posArgs)
+	}
 	if err := clibase.AfterParse(fs, &o, noHeader, posArgs); err != nil {
 		return o, err
 	}
+	if err := clibase.ValidateVCF(&o); err != nil {
+		return o, err
+	}
+	if err := clibase.ValidateCoverage(&o); err != nil {
+		return o, err
+	}
 	return o, nil
 }
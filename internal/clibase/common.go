@@ -8,6 +8,7 @@ import (
 	"ipcr-core/primer"
 	"ipcr/internal/cliutil"
 	"ipcr/internal/output"
+	"ipcr/internal/pipeline"
 )
 
 // Common holds CLI fields shared by ipcr/ipcr-probe/ipcr-nested/ipcr-multiplex.
@@ -17,9 +18,18 @@ type Common struct {
 	Fwd        string
 	Rev        string
 	SeqFiles   []string
+	Index      string // prebuilt reference index (ipcr index build), replaces SeqFiles
+
+	Regions        string // BED: scan only these intervals of each record
+	ExcludeRegions string // BED: skip these intervals
+	MaskPolicy     string // soft-masked (lowercase) bases: ignore|no-primer-in-mask|report
+	Bisulfite      bool   // scan bisulfite-converted templates (ipcr, ipcr-thermo)
+	VCF            string // known variants checked against primer sites (ipcr, ipcr-thermo)
+	VCFFailAF      float64
 
 	// PCR
 	Mismatches     int
+	MaxIndels      int
 	MinLen         int
 	MaxLen         int
 	HitCap         int
@@ -34,12 +44,20 @@ type Common struct {
 	DedupeCap  int // LRU window capacity for cross-chunk de-duplication (0=default)
 
 	// Output
-	Output          string // text|json|jsonl|fasta
+	Output          string // text|json|jsonl|fasta|bed|bed12|gff3|sam
 	Products        bool
 	Pretty          bool
 	Sort            bool
 	Header          bool
 	NoMatchExitCode int
+	Summary         bool   // per-genome × per-pair summary instead of products
+	AssemblyMap     string // TSV: file or record → genome ID (for --summary)
+	Coverage        bool   // population coverage report instead of products (ipcr)
+	CoverageTop     int    // site variants listed per primer (--coverage)
+
+	// Provenance (ipcr, ipcr-thermo)
+	Manifest string // JSON sidecar recording the run
+	Replay   string // manifest of an earlier run to repeat
 
 	// Misc
 	Quiet   bool
@@ -56,6 +74,7 @@ func (s *sliceValue) String() string {
 	return fmt.Sprint(*s.dst)
 }
 func (s *sliceValue) Set(v string) error { *s.dst = append(*s.dst, v); return nil }
+func (s *sliceValue) Get() any           { return append([]string{}, *s.dst...) }
 
 // Register wires shared flags onto fs and returns a pointer to the “no-header” bool.
 func Register(fs *flag.FlagSet, c *Common) *bool {
@@ -69,9 +88,14 @@ func Register(fs *flag.FlagSet, c *Common) *bool {
 	seqVal := &sliceValue{dst: &c.SeqFiles}
 	fs.Var(seqVal, "sequences", "FASTA file(s) (repeatable) or '-'")
 	fs.Var(seqVal, "s", "alias of --sequences")
+	fs.StringVar(&c.Index, "index", "", "prebuilt reference index (from 'ipcr index build') instead of FASTA")
+	fs.StringVar(&c.Regions, "regions", "", "BED file: scan only these intervals")
+	fs.StringVar(&c.ExcludeRegions, "exclude-regions", "", "BED file: skip these intervals")
+	fs.StringVar(&c.MaskPolicy, "mask-policy", "ignore", "soft-masked (lowercase) bases: ignore | no-primer-in-mask | report [ignore]")
 
 	// PCR
 	fs.IntVar(&c.Mismatches, "mismatches", 0, "max mismatches per primer [0]")
+	fs.IntVar(&c.MaxIndels, "max-indels", 0, "max insertions/deletions per primer site [0]")
 	fs.IntVar(&c.MinLen, "min-length", 0, "minimum product length [0]")
 	fs.IntVar(&c.MaxLen, "max-length", 2000, "maximum product length [2000]")
 	fs.IntVar(&c.HitCap, "hit-cap", 10000, "max matches stored per primer/window (0=unlimited) [10000]")
@@ -90,7 +114,7 @@ func Register(fs *flag.FlagSet, c *Common) *bool {
 	fs.IntVar(&c.DedupeCap, "dedupe-cap", 200000, "dedupe window capacity for cross-chunk uniqueness [200000]")
 
 	// Output
-	fs.StringVar(&c.Output, "output", "text", "output: text | json | jsonl | fasta [text]")
+	fs.StringVar(&c.Output, "output", "text", "output: text | json | jsonl | fasta | bed | bed12 | gff3 | sam [text]")
 	fs.StringVar(&c.Output, "o", "text", "alias of --output")
 	fs.BoolVar(&c.Products, "products", false, "emit product sequences [false]")
 	fs.BoolVar(&c.Pretty, "pretty", false, "pretty ASCII alignment block (text) [false]")
@@ -99,6 +123,8 @@ func Register(fs *flag.FlagSet, c *Common) *bool {
 	fs.BoolVar(&noHeader, "no-header", false, "suppress header line [false]")
 	// Default is now 0 so “no matches” is not treated as an error unless requested.
 	fs.IntVar(&c.NoMatchExitCode, "no-match-exit-code", 0, "exit code when no amplicons found [0]")
+	fs.BoolVar(&c.Summary, "summary", false, "report per-genome × per-pair hits instead of products [false]")
+	fs.StringVar(&c.AssemblyMap, "assembly-map", "", "TSV mapping file or record to genome ID (for --summary)")
 
 	// Misc
 	fs.BoolVar(&c.Quiet, "quiet", false, "suppress non-essential warnings [false]")
@@ -144,15 +170,38 @@ func Validate(c *Common) error {
 		c.Fwd = fwd
 		c.Rev = rev
 	}
-	if len(c.SeqFiles) == 0 {
+	return ValidateScan(c)
+}
+
+// ValidateScan checks the options other than the primer source, normalizing
+// MaskPolicy.
+func ValidateScan(c *Common) error {
+	switch {
+	case c.Index != "" && len(c.SeqFiles) > 0:
+		return errors.New("--index conflicts with FASTA sequence inputs")
+	case c.Index == "" && len(c.SeqFiles) == 0:
 		return errors.New("at least one sequence file is required")
 	}
+	if c.Circular && (c.Regions != "" || c.ExcludeRegions != "") {
+		return errors.New("--regions/--exclude-regions cannot be combined with --circular")
+	}
+	mp, err := pipeline.ParseMaskPolicy(c.MaskPolicy)
+	if err != nil {
+		return err
+	}
+	c.MaskPolicy = string(mp)
+	if c.Index != "" && mp != pipeline.MaskIgnore {
+		return errors.New("--mask-policy needs FASTA input; the index does not keep soft-masking")
+	}
 	if c.Threads < 0 {
 		return errors.New("--threads must be ≥ 0")
 	}
 	if c.ChunkSize < 0 {
 		return errors.New("--chunk-size must be ≥ 0")
 	}
+	if c.MaxIndels < 0 {
+		return errors.New("--max-indels must be ≥ 0")
+	}
 	if c.HitCap < 0 {
 		return errors.New("--hit-cap must be ≥ 0")
 	}
@@ -160,15 +209,123 @@ func Validate(c *Common) error {
 		return errors.New("--dedupe-cap must be ≥ 0")
 	}
 	switch c.Output {
-	case output.FormatText, output.FormatJSON, output.FormatJSONL, output.FormatFASTA:
+	case output.FormatText, output.FormatJSON, output.FormatJSONL, output.FormatFASTA,
+		output.FormatBED, output.FormatBED12, output.FormatGFF3, output.FormatSAM:
 	default:
 		return fmt.Errorf("invalid --output %q", c.Output)
 	}
+	if c.Summary && !output.IsTabular(c.Output) {
+		return errors.New("--summary supports text, json or jsonl output")
+	}
+	if c.AssemblyMap != "" && !c.Summary {
+		return errors.New("--assembly-map requires --summary")
+	}
 	if c.TerminalWindow < -1 {
 		return errors.New("--terminal-window must be ≥ -1")
 	}
 	if c.NoMatchExitCode < 0 || c.NoMatchExitCode > 255 {
 		return errors.New("--no-match-exit-code must be between 0 and 255")
 	}
+	return ValidateBisulfite(c)
+}
+
+// RegisterBisulfite adds --bisulfite for tools that label plain products
+// with the converted strand and methylation state.
+func RegisterBisulfite(fs *flag.FlagSet, c *Common) {
+	fs.BoolVar(&c.Bisulfite, "bisulfite", false, "scan the four bisulfite-converted strands (MSP/BSP) [false]")
+}
+
+// ValidateBisulfite checks --bisulfite against the output options: the
+// strand and methylation labels are reported in text, JSON and JSONL output.
+func ValidateBisulfite(c *Common) error {
+	if !c.Bisulfite {
+		return nil
+	}
+	if c.Summary {
+		return errors.New("--bisulfite cannot be combined with --summary")
+	}
+	if !output.IsTabular(c.Output) {
+		return errors.New("--bisulfite labels products in text, json or jsonl output")
+	}
 	return nil
 }
+
+// RegisterVCF adds --vcf and --vcf-fail-af for tools that annotate plain
+// products with primer-site variants.
+func RegisterVCF(fs *flag.FlagSet, c *Common) {
+	fs.StringVar(&c.VCF, "vcf", "", "VCF (.vcf or .vcf.gz) of known variants to check under primer sites")
+	fs.Float64Var(&c.VCFFailAF, "vcf-fail-af", -1, "drop products with a variant of AF ≥ this in a primer 3' window (<0 disables) [-1]")
+}
+
+// ValidateVCF checks the --vcf options: variant hits are reported in text,
+// JSON and JSONL output. Only tools that call RegisterVCF validate them.
+func ValidateVCF(c *Common) error {
+	if c.VCF == "" {
+		if c.VCFFailAF >= 0 {
+			return errors.New("--vcf-fail-af requires --vcf")
+		}
+		return nil
+	}
+	if c.VCFFailAF > 1 {
+		return errors.New("--vcf-fail-af must be ≤ 1")
+	}
+	if !output.IsTabular(c.Output) && !c.Summary && !c.Coverage {
+		return errors.New("--vcf reports variants in text, json or jsonl output")
+	}
+	return nil
+}
+
+// RegisterCoverage adds --coverage and --coverage-top.
+func RegisterCoverage(fs *flag.FlagSet, c *Common) {
+	fs.BoolVar(&c.Coverage, "coverage", false, "report per-pair coverage, primer mismatch spectra and site variants instead of products [false]")
+	fs.IntVar(&c.CoverageTop, "coverage-top", 10, "site variants listed per primer with --coverage (0=all) [10]")
+}
+
+// ValidateCoverage checks --coverage against the other report and input
+// options. Only tools that call RegisterCoverage validate them.
+func ValidateCoverage(c *Common) error {
+	if !c.Coverage {
+		return nil
+	}
+	if c.CoverageTop < 0 {
+		return errors.New("--coverage-top must be ≥ 0")
+	}
+	if c.Summary {
+		return errors.New("--coverage cannot be combined with --summary")
+	}
+	if c.Bisulfite {
+		return errors.New("--coverage cannot be combined with --bisulfite")
+	}
+	if !output.IsTabular(c.Output) {
+		return errors.New("--coverage supports text, json or jsonl output")
+	}
+	if mp, _ := pipeline.ParseMaskPolicy(c.MaskPolicy); mp != pipeline.MaskIgnore {
+		return errors.New("--coverage cannot be combined with --mask-policy")
+	}
+	return nil
+}
+
+// RegisterManifest adds --manifest and --replay.
+func RegisterManifest(fs *flag.FlagSet, c *Common) {
+	fs.StringVar(&c.Manifest, "manifest", "", "write a JSON manifest of options, inputs and results to this file")
+	fs.StringVar(&c.Replay, "replay", "", "rerun with the options of this manifest")
+}
+
+// ValidateReplay checks that --replay comes alone: the manifest sets every
+// option and input. Only --manifest may be added, for the new run.
+func ValidateReplay(fs *flag.FlagSet, posArgs []string) error {
+	if len(posArgs) > 0 {
+		return errors.New("--replay takes its inputs from the manifest; drop the sequence arguments")
+	}
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if err == nil && f.Name != "replay" && f.Name != "manifest" {
+			dash := "--"
+			if len(f.Name) == 1 {
+				dash = "-"
+			}
+			err = fmt.Errorf("%s%s cannot be combined with --replay; the manifest sets every option", dash, f.Name)
+		}
+	})
+	return err
+}
@@ -8,7 +8,33 @@ import (
 	"ipcr/internal/version"
 )
 
+// UsageHeader prints the banner shared by every ipcr tool's help text.
+func UsageHeader(out io.Writer, name string) {
+	_, _ = fmt.Fprintf(out, "%s – in-silico PCR toolkit\n\n", name)
+	_, _ = fmt.Fprintln(out, "Author:  Erick Samera (erick.samera@kpu.ca)")
+	_, _ = fmt.Fprintln(out, "License: MIT")
+	_, _ = fmt.Fprintf(out, "Version: %s\n", version.Version)
+	_, _ = fmt.Fprintf(out, "Engine: %s\n", version.EngineVersion)
+	_, _ = fmt.Fprintf(out, "Thermo: %s\n", version.ThermoVersion)
+	_, _ = fmt.Fprintf(out, "Output schema: %s\n\n", version.OutputSchemaVersion)
+}
+
 func UsageCommon(fs *flag.FlagSet, name string, extra func(out io.Writer, def func(string) string)) {
+	UsageCommonScoped(fs, name, UsageScope{}, extra)
+}
+
+// UsageScope trims the shared help to the flags a tool honours.
+type UsageScope struct {
+	Hide        []string // shared flags (long names) the tool rejects or ignores
+	TabularOnly bool     // --output takes text, json or jsonl only
+}
+
+// UsageCommonScoped is UsageCommon without the lines of scope.Hide.
+func UsageCommonScoped(fs *flag.FlagSet, name string, scope UsageScope, extra func(out io.Writer, def func(string) string)) {
+	hidden := make(map[string]bool, len(scope.Hide))
+	for _, n := range scope.Hide {
+		hidden[n] = true
+	}
 	fs.Usage = func() {
 		out := fs.Output()
 		def := func(flagName string) string {
@@ -17,46 +43,56 @@ func UsageCommon(fs *flag.FlagSet, name string, extra func(out io.Writer, def fu
 			}
 			return ""
 		}
+		line := func(flagName, format string, a ...any) {
+			if !hidden[flagName] {
+				_, _ = fmt.Fprintf(out, format+"\n", a...)
+			}
+		}
 
-		_, _ = fmt.Fprintf(out, "%s – in-silico PCR toolkit\n\n", name)
-		_, _ = fmt.Fprintln(out, "Author:  Erick Samera (erick.samera@kpu.ca)")
-		_, _ = fmt.Fprintln(out, "License: MIT")
-		_, _ = fmt.Fprintf(out, "Version: %s\n", version.Version)
-		_, _ = fmt.Fprintf(out, "Engine: %s\n", version.EngineVersion)
-		_, _ = fmt.Fprintf(out, "Thermo: %s\n", version.ThermoVersion)
-		_, _ = fmt.Fprintf(out, "Output schema: %s\n\n", version.OutputSchemaVersion)
+		UsageHeader(out, name)
 
 		if extra != nil {
 			extra(out, def)
 		}
 
 		_, _ = fmt.Fprintln(out, "\nInput:")
-		_, _ = fmt.Fprintln(out, "  -f, --forward string        Forward primer sequence (5'→3') [*]")
-		_, _ = fmt.Fprintln(out, "  -r, --reverse string        Reverse primer sequence (5'→3') [*]")
-		_, _ = fmt.Fprintln(out, "  -p, --primers string        Primer TSV (id fwd rev [min] [max])")
-		_, _ = fmt.Fprintln(out, "  -s, --sequences file        FASTA file(s) (repeatable) or '-' for STDIN")
+		line("forward", "  -f, --forward string        Forward primer sequence (5'→3') [*]")
+		line("reverse", "  -r, --reverse string        Reverse primer sequence (5'→3') [*]")
+		line("primers", "  -p, --primers string        Primer TSV (id fwd rev [min] [max])")
+		line("sequences", "  -s, --sequences file        FASTA file(s) (repeatable) or '-' for STDIN")
+		line("index", "      --index file            Prebuilt reference index (ipcr index build) instead of FASTA")
+		line("regions", "      --regions file          BED: scan only these intervals (coordinates stay record-global)")
+		line("exclude-regions", "      --exclude-regions file  BED: skip these intervals (e.g. repeats, plasmids)")
+		line("mask-policy", "      --mask-policy string    Soft-masked bases: ignore | no-primer-in-mask | report [%s]", def("mask-policy"))
 
 		_, _ = fmt.Fprintln(out, "\nPCR:")
-		_, _ = fmt.Fprintf(out, "  -m, --mismatches int        Max mismatches allowed per primer [%s]\n", def("mismatches"))
-		_, _ = fmt.Fprintf(out, "      --min-length int        Minimum product length [%s]\n", def("min-length"))
-		_, _ = fmt.Fprintf(out, "      --max-length int        Maximum product length [%s]\n", def("max-length"))
-		_, _ = fmt.Fprintf(out, "      --hit-cap int           Max matches stored per primer/window (0=unlimited) [%s]\n", def("hit-cap"))
-		_, _ = fmt.Fprintf(out, "      --terminal-window int   3' terminal window (N<1 disables) [%s]\n", def("terminal-window"))
-		_, _ = fmt.Fprintf(out, "      --self                  Allow single-oligo amplification (A×rc(A), B×rc(B)) [%s]\n", def("self"))
+		line("mismatches", "  -m, --mismatches int        Max mismatches allowed per primer [%s]", def("mismatches"))
+		line("max-indels", "      --max-indels int        Max insertions/deletions per primer site [%s]", def("max-indels"))
+		line("min-length", "      --min-length int        Minimum product length [%s]", def("min-length"))
+		line("max-length", "      --max-length int        Maximum product length [%s]", def("max-length"))
+		line("hit-cap", "      --hit-cap int           Max matches stored per primer/window (0=unlimited) [%s]", def("hit-cap"))
+		line("terminal-window", "      --terminal-window int   3' terminal window (N<1 disables) [%s]", def("terminal-window"))
+		line("self", "      --self                  Allow single-oligo amplification (A×rc(A), B×rc(B)) [%s]", def("self"))
 
 		_, _ = fmt.Fprintln(out, "\nPerformance:")
-		_, _ = fmt.Fprintf(out, "  -t, --threads int           Worker threads (0=all CPUs) [%s]\n", def("threads"))
-		_, _ = fmt.Fprintf(out, "      --chunk-size int        Split sequences into N-bp windows (0=no chunking) [%s]\n", def("chunk-size"))
-		_, _ = fmt.Fprintf(out, "      --seed-length int       Seed length for multi-pattern scan (0=auto) [%s]\n", def("seed-length"))
-		_, _ = fmt.Fprintf(out, "  -c, --circular              Treat each FASTA record as circular [%s]\n", def("circular"))
+		line("threads", "  -t, --threads int           Worker threads (0=all CPUs) [%s]", def("threads"))
+		line("chunk-size", "      --chunk-size int        Split sequences into N-bp windows (0=no chunking) [%s]", def("chunk-size"))
+		line("seed-length", "      --seed-length int       Seed length for multi-pattern scan (0=auto) [%s]", def("seed-length"))
+		line("circular", "  -c, --circular              Treat each FASTA record as circular [%s]", def("circular"))
 
 		_, _ = fmt.Fprintln(out, "\nOutput:")
-		_, _ = fmt.Fprintf(out, "  -o, --output string         Output: text | json | jsonl | fasta [%s]\n", def("output"))
-		_, _ = fmt.Fprintf(out, "      --products              Emit product sequences [%s]\n", def("products"))
-		_, _ = fmt.Fprintf(out, "      --pretty                Pretty ASCII alignment block (text) [%s]\n", def("pretty"))
-		_, _ = fmt.Fprintf(out, "      --sort                  Sort outputs deterministically [%s]\n", def("sort"))
-		_, _ = fmt.Fprintf(out, "      --no-header             Suppress header line [%s]\n", def("no-header"))
-		_, _ = fmt.Fprintf(out, "      --no-match-exit-code int  Exit code when no amplicons found [%s]\n", def("no-match-exit-code"))
+		if scope.TabularOnly {
+			line("output", "  -o, --output string         Output: text | json | jsonl [%s]", def("output"))
+		} else {
+			line("output", "  -o, --output string         Output: text | json | jsonl | fasta | bed | bed12 | gff3 | sam [%s]", def("output"))
+		}
+		line("products", "      --products              Emit product sequences [%s]", def("products"))
+		line("pretty", "      --pretty                Pretty ASCII alignment block (text) [%s]", def("pretty"))
+		line("sort", "      --sort                  Sort outputs deterministically [%s]", def("sort"))
+		line("no-header", "      --no-header             Suppress header line [%s]", def("no-header"))
+		line("no-match-exit-code", "      --no-match-exit-code int  Exit code when no amplicons found [%s]", def("no-match-exit-code"))
+		line("summary", "      --summary               Per-genome × per-pair hit summary instead of products [%s]", def("summary"))
+		line("assembly-map", "      --assembly-map file     TSV: file or record → genome ID (groups --summary rows)")
 
 		_, _ = fmt.Fprintln(out, "\nMiscellaneous:")
 		_, _ = fmt.Fprintf(out, "  -q, --quiet                 Suppress non-essential warnings [%s]\n", def("quiet"))
@@ -8,29 +8,18 @@ import (
 
 // AddSelfPairs appends per-row A:self and B:self pairs (Forward == Reverse).
 // Sequences are uppercased; Min/Max product bounds fall back to engine/global cfg.
+// Per-pair panel limits carry over (see selfPair).
 func AddSelfPairs(pairs []primer.Pair) []primer.Pair {
 	out := make([]primer.Pair, 0, len(pairs)+2*len(pairs))
 	out = append(out, pairs...)
 	for _, p := range pairs {
 		if p.Forward != "" {
 			u := strings.ToUpper(p.Forward)
-			out = append(out, primer.Pair{
-				ID:         p.ID + "+A:self",
-				Forward:    u,
-				Reverse:    u,
-				MinProduct: 0,
-				MaxProduct: 0,
-			})
+			out = append(out, selfPair(p, 'A', u))
 		}
 		if p.Reverse != "" {
 			u := strings.ToUpper(p.Reverse)
-			out = append(out, primer.Pair{
-				ID:         p.ID + "+B:self",
-				Forward:    u,
-				Reverse:    u,
-				MinProduct: 0,
-				MaxProduct: 0,
-			})
+			out = append(out, selfPair(p, 'B', u))
 		}
 	}
 	return out
@@ -48,27 +37,45 @@ func AddSelfPairsUnique(pairs []primer.Pair) []primer.Pair {
 		if f := strings.ToUpper(strings.TrimSpace(p.Forward)); f != "" {
 			if _, ok := seenA[f]; !ok {
 				seenA[f] = struct{}{}
-				out = append(out, primer.Pair{
-					ID:         p.ID + "+A:self",
-					Forward:    f,
-					Reverse:    f,
-					MinProduct: 0,
-					MaxProduct: 0,
-				})
+				out = append(out, selfPair(p, 'A', f))
 			}
 		}
 		if r := strings.ToUpper(strings.TrimSpace(p.Reverse)); r != "" {
 			if _, ok := seenB[r]; !ok {
 				seenB[r] = struct{}{}
-				out = append(out, primer.Pair{
-					ID:         p.ID + "+B:self",
-					Forward:    r,
-					Reverse:    r,
-					MinProduct: 0,
-					MaxProduct: 0,
-				})
+				out = append(out, selfPair(p, 'B', r))
 			}
 		}
 	}
 	return out
 }
+
+// selfPair builds the which ('A' or 'B') self pair of p for primer seq. The
+// primer's own mismatch limit applies to both ends; the terminal window and
+// topology overrides are copied as-is.
+func selfPair(p primer.Pair, which byte, seq string) primer.Pair {
+	sp := primer.Pair{
+		ID:             p.ID + "+" + string(which) + ":self",
+		Forward:        seq,
+		Reverse:        seq,
+		TerminalWindow: p.TerminalWindow,
+		Circular:       p.Circular,
+	}
+	mm := p.MaxMMFwd
+	if which == 'B' {
+		mm = p.MaxMMRev
+	}
+	sp.MaxMMFwd, sp.MaxMMRev = mm, mm
+	return sp
+}
+
+// SelfPairBase returns the originating pair ID for a synthetic A:self/B:self
+// pair added by AddSelfPairs/AddSelfPairsUnique.
+func SelfPairBase(id string) (string, bool) {
+	for _, suf := range []string{"+A:self", "+B:self"} {
+		if base, ok := strings.CutSuffix(id, suf); ok {
+			return base, true
+		}
+	}
+	return id, false
+}
@@ -0,0 +1,355 @@
+// internal/conservation/conservation.go
+
+// Package conservation profiles primer sites in a multiple sequence
+// alignment (ipcr msa): each primer's footprint in alignment columns, the
+// per-column Shannon entropy and consensus, and the degenerate IUPAC
+// primer that covers a given share of the aligned sequences.
+package conservation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr-core/msa"
+	"ipcr-core/primer"
+	"ipcr/internal/jsonutil"
+	"ipcr/pkg/api"
+	"math"
+	"sort"
+	"strings"
+)
+
+// TSV headers of the two blocks of the text report.
+const (
+	PrimerTSVHeader   = "experiment_id\tprimer\tsequence\tsequences\tlocated\tstart_column\tend_column\tmean_entropy\tconsensus\tdegenerate\tdegeneracy\ttarget_percent\tcovered\tpercent_covered"
+	PositionTSVHeader = "experiment_id\tprimer\tpos\tbase\tcolumn\ta\tc\tg\tt\tgap\tother\tentropy\tconsensus\tdegenerate"
+)
+
+// Options tune Profile.
+type Options struct {
+	MaxMM   int     // mismatches allowed where a primer is located in a sequence
+	Percent float64 // share of sequences (0–100] the degenerate primer must cover
+}
+
+// ungapped is one alignment row without its gaps, and the column of each
+// residue.
+type ungapped struct {
+	seq  []byte
+	cols []int
+}
+
+// Profile returns one report per pair, in panel order. Primers must be
+// normalized IUPAC sequences (primer.Validate).
+func Profile(a *msa.Alignment, pairs []primer.Pair, o Options) []api.ConservationV1 {
+	rows := make([]ungapped, len(a.Rows))
+	for i, r := range a.Rows {
+		for c, b := range r {
+			if b != '-' {
+				rows[i].seq = append(rows[i].seq, b)
+				rows[i].cols = append(rows[i].cols, c)
+			}
+		}
+	}
+	out := make([]api.ConservationV1, 0, len(pairs))
+	for _, p := range pairs {
+		out = append(out, api.ConservationV1{
+			ExperimentID: p.ID,
+			Sequences:    len(a.Rows),
+			Forward:      profile(a, rows, p.Forward, false, o),
+			Reverse:      profile(a, rows, p.Reverse, true, o),
+		})
+	}
+	return out
+}
+
+// profile locates pr in every row (the reverse primer as its reverse
+// complement), takes the most common column of each primer base as its
+// footprint and reads every row across it.
+func profile(a *msa.Alignment, rows []ungapped, pr string, reverse bool, o Options) api.PrimerConservationV1 {
+	n := len(pr)
+	votes := make([]map[int]int, n)
+	for j := range votes {
+		votes[j] = make(map[int]int)
+	}
+	out := api.PrimerConservationV1{Primer: pr, StartColumn: -1, EndColumn: -1, TargetPercent: o.Percent, Positions: make([]api.ColumnConservationV1, n)}
+	loc := newLocator(pr, reverse, o.MaxMM)
+	for _, r := range rows {
+		off := loc.locate(r.seq)
+		if off < 0 {
+			continue
+		}
+		out.Located++
+		for j := 0; j < n; j++ {
+			votes[j][r.cols[off+j]]++
+		}
+	}
+	for i := range out.Positions {
+		out.Positions[i] = api.ColumnConservationV1{Pos: i, Base: pr[i : i+1], Column: -1}
+	}
+	if out.Located == 0 {
+		return out
+	}
+
+	// cols[i] is the column under primer position i (primer orientation).
+	cols := make([]int, n)
+	for j := 0; j < n; j++ {
+		i := j
+		if reverse {
+			i = n - 1 - j
+		}
+		cols[i] = mode(votes[j])
+	}
+	out.StartColumn, out.EndColumn = cols[0], cols[n-1]
+	if reverse {
+		out.StartColumn, out.EndColumn = cols[n-1], cols[0]
+	}
+
+	sites := make(map[string]int)
+	site := make([]byte, n)
+	for _, r := range a.Rows {
+		for i, c := range cols {
+			b := r[c]
+			if reverse && b != '-' {
+				if b = primer.Complement(b); b == 0 {
+					b = 'N'
+				}
+			}
+			site[i] = b
+			pos := &out.Positions[i]
+			switch b {
+			case 'A':
+				pos.A++
+			case 'C':
+				pos.C++
+			case 'G':
+				pos.G++
+			case 'T':
+				pos.T++
+			case '-':
+				pos.Gap++
+			default:
+				pos.Other++
+			}
+		}
+		sites[string(site)]++
+	}
+
+	var cons strings.Builder
+	for i, c := range cols {
+		pos := &out.Positions[i]
+		pos.Column = c
+		pos.Entropy = entropy(pos.A, pos.C, pos.G, pos.T)
+		pos.Consensus = consensus(*pos)
+		out.MeanEntropy += pos.Entropy
+		cons.WriteString(pos.Consensus)
+	}
+	out.MeanEntropy /= float64(n)
+	out.Consensus = cons.String()
+	degenerate(&out, sites, len(a.Rows))
+	return out
+}
+
+// locator finds a primer in alignment rows with the engine's seeded site
+// scan.
+type locator struct {
+	eng     *engine.Engine
+	cp      *engine.CompiledPanel
+	scratch *engine.SimulationScratch
+	strand  string // "+": rows carry the primer itself, "-": its reverse complement
+}
+
+func newLocator(pr string, reverse bool, maxMM int) locator {
+	eng := engine.New(engine.Config{MaxMM: maxMM})
+	cp := eng.CompilePanel([]primer.Pair{{ID: "p", Forward: pr, Reverse: pr}})
+	l := locator{eng: eng, cp: cp, scratch: eng.NewSimulationScratch(cp), strand: "+"}
+	if reverse {
+		l.strand = "-"
+	}
+	return l
+}
+
+// locate returns the offset of the best site in seq (fewest mismatches,
+// leftmost on ties), or -1 when none is within the mismatch limit.
+func (l locator) locate(seq []byte) int {
+	best, bestMM := -1, 0
+	_ = l.eng.ForEachCompiledSite("", seq, l.cp, l.scratch, func(s engine.BindingSite) error {
+		if s.Strand == l.strand && (best < 0 || s.Mismatches < bestMM || (s.Mismatches == bestMM && s.Start < best)) {
+			best, bestMM = s.Start, s.Mismatches
+		}
+		return nil
+	})
+	return best
+}
+
+// mode returns the most common key of votes, the smallest on ties.
+func mode(votes map[int]int) int {
+	best, bestN := -1, 0
+	for k, n := range votes {
+		if n > bestN || (n == bestN && k < best) {
+			best, bestN = k, n
+		}
+	}
+	return best
+}
+
+func entropy(counts ...int) float64 {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	h := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(total)
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// consensus is the most common base (A, C, G, T order on ties), "-" when
+// gaps outnumber it and "N" when the column holds neither.
+func consensus(p api.ColumnConservationV1) string {
+	best, bestN := "N", 0
+	for i, n := range []int{p.A, p.C, p.G, p.T} {
+		if n > bestN {
+			best, bestN = "ACGT"[i:i+1], n
+		}
+	}
+	if p.Gap > bestN {
+		return "-"
+	}
+	return best
+}
+
+// degenerate merges the most common coverable site variants (A/C/G/T only)
+// into one IUPAC primer until they make up out.TargetPercent of total,
+// then counts every site the merged primer matches.
+func degenerate(out *api.PrimerConservationV1, sites map[string]int, total int) {
+	type variant struct {
+		site string
+		n    int
+	}
+	var vs []variant
+	for s, n := range sites {
+		if strings.Trim(s, "ACGT") == "" {
+			vs = append(vs, variant{s, n})
+		}
+	}
+	if len(vs) == 0 {
+		return
+	}
+	sort.Slice(vs, func(i, j int) bool {
+		if vs[i].n != vs[j].n {
+			return vs[i].n > vs[j].n
+		}
+		return vs[i].site < vs[j].site
+	})
+	need := int(math.Ceil(out.TargetPercent / 100 * float64(total)))
+	masks := make([]byte, len(out.Positions))
+	for k, got := 0, 0; k < len(vs) && (k == 0 || got < need); k++ {
+		for i := range masks {
+			masks[i] |= primer.Mask(vs[k].site[i])
+		}
+		got += vs[k].n
+	}
+
+	deg := make([]byte, len(masks))
+	out.Degeneracy = 1
+	for i, m := range masks {
+		deg[i] = primer.Code(m)
+		out.Positions[i].Degenerate = string(deg[i])
+		if out.Degeneracy < math.MaxInt32 {
+			out.Degeneracy *= bits(m)
+		}
+	}
+	out.Degenerate = string(deg)
+	for _, v := range vs {
+		if covers(masks, v.site) {
+			out.Covered += v.n
+		}
+	}
+	out.PercentCovered = 100 * float64(out.Covered) / float64(total)
+}
+
+func bits(m byte) int {
+	n := 0
+	for ; m != 0; m &= m - 1 {
+		n++
+	}
+	return n
+}
+
+func covers(masks []byte, site string) bool {
+	for i, m := range masks {
+		if m&primer.Mask(site[i]) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTSV writes rows as two TSV blocks (primers, primer positions)
+// separated by a blank line; "-" marks values of unlocated primers.
+func WriteTSV(w io.Writer, rows []api.ConservationV1, header bool) error {
+	var b strings.Builder
+	if header {
+		b.WriteString(PrimerTSVHeader + "\n")
+	}
+	for _, r := range rows {
+		for _, pc := range primersOf(r) {
+			c := pc.c
+			fmt.Fprintf(&b, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%.4f\t%s\t%s\t%d\t%.2f\t%d\t%.2f\n",
+				r.ExperimentID, pc.name, c.Primer, r.Sequences, c.Located, c.StartColumn, c.EndColumn, c.MeanEntropy,
+				dash(c.Consensus), dash(c.Degenerate), c.Degeneracy, c.TargetPercent, c.Covered, c.PercentCovered)
+		}
+	}
+	b.WriteByte('\n')
+	if header {
+		b.WriteString(PositionTSVHeader + "\n")
+	}
+	for _, r := range rows {
+		for _, pc := range primersOf(r) {
+			for _, p := range pc.c.Positions {
+				fmt.Fprintf(&b, "%s\t%s\t%d\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%.4f\t%s\t%s\n",
+					r.ExperimentID, pc.name, p.Pos, p.Base, p.Column, p.A, p.C, p.G, p.T, p.Gap, p.Other, p.Entropy,
+					dash(p.Consensus), dash(p.Degenerate))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type namedPrimer struct {
+	name string
+	c    api.PrimerConservationV1
+}
+
+func primersOf(r api.ConservationV1) []namedPrimer {
+	return []namedPrimer{{"forward", r.Forward}, {"reverse", r.Reverse}}
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// WriteJSON writes rows as an indented JSON array.
+func WriteJSON(w io.Writer, rows []api.ConservationV1) error {
+	return jsonutil.EncodePretty(w, rows)
+}
+
+// WriteJSONL writes one JSON object per pair.
+func WriteJSONL(w io.Writer, rows []api.ConservationV1) error {
+	enc := json.NewEncoder(w)
+	for _, r := range rows {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
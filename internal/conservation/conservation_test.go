@@ -0,0 +1,130 @@
+package conservation
+
+import (
+	"bytes"
+	"ipcr-core/msa"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Forward primer GATTACAGGC sits in columns 2–12 around an insertion
+// column (6) only s4 fills; the reverse primer CTGATGGCAA binds columns
+// 17–26. s3 carries one mismatch under each primer. s4's best forward
+// site is shifted by its insertion (3 mismatches); the other sequences
+// outvote its columns, and it is read across the common footprint.
+var testAlignment = &msa.Alignment{
+	Names: []string{"s1", "s2", "s3", "s4"},
+	Rows: [][]byte{
+		[]byte("AAGATT-ACAGGCCCCCTTGCCATCAGGG"),
+		[]byte("AAGATT-ACAGGCCCCCTTGCCATCAGGG"),
+		[]byte("AAGACT-ACAGGCCCCCTTGCCATTAGGG"),
+		[]byte("AAGATTAACAGGCCCCCTTGCCATCAGG-"),
+	},
+}
+
+func TestProfile(t *testing.T) {
+	pairs := []primer.Pair{{ID: "p1", Forward: "GATTACAGGC", Reverse: "CTGATGGCAA"}}
+	rows := Profile(testAlignment, pairs, Options{MaxMM: 3, Percent: 95})
+	if len(rows) != 1 || rows[0].Sequences != 4 {
+		t.Fatalf("rows: %+v", rows)
+	}
+	f, r := rows[0].Forward, rows[0].Reverse
+	if f.Located != 4 || f.StartColumn != 2 || f.EndColumn != 12 || f.Positions[4].Column != 7 {
+		t.Fatalf("forward footprint: located %d, columns %d–%d, pos 4 at %d", f.Located, f.StartColumn, f.EndColumn, f.Positions[4].Column)
+	}
+	p := f.Positions[2]
+	if p.T != 3 || p.C != 1 || p.Consensus != "T" || p.Degenerate != "Y" || math.Abs(p.Entropy-0.8113) > 1e-4 {
+		t.Fatalf("forward pos 2: %+v", p)
+	}
+	if f.Consensus != "GATTACAGGC" || f.Degenerate != "GAYTACAGGC" || f.Degeneracy != 2 || f.Covered != 4 || f.PercentCovered != 100 {
+		t.Fatalf("forward: %+v", f)
+	}
+	if f.Positions[0].Entropy != 0 || math.Abs(f.MeanEntropy-0.08113) > 1e-4 {
+		t.Fatalf("forward entropy: %v mean %v", f.Positions[0].Entropy, f.MeanEntropy)
+	}
+
+	// The reverse primer reads the alignment reverse-complemented.
+	if r.Located != 4 || r.StartColumn != 17 || r.EndColumn != 26 || r.Positions[0].Column != 26 {
+		t.Fatalf("reverse footprint: %+v", r)
+	}
+	if p := r.Positions[2]; p.G != 3 || p.A != 1 || p.Column != 24 || r.Degenerate != "CTRATGGCAA" {
+		t.Fatalf("reverse: %s %+v", r.Degenerate, p)
+	}
+
+	// Half the sequences are covered by the most common site alone.
+	half := Profile(testAlignment, pairs, Options{MaxMM: 3, Percent: 50})[0].Forward
+	if half.Degenerate != "GATTACAGGC" || half.Degeneracy != 1 || half.Covered != 3 {
+		t.Fatalf("50%%: %+v", half)
+	}
+}
+
+func TestProfileUnlocated(t *testing.T) {
+	pairs := []primer.Pair{{ID: "p1", Forward: "GGGGGGGGGG", Reverse: "CTGATGGCAA"}}
+	f := Profile(testAlignment, pairs, Options{MaxMM: 1, Percent: 95})[0].Forward
+	if f.Located != 0 || f.StartColumn != -1 || f.Degenerate != "" || f.Positions[0].Column != -1 {
+		t.Fatalf("unlocated: %+v", f)
+	}
+}
+
+func TestWriteTSV(t *testing.T) {
+	pairs := []primer.Pair{{ID: "p1", Forward: "GATTACAGGC", Reverse: "CTGATGGCAA"}}
+	var b bytes.Buffer
+	if err := WriteTSV(&b, Profile(testAlignment, pairs, Options{MaxMM: 3, Percent: 95}), true); err != nil {
+		t.Fatal(err)
+	}
+	blocks := strings.Split(b.String(), "\n\n")
+	if len(blocks) != 2 {
+		t.Fatalf("blocks:\n%s", b.String())
+	}
+	primers := strings.Split(strings.TrimSpace(blocks[0]), "\n")
+	positions := strings.Split(strings.TrimSpace(blocks[1]), "\n")
+	if primers[0] != PrimerTSVHeader || len(primers) != 3 || positions[0] != PositionTSVHeader || len(positions) != 21 {
+		t.Fatalf("tsv:\n%s", b.String())
+	}
+	if primers[1] != "p1\tforward\tGATTACAGGC\t4\t4\t2\t12\t0.0811\tGATTACAGGC\tGAYTACAGGC\t2\t95.00\t4\t100.00" ||
+		positions[3] != "p1\tforward\t2\tT\t4\t0\t1\t0\t3\t0\t0\t0.8113\tT\tY" {
+		t.Fatalf("tsv:\n%s", b.String())
+	}
+}
+
+// TestLocateMatchesBruteForce checks the seeded scan against trying every
+// offset.
+func TestLocateMatchesBruteForce(t *testing.T) {
+	randSeq := seqtest.Random(5)
+	brute := func(seq, target []byte, maxMM int) int {
+		best, bestMM := -1, maxMM+1
+		for off := 0; off+len(target) <= len(seq); off++ {
+			if mm := primer.MismatchCount(seq[off:off+len(target)], target); mm < bestMM {
+				best, bestMM = off, mm
+			}
+		}
+		return best
+	}
+	pr := []byte(randSeq(18))
+	pr[7] = 'R' // degenerate bases match either way
+	for _, reverse := range []bool{false, true} {
+		target := pr
+		if reverse {
+			target = primer.RevComp(pr)
+		}
+		for maxMM := 0; maxMM <= 3; maxMM++ {
+			loc := newLocator(string(pr), reverse, maxMM)
+			for i := 0; i < 100; i++ {
+				seq := []byte(randSeq(150))
+				site := append([]byte(nil), pr...)
+				site[7] = 'G'
+				site[(i*5)%18], site[(i*11+3)%18] = 'A', 'C' // up to two mismatches
+				if reverse {
+					site = primer.RevComp(site)
+				}
+				copy(seq[40+i%60:], site)
+				if got, want := loc.locate(seq), brute(seq, target, maxMM); got != want {
+					t.Fatalf("case %d, reverse=%v, maxMM=%d: got %d want %d", i, reverse, maxMM, got, want)
+				}
+			}
+		}
+	}
+}
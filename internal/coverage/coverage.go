@@ -0,0 +1,272 @@
+// internal/coverage/coverage.go
+
+// Package coverage aggregates products over large sequence sets
+// (--coverage): per pair, the share of sequences amplified, the mismatch
+// frequency and substitution spectrum at every primer position, and the
+// most common primer sites.
+package coverage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"ipcr/internal/common"
+	"ipcr/internal/jsonutil"
+	"ipcr/pkg/api"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TSV headers of the three blocks of the text report.
+const (
+	CoverageTSVHeader = "experiment_id\tsequences\tamplified\tpercent_amplified"
+	PositionTSVHeader = "experiment_id\tprimer\tpos\tbase\tsites\tmismatches\tmismatch_freq\tsubstitutions"
+	VariantTSVHeader  = "experiment_id\tprimer\tsite\tcount\tfraction\tmismatches"
+)
+
+type seqKey struct{ file, id string }
+
+type hitKey struct {
+	pair string
+	seq  seqKey
+}
+
+// site is one primer's binding site (primer orientation) and the primer
+// positions that mismatch it.
+type site struct {
+	seq string
+	mm  []int
+}
+
+type hit struct {
+	mm       int
+	fwd, rev site
+}
+
+// better orders the products of one sequence: fewer mismatches first, then
+// by site sequence so the pick does not depend on product order.
+func (h hit) better(o hit) bool {
+	if h.mm != o.mm {
+		return h.mm < o.mm
+	}
+	if h.fwd.seq != o.fwd.seq {
+		return h.fwd.seq < o.fwd.seq
+	}
+	return h.rev.seq < o.rev.seq
+}
+
+// Report accumulates scanned sequences and products. Seen and Add may be
+// called from different goroutines.
+type Report struct {
+	pairs []primer.Pair
+	ids   map[string]bool
+	top   int
+
+	mu   sync.Mutex
+	seqs map[seqKey]bool
+	best map[hitKey]hit
+}
+
+// New reports on pairs, listing up to top site variants per primer (all
+// when top ≤ 0). Products of other pairs (such as self pairs) are ignored.
+func New(pairs []primer.Pair, top int) *Report {
+	ids := make(map[string]bool, len(pairs))
+	for _, p := range pairs {
+		ids[p.ID] = true
+	}
+	return &Report{pairs: pairs, ids: ids, top: top, seqs: make(map[seqKey]bool), best: make(map[hitKey]hit)}
+}
+
+// Seen counts a scanned sequence. Chunks and region intervals
+// ("id:start-end") count toward their record.
+func (r *Report) Seen(sourceFile, id string) {
+	base, _, _ := common.SplitChunkSuffix(id)
+	r.mu.Lock()
+	r.seqs[seqKey{sourceFile, base}] = true
+	r.mu.Unlock()
+}
+
+// Add records one product; only the best product of each sequence and pair
+// is kept. Products need their sites (engine.Config.NeedSites).
+func (r *Report) Add(p engine.Product) {
+	if !r.ids[p.ExperimentID] {
+		return
+	}
+	h := hit{
+		mm:  p.FwdMM + p.RevMM,
+		fwd: site{p.FwdSite, p.FwdMismatchIdx},
+		rev: site{p.RevSite, p.RevMismatchIdx},
+	}
+	if p.Type == "revcomp" {
+		// the left site carries the pair's reverse primer
+		h.fwd, h.rev = h.rev, h.fwd
+	}
+	sk := seqKey{p.SourceFile, p.SequenceID}
+	k := hitKey{p.ExperimentID, sk}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seqs[sk] = true
+	if old, ok := r.best[k]; !ok || h.better(old) {
+		r.best[k] = h
+	}
+}
+
+// Rows returns one report per pair, in panel order.
+func (r *Report) Rows() []api.CoverageV1 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byPair := make(map[string][]hit)
+	for k, h := range r.best {
+		byPair[k.pair] = append(byPair[k.pair], h)
+	}
+	rows := make([]api.CoverageV1, 0, len(r.pairs))
+	for _, p := range r.pairs {
+		hits := byPair[p.ID]
+		row := api.CoverageV1{ExperimentID: p.ID, Sequences: len(r.seqs), Amplified: len(hits)}
+		if row.Sequences > 0 {
+			row.PercentAmplified = 100 * float64(row.Amplified) / float64(row.Sequences)
+		}
+		fwd, rev := make([]site, len(hits)), make([]site, len(hits))
+		for i, h := range hits {
+			fwd[i], rev[i] = h.fwd, h.rev
+		}
+		row.Forward = tally(p.Forward, fwd, r.top)
+		row.Reverse = tally(p.Reverse, rev, r.top)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func tally(pr string, sites []site, top int) api.PrimerCoverageV1 {
+	out := api.PrimerCoverageV1{Primer: pr, Sites: len(sites), Positions: make([]api.PositionCoverageV1, len(pr)), Variants: []api.SiteVariantV1{}}
+	for i := range pr {
+		out.Positions[i] = api.PositionCoverageV1{Pos: i, Base: pr[i : i+1]}
+	}
+	counts := make(map[string]int)
+	mms := make(map[string]int)
+	for _, s := range sites {
+		for _, i := range s.mm {
+			if i < 0 || i >= len(pr) {
+				continue
+			}
+			pos := &out.Positions[i]
+			pos.Mismatches++
+			if len(s.seq) == len(pr) {
+				if pos.Substitutions == nil {
+					pos.Substitutions = make(map[string]int)
+				}
+				pos.Substitutions[pr[i:i+1]+">"+s.seq[i:i+1]]++
+			}
+		}
+		if s.seq != "" {
+			counts[s.seq]++
+			mms[s.seq] = len(s.mm)
+		}
+	}
+	if len(sites) > 0 {
+		for i := range out.Positions {
+			out.Positions[i].MismatchFreq = float64(out.Positions[i].Mismatches) / float64(len(sites))
+		}
+	}
+	for seq, n := range counts {
+		out.Variants = append(out.Variants, api.SiteVariantV1{Site: seq, Count: n, Fraction: float64(n) / float64(len(sites)), Mismatches: mms[seq]})
+	}
+	sort.Slice(out.Variants, func(i, j int) bool {
+		a, b := out.Variants[i], out.Variants[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Site < b.Site
+	})
+	if top > 0 && len(out.Variants) > top {
+		out.Variants = out.Variants[:top]
+	}
+	return out
+}
+
+// WriteTSV writes rows as three TSV blocks (pairs, primer positions, site
+// variants) separated by blank lines.
+func WriteTSV(w io.Writer, rows []api.CoverageV1, header bool) error {
+	var b strings.Builder
+	if header {
+		b.WriteString(CoverageTSVHeader + "\n")
+	}
+	for _, r := range rows {
+		fmt.Fprintf(&b, "%s\t%d\t%d\t%.2f\n", r.ExperimentID, r.Sequences, r.Amplified, r.PercentAmplified)
+	}
+	b.WriteByte('\n')
+	if header {
+		b.WriteString(PositionTSVHeader + "\n")
+	}
+	for _, r := range rows {
+		for _, pc := range primersOf(r) {
+			for _, p := range pc.c.Positions {
+				fmt.Fprintf(&b, "%s\t%s\t%d\t%s\t%d\t%d\t%.4f\t%s\n", r.ExperimentID, pc.name, p.Pos, p.Base, pc.c.Sites, p.Mismatches, p.MismatchFreq, spectrum(p.Substitutions))
+			}
+		}
+	}
+	b.WriteByte('\n')
+	if header {
+		b.WriteString(VariantTSVHeader + "\n")
+	}
+	for _, r := range rows {
+		for _, pc := range primersOf(r) {
+			for _, v := range pc.c.Variants {
+				fmt.Fprintf(&b, "%s\t%s\t%s\t%d\t%.4f\t%d\n", r.ExperimentID, pc.name, v.Site, v.Count, v.Fraction, v.Mismatches)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type namedPrimer struct {
+	name string
+	c    api.PrimerCoverageV1
+}
+
+func primersOf(r api.CoverageV1) []namedPrimer {
+	return []namedPrimer{{"forward", r.Forward}, {"reverse", r.Reverse}}
+}
+
+// spectrum renders substitutions as "C>T:3,C>A:1" (most frequent first),
+// or "-" when there are none.
+func spectrum(subs map[string]int) string {
+	if len(subs) == 0 {
+		return "-"
+	}
+	keys := make([]string, 0, len(subs))
+	for k := range subs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if subs[keys[i]] != subs[keys[j]] {
+			return subs[keys[i]] > subs[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s:%d", k, subs[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+// WriteJSON writes rows as an indented JSON array.
+func WriteJSON(w io.Writer, rows []api.CoverageV1) error {
+	return jsonutil.EncodePretty(w, rows)
+}
+
+// WriteJSONL writes one JSON object per pair.
+func WriteJSONL(w io.Writer, rows []api.CoverageV1) error {
+	enc := json.NewEncoder(w)
+	for _, r := range rows {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
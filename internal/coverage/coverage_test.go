@@ -0,0 +1,56 @@
+package coverage
+
+import (
+	"bytes"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"strings"
+	"testing"
+)
+
+func TestReportRows(t *testing.T) {
+	pairs := []primer.Pair{{ID: "P1", Forward: "ACGTAC", Reverse: "GGCCTT"}}
+	r := New(pairs, 1)
+	for _, id := range []string{"s1", "s2:0-100", "s2:90-200", "s3", "s4"} {
+		r.Seen("a.fa", id)
+	}
+	// s1: perfect; s2: a C>T at fwd position 1 (its worse product is
+	// ignored); s3: found as revcomp, so the left site is the reverse primer's.
+	r.Add(engine.Product{ExperimentID: "P1", SequenceID: "s1", SourceFile: "a.fa", Type: "forward", FwdSite: "ACGTAC", RevSite: "GGCCTT"})
+	r.Add(engine.Product{ExperimentID: "P1", SequenceID: "s2", SourceFile: "a.fa", Type: "forward",
+		FwdMM: 1, FwdMismatchIdx: []int{1}, FwdSite: "ATGTAC", RevSite: "GGCCTT"})
+	r.Add(engine.Product{ExperimentID: "P1", SequenceID: "s2", SourceFile: "a.fa", Type: "forward",
+		FwdMM: 2, FwdMismatchIdx: []int{0, 1}, FwdSite: "TTGTAC", RevSite: "GGCCTT"})
+	r.Add(engine.Product{ExperimentID: "P1", SequenceID: "s3", SourceFile: "a.fa", Type: "revcomp",
+		FwdMM: 1, FwdMismatchIdx: []int{5}, FwdSite: "GGCCTA", RevMM: 1, RevMismatchIdx: []int{1}, RevSite: "ATGTAC"})
+
+	rows := r.Rows()
+	if len(rows) != 1 {
+		t.Fatalf("rows: %+v", rows)
+	}
+	row := rows[0]
+	if row.Sequences != 4 || row.Amplified != 3 || row.PercentAmplified != 75 {
+		t.Fatalf("amplification: %+v", row)
+	}
+	f1 := row.Forward.Positions[1]
+	if row.Forward.Sites != 3 || f1.Mismatches != 2 || f1.Substitutions["C>T"] != 2 || row.Forward.Positions[0].Mismatches != 0 {
+		t.Fatalf("forward positions: %+v", row.Forward.Positions)
+	}
+	if r5 := row.Reverse.Positions[5]; r5.Mismatches != 1 || r5.Substitutions["T>A"] != 1 {
+		t.Fatalf("reverse positions: %+v", row.Reverse.Positions)
+	}
+	if v := row.Forward.Variants; len(v) != 1 || v[0].Site != "ATGTAC" || v[0].Count != 2 || v[0].Mismatches != 1 {
+		t.Fatalf("forward variants (top 1): %+v", v)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTSV(&buf, rows, true); err != nil {
+		t.Fatal(err)
+	}
+	blocks := strings.Split(buf.String(), "\n\n")
+	if len(blocks) != 3 || !strings.HasPrefix(blocks[0], CoverageTSVHeader+"\nP1\t4\t3\t75.00") ||
+		!strings.Contains(blocks[1], "P1\tforward\t1\tC\t3\t2\t0.6667\tC>T:2\n") ||
+		!strings.Contains(blocks[2], "P1\treverse\tGGCCTT\t2\t0.6667\t0\n") {
+		t.Fatalf("TSV:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,223 @@
+// internal/designapp/app.go
+package designapp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/design"
+	"ipcr-core/fasta"
+	"ipcr-core/thermo"
+	"ipcr/internal/clibase"
+	"ipcr/internal/cmdutil"
+	"ipcr/internal/designcli"
+	"ipcr/internal/jsonutil"
+	"ipcr/internal/output"
+	"ipcr/internal/version"
+	"ipcr/internal/writers"
+	"ipcr/pkg/api"
+)
+
+func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer) int {
+	outw := bufio.NewWriter(stdout)
+	defer func() { _ = outw.Flush() }()
+
+	fs := designcli.NewFlagSet("ipcr-design")
+	fs.SetOutput(io.Discard)
+
+	if len(argv) == 0 {
+		_, _ = designcli.ParseArgs(fs, []string{"-h"})
+		fs.SetOutput(outw)
+		fs.Usage()
+		if err := outw.Flush(); writers.IsBrokenPipe(err) {
+			return 0
+		} else if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 3
+		}
+		return 0
+	}
+
+	opts, err := designcli.ParseArgs(fs, argv)
+	if err != nil {
+		if errors.Is(err, clibase.ErrPrintedAndExitOK) {
+			designcli.PrintExamples(outw)
+			if e := outw.Flush(); writers.IsBrokenPipe(e) {
+				return 0
+			} else if e != nil {
+				_, _ = fmt.Fprintln(stderr, e)
+				return 3
+			}
+			return 0
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(outw)
+			fs.Usage()
+			if e := outw.Flush(); writers.IsBrokenPipe(e) {
+				return 0
+			} else if e != nil {
+				_, _ = fmt.Fprintln(stderr, e)
+				return 3
+			}
+			return 0
+		}
+		_, _ = fmt.Fprintln(stderr, err)
+		fs.SetOutput(outw)
+		fs.Usage()
+		if e := outw.Flush(); writers.IsBrokenPipe(e) {
+			return 0
+		} else if e != nil {
+			_, _ = fmt.Fprintln(stderr, e)
+			return 3
+		}
+		return 2
+	}
+
+	if opts.Version {
+		version.Write(outw, "ipcr-design")
+		if e := outw.Flush(); writers.IsBrokenPipe(e) {
+			return 0
+		} else if e != nil {
+			_, _ = fmt.Fprintln(stderr, e)
+			return 3
+		}
+		return 0
+	}
+
+	cons, err := constraints(opts)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	var rows []api.DesignPairV1
+	regionSeen := false
+	for _, path := range opts.SeqFiles {
+		err := fasta.StreamChunksPathCtx(parent, path, 0, 0, func(rec fasta.Record) error {
+			if opts.RegionID != "" && rec.ID != opts.RegionID {
+				return nil
+			}
+			regionSeen = true
+			pairs, err := design.Design(rec.Seq, cons)
+			if err != nil {
+				return fmt.Errorf("%s: %w", rec.ID, err)
+			}
+			if len(pairs) == 0 {
+				cmdutil.Warnf(stderr, opts.Quiet, "%s: no primer pairs satisfy the constraints", rec.ID)
+			}
+			for i, p := range pairs {
+				rows = append(rows, toAPI(rec.ID, i+1, p, cons))
+			}
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return 130
+			}
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+	if opts.RegionID != "" && !regionSeen {
+		_, _ = fmt.Fprintf(stderr, "--region: sequence %q not found\n", opts.RegionID)
+		return 2
+	}
+
+	if opts.Output == output.FormatJSON {
+		err = jsonutil.EncodePretty(outw, rows)
+	} else {
+		err = writeTSV(outw, rows, opts.Header)
+	}
+	if err == nil {
+		err = outw.Flush()
+	}
+	if writers.IsBrokenPipe(err) {
+		return 0
+	} else if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 3
+	}
+	return 0
+}
+
+func Run(argv []string, stdout, stderr io.Writer) int {
+	return RunContext(context.Background(), argv, stdout, stderr)
+}
+
+func constraints(o designcli.Options) (design.Constraints, error) {
+	c := design.DefaultConstraints()
+	c.MinLen, c.MaxLen = o.MinLen, o.MaxLen
+	c.MinProduct, c.MaxProduct = o.MinProduct, o.MaxProduct
+	c.MinGC, c.MaxGC = o.MinGC/100, o.MaxGC/100
+	c.MinTm, c.MaxTm = o.MinTm, o.MaxTm
+	c.MaxTmDiff = o.MaxTmDiff
+	c.Clamp = o.Clamp
+	c.MinHairpinDG, c.MinDimerDG = o.MinHairpinDG, o.MinDimerDG
+	c.TargetStart, c.TargetEnd = o.TargetStart, o.TargetEnd
+	c.Candidates, c.MaxPairs = o.Candidates, o.NumPairs
+
+	cond := thermo.DefaultConditions()
+	cond.AnnealC = o.AnnealTempC
+	for _, f := range []struct {
+		name, spec string
+		dst        *float64
+	}{
+		{"--na", o.NaSpec, &cond.NaM},
+		{"--mg", o.MgSpec, &cond.MgM},
+		{"--dntp", o.DntpSpec, &cond.DntpM},
+		{"--primer-conc", o.PrimerConcSpec, &cond.PrimerTotalM},
+	} {
+		v, err := thermo.ParseConc(f.spec)
+		if err != nil {
+			return c, fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.dst = v
+	}
+	model, err := thermo.ParseSaltModel(o.SaltModel)
+	if err != nil {
+		return c, fmt.Errorf("--salt-model: %w", err)
+	}
+	cond.SaltModel = model
+	c.Conditions = cond
+	return c, c.Validate()
+}
+
+func toAPI(seqID string, rank int, p design.Pair, c design.Constraints) api.DesignPairV1 {
+	oligo := func(o design.Oligo) api.DesignOligoV1 {
+		return api.DesignOligoV1{
+			Seq: o.Seq, Start: o.Pos, End: o.Pos + o.Len, TmC: o.TmC, GC: o.GC,
+			HairpinDG: o.HairpinDG, SelfDimerDG: o.SelfDimerDG,
+		}
+	}
+	return api.DesignPairV1{
+		ExperimentID: fmt.Sprintf("%s_%d", seqID, rank),
+		SequenceID:   seqID,
+		Rank:         rank,
+		Forward:      oligo(p.Forward),
+		Reverse:      oligo(p.Reverse),
+		ProductLen:   p.ProductLen,
+		CrossDimerDG: p.CrossDimerDG,
+		Penalty:      p.Penalty,
+		MinProduct:   c.MinProduct,
+		MaxProduct:   c.MaxProduct,
+	}
+}
+
+// writeTSV emits rows in the primer.LoadTSV layout (id fwd rev min max); the
+// header is a '#' comment so the file loads unchanged with --primers.
+func writeTSV(w io.Writer, rows []api.DesignPairV1, header bool) error {
+	if header {
+		if _, err := io.WriteString(w, "# id\tforward\treverse\tmin_len\tmax_len\n"); err != nil {
+			return err
+		}
+	}
+	for _, r := range rows {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", r.ExperimentID, r.Forward.Seq, r.Reverse.Seq, r.MinProduct, r.MaxProduct); err != nil {
+			return err
+		}
+	}
+	return nil
+}
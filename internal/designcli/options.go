@@ -0,0 +1,238 @@
+package designcli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/design"
+	"ipcr-core/thermo"
+	"ipcr/internal/clibase"
+	"ipcr/internal/cliutil"
+	"ipcr/internal/output"
+	"strconv"
+	"strings"
+)
+
+type Options struct {
+	SeqFiles []string
+	Region   string // [ID:]START-END, 0-based half-open
+
+	// Parsed from Region
+	RegionID    string
+	TargetStart int
+	TargetEnd   int
+
+	MinLen, MaxLen         int
+	MinProduct, MaxProduct int
+	MinGC, MaxGC           float64 // percent
+	MinTm, MaxTm           float64
+	MaxTmDiff              float64
+	Clamp                  int
+	MinHairpinDG           float64
+	MinDimerDG             float64
+
+	AnnealTempC    float64
+	NaSpec         string
+	MgSpec         string
+	DntpSpec       string
+	PrimerConcSpec string
+	SaltModel      string
+
+	NumPairs   int
+	Candidates int
+
+	Output  string // text|json
+	Header  bool
+	Quiet   bool
+	Version bool
+}
+
+type sliceValue struct{ dst *[]string }
+
+func (s *sliceValue) String() string {
+	if s.dst == nil {
+		return ""
+	}
+	return fmt.Sprint(*s.dst)
+}
+func (s *sliceValue) Set(v string) error { *s.dst = append(*s.dst, v); return nil }
+
+func NewFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		def := func(flagName string) string {
+			if f := fs.Lookup(flagName); f != nil {
+				return f.DefValue
+			}
+			return ""
+		}
+		clibase.UsageHeader(out, name)
+		_, _ = fmt.Fprintln(out, "Usage:")
+		_, _ = fmt.Fprintf(out, "  %s [options] --region 1200-1350 target.fa > primers.tsv\n", name)
+		_, _ = fmt.Fprintf(out, "  %s [options] --region chr1:1200-1350 genome.fa.gz\n", name)
+
+		_, _ = fmt.Fprintln(out, "\nInput:")
+		_, _ = fmt.Fprintln(out, "  -s, --sequences file        Template FASTA(s) (repeatable) or '-' for STDIN")
+		_, _ = fmt.Fprintln(out, "      --region [ID:]S-E       Target every product must span (0-based, half-open)")
+
+		_, _ = fmt.Fprintln(out, "\nPrimers:")
+		_, _ = fmt.Fprintf(out, "      --min-primer-len int    Minimum primer length [%s]\n", def("min-primer-len"))
+		_, _ = fmt.Fprintf(out, "      --max-primer-len int    Maximum primer length [%s]\n", def("max-primer-len"))
+		_, _ = fmt.Fprintf(out, "      --min-product int       Minimum product length [%s]\n", def("min-product"))
+		_, _ = fmt.Fprintf(out, "      --max-product int       Maximum product length [%s]\n", def("max-product"))
+		_, _ = fmt.Fprintf(out, "      --min-gc float          Minimum GC content (%%) [%s]\n", def("min-gc"))
+		_, _ = fmt.Fprintf(out, "      --max-gc float          Maximum GC content (%%) [%s]\n", def("max-gc"))
+		_, _ = fmt.Fprintf(out, "      --min-tm float          Minimum primer Tm (°C) [%s]\n", def("min-tm"))
+		_, _ = fmt.Fprintf(out, "      --max-tm float          Maximum primer Tm (°C) [%s]\n", def("max-tm"))
+		_, _ = fmt.Fprintf(out, "      --max-tm-diff float     Maximum Tm difference within a pair (°C) [%s]\n", def("max-tm-diff"))
+		_, _ = fmt.Fprintf(out, "      --gc-clamp int          3' bases that must be G/C (0=off) [%s]\n", def("gc-clamp"))
+		_, _ = fmt.Fprintf(out, "      --min-hairpin-dg float  Reject hairpins more stable than ΔG (kcal/mol) [%s]\n", def("min-hairpin-dg"))
+		_, _ = fmt.Fprintf(out, "      --min-dimer-dg float    Reject self/cross-dimers more stable than ΔG (kcal/mol) [%s]\n", def("min-dimer-dg"))
+
+		_, _ = fmt.Fprintln(out, "\nConditions:")
+		_, _ = fmt.Fprintf(out, "      --anneal-temp float     Annealing temperature (°C) [%s]\n", def("anneal-temp"))
+		_, _ = fmt.Fprintf(out, "      --na string             Monovalent salt [%s]\n", def("na"))
+		_, _ = fmt.Fprintf(out, "      --mg string             Mg2+ [%s]\n", def("mg"))
+		_, _ = fmt.Fprintf(out, "      --dntp string           Total dNTP [%s]\n", def("dntp"))
+		_, _ = fmt.Fprintf(out, "      --primer-conc string    Primer concentration [%s]\n", def("primer-conc"))
+		_, _ = fmt.Fprintf(out, "      --salt-model string     %s [%s]\n", thermo.KnownSaltModels(), def("salt-model"))
+
+		_, _ = fmt.Fprintln(out, "\nOutput:")
+		_, _ = fmt.Fprintf(out, "  -n, --num-pairs int         Pairs to report per record [%s]\n", def("num-pairs"))
+		_, _ = fmt.Fprintf(out, "      --candidates int        Best oligos per strand considered for pairing [%s]\n", def("candidates"))
+		_, _ = fmt.Fprintf(out, "  -o, --output string         Output: text (primer TSV) | json [%s]\n", def("output"))
+		_, _ = fmt.Fprintf(out, "      --no-header             Suppress the '#' header line [%s]\n", def("no-header"))
+
+		_, _ = fmt.Fprintln(out, "\nMiscellaneous:")
+		_, _ = fmt.Fprintf(out, "  -q, --quiet                 Suppress non-essential warnings [%s]\n", def("quiet"))
+		_, _ = fmt.Fprintln(out, "  -v, --version               Print version and exit")
+		_, _ = fmt.Fprintln(out, "  -h, --help                  Show this help and exit")
+		_, _ = fmt.Fprintln(out, "      --examples              Show quickstart examples and exit")
+	}
+	return fs
+}
+
+func PrintExamples(out io.Writer) {
+	clibase.PrintExamples(out, "ipcr-design", func(w io.Writer) {
+		_, _ = fmt.Fprintln(out, "Primer design: propose ranked pairs around a target region.")
+		_, _ = fmt.Fprintln(out, "The text output is a primer TSV that ipcr/ipcr-thermo accept via --primers.")
+		_, _ = fmt.Fprintln(out, "\nExample:")
+		_, _ = fmt.Fprintln(out, "  ipcr-design \\")
+		_, _ = fmt.Fprintln(out, "    --region NC_000913.3:4166000-4166150 \\")
+		_, _ = fmt.Fprintln(out, "    --min-product 150 --max-product 400 \\")
+		_, _ = fmt.Fprintln(out, "    Escherichia-coli.fna.gz > candidates.tsv")
+		_, _ = fmt.Fprintln(out, "  ipcr --primers candidates.tsv --mismatches 1 other-genomes/*.fna.gz")
+	})
+}
+
+func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
+	var o Options
+	var help, showExamples, noHeader bool
+	d := design.DefaultConstraints()
+
+	seqVal := &sliceValue{dst: &o.SeqFiles}
+	fs.Var(seqVal, "sequences", "template FASTA(s) (repeatable) or '-'")
+	fs.Var(seqVal, "s", "alias of --sequences")
+	fs.StringVar(&o.Region, "region", "", "target region [ID:]START-END")
+
+	fs.IntVar(&o.MinLen, "min-primer-len", d.MinLen, "minimum primer length")
+	fs.IntVar(&o.MaxLen, "max-primer-len", d.MaxLen, "maximum primer length")
+	fs.IntVar(&o.MinProduct, "min-product", d.MinProduct, "minimum product length")
+	fs.IntVar(&o.MaxProduct, "max-product", d.MaxProduct, "maximum product length")
+	fs.Float64Var(&o.MinGC, "min-gc", d.MinGC*100, "minimum GC content (%)")
+	fs.Float64Var(&o.MaxGC, "max-gc", d.MaxGC*100, "maximum GC content (%)")
+	fs.Float64Var(&o.MinTm, "min-tm", d.MinTm, "minimum primer Tm (°C)")
+	fs.Float64Var(&o.MaxTm, "max-tm", d.MaxTm, "maximum primer Tm (°C)")
+	fs.Float64Var(&o.MaxTmDiff, "max-tm-diff", d.MaxTmDiff, "maximum Tm difference within a pair (°C)")
+	fs.IntVar(&o.Clamp, "gc-clamp", d.Clamp, "3' bases that must be G/C")
+	fs.Float64Var(&o.MinHairpinDG, "min-hairpin-dg", d.MinHairpinDG, "reject hairpins more stable than ΔG (kcal/mol)")
+	fs.Float64Var(&o.MinDimerDG, "min-dimer-dg", d.MinDimerDG, "reject dimers more stable than ΔG (kcal/mol)")
+
+	fs.Float64Var(&o.AnnealTempC, "anneal-temp", 60, "annealing temperature (°C)")
+	fs.StringVar(&o.NaSpec, "na", "50mM", "monovalent salt (e.g., 50mM)")
+	fs.StringVar(&o.MgSpec, "mg", "3mM", "Mg2+ (e.g., 3mM)")
+	fs.StringVar(&o.DntpSpec, "dntp", "0mM", "total dNTP concentration (e.g., 200uM)")
+	fs.StringVar(&o.PrimerConcSpec, "primer-conc", "250nM", "primer concentration (e.g., 250nM)")
+	fs.StringVar(&o.SaltModel, "salt-model", thermo.SaltModelMonovalent.String(), "salt model: "+thermo.KnownSaltModels())
+
+	fs.IntVar(&o.NumPairs, "num-pairs", d.MaxPairs, "pairs to report per record")
+	fs.IntVar(&o.NumPairs, "n", d.MaxPairs, "alias of --num-pairs")
+	fs.IntVar(&o.Candidates, "candidates", d.Candidates, "best oligos per strand considered for pairing")
+	fs.StringVar(&o.Output, "output", output.FormatText, "output: text | json")
+	fs.StringVar(&o.Output, "o", output.FormatText, "alias of --output")
+	fs.BoolVar(&noHeader, "no-header", false, "suppress header line [false]")
+
+	fs.BoolVar(&o.Quiet, "quiet", false, "suppress non-essential warnings [false]")
+	fs.BoolVar(&o.Quiet, "q", false, "alias of --quiet")
+	fs.BoolVar(&o.Version, "v", false, "print version and exit [false]")
+	fs.BoolVar(&o.Version, "version", false, "print version and exit [false]")
+	fs.BoolVar(&help, "h", false, "show this help [false]")
+	fs.BoolVar(&showExamples, "examples", false, "show quickstart examples and exit [false]")
+
+	flagArgs, posArgs := cliutil.SplitFlagsAndPositionals(fs, argv)
+	if err := fs.Parse(flagArgs); err != nil {
+		return o, err
+	}
+	if showExamples {
+		return o, clibase.ErrPrintedAndExitOK
+	}
+	if help {
+		return o, flag.ErrHelp
+	}
+	if o.Version {
+		return o, nil
+	}
+
+	o.Header = !noHeader
+	if len(posArgs) > 0 {
+		exp, err := cliutil.ExpandPositionals(posArgs)
+		if err != nil {
+			return o, err
+		}
+		o.SeqFiles = append(o.SeqFiles, exp...)
+	}
+	if len(o.SeqFiles) == 0 {
+		return o, errors.New("at least one sequence file is required")
+	}
+	if o.Region != "" {
+		var err error
+		if o.RegionID, o.TargetStart, o.TargetEnd, err = ParseRegion(o.Region); err != nil {
+			return o, err
+		}
+	}
+	if o.NumPairs < 1 {
+		return o, errors.New("--num-pairs must be ≥ 1")
+	}
+	if o.Candidates < 1 {
+		return o, errors.New("--candidates must be ≥ 1")
+	}
+	switch o.Output {
+	case output.FormatText, output.FormatJSON:
+	default:
+		return o, fmt.Errorf("invalid --output %q (text or json)", o.Output)
+	}
+	return o, nil
+}
+
+// ParseRegion parses "[ID:]START-END" (0-based, half-open, END > START).
+func ParseRegion(s string) (id string, start, end int, err error) {
+	span := s
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		id, span = s[:i], s[i+1:]
+		if id == "" {
+			return "", 0, 0, fmt.Errorf("--region %q: empty sequence ID", s)
+		}
+	}
+	a, b, ok := strings.Cut(span, "-")
+	if !ok {
+		return "", 0, 0, fmt.Errorf("--region %q: want [ID:]START-END", s)
+	}
+	start, err1 := strconv.Atoi(strings.TrimSpace(a))
+	end, err2 := strconv.Atoi(strings.TrimSpace(b))
+	if err1 != nil || err2 != nil || start < 0 || end <= start {
+		return "", 0, 0, fmt.Errorf("--region %q: want 0 ≤ START < END", s)
+	}
+	return id, start, end, nil
+}
@@ -0,0 +1,82 @@
+package designintegration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"ipcr/internal/designapp"
+	"ipcr/pkg/api"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, name, data string) string {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return name
+}
+
+func TestDesignedPrimersFeedBackIntoIPCR(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(11)
+	fa := write(t, filepath.Join(dir, "target.fa"), ">tgt\n"+randSeq(1500)+"\n>other\n"+randSeq(200)+"\n")
+
+	var out, errB bytes.Buffer
+	if code := designapp.Run([]string{"--region", "tgt:700-760", "--num-pairs", "3", fa}, &out, &errB); code != 0 {
+		t.Fatalf("design exit %d: %s", code, errB.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "# id") || !strings.HasPrefix(lines[1], "tgt_1\t") {
+		t.Fatalf("unexpected TSV:\n%s", out.String())
+	}
+	tsv := write(t, filepath.Join(dir, "primers.tsv"), out.String())
+
+	out.Reset()
+	errB.Reset()
+	if code := app.Run([]string{"--primers", tsv, "--self=false", "--output", "json", fa}, &out, &errB); code != 0 {
+		t.Fatalf("ipcr exit %d: %s", code, errB.String())
+	}
+	var prods []api.ProductV1
+	if err := json.Unmarshal(out.Bytes(), &prods); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+	found := map[string]bool{}
+	for _, p := range prods {
+		if p.SequenceID == "tgt" && p.Start <= 700 && p.End >= 760 {
+			found[p.ExperimentID] = true
+		}
+	}
+	for _, id := range []string{"tgt_1", "tgt_2", "tgt_3"} {
+		if !found[id] {
+			t.Fatalf("designed pair %s did not amplify the target; products: %+v", id, prods)
+		}
+	}
+}
+
+func TestDesignJSONAndMissingRegion(t *testing.T) {
+	dir := t.TempDir()
+	fa := write(t, filepath.Join(dir, "target.fa"), ">tgt\n"+seqtest.Random(11)(800)+"\n")
+
+	var out, errB bytes.Buffer
+	if code := designapp.Run([]string{"--region", "350-400", "-n", "1", "--output", "json", fa}, &out, &errB); code != 0 {
+		t.Fatalf("design exit %d: %s", code, errB.String())
+	}
+	var rows []api.DesignPairV1
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil || len(rows) != 1 {
+		t.Fatalf("want one JSON pair, got %v (%s)", err, out.String())
+	}
+	if r := rows[0]; r.Forward.End > 350 || r.Reverse.Start < 400 || r.ProductLen != r.Reverse.End-r.Forward.Start {
+		t.Fatalf("pair does not flank the region: %+v", r)
+	}
+
+	out.Reset()
+	errB.Reset()
+	if code := designapp.Run([]string{"--region", "nope:1-10", fa}, &out, &errB); code != 2 {
+		t.Fatalf("want exit 2 for unknown region ID, got %d", code)
+	}
+}
@@ -0,0 +1,426 @@
+// internal/diff/diff.go
+
+// Package diff compares two product result sets (ipcr diff). Products are
+// matched by the key the pipeline dedupes them with.
+package diff
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"ipcr/internal/jsonutil"
+	"ipcr/internal/pipeline"
+	"ipcr/pkg/api"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Record is the part of a product that diff compares. It reads
+// api.ProductV1, api.NestedProductV1 and ipcr-thermo products alike.
+type Record struct {
+	ExperimentID    string   `json:"experiment_id"`
+	SequenceID      string   `json:"sequence_id"`
+	Start           int      `json:"start"`
+	End             int      `json:"end"`
+	Type            string   `json:"type"`
+	FwdMM           int      `json:"fwd_mm"`
+	RevMM           int      `json:"rev_mm"`
+	SourceFile      string   `json:"source_file"`
+	BisulfiteStrand string   `json:"bisulfite_strand"`
+	Methylation     string   `json:"methylation"`
+	AltAllele       string   `json:"alt_allele"`
+	Score           *float64 `json:"score"`
+	Thermo          *struct {
+		ScoreC *float64 `json:"score_c"`
+	} `json:"thermo"`
+
+	InnerFound    *bool    `json:"inner_found"`
+	InnerPairID   string   `json:"inner_experiment_id"`
+	InnerStart    int      `json:"inner_start"`
+	InnerEnd      int      `json:"inner_end"`
+	InnerProducts []Inner  `json:"inner_products"`
+	OuterScore    *float64 `json:"outer_score"`
+	InnerScore    *float64 `json:"inner_score"`
+	NestedScore   *float64 `json:"nested_score"`
+}
+
+// Inner is one inner-round product of a nested record (api.NestedInnerV1).
+type Inner struct {
+	Round        int      `json:"round"`
+	ExperimentID string   `json:"experiment_id"`
+	Start        int      `json:"start"`
+	End          int      `json:"end"`
+	Type         string   `json:"type"`
+	FwdMM        int      `json:"fwd_mm"`
+	RevMM        int      `json:"rev_mm"`
+	Score        *float64 `json:"score"`
+	Inner        []Inner  `json:"inner_products"`
+}
+
+// score is the product score: "nested_score" for scored nested records,
+// else "score", or the thermo score_c of builds that leave "score" out.
+func (r Record) score() *float64 {
+	if r.NestedScore != nil {
+		return r.NestedScore
+	}
+	if r.Score == nil && r.Thermo != nil {
+		return r.Thermo.ScoreC
+	}
+	return r.Score
+}
+
+// Read loads the products of a JSON (array) or JSONL file; "-" reads
+// standard input.
+func Read(path string) ([]Record, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+	br := bufio.NewReader(in)
+	first, err := firstByte(br)
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if first != '[' && first != '{' {
+		return nil, fmt.Errorf("%s: not JSON or JSONL products (write them with --output json or jsonl)", path)
+	}
+	dec := json.NewDecoder(br)
+	if first == '[' {
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	var out []Record
+	for first == '{' || dec.More() {
+		var r Record
+		if err := dec.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: product %d: %w", path, len(out)+1, err)
+		}
+		if r.ExperimentID == "" || r.SequenceID == "" {
+			return nil, fmt.Errorf("%s: product %d: no experiment_id/sequence_id; not a product record", path, len(out)+1)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func firstByte(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			return b, br.UnreadByte()
+		}
+	}
+}
+
+// Options tune Compare.
+type Options struct {
+	Tolerance  float64 // score deltas up to this are not changes
+	IgnoreFile bool    // match products across source files (moved or renamed inputs)
+}
+
+func (o Options) key(r Record) pipeline.Key {
+	k := pipeline.Key{
+		Base: r.SequenceID, File: r.SourceFile, Start: r.Start, End: r.End, Type: r.Type, Exp: r.ExperimentID,
+		Bisulfite: r.BisulfiteStrand + "/" + r.Methylation, AltAllele: r.AltAllele,
+	}
+	if o.IgnoreFile {
+		k.File = ""
+	}
+	return k
+}
+
+// index keys records, the first of duplicates winning as in the pipeline.
+func (o Options) index(recs []Record) (map[pipeline.Key]Record, []pipeline.Key) {
+	m := make(map[pipeline.Key]Record, len(recs))
+	var keys []pipeline.Key
+	for _, r := range recs {
+		k := o.key(r)
+		if _, dup := m[k]; !dup {
+			m[k] = r
+			keys = append(keys, k)
+		}
+	}
+	return m, keys
+}
+
+// Compare reports the products gained, lost or changed from a to b, in
+// coordinate order, and counts every outcome.
+func Compare(a, b []Record, o Options) ([]api.ProductDiffV1, api.DiffSummaryV1) {
+	ma, ka := o.index(a)
+	mb, kb := o.index(b)
+	sum := api.DiffSummaryV1{A: len(ka), B: len(kb)}
+	var rows []api.ProductDiffV1
+	for _, k := range ka {
+		ra := ma[k]
+		rb, ok := mb[k]
+		if !ok {
+			sum.Lost++
+			rows = append(rows, row(api.DiffLost, nil, &ra, nil))
+			continue
+		}
+		changes, inner := o.changes(ra, rb)
+		if len(changes) == 0 {
+			sum.Unchanged++
+			continue
+		}
+		sum.Changed++
+		d := row(api.DiffChanged, changes, &ra, &rb)
+		d.Inner = inner
+		rows = append(rows, d)
+	}
+	for _, k := range kb {
+		if _, ok := ma[k]; !ok {
+			rb := mb[k]
+			sum.Gained++
+			rows = append(rows, row(api.DiffGained, nil, nil, &rb))
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		x, y := rows[i], rows[j]
+		if x.SourceFile != y.SourceFile {
+			return x.SourceFile < y.SourceFile
+		}
+		if x.SequenceID != y.SequenceID {
+			return x.SequenceID < y.SequenceID
+		}
+		if x.Start != y.Start {
+			return x.Start < y.Start
+		}
+		if x.End != y.End {
+			return x.End < y.End
+		}
+		if x.ExperimentID != y.ExperimentID {
+			return x.ExperimentID < y.ExperimentID
+		}
+		return x.Type < y.Type
+	})
+	return rows, sum
+}
+
+// changes names what differs between a and b, with the inner products that
+// do.
+func (o Options) changes(a, b Record) ([]string, []api.InnerDiffV1) {
+	var out []string
+	if a.FwdMM != b.FwdMM || a.RevMM != b.RevMM {
+		out = append(out, "mismatches")
+	}
+	if o.scoreMoved(a.score(), b.score()) {
+		out = append(out, "score")
+	}
+	best := a.InnerPairID != b.InnerPairID || a.InnerStart != b.InnerStart || a.InnerEnd != b.InnerEnd
+	if a.InnerFound != nil && b.InnerFound != nil && *a.InnerFound != *b.InnerFound {
+		best = true
+	}
+	if best {
+		out = append(out, "inner")
+	}
+	if o.scoreMoved(a.OuterScore, b.OuterScore) || o.scoreMoved(a.InnerScore, b.InnerScore) {
+		out = append(out, "round_scores")
+	}
+	inner := o.innerChanges(a.InnerProducts, b.InnerProducts)
+	if len(inner) > 0 {
+		out = append(out, "inner_products")
+	}
+	return out, inner
+}
+
+func (o Options) scoreMoved(a, b *float64) bool {
+	return a != nil && b != nil && math.Abs(*b-*a) > o.Tolerance
+}
+
+// innerChanges matches the inner products of a and b round by round, keyed
+// by experiment ID, coordinates and type within their enclosing product,
+// and reports those gained, lost or with other mismatches or score, in the
+// order of a then b.
+func (o Options) innerChanges(a, b []Inner) []api.InnerDiffV1 {
+	type keyed struct {
+		parent string // enclosing inner product, "" in the first inner round
+		in     Inner
+	}
+	flatten := func(list []Inner) (map[string]keyed, []string) {
+		m := map[string]keyed{}
+		var keys []string
+		var walk func(path, parent string, list []Inner)
+		walk = func(path, parent string, list []Inner) {
+			for _, in := range list {
+				self := fmt.Sprintf("%s:%d-%d", in.ExperimentID, in.Start, in.End)
+				k := fmt.Sprintf("%s/%d/%s/%s", path, in.Round, self, in.Type)
+				if _, dup := m[k]; dup {
+					continue
+				}
+				m[k] = keyed{parent, in}
+				keys = append(keys, k)
+				walk(k, self, in.Inner)
+			}
+		}
+		walk("", "", list)
+		return m, keys
+	}
+	side := func(in *Inner) *api.DiffSideV1 {
+		if in == nil {
+			return nil
+		}
+		return &api.DiffSideV1{FwdMM: in.FwdMM, RevMM: in.RevMM, Score: in.Score}
+	}
+	diff := func(change string, k keyed, a, b *Inner) api.InnerDiffV1 {
+		return api.InnerDiffV1{
+			Change: change, Parent: k.parent,
+			Round: k.in.Round, ExperimentID: k.in.ExperimentID, Start: k.in.Start, End: k.in.End, Type: k.in.Type,
+			A: side(a), B: side(b),
+		}
+	}
+	ma, ka := flatten(a)
+	mb, kb := flatten(b)
+	var out []api.InnerDiffV1
+	for _, k := range ka {
+		x := ma[k]
+		y, ok := mb[k]
+		switch {
+		case !ok:
+			out = append(out, diff(api.DiffLost, x, &x.in, nil))
+		case x.in.FwdMM != y.in.FwdMM || x.in.RevMM != y.in.RevMM || o.scoreMoved(x.in.Score, y.in.Score):
+			out = append(out, diff(api.DiffChanged, x, &x.in, &y.in))
+		}
+	}
+	for _, k := range kb {
+		if _, ok := ma[k]; !ok {
+			y := mb[k]
+			out = append(out, diff(api.DiffGained, y, nil, &y.in))
+		}
+	}
+	return out
+}
+
+func row(change string, changes []string, a, b *Record) api.ProductDiffV1 {
+	r := a
+	if r == nil {
+		r = b
+	}
+	d := api.ProductDiffV1{
+		Change: change, Changes: changes,
+		ExperimentID: r.ExperimentID, SequenceID: r.SequenceID, Start: r.Start, End: r.End, Type: r.Type,
+		SourceFile: r.SourceFile, BisulfiteStrand: r.BisulfiteStrand, Methylation: r.Methylation,
+		A: side(a), B: side(b),
+	}
+	if a != nil && b != nil {
+		if sa, sb := a.score(), b.score(); sa != nil && sb != nil {
+			delta := *sb - *sa
+			d.ScoreDelta = &delta
+		}
+	}
+	return d
+}
+
+func side(r *Record) *api.DiffSideV1 {
+	if r == nil {
+		return nil
+	}
+	return &api.DiffSideV1{FwdMM: r.FwdMM, RevMM: r.RevMM, Score: r.score(), InnerFound: r.InnerFound}
+}
+
+// ParseFailOn reads --fail-on: a comma-separated list of changes that fail
+// the run, or "none".
+func ParseFailOn(s string) (map[string]bool, error) {
+	out := map[string]bool{}
+	if strings.TrimSpace(s) == "none" {
+		return out, nil
+	}
+	for _, c := range strings.Split(s, ",") {
+		switch c = strings.TrimSpace(c); c {
+		case api.DiffGained, api.DiffLost, api.DiffChanged:
+			out[c] = true
+		case "":
+		default:
+			return nil, fmt.Errorf("--fail-on: unknown change %q (want gained, lost, changed or none)", c)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("--fail-on: no changes given (use none to never fail)")
+	}
+	return out, nil
+}
+
+// TSVHeader names the columns of WriteTSV.
+const TSVHeader = "change\tchanges\texperiment_id\tsequence_id\tstart\tend\ttype\tsource_file\ta_fwd_mm\ta_rev_mm\tb_fwd_mm\tb_rev_mm\ta_score\tb_score\tscore_delta"
+
+// WriteTSV writes one row per differing product; "-" marks values a side
+// does not have.
+func WriteTSV(w io.Writer, rows []api.ProductDiffV1, header bool) error {
+	var b strings.Builder
+	if header {
+		b.WriteString(TSVHeader + "\n")
+	}
+	for _, r := range rows {
+		changes := strings.Join(r.Changes, ",")
+		if changes == "" {
+			changes = "-"
+		}
+		src := r.SourceFile
+		if src == "" {
+			src = "-"
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Change, changes, r.ExperimentID, r.SequenceID, r.Start, r.End, r.Type, src,
+			mm(r.A), mm(r.B), score(r.A), score(r.B), num(r.ScoreDelta))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func mm(s *api.DiffSideV1) string {
+	if s == nil {
+		return "-\t-"
+	}
+	return strconv.Itoa(s.FwdMM) + "\t" + strconv.Itoa(s.RevMM)
+}
+
+func score(s *api.DiffSideV1) string {
+	if s == nil {
+		return "-"
+	}
+	return num(s.Score)
+}
+
+func num(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*v, 'f', 3, 64)
+}
+
+// WriteJSON writes rows as an indented JSON array.
+func WriteJSON(w io.Writer, rows []api.ProductDiffV1) error {
+	if rows == nil {
+		rows = []api.ProductDiffV1{}
+	}
+	return jsonutil.EncodePretty(w, rows)
+}
+
+// WriteJSONL writes one JSON object per differing product.
+func WriteJSONL(w io.Writer, rows []api.ProductDiffV1) error {
+	enc := json.NewEncoder(w)
+	for _, r := range rows {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
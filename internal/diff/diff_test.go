@@ -0,0 +1,162 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"ipcr/pkg/api"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadAndCompare(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.json")
+	b := filepath.Join(dir, "b.jsonl")
+	// a is a JSON array (--output json); b is JSONL. Scores come from
+	// "score" or, in builds without it, thermo.score_c.
+	if err := os.WriteFile(a, []byte(`[
+  {"experiment_id":"p1","sequence_id":"s1","start":10,"end":110,"length":100,"type":"forward","source_file":"r.fa","score":5.0},
+  {"experiment_id":"p1","sequence_id":"s1","start":10,"end":110,"length":100,"type":"forward","source_file":"r.fa","score":9.0},
+  {"experiment_id":"p1","sequence_id":"s2","start":0,"end":100,"length":100,"type":"revcomp","source_file":"r.fa","fwd_mm":1},
+  {"experiment_id":"p2","sequence_id":"s3","start":5,"end":50,"length":45,"type":"forward","source_file":"r.fa","thermo":{"score_c":2.0}},
+  {"experiment_id":"n1","sequence_id":"s4","start":0,"end":300,"length":300,"type":"forward","source_file":"r.fa","inner_found":true,"inner_experiment_id":"i1","inner_start":20,"inner_end":120}
+]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte(strings.Join([]string{
+		`{"experiment_id":"p1","sequence_id":"s1","start":10,"end":110,"length":100,"type":"forward","source_file":"r.fa","score":5.05}`,
+		`{"experiment_id":"p2","sequence_id":"s3","start":5,"end":50,"length":45,"type":"forward","source_file":"r.fa","fwd_mm":2,"thermo":{"score_c":1.0}}`,
+		`{"experiment_id":"n1","sequence_id":"s4","start":0,"end":300,"length":300,"type":"forward","source_file":"r.fa","inner_found":false}`,
+		`{"experiment_id":"p3","sequence_id":"s1","start":400,"end":500,"length":100,"type":"forward","source_file":"r.fa"}`,
+	}, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ra, err := Read(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb, err := Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, sum := Compare(ra, rb, Options{Tolerance: 0.1})
+	if sum != (api.DiffSummaryV1{A: 4, B: 4, Gained: 1, Lost: 1, Changed: 2, Unchanged: 1}) {
+		t.Fatalf("summary: %+v", sum)
+	}
+	var got []string
+	for _, r := range rows {
+		got = append(got, r.Change+":"+r.SequenceID+":"+strings.Join(r.Changes, ","))
+	}
+	want := "gained:s1:|lost:s2:|changed:s3:mismatches,score|changed:s4:inner"
+	if strings.Join(got, "|") != want {
+		t.Fatalf("rows %q, want %q", strings.Join(got, "|"), want)
+	}
+	if d := rows[2].ScoreDelta; d == nil || *d != -1 || rows[2].A.FwdMM != 0 || rows[2].B.FwdMM != 2 {
+		t.Fatalf("changed row: %+v", rows[2])
+	}
+	if rows[0].A != nil || rows[1].B != nil {
+		t.Fatalf("gained/lost sides: %+v %+v", rows[0], rows[1])
+	}
+
+	// The first of duplicate products wins, as in the pipeline: a tighter
+	// tolerance makes its 0.05 score delta a change.
+	if _, sum := Compare(ra, rb, Options{Tolerance: 0.01}); sum.Changed != 3 {
+		t.Fatalf("tolerance 0.01: %+v", sum)
+	}
+
+	var tsv bytes.Buffer
+	if err := WriteTSV(&tsv, rows[:3], true); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(tsv.String()), "\n")
+	if lines[0] != TSVHeader || lines[1] != "gained\t-\tp3\ts1\t400\t500\tforward\tr.fa\t-\t-\t0\t0\t-\t-\t-" ||
+		lines[3] != "changed\tmismatches,score\tp2\ts3\t5\t50\tforward\tr.fa\t0\t0\t2\t0\t2.000\t1.000\t-1.000" {
+		t.Fatalf("tsv:\n%s", tsv.String())
+	}
+}
+
+func TestCompareNestedInnerProductsAndScores(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.jsonl")
+	b := filepath.Join(dir, "b.jsonl")
+	// --all-inner: b gains a nonspecific inner product and loses a third-round
+	// one; the outer product's nested score drops with its inner round.
+	if err := os.WriteFile(a, []byte(strings.Join([]string{
+		`{"experiment_id":"o1","sequence_id":"s1","start":0,"end":400,"length":400,"type":"forward","source_file":"r.fa","inner_found":true,"inner_experiment_id":"i1","inner_start":50,"inner_end":250,` +
+			`"inner_products":[{"round":2,"experiment_id":"i1","start":50,"end":250,"length":200,"type":"forward","score":60,"inner_products":[{"round":3,"experiment_id":"j1","start":20,"end":120,"length":100,"type":"forward"}]}],` +
+			`"outer_score":70,"inner_score":60,"nested_score":60}`,
+	}, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte(strings.Join([]string{
+		`{"experiment_id":"o1","sequence_id":"s1","start":0,"end":400,"length":400,"type":"forward","source_file":"r.fa","inner_found":true,"inner_experiment_id":"i1","inner_start":50,"inner_end":250,` +
+			`"inner_products":[{"round":2,"experiment_id":"i1","start":50,"end":250,"length":200,"type":"forward","score":40},{"round":2,"experiment_id":"i1","start":300,"end":390,"length":90,"type":"revcomp","score":35}],` +
+			`"outer_score":70,"inner_score":40,"nested_score":40}`,
+	}, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ra, err := Read(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb, err := Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, sum := Compare(ra, rb, Options{Tolerance: 0.1})
+	if sum.Changed != 1 || len(rows) != 1 {
+		t.Fatalf("summary %+v, rows %+v", sum, rows)
+	}
+	r := rows[0]
+	if strings.Join(r.Changes, ",") != "score,round_scores,inner_products" || r.ScoreDelta == nil || *r.ScoreDelta != -20 {
+		t.Fatalf("row: %+v", r)
+	}
+	var got []string
+	for _, d := range r.Inner {
+		got = append(got, fmt.Sprintf("%s:%d:%s:%d-%d:%s", d.Change, d.Round, d.Parent, d.Start, d.End, d.Type))
+	}
+	want := "changed:2::50-250:forward|lost:3:i1:50-250:20-120:forward|gained:2::300-390:revcomp"
+	if strings.Join(got, "|") != want {
+		t.Fatalf("inner diffs %q, want %q", strings.Join(got, "|"), want)
+	}
+}
+
+func TestReadRejectsNonProducts(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"text.tsv":     "experiment_id\tsequence_id\n",
+		"summary.json": `[{"genome":"g","experiment_id":"p1","hit":true}]`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Read(path); err == nil {
+			t.Fatalf("%s accepted", name)
+		}
+	}
+	empty := filepath.Join(dir, "empty.jsonl")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if recs, err := Read(empty); err != nil || len(recs) != 0 {
+		t.Fatalf("empty: %v %v", recs, err)
+	}
+}
+
+func TestParseFailOn(t *testing.T) {
+	if m, err := ParseFailOn("lost, changed"); err != nil || len(m) != 2 || !m["lost"] || m["gained"] {
+		t.Fatalf("ParseFailOn: %v %v", m, err)
+	}
+	if m, err := ParseFailOn("none"); err != nil || len(m) != 0 {
+		t.Fatalf("none: %v %v", m, err)
+	}
+	for _, bad := range []string{"", "lost,moved"} {
+		if _, err := ParseFailOn(bad); err == nil {
+			t.Fatalf("ParseFailOn(%q) accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// internal/diffapp/app.go
+package diffapp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr/internal/cliutil"
+	"ipcr/internal/diff"
+	"ipcr/internal/output"
+	"ipcr/internal/writers"
+	"ipcr/pkg/api"
+)
+
+func usage(out io.Writer) {
+	_, _ = fmt.Fprintln(out, "Usage:")
+	_, _ = fmt.Fprintln(out, "  ipcr diff [options] a.jsonl b.jsonl")
+	_, _ = fmt.Fprintln(out, "\nCompare two product result sets (ipcr, ipcr-nested or ipcr-thermo; --output json or jsonl).")
+	_, _ = fmt.Fprintln(out, "Products are matched by file, sequence, start, end, type and experiment; each one gained,")
+	_, _ = fmt.Fprintln(out, "lost or changed (mismatch counts, score beyond the tolerance, inner product) is reported.")
+	_, _ = fmt.Fprintln(out, "Exit status: 0 no difference, 1 differences (see --fail-on), 2 usage or input error.")
+	_, _ = fmt.Fprintln(out, "\nOptions:")
+	_, _ = fmt.Fprintln(out, "  -o, --output string         Output: text | json | jsonl [text]")
+	_, _ = fmt.Fprintln(out, "      --score-tolerance float Score deltas up to this are not changes [0.1]")
+	_, _ = fmt.Fprintln(out, "      --ignore-file           Match products across source files (moved or renamed inputs) [false]")
+	_, _ = fmt.Fprintln(out, "      --fail-on list          Changes that exit 1: gained,lost,changed or none [gained,lost,changed]")
+	_, _ = fmt.Fprintln(out, "      --no-header             Suppress header line [false]")
+	_, _ = fmt.Fprintln(out, "  -q, --quiet                 Suppress the summary line [false]")
+}
+
+// RunContext implements `ipcr diff`. argv excludes "diff".
+func RunContext(_ context.Context, argv []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ipcr diff", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var (
+		format   string
+		o        diff.Options
+		failOn   string
+		noHeader bool
+		quiet    bool
+	)
+	fs.StringVar(&format, "output", output.FormatText, "output format")
+	fs.StringVar(&format, "o", output.FormatText, "alias of --output")
+	fs.Float64Var(&o.Tolerance, "score-tolerance", 0.1, "score deltas up to this are not changes")
+	fs.BoolVar(&o.IgnoreFile, "ignore-file", false, "match products across source files")
+	fs.StringVar(&failOn, "fail-on", "gained,lost,changed", "changes that exit 1")
+	fs.BoolVar(&noHeader, "no-header", false, "suppress header line")
+	fs.BoolVar(&quiet, "quiet", false, "suppress the summary line")
+	fs.BoolVar(&quiet, "q", false, "alias of --quiet")
+
+	flagArgs, posArgs := cliutil.SplitFlagsAndPositionals(fs, argv)
+	if err := fs.Parse(flagArgs); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			usage(stdout)
+			return 0
+		}
+		_, _ = fmt.Fprintln(stderr, err)
+		usage(stderr)
+		return 2
+	}
+	fail, err := diff.ParseFailOn(failOn)
+	switch {
+	case len(posArgs) != 2:
+		_, _ = fmt.Fprintln(stderr, "ipcr diff compares two result files")
+		usage(stderr)
+		return 2
+	case posArgs[0] == "-" && posArgs[1] == "-":
+		_, _ = fmt.Fprintln(stderr, "only one result set can come from standard input")
+		return 2
+	case !output.IsTabular(format):
+		_, _ = fmt.Fprintf(stderr, "invalid --output %q (want text, json or jsonl)\n", format)
+		return 2
+	case o.Tolerance < 0:
+		_, _ = fmt.Fprintln(stderr, "--score-tolerance must be ≥ 0")
+		return 2
+	case err != nil:
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	a, err := diff.Read(posArgs[0])
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+	b, err := diff.Read(posArgs[1])
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+	rows, sum := diff.Compare(a, b, o)
+
+	outw := bufio.NewWriter(stdout)
+	switch format {
+	case output.FormatJSON:
+		err = diff.WriteJSON(outw, rows)
+	case output.FormatJSONL:
+		err = diff.WriteJSONL(outw, rows)
+	default:
+		err = diff.WriteTSV(outw, rows, !noHeader)
+	}
+	if err == nil {
+		err = outw.Flush()
+	}
+	if err != nil && !writers.IsBrokenPipe(err) {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 3
+	}
+	if !quiet {
+		_, _ = fmt.Fprintf(stderr, "%s: %d products, %s: %d; %d gained, %d lost, %d changed, %d unchanged\n",
+			posArgs[0], sum.A, posArgs[1], sum.B, sum.Gained, sum.Lost, sum.Changed, sum.Unchanged)
+	}
+	if (fail[api.DiffGained] && sum.Gained > 0) || (fail[api.DiffLost] && sum.Lost > 0) || (fail[api.DiffChanged] && sum.Changed > 0) {
+		return 1
+	}
+	return 0
+}
+
+func Run(argv []string, stdout, stderr io.Writer) int {
+	return RunContext(context.Background(), argv, stdout, stderr)
+}
@@ -0,0 +1,112 @@
+// internal/indexapp/app.go
+package indexapp
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/refindex"
+	"ipcr/internal/cliutil"
+	"ipcr/internal/writers"
+	"os"
+	"path/filepath"
+)
+
+func usage(out io.Writer) {
+	_, _ = fmt.Fprintln(out, "Usage:")
+	_, _ = fmt.Fprintln(out, "  ipcr index build --out ref.ipcridx [--k N] ref.fa [more.fa ...]")
+	_, _ = fmt.Fprintln(out, "\nBuild a reusable reference index (packed 2-bit sequence + k-mer seeds).")
+	_, _ = fmt.Fprintln(out, "Scan it with: ipcr --index ref.ipcridx --forward AAA --reverse TTT")
+	_, _ = fmt.Fprintln(out, "\nOptions:")
+	_, _ = fmt.Fprintln(out, "      --out file              Index file to write (required)")
+	_, _ = fmt.Fprintf(out, "      --k int                 Seed k-mer length (%d-%d) [%d]\n", refindex.MinK, refindex.MaxK, refindex.DefaultK)
+	_, _ = fmt.Fprintln(out, "  -q, --quiet                 Suppress the summary line [false]")
+}
+
+// RunContext implements `ipcr index <subcommand>`. argv excludes "index".
+func RunContext(ctx context.Context, argv []string, stdout, stderr io.Writer) int {
+	if len(argv) == 0 || argv[0] == "-h" || argv[0] == "--help" {
+		usage(stdout)
+		return 0
+	}
+	if argv[0] != "build" {
+		_, _ = fmt.Fprintf(stderr, "unknown index subcommand %q\n", argv[0])
+		usage(stderr)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("ipcr index build", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var (
+		out   string
+		k     int
+		quiet bool
+	)
+	fs.StringVar(&out, "out", "", "index file to write")
+	fs.IntVar(&k, "k", refindex.DefaultK, "seed k-mer length")
+	fs.BoolVar(&quiet, "quiet", false, "suppress the summary line")
+	fs.BoolVar(&quiet, "q", false, "alias of --quiet")
+
+	flagArgs, posArgs := cliutil.SplitFlagsAndPositionals(fs, argv[1:])
+	if err := fs.Parse(flagArgs); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			usage(stdout)
+			return 0
+		}
+		_, _ = fmt.Fprintln(stderr, err)
+		usage(stderr)
+		return 2
+	}
+	paths, err := cliutil.ExpandPositionals(posArgs)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+	switch {
+	case out == "":
+		_, _ = fmt.Fprintln(stderr, "--out is required")
+		return 2
+	case len(paths) == 0:
+		_, _ = fmt.Fprintln(stderr, "at least one sequence file is required")
+		return 2
+	case k < refindex.MinK || k > refindex.MaxK:
+		_, _ = fmt.Fprintf(stderr, "--k must be between %d and %d\n", refindex.MinK, refindex.MaxK)
+		return 2
+	}
+
+	// Write to a sibling temp file so an interrupted build never leaves a
+	// truncated index under the final name.
+	tmp, err := os.CreateTemp(filepath.Dir(out), ".ipcridx-*")
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 3
+	}
+	_ = tmp.Chmod(0o644)
+	st, berr := refindex.Build(ctx, tmp, paths, k)
+	if cerr := tmp.Close(); berr == nil {
+		berr = cerr
+	}
+	if berr == nil {
+		berr = os.Rename(tmp.Name(), out)
+	}
+	if berr != nil {
+		_ = os.Remove(tmp.Name())
+		if errors.Is(berr, context.Canceled) {
+			return 130
+		}
+		_, _ = fmt.Fprintln(stderr, berr)
+		return 3
+	}
+
+	if !quiet {
+		if _, err := fmt.Fprintf(stdout, "indexed %d record(s), %d bp, %d %d-mers → %s\n", st.Records, st.Bases, st.Kmers, k, out); writers.IsBrokenPipe(err) {
+			return 0
+		} else if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 3
+		}
+	}
+	return 0
+}
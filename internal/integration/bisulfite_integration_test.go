@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/bisulfite"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBisulfite(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(18)
+	// MSP sites carry CpGs; BSP sites carry none. "AA" spacers keep CpG
+	// context from spilling into the sites.
+	const mspF, mspR = "GATTCGCCATTACGGTCAAC", "TCCACGTTAGCCTCGATCAC"
+	const bspF, bspR = "GATTCAACCTTAGCCATTGA", "TTGACCATGATCCAAGTCAT"
+	site := func(s string) string { return "AA" + s + "AA" }
+	genome := randSeq(200) + site(mspF) + randSeq(120) + site(mspR) + randSeq(800) +
+		site(bspF) + randSeq(120) + site(bspR) + randSeq(200)
+	fa := filepath.Join(dir, "ref.fa")
+	write(t, fa, ">chr1\n"+genome+"\n")
+
+	// Primers for a converted template: the forward site as converted and
+	// the reverse complement of the converted reverse site.
+	pair := func(c bisulfite.Conversion, f, r string) (string, string) {
+		conv := string(bisulfite.Convert([]byte(genome), c))
+		i, j := strings.Index(genome, f), strings.Index(genome, r)
+		return conv[i : i+len(f)], string(primer.RevComp([]byte(conv[j : j+len(r)])))
+	}
+	type row struct {
+		Start       int    `json:"start"`
+		Strand      string `json:"bisulfite_strand"`
+		Methylation string `json:"methylation"`
+	}
+	run := func(fwd, rev string, args ...string) []row {
+		t.Helper()
+		var out, errB bytes.Buffer
+		args = append([]string{"-f", fwd, "-r", rev, "--self=false", "--max-length", "300", "--sort", "-o", "jsonl"}, args...)
+		if code := app.Run(append(args, fa), &out, &errB); code != 0 {
+			t.Fatalf("%v: exit %d: %s", args, code, errB.String())
+		}
+		var rows []row
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var r row
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatal(err)
+			}
+			rows = append(rows, r)
+		}
+		return rows
+	}
+	mspStart := 202
+
+	for _, c := range bisulfite.Conversions {
+		fwd, rev := pair(c, mspF, mspR)
+		got := run(fwd, rev, "--bisulfite")
+		if len(got) != 1 || got[0].Start != mspStart || got[0].Strand != string(c.Strand) || got[0].Methylation != c.State() {
+			t.Fatalf("MSP primers for %s: %+v", c, got)
+		}
+		// The converted primers do not bind the unconverted genome.
+		if got := run(fwd, rev); len(got) != 0 {
+			t.Fatalf("MSP primers for %s without --bisulfite: %+v", c, got)
+		}
+	}
+
+	// No CpG under the primers: one methylation-independent product.
+	fwd, rev := pair(bisulfite.Conversion{Strand: bisulfite.Top}, bspF, bspR)
+	if got := run(fwd, rev, "--bisulfite"); len(got) != 1 || got[0].Strand != "top" || got[0].Methylation != bisulfite.Any {
+		t.Fatalf("BSP primers: %+v", got)
+	}
+
+	// Text output labels products in two trailing columns.
+	var out, errB bytes.Buffer
+	if code := app.Run([]string{"-f", fwd, "-r", rev, "--self=false", "--max-length", "300", "--bisulfite", fa}, &out, &errB); code != 0 {
+		t.Fatalf("text: exit %d: %s", code, errB.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "\tbisulfite_strand\tmethylation") || !strings.HasSuffix(lines[1], "\ttop\t"+bisulfite.Any) {
+		t.Fatalf("text output:\n%s", out.String())
+	}
+
+	for _, bad := range [][]string{{"--bisulfite", "-o", "bed"}, {"--bisulfite", "-o", "json", "--summary"}} {
+		if code := app.Run(append(append([]string{"-f", fwd, "-r", rev}, bad...), fa), &out, &errB); code != 2 {
+			t.Fatalf("%v: want exit 2, got %d", bad, code)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package integration
+
+import (
+	"bytes"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoordinateFormatsIgnoreChunking(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(11)
+	const fwd, rev = "AGAGTTTGATCCTGGCTCAG", "TACGGTTACCTTGTTACGAC"
+	rcRev := "GTCGTAACAAGGTAACCGTA"
+	chr := randSeq(900) + fwd + randSeq(120) + rcRev + randSeq(700) + "AGAGTTTGATCATGGCTCAG" + randSeq(60) + rcRev + randSeq(300)
+	fa := filepath.Join(dir, "ref.fa")
+	write(t, fa, ">chr1\n"+chr+"\n")
+
+	for _, format := range []string{"bed", "bed12", "gff3", "sam"} {
+		run := func(extra ...string) string {
+			var out, errB bytes.Buffer
+			args := append([]string{"-f", fwd, "-r", rev, "-m", "1", "--self=false", "--max-length", "300", "--sort", "-o", format}, extra...)
+			if code := app.Run(append(args, fa), &out, &errB); code != 0 {
+				t.Fatalf("%s: exit %d: %s", format, code, errB.String())
+			}
+			return out.String()
+		}
+		whole, chunked := run(), run("--chunk-size", "500")
+		if whole != chunked {
+			t.Fatalf("%s differs when chunked\nwhole:\n%s\nchunked:\n%s", format, whole, chunked)
+		}
+		if !strings.Contains(whole, "chr1\t") {
+			t.Fatalf("%s: no records:\n%s", format, whole)
+		}
+	}
+
+	var out, errB bytes.Buffer
+	if code := app.Run([]string{"-f", fwd, "-r", rev, "--self=false", "--max-length", "300", "-o", "bed", fa}, &out, &errB); code != 0 {
+		t.Fatalf("exit %d: %s", code, errB.String())
+	}
+	if want := "chr1\t900\t1060\tmanual\t0\t+\n"; out.String() != want {
+		t.Fatalf("BED got %q want %q", out.String(), want)
+	}
+
+	errB.Reset()
+	if code := app.Run([]string{"-f", fwd, "-r", rev, "--summary", "-o", "bed", fa}, &out, &errB); code != 2 {
+		t.Fatalf("--summary with bed should be a usage error, exit %d", code)
+	}
+}
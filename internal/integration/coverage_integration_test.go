@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"ipcr/pkg/api"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoverageReport(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(20)
+	const fwd, revSite = "GATTCAACCTTAGCCATTGA", "TTGACCATGATCCAAGTCAT"
+	rev := string(primer.RevComp([]byte(revSite)))
+	// Ten aligned sequences: six match perfectly (one with a gap column
+	// inside the forward site), two carry a G>T at forward position 12 and
+	// two lack the reverse site.
+	fwdVar := fwd[:12] + "T" + fwd[13:]
+	var fa strings.Builder
+	for i := 0; i < 10; i++ {
+		f, r := fwd, revSite
+		switch {
+		case i == 1:
+			f = fwd[:10] + "--" + fwd[10:]
+		case i < 6:
+		case i < 8:
+			f = fwdVar
+		default:
+			r = randSeq(len(revSite))
+		}
+		fmt.Fprintf(&fa, ">seq%d\n%s---%s%s..%s%s\n", i, randSeq(30), f, randSeq(60), r, randSeq(30))
+	}
+	path := filepath.Join(dir, "aln.fa")
+	write(t, path, fa.String())
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out, errB bytes.Buffer
+		args = append([]string{"-f", fwd, "-r", rev, "--self=false", "-m", "1", "--coverage"}, args...)
+		if code := app.Run(append(args, path), &out, &errB); code != 0 {
+			t.Fatalf("%v: exit %d: %s", args, code, errB.String())
+		}
+		return out.String()
+	}
+
+	var rows []api.CoverageV1
+	if err := json.Unmarshal([]byte(run("-o", "json")), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("rows: %+v", rows)
+	}
+	row := rows[0]
+	if row.Sequences != 10 || row.Amplified != 8 || row.PercentAmplified != 80 {
+		t.Fatalf("amplification: %d/%d (%g%%)", row.Amplified, row.Sequences, row.PercentAmplified)
+	}
+	p12 := row.Forward.Positions[12]
+	if row.Forward.Sites != 8 || p12.Mismatches != 2 || p12.MismatchFreq != 0.25 || p12.Substitutions["G>T"] != 2 {
+		t.Fatalf("forward position 12: %+v", p12)
+	}
+	v := row.Forward.Variants
+	if len(v) != 2 || v[0].Site != fwd || v[0].Count != 6 || v[1].Site != fwdVar || v[1].Mismatches != 1 {
+		t.Fatalf("forward variants: %+v", v)
+	}
+	if len(row.Reverse.Variants) != 1 || row.Reverse.Variants[0].Site != rev {
+		t.Fatalf("reverse variants: %+v", row.Reverse.Variants)
+	}
+
+	text := run("--coverage-top", "1")
+	blocks := strings.Split(text, "\n\n")
+	if len(blocks) != 3 || !strings.Contains(blocks[0], "manual\t10\t8\t80.00") ||
+		!strings.Contains(blocks[1], "manual\tforward\t12\tG\t8\t2\t0.2500\tG>T:2\n") ||
+		strings.Count(blocks[2], "\n") != 3 {
+		t.Fatalf("text report:\n%s", text)
+	}
+
+	// By default (--self) the scan adds self pairs; only the panel's pairs
+	// are reported.
+	var out, errB bytes.Buffer
+	if code := app.Run([]string{"-f", fwd, "-r", rev, "-m", "1", "--coverage", "-o", "json", path}, &out, &errB); code != 0 {
+		t.Fatalf("default --self: exit %d: %s", code, errB.String())
+	}
+	var def []api.CoverageV1
+	if err := json.Unmarshal(out.Bytes(), &def); err != nil {
+		t.Fatal(err)
+	}
+	if len(def) != 1 || def[0].ExperimentID != "manual" || def[0].Amplified != 8 {
+		t.Fatalf("default --self rows: %+v", def)
+	}
+
+	for _, bad := range [][]string{
+		{"--coverage", "--summary"},
+		{"--coverage", "-o", "bed"},
+		{"--coverage", "--coverage-top", "-1"},
+		{"--coverage", "--bisulfite", "-o", "json"},
+	} {
+		if code := app.Run(append(append([]string{"-f", fwd, "-r", rev}, bad...), path), &out, &errB); code != 2 {
+			t.Fatalf("%v: want exit 2, got %d", bad, code)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"ipcr/pkg/api"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffGatesOnChangedResults(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(24)
+	const fwd, rev = "AGAGTTTGATCCTGGCTCAG", "TACGGTTACCTTGTTACGAC"
+	amp := fwd + randSeq(120) + "GTCGTAACAAGGTAACCGTA"
+	mm := "AGAGTATGATCCTGGCTCAG" + randSeq(120) + "GTCGTAACAAGGTAACCGTA" // one forward mismatch
+	fa := write(t, filepath.Join(dir, "ref.fa"), ">s1\n"+randSeq(60)+amp+randSeq(60)+"\n>s2\n"+mm+"\n")
+
+	run := func(args ...string) (string, string, int) {
+		var out, errB bytes.Buffer
+		code := app.Run(args, &out, &errB)
+		return out.String(), errB.String(), code
+	}
+	results := func(name string, extra ...string) string {
+		out, errS, code := run(append([]string{"-f", fwd, "-r", rev, "--self=false", "-o", "jsonl", fa}, extra...)...)
+		if code != 0 {
+			t.Fatalf("%s: exit %d: %s", name, code, errS)
+		}
+		return write(t, filepath.Join(dir, name), out)
+	}
+	strict := results("strict.jsonl")
+	loose := results("loose.jsonl", "-m", "1")
+
+	if out, errS, code := run("diff", strict, strict); code != 0 || strings.Count(out, "\n") != 1 || !strings.Contains(errS, "1 unchanged") {
+		t.Fatalf("same results: exit %d\n%s%s", code, out, errS)
+	}
+	out, errS, code := run("diff", "-o", "json", strict, loose)
+	if code != 1 || !strings.Contains(errS, "1 gained, 0 lost, 0 changed, 1 unchanged") {
+		t.Fatalf("gained product: exit %d: %s", code, errS)
+	}
+	var rows []api.ProductDiffV1
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Change != api.DiffGained || rows[0].SequenceID != "s2" || rows[0].B.FwdMM != 1 {
+		t.Fatalf("rows: %+v", rows)
+	}
+
+	// Gate on lost products only: gaining one passes.
+	if _, _, code := run("diff", "-q", "--fail-on", "lost", strict, loose); code != 0 {
+		t.Fatalf("--fail-on lost: exit %d", code)
+	}
+	if _, _, code := run("diff", "--fail-on", "lost", loose, strict); code != 1 {
+		t.Fatalf("lost product: exit %d", code)
+	}
+	if _, errS, code := run("diff", strict); code != 2 || errS == "" {
+		t.Fatalf("one file: exit %d", code)
+	}
+}
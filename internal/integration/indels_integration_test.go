@@ -0,0 +1,39 @@
+package integration
+
+import (
+	"bytes"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaxIndelsTextColumns(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(31)
+	// Both primers open with a homopolymer run, so neighbouring anchors
+	// re-align the exact chr1 sites with a gap.
+	const fwd, rev = "CCGATTCAACCTTAGCCATG", "GGTGACCATGATCCAAGTCA"
+	rcRev := string(primer.RevComp([]byte(rev)))
+	gapped := fwd[:13] + fwd[14:] // chr2 lacks forward primer base 13
+	fa := filepath.Join(dir, "ref.fa")
+	write(t, fa, ">chr1\n"+randSeq(50)+fwd+randSeq(120)+rcRev+randSeq(50)+
+		"\n>chr2\n"+randSeq(50)+gapped+randSeq(120)+rcRev+randSeq(50)+"\n")
+
+	var out, errB bytes.Buffer
+	if code := app.Run([]string{"-f", fwd, "-r", rev, "--self=false", "--max-indels", "1", "--sort", fa}, &out, &errB); code != 0 {
+		t.Fatalf("exit %d: %s", code, errB.String())
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "\tfwd_indels\trev_indels") {
+		t.Fatalf("want a header and one product per record, got:\n%s", out.String())
+	}
+	for i, want := range []string{"", "del13"} {
+		f := strings.Split(lines[i+1], "\t")
+		if len(f) != 13 || f[3] != "50" || f[11] != want || f[12] != "" {
+			t.Fatalf("row %d: %q", i, f)
+		}
+	}
+}
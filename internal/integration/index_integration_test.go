@@ -0,0 +1,65 @@
+package integration
+
+import (
+	"bytes"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIndexRunMatchesFASTARun(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(7)
+	const fwd, rev = "AGAGTTTGATCCTGGCTCAG", "TACGGTTACCTTGTTACGAC"
+	rcRev := "GTCGTAACAAGGTAACCGTA"
+	// chr1: one exact amplicon, one with a primer mismatch, an N run inside.
+	chr1 := randSeq(300) + fwd + randSeq(150) + rcRev + randSeq(200) +
+		"AGAGTTTGATCATGGCTCAG" + randSeq(80) + strings.Repeat("N", 30) + randSeq(40) + rcRev + randSeq(100)
+	chr2 := randSeq(500)
+	fa := filepath.Join(dir, "ref.fa")
+	write(t, fa, ">chr1 desc\n"+chr1+"\n>chr2\n"+chr2+"\n")
+	idx := filepath.Join(dir, "ref.ipcridx")
+
+	var out, errB bytes.Buffer
+	if code := app.Run([]string{"index", "build", "--out", idx, "--k", "8", fa}, &out, &errB); code != 0 {
+		t.Fatalf("index build exit %d: %s", code, errB.String())
+	}
+	if _, err := os.Stat(idx); err != nil {
+		t.Fatalf("index not written: %v", err)
+	}
+
+	run := func(in ...string) string {
+		var out, errB bytes.Buffer
+		args := append([]string{
+			"--forward", fwd, "--reverse", rev,
+			"--mismatches", "1", "--output", "json", "--sort", "--products",
+		}, in...)
+		if code := app.Run(args, &out, &errB); code != 0 {
+			t.Fatalf("exit %d err %s", code, errB.String())
+		}
+		return out.String()
+	}
+
+	want := run("--sequences", fa)
+	got := run("--index", idx)
+	if want != got {
+		t.Fatalf("indexed output differs\nfasta: %s\nindex: %s", want, got)
+	}
+	if w, g := run("--circular", "--sequences", fa), run("--circular", "--index", idx); w != g {
+		t.Fatalf("indexed circular output differs\nfasta: %s\nindex: %s", w, g)
+	}
+	if !strings.Contains(want, fwd) {
+		t.Fatalf("expected at least one product, got %s", want)
+	}
+}
+
+func TestIndexConflictsWithSequences(t *testing.T) {
+	var out, errB bytes.Buffer
+	code := app.Run([]string{"--forward", "ACG", "--reverse", "ACG", "--index", "x.ipcridx", "--sequences", "x.fa"}, &out, &errB)
+	if code != 2 || !strings.Contains(errB.String(), "--index conflicts") {
+		t.Fatalf("want usage error, got exit %d: %s", code, errB.String())
+	}
+}
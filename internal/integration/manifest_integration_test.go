@@ -0,0 +1,120 @@
+package integration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"ipcr/pkg/api"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifestRecordsRunAndReplays(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(23)
+	const fwd, rev = "AGAGTTTGATCCTGGCTCAG", "TACGGTTACCTTGTTACGAC"
+	amp := fwd + randSeq(120) + "GTCGTAACAAGGTAACCGTA"
+	fa := write(t, filepath.Join(dir, "a.fa"), ">a1\n"+randSeq(100)+amp+randSeq(100)+"\n>a2\n"+amp+"\n")
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(">b1\n" + randSeq(400) + "\n>b2\n" + randSeq(50) + amp + "\n>b3\nACGT\n"))
+	_ = zw.Close()
+	faGz := write(t, filepath.Join(dir, "b.fa.gz"), gz.String())
+	panel := write(t, filepath.Join(dir, "panel.tsv"), "16S\t"+fwd+"\t"+rev+"\nnone\tCCCCCCCCCCGGGGGGGGGG\tTTTTTTTTTTAAAAAAAAAA\n")
+
+	run := func(args ...string) (string, string, int) {
+		var out, errB bytes.Buffer
+		code := app.Run(args, &out, &errB)
+		return out.String(), errB.String(), code
+	}
+	readManifest := func(path string) api.ManifestV1 {
+		t.Helper()
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m api.ManifestV1
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	first := filepath.Join(dir, "run.json")
+	want, errS, code := run("--primers", panel, "--self=false", "-m", "1", "--max-length", "500", "--sort", "-o", "jsonl", "--manifest", first, fa, faGz)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, errS)
+	}
+	m := readManifest(first)
+	if m.Schema != api.ManifestSchemaV1 || m.Tool != "ipcr" || m.Version == "" || m.ExitCode != 0 || m.Finished == "" {
+		t.Fatalf("manifest header: %+v", m)
+	}
+	if m.Products != 3 || len(m.Pairs) != 2 || m.Pairs[0].ID != "16S" || m.Pairs[0].Products != 3 || m.Pairs[1].Products != 0 {
+		t.Fatalf("pair counts: %d %+v", m.Products, m.Pairs)
+	}
+	if m.Options["mismatches"] != float64(1) || m.Options["max-length"] != float64(500) || m.Options["self"] != false {
+		t.Fatalf("options: %v", m.Options)
+	}
+	if _, ok := m.Options["m"]; ok {
+		t.Fatal("aliases should not be recorded")
+	}
+	records := map[string]int{}
+	for _, in := range m.Inputs {
+		if len(in.SHA256) != 64 || in.Bytes == 0 {
+			t.Fatalf("input not checksummed: %+v", in)
+		}
+		records[in.Role+":"+filepath.Base(in.Path)] = in.Records
+	}
+	if len(records) != 3 || records["sequences:a.fa"] != 2 || records["sequences:b.fa.gz"] != 3 || records["primers:panel.tsv"] != 0 {
+		t.Fatalf("inputs: %+v", m.Inputs)
+	}
+
+	// A replay runs the recorded options, whatever this build's defaults.
+	second := filepath.Join(dir, "replay.json")
+	got, errS, code := run("--replay", first, "--manifest", second)
+	if code != 0 || got != want || errS != "" {
+		t.Fatalf("replay: exit %d, stderr %q, output equal %v", code, errS, got == want)
+	}
+	if r := readManifest(second); r.Products != m.Products || r.Inputs[1].SHA256 != m.Inputs[1].SHA256 {
+		t.Fatalf("replay manifest: %+v", r)
+	}
+
+	// Relative inputs are read from the directory of the recorded run.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel := filepath.Join(dir, "rel.json")
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	relOut, errS, code := run("--primers", "panel.tsv", "--self=false", "-m", "1", "--max-length", "500", "--manifest", rel, "a.fa", "b.fa.gz")
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+	if code != 0 {
+		t.Fatalf("relative run: exit %d: %s", code, errS)
+	}
+	if r := readManifest(rel); r.Cwd == "" || r.Inputs[0].Path != "a.fa" {
+		t.Fatalf("relative manifest: cwd %q, inputs %+v", r.Cwd, r.Inputs)
+	}
+	if got, errS, code := run("--replay", rel); code != 0 || errS != "" || got != relOut || strings.Count(got, "\n") != 4 {
+		t.Fatalf("replay from another directory: exit %d, stderr %q\n%s", code, errS, got)
+	}
+
+	write(t, panel, "16S\t"+fwd+"\t"+rev+"\n")
+	if _, errS, _ := run("--replay", first); !strings.Contains(errS, "panel.tsv changed") {
+		t.Fatalf("changed input not reported: %q", errS)
+	}
+	if _, errS, code := run("--replay", first, "--threads", "2"); code != 2 || !strings.Contains(errS, "--threads cannot be combined with --replay") {
+		t.Fatalf("extra option: exit %d: %s", code, errS)
+	}
+	if _, errS, code := run("--replay", filepath.Join(dir, "missing.json")); code != 2 || errS == "" {
+		t.Fatalf("missing manifest: exit %d", code)
+	}
+}
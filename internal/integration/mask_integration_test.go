@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaskPolicy(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(10)
+	const fwd, rev = "AGAGTTTGATCCTGGCTCAG", "TACGGTTACCTTGTTACGAC"
+	rcRev := "GTCGTAACAAGGTAACCGTA"
+	// First amplicon: clean primer sites inside a soft-masked insert.
+	// Second: the last 4 bases of the forward site are soft-masked.
+	amp1 := fwd + strings.ToLower(randSeq(120)) + rcRev
+	amp2 := fwd[:16] + strings.ToLower(fwd[16:]) + randSeq(120) + rcRev
+	fa := filepath.Join(dir, "ref.fa")
+	write(t, fa, ">chr1\n"+randSeq(300)+amp1+randSeq(400)+amp2+randSeq(300)+"\n")
+
+	type row struct {
+		Start     int `json:"start"`
+		FwdMasked int `json:"fwd_masked"`
+		RevMasked int `json:"rev_masked"`
+	}
+	run := func(args ...string) []row {
+		var out, errB bytes.Buffer
+		args = append([]string{"-f", fwd, "-r", rev, "--self=false", "--max-length", "300", "--sort", "-o", "jsonl"}, args...)
+		if code := app.Run(append(args, fa), &out, &errB); code != 0 {
+			t.Fatalf("%v: exit %d: %s", args, code, errB.String())
+		}
+		var rows []row
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var r row
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatal(err)
+			}
+			rows = append(rows, r)
+		}
+		return rows
+	}
+
+	for _, chunk := range []string{"0", "500"} {
+		if got := run("--chunk-size", chunk); len(got) != 2 || got[1].FwdMasked != 0 {
+			t.Fatalf("ignore (chunk %s): %+v", chunk, got)
+		}
+		if got := run("--chunk-size", chunk, "--mask-policy", "no-primer-in-mask"); len(got) != 1 || got[0].Start != 300 {
+			t.Fatalf("no-primer-in-mask (chunk %s): %+v", chunk, got)
+		}
+		got := run("--chunk-size", chunk, "--mask-policy", "report")
+		if len(got) != 2 || got[0] != (row{300, 0, 0}) || got[1] != (row{860, 4, 0}) {
+			t.Fatalf("report (chunk %s): %+v", chunk, got)
+		}
+	}
+
+	var out, errB bytes.Buffer
+	if code := app.Run([]string{"sites", "-f", fwd, "-r", rev, "--mask-policy", "no-primer-in-mask", "--self=false", fa}, &out, &errB); code != 0 {
+		t.Fatalf("sites: exit %d: %s", code, errB.String())
+	}
+	if n := strings.Count(out.String(), "\tforward\t"); n != 1 {
+		t.Fatalf("sites: want the unmasked forward site only, got:\n%s", out.String())
+	}
+
+	errB.Reset()
+	if code := app.Run([]string{"-f", fwd, "-r", rev, "--mask-policy", "hide", fa}, &out, &errB); code != 2 {
+		t.Fatalf("bad --mask-policy should exit 2, got %d", code)
+	}
+	if code := app.Run([]string{"-f", fwd, "-r", rev, "--mask-policy", "report", "--index", "x.ipcridx"}, &out, &errB); code != 2 {
+		t.Fatalf("--mask-policy with --index should exit 2, got %d", code)
+	}
+}
@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr/internal/app"
+	"ipcr/pkg/api"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMSAConservationProfile(t *testing.T) {
+	dir := t.TempDir()
+	aln := write(t, filepath.Join(dir, "targets.aln"), "CLUSTAL W (1.83) multiple sequence alignment\n\n"+
+		"s1      AAGATT-ACAGGCCCC\n"+
+		"s2      AAGATT-ACAGGCCCC\n"+
+		"s3      AAGACT-ACAGGCCCC\n"+
+		"s4      AAGATT-ACAGGCCCC\n"+
+		"        **** * ******  \n\n"+
+		"s1      CTTGCCATCAGGG\n"+
+		"s2      CTTGCCATCAGGG\n"+
+		"s3      CTTGCCATTAGGG\n"+
+		"s4      CTTGCCATCAGG-\n")
+
+	run := func(args ...string) (string, string, int) {
+		var out, errB bytes.Buffer
+		code := app.Run(append([]string{"msa"}, args...), &out, &errB)
+		return out.String(), errB.String(), code
+	}
+	out, errS, code := run("-f", "GATTACAGGC", "-r", "CTGATGGCAA", "--cover", "90", "-o", "json", aln)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, errS)
+	}
+	var rows []api.ConservationV1
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Sequences != 4 {
+		t.Fatalf("rows: %s", out)
+	}
+	f, r := rows[0].Forward, rows[0].Reverse
+	if f.Degenerate != "GAYTACAGGC" || f.Covered != 4 || f.StartColumn != 2 || f.Positions[4].Column != 7 {
+		t.Fatalf("forward: %+v", f)
+	}
+	if r.Degenerate != "CTRATGGCAA" || r.StartColumn != 17 || r.EndColumn != 26 {
+		t.Fatalf("reverse: %+v", r)
+	}
+
+	// A primer absent from the alignment is reported with a warning.
+	out, errS, code = run("-f", "GGGGGGGGGG", "-r", "CTGATGGCAA", "-m", "1", aln)
+	if code != 0 || !strings.Contains(errS, "WARN: manual: primer GGGGGGGGGG not found") || !strings.Contains(out, "\tforward\tGGGGGGGGGG\t4\t0\t-1\t-1\t") {
+		t.Fatalf("unlocated: exit %d\n%s%s", code, out, errS)
+	}
+
+	for name, args := range map[string][]string{
+		"no alignment": {"-f", "GATTACAGGC", "-r", "CTGATGGCAA"},
+		"no primers":   {aln},
+		"bad cover":    {"-f", "GATTACAGGC", "-r", "CTGATGGCAA", "--cover", "0", aln},
+		"bad format":   {"-f", "GATTACAGGC", "-r", "CTGATGGCAA", "--format", "phylip", aln},
+		"not aligned":  {"-f", "GATTACAGGC", "-r", "CTGATGGCAA", write(t, filepath.Join(dir, "raw.fa"), ">a\nACGT\n>b\nACG\n")},
+	} {
+		if _, errS, code := run(args...); code != 2 || errS == "" {
+			t.Fatalf("%s: exit %d", name, code)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestExtendedPanelPerPairLimits(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(11)
+	const fwd, rev = "AGAGTTTGATCCTGGCTCAG", "TACGGTTACCTTGTTACGAC"
+	rcRev := "GTCGTAACAAGGTAACCGTA"
+	// One forward-site mismatch, away from the 3' end.
+	fwdMM := fwd[:5] + "C" + fwd[6:]
+	fa := filepath.Join(dir, "ref.fa")
+	write(t, fa, ">chr1\n"+randSeq(200)+fwdMM+randSeq(120)+rcRev+randSeq(200)+"\n")
+
+	panel := filepath.Join(dir, "panel.tsv")
+	write(t, panel, "id\tfwd\trev\tmax_len\tmax_mm_fwd\tnotes\n"+
+		"strict\t"+fwd+"\t"+rev+"\t300\t0\texact only\n"+
+		"loose\t"+fwd+"\t"+rev+"\t300\t1\t\n"+
+		"default\t"+fwd+"\t"+rev+"\t300\t-\t\n")
+
+	ids := func(extra ...string) string {
+		var out, errB bytes.Buffer
+		args := append([]string{"--primers", panel, "--self=false", "-o", "jsonl"}, extra...)
+		if code := app.Run(append(args, fa), &out, &errB); code != 0 {
+			t.Fatalf("%v: exit %d: %s", extra, code, errB.String())
+		}
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var r struct {
+				ID string `json:"experiment_id"`
+			}
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, r.ID)
+		}
+		sort.Strings(got)
+		return strings.Join(got, ",")
+	}
+	if got := ids("--mismatches", "0"); got != "loose" {
+		t.Fatalf("--mismatches 0: got %q", got)
+	}
+	if got := ids("--mismatches", "1"); got != "default,loose" {
+		t.Fatalf("--mismatches 1: got %q", got)
+	}
+
+	// Circular rows disable chunking and cannot be combined with regions.
+	ring := filepath.Join(dir, "ring.tsv")
+	write(t, ring, "id\tfwd\trev\tcircular\nring\t"+fwd+"\t"+rev+"\ttrue\n")
+	bed := filepath.Join(dir, "r.bed")
+	write(t, bed, "chr1\t0\t100\n")
+	var out, errB bytes.Buffer
+	if code := app.Run([]string{"--primers", ring, "--regions", bed, fa}, &out, &errB); code != 2 {
+		t.Fatalf("circular panel row with --regions should exit 2, got %d", code)
+	}
+}
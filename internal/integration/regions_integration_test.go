@@ -0,0 +1,66 @@
+package integration
+
+import (
+	"bytes"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegionsRestrictScanKeepingGlobalCoordinates(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(8)
+	const fwd, rev = "AGAGTTTGATCCTGGCTCAG", "TACGGTTACCTTGTTACGAC"
+	rcRev := "GTCGTAACAAGGTAACCGTA"
+	amp := fwd + randSeq(120) + rcRev // 160 bp
+	chr1 := randSeq(900) + amp + randSeq(700) + amp + randSeq(300)
+	chr2 := randSeq(200) + amp + randSeq(200)
+	fa := filepath.Join(dir, "ref.fa")
+	write(t, fa, ">chr1\n"+chr1+"\n>chr2\n"+chr2+"\n")
+	idx := filepath.Join(dir, "ref.ipcridx")
+	var out, errB bytes.Buffer
+	if code := app.Run([]string{"index", "build", "--out", idx, "--k", "8", fa}, &out, &errB); code != 0 {
+		t.Fatalf("index build exit %d: %s", code, errB.String())
+	}
+
+	inc := filepath.Join(dir, "loci.bed")
+	write(t, inc, "track name=loci\nchr1\t850\t1100\tfirst\nchr2\t0\t600\n")
+	exc := filepath.Join(dir, "mask.bed")
+	write(t, exc, "chr1\t0\t1200\nchr2\t250\t260\n")
+
+	run := func(extra ...string) string {
+		var out, errB bytes.Buffer
+		args := append([]string{"-f", fwd, "-r", rev, "--self=false", "--max-length", "300", "--sort", "-o", "bed"}, extra...)
+		if code := app.Run(args, &out, &errB); code != 0 {
+			t.Fatalf("%v: exit %d: %s", extra, code, errB.String())
+		}
+		return out.String()
+	}
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--regions", inc}, "chr1\t900\t1060\tmanual\t0\t+\nchr2\t200\t360\tmanual\t0\t+\n"},
+		{[]string{"--exclude-regions", exc}, "chr1\t1760\t1920\tmanual\t0\t+\n"},
+		{[]string{"--regions", inc, "--exclude-regions", exc}, ""},
+	}
+	for _, c := range cases {
+		for _, input := range [][]string{{fa}, {fa, "--chunk-size", "400"}, {"--index", idx}} {
+			args := append(append([]string{}, c.args...), input...)
+			if got := run(args...); got != c.want {
+				t.Errorf("%v: got %q want %q", args, got, c.want)
+			}
+		}
+	}
+
+	errB.Reset()
+	if code := app.Run([]string{"-f", fwd, "-r", rev, "--regions", inc, "--circular", fa}, &out, &errB); code != 2 {
+		t.Fatalf("--regions with --circular should be a usage error, exit %d", code)
+	}
+	errB.Reset()
+	if code := app.Run([]string{"-f", fwd, "-r", rev, "--regions", filepath.Join(dir, "missing.bed"), fa}, &out, &errB); code != 2 {
+		t.Fatalf("missing regions file should exit 2, got %d", code)
+	}
+}
@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr/internal/app"
+	"ipcr/pkg/api"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSitesReportsUnpairedOffTargetBinding(t *testing.T) {
+	dir := t.TempDir()
+	const fwd, rev = "ACGTACGGATTCAG", "GGCATTCGATCCAA"
+	// No primer pair amplicon forms: the reverse primer never binds. The forward primer
+	// binds once perfectly, once with a 3'-proximal mismatch and once on the
+	// minus strand with a 5'-side mismatch.
+	fa := filepath.Join(dir, "host.fa")
+	write(t, fa, ">chr1\n"+strings.Repeat("T", 10)+fwd+strings.Repeat("T", 15)+
+		"CTGAATCCGTTCGT"+strings.Repeat("T", 12)+"ACGTACGGATTGAG"+strings.Repeat("T", 5)+"\n")
+
+	var out, errB bytes.Buffer
+	if code := app.Run([]string{"-f", fwd, "-r", rev, "-m", "2", "--self=false", fa}, &out, &errB); code != 0 {
+		t.Fatalf("ipcr exit %d: %s", code, errB.String())
+	}
+	if n := strings.Count(out.String(), "\n"); n != 1 {
+		t.Fatalf("expected header only from product mode, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	errB.Reset()
+	code := app.Run([]string{"sites", "-f", fwd, "-r", rev, "-m", "2", "--output", "json", "--sort", fa}, &out, &errB)
+	if code != 0 {
+		t.Fatalf("sites exit %d: %s", code, errB.String())
+	}
+	var sites []api.BindingSiteV1
+	if err := json.Unmarshal(out.Bytes(), &sites); err != nil {
+		t.Fatalf("decode: %v\n%s", err, out.String())
+	}
+	type row struct {
+		strand    string
+		start, mm int
+		tpMM      int
+		tpDist    int
+	}
+	want := []row{{"+", 10, 0, 0, -1}, {"-", 39, 1, 0, 10}, {"+", 65, 1, 1, 2}}
+	if len(sites) != len(want) {
+		t.Fatalf("want %d sites, got %+v", len(want), sites)
+	}
+	for i, w := range want {
+		s := sites[i]
+		got := row{s.Strand, s.Start, s.Mismatches, s.ThreePrimeMM, s.ThreePrimeDist}
+		if got != w || s.Primer != "forward" || s.PrimerSeq != fwd {
+			t.Fatalf("site %d: got %+v want %+v", i, s, w)
+		}
+	}
+
+	// The default 3' terminal window is off in sites mode; restoring it
+	// drops the 3'-proximal site.
+	out.Reset()
+	errB.Reset()
+	if code := app.Run([]string{"sites", "-f", fwd, "-r", rev, "-m", "2", "--terminal-window", "3", "--no-header", fa}, &out, &errB); code != 0 {
+		t.Fatalf("sites exit %d: %s", code, errB.String())
+	}
+	if n := strings.Count(out.String(), "\n"); n != 2 {
+		t.Fatalf("want 2 sites with --terminal-window 3, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	errB.Reset()
+	if code := app.Run([]string{"sites", "-f", fwd, "-r", rev, "--max-length", "500", fa}, &out, &errB); code != 2 {
+		t.Fatalf("--max-length should be rejected, exit %d", code)
+	}
+	if out.Len() > 0 || strings.Contains(errB.String(), "Usage:") {
+		t.Fatalf("usage errors should not print the full help:\n%s%s", out.String(), errB.String())
+	}
+
+	// One primer is enough.
+	out.Reset()
+	errB.Reset()
+	if code := app.Run([]string{"sites", "-r", fwd, "-m", "2", "--no-header", fa}, &out, &errB); code != 0 {
+		t.Fatalf("sites -r only: exit %d: %s", code, errB.String())
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 3 || !strings.Contains(lines[0], "\treverse\t") {
+		t.Fatalf("sites -r only:\n%s", out.String())
+	}
+}
+
+func TestSitesGappedSiteOnce(t *testing.T) {
+	dir := t.TempDir()
+	const fwd = "CCGATTCAACCTTAGCCATG"
+	// The 5' CC run lets anchors one or two bases away re-align the exact
+	// site with a terminal gap; it must be reported once.
+	fa := filepath.Join(dir, "host.fa")
+	write(t, fa, ">chr1\n"+strings.Repeat("T", 20)+fwd+strings.Repeat("T", 20)+"\n")
+
+	var out, errB bytes.Buffer
+	if code := app.Run([]string{"sites", "-f", fwd, "-m", "2", "--max-indels", "2", "--no-header", fa}, &out, &errB); code != 0 {
+		t.Fatalf("exit %d: %s", code, errB.String())
+	}
+	if f := strings.Split(strings.TrimSpace(out.String()), "\t"); len(f) != 12 || f[5] != "20" || f[6] != "40" {
+		t.Fatalf("want the exact site 20-40 once, got:\n%s", out.String())
+	}
+}
+
+func TestSitesHelpListsOnlyHonouredFlags(t *testing.T) {
+	var out, errB bytes.Buffer
+	if code := app.Run([]string{"sites", "-h"}, &out, &errB); code != 0 {
+		t.Fatalf("exit %d: %s", code, errB.String())
+	}
+	help := out.String()
+	for _, want := range []string{"--three-prime-window", "--index", "--max-indels", "text | json | jsonl ["} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help lacks %q", want)
+		}
+	}
+	for _, bad := range []string{"--min-length", "--max-length", "--self", "--circular", "--products", "--pretty", "--summary", "--assembly-map", "bed12"} {
+		if strings.Contains(help, bad) {
+			t.Errorf("help lists %q", bad)
+		}
+	}
+}
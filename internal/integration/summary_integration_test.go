@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"bytes"
+	"ipcr-core/primer"
+	"ipcr/internal/app"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummaryWithAssemblyMap(t *testing.T) {
+	dir := t.TempDir()
+	const fwd, rev = "GATTACAGATTACAGG", "CCTTGGAACCTTGGTT"
+	rcRev := string(primer.RevComp([]byte(rev)))
+	pad := strings.Repeat("T", 40)
+	amp := fwd + strings.Repeat("C", 60) + rcRev
+
+	// Genome G1 is split over two contig files; G2 has no target.
+	g1a := write(t, filepath.Join(dir, "g1_part1.fa"), ">c1\n"+pad+amp+pad+"\n")
+	g1b := write(t, filepath.Join(dir, "g1_part2.fa"), ">c2\n"+pad+fwd+strings.Repeat("C", 10)+rcRev+pad+"\n")
+	g2 := write(t, filepath.Join(dir, "g2.fa"), ">c1\n"+pad+pad+"\n")
+	amap := write(t, filepath.Join(dir, "map.tsv"), "g1_part1.fa\tG1\ng1_part2.fa\tG1\ng2.fa\tG2\n")
+
+	var out, errB bytes.Buffer
+	code := app.Run([]string{
+		"--forward", fwd, "--reverse", rev, "--self=false",
+		"--summary", "--assembly-map", amap,
+		g1a, g1b, g2,
+	}, &out, &errB)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, errB.String())
+	}
+	want := "genome\texperiment_id\thit\tproducts\tlengths\tmin_mm\n" +
+		"G1\tmanual\tyes\t2\t42,92\t0\n" +
+		"G2\tmanual\tno\t0\t-\t-\n"
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	// Self pairs (--self, the default) are not rows of the matrix.
+	out.Reset()
+	if code := app.Run([]string{"--forward", fwd, "--reverse", rev, "--summary", "--assembly-map", amap, g1a, g1b, g2}, &out, &errB); code != 0 || out.String() != want {
+		t.Fatalf("--self: exit %d:\n%s", code, out.String())
+	}
+}
+
+func TestAssemblyMapRequiresSummary(t *testing.T) {
+	var out, errB bytes.Buffer
+	code := app.Run([]string{"--forward", "ACG", "--reverse", "ACG", "--assembly-map", "m.tsv", "x.fa"}, &out, &errB)
+	if code != 2 || !strings.Contains(errB.String(), "--assembly-map requires --summary") {
+		t.Fatalf("want usage error, got exit %d: %s", code, errB.String())
+	}
+}
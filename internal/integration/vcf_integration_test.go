@@ -0,0 +1,173 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/internal/app"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVCFVariants(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(19)
+	const fwd, revSite = "GATTCAACCTTAGCCATTGA", "TTGACCATGATCCAAGTCAT"
+	rev := string(primer.RevComp([]byte(revSite)))
+	genome := randSeq(200) + fwd + randSeq(120) + revSite + randSeq(200)
+	fa := filepath.Join(dir, "ref.fa")
+	write(t, fa, ">chr1\n"+genome+"\n")
+
+	// rs1 hits the forward primer's 3' base, rs2 the 5' end of the reverse
+	// primer, rs3 lies between the sites and rs4 on another record.
+	other := func(b byte) string { return map[byte]string{'A': "C", 'C': "G", 'G': "T", 'T': "A"}[b] }
+	snp := func(id string, pos int, af string) string {
+		return fmt.Sprintf("chr1\t%d\t%s\t%c\t%s\t.\tPASS\tAF=%s\n", pos+1, id, genome[pos], other(genome[pos]), af)
+	}
+	revEnd := 200 + len(fwd) + 120 + len(revSite)
+	vcfPath := filepath.Join(dir, "v.vcf")
+	write(t, vcfPath, "##fileformat=VCFv4.2\n#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n"+
+		snp("rs1", 200+len(fwd)-1, "0.3")+snp("rs2", revEnd-1, "0.9")+snp("rs3", 300, "0.5")+
+		"chr2\t10\trs4\tA\tG\t.\tPASS\tAF=0.9\n")
+
+	type hit struct {
+		ID         string  `json:"id"`
+		AF         float64 `json:"af"`
+		Site       string  `json:"site"`
+		PrimerPos  int     `json:"primer_pos"`
+		ThreePrime bool    `json:"three_prime"`
+		Effect     string  `json:"effect"`
+	}
+	run := func(args ...string) [][]hit {
+		t.Helper()
+		var out, errB bytes.Buffer
+		args = append([]string{"-f", fwd, "-r", rev, "--self=false", "--vcf", vcfPath, "-o", "jsonl"}, args...)
+		if code := app.Run(append(args, fa), &out, &errB); code != 0 {
+			t.Fatalf("%v: exit %d: %s", args, code, errB.String())
+		}
+		var got [][]hit
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var p struct {
+				Variants []hit `json:"variants"`
+			}
+			if err := json.Unmarshal([]byte(line), &p); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, p.Variants)
+		}
+		return got
+	}
+
+	got := run()
+	if len(got) != 1 || len(got[0]) != 2 {
+		t.Fatalf("want one product with two variant hits, got %+v", got)
+	}
+	h1, h2 := got[0][0], got[0][1]
+	if h1.ID != "rs1" || h1.Site != "fwd" || h1.PrimerPos != len(fwd)-1 || !h1.ThreePrime || h1.Effect != "mismatch" || h1.AF != 0.3 {
+		t.Fatalf("rs1: %+v", h1)
+	}
+	if h2.ID != "rs2" || h2.Site != "rev" || h2.PrimerPos != 0 || h2.ThreePrime || h2.Effect != "mismatch" {
+		t.Fatalf("rs2: %+v", h2)
+	}
+
+	// Only 3' hits at or above the AF threshold fail a product.
+	if got := run("--vcf-fail-af", "0.2"); len(got) != 0 {
+		t.Fatalf("--vcf-fail-af 0.2 kept %+v", got)
+	}
+	if got := run("--vcf-fail-af", "0.5"); len(got) != 1 {
+		t.Fatalf("--vcf-fail-af 0.5: %+v", got)
+	}
+
+	// --summary counts the products that pass.
+	var out, errB bytes.Buffer
+	args := []string{"-f", fwd, "-r", rev, "--self=false", "--vcf", vcfPath, "--vcf-fail-af", "0.2", "--summary", "--no-header", fa}
+	if code := app.Run(args, &out, &errB); code != 0 || !strings.Contains(out.String(), "\tno\t0\t") {
+		t.Fatalf("--summary: exit %d: %s%s", code, out.String(), errB.String())
+	}
+
+	for _, bad := range [][]string{
+		{"--vcf", vcfPath, "-o", "fasta"},
+		{"--vcf-fail-af", "0.2", "-o", "json"},
+		{"--vcf", vcfPath, "--vcf-fail-af", "2", "-o", "json"},
+		{"--vcf", filepath.Join(dir, "missing.vcf"), "-o", "json"},
+	} {
+		if code := app.Run(append(append([]string{"-f", fwd, "-r", rev}, bad...), fa), &out, &errB); code != 2 {
+			t.Fatalf("%v: want exit 2, got %d", bad, code)
+		}
+	}
+}
+
+func TestVCFAltOnlyProducts(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(23)
+	const fwd, revSite = "GATTCAACCTTAGCCATTGA", "TTGACCATGATCCAAGTCAT"
+	rev := string(primer.RevComp([]byte(revSite)))
+	left, mid, right := randSeq(200), randSeq(120), randSeq(200)
+
+	// chr1 carries a mismatch at forward primer base 5 that rs5 repairs;
+	// chr2 an extra base inside the reverse site that del1 removes.
+	broken := fwd[:5] + "C" + fwd[6:]
+	if fwd[5] == 'C' {
+		t.Fatal("fixture: forward primer base 5 must not be C")
+	}
+	ins := 200 + len(fwd) + len(mid) + 10 // position of the extra base on chr2
+	chr2 := left + fwd + mid + revSite[:10] + "G" + revSite[10:] + right
+	fa := filepath.Join(dir, "ref.fa")
+	write(t, fa, ">chr1\n"+left+broken+mid+revSite+right+"\n>chr2\n"+chr2+"\n")
+	vcfPath := filepath.Join(dir, "v.vcf")
+	write(t, vcfPath, "##fileformat=VCFv4.2\n#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n"+
+		fmt.Sprintf("chr1\t%d\trs5\tC\t%c\t.\tPASS\tAF=0.4\n", 200+5+1, fwd[5])+
+		fmt.Sprintf("chr2\t%d\tdel1\t%s\t%c\t.\tPASS\t.\n", ins, chr2[ins-1:ins+1], chr2[ins-1]))
+
+	base := []string{"-f", fwd, "-r", rev, "--self=false", "--sort"}
+	var out, errB bytes.Buffer
+	if code := app.Run(append(base, "--no-header", fa), &out, &errB); code != 0 || out.Len() != 0 {
+		t.Fatalf("reference scan: want no products, got exit %d: %s", code, out.String())
+	}
+
+	out.Reset()
+	if code := app.Run(append(base, "--vcf", vcfPath, fa), &out, &errB); code != 0 {
+		t.Fatalf("exit %d: %s", code, errB.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "\talt_allele\tvariants") {
+		t.Fatalf("want a header and two products, got:\n%s", out.String())
+	}
+	end := 200 + len(fwd) + len(mid) + len(revSite)
+	for i, want := range []struct {
+		seq, allele, hit string
+		end              int
+	}{
+		{"chr1", "rs5", "rs5@fwd:5:match", end},
+		{"chr2", "del1", "del1@rev:", end + 1},
+	} {
+		f := strings.Split(lines[i+1], "\t")
+		if len(f) != 13 || f[1] != want.seq || f[3] != "200" || f[4] != fmt.Sprint(want.end) || f[11] != want.allele || !strings.HasPrefix(f[12], want.hit) {
+			t.Fatalf("row %d: %q", i, f)
+		}
+	}
+
+	out.Reset()
+	if code := app.Run(append(base, "--vcf", vcfPath, "-o", "jsonl", fa), &out, &errB); code != 0 || strings.Count(out.String(), `"alt_allele":`) != 2 {
+		t.Fatalf("jsonl: exit %d: %s", code, out.String())
+	}
+
+	// Unbounded products would rescan whole records per allele.
+	out.Reset()
+	errB.Reset()
+	if code := app.Run(append(base, "--vcf", vcfPath, "--max-length", "0", "--no-header", fa), &out, &errB); code != 0 || out.Len() != 0 || !strings.Contains(errB.String(), "not rescanned") {
+		t.Fatalf("--max-length 0: exit %d: %s / %s", code, out.String(), errB.String())
+	}
+
+	// Allele-only products are not reference hits.
+	out.Reset()
+	if code := app.Run(append(base, "--vcf", vcfPath, "--summary", "--no-header", fa), &out, &errB); code != 0 || !strings.Contains(out.String(), "\tmanual\tno\t0\t") {
+		t.Fatalf("--summary: exit %d: %s", code, out.String())
+	}
+}
@@ -0,0 +1,360 @@
+// internal/manifest/manifest.go
+
+// Package manifest writes the provenance sidecar of --manifest and reads it
+// back for --replay.
+package manifest
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"ipcr-core/refindex"
+	"ipcr-core/thermo"
+	"ipcr/internal/clibase"
+	"ipcr/internal/version"
+	"ipcr/pkg/api"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder collects the manifest of one run: options and pairs up front,
+// products as they are reported, inputs and timing at the end.
+type Recorder struct {
+	m     api.ManifestV1
+	start time.Time
+	pair  map[string]int // pair ID → index in m.Pairs
+
+	mu    sync.Mutex
+	sets  map[string]bool
+	cites map[string]bool
+}
+
+// New starts the manifest of a tool run. argv is the command line, fs the
+// flag set it was parsed with and pairs the scanned panel.
+func New(tool string, argv []string, fs *flag.FlagSet, pairs []primer.Pair) *Recorder {
+	cwd, _ := os.Getwd()
+	r := &Recorder{
+		start: time.Now(),
+		pair:  make(map[string]int, len(pairs)),
+		sets:  make(map[string]bool),
+		cites: make(map[string]bool),
+	}
+	r.m = api.ManifestV1{
+		Schema:        api.ManifestSchemaV1,
+		Tool:          tool,
+		Version:       version.Version,
+		EngineVersion: version.EngineVersion,
+		ThermoVersion: version.ThermoVersion,
+		OutputSchema:  version.OutputSchemaVersion,
+		Args:          append([]string{}, argv...),
+		Cwd:           cwd,
+		Options:       Options(fs),
+		Inputs:        []api.InputFileV1{},
+		Pairs:         make([]api.PairCountV1, 0, len(pairs)),
+	}
+	for _, p := range pairs {
+		if _, dup := r.pair[p.ID]; dup {
+			continue
+		}
+		r.pair[p.ID] = len(r.m.Pairs)
+		r.m.Pairs = append(r.m.Pairs, api.PairCountV1{ID: p.ID, Forward: p.Forward, Reverse: p.Reverse})
+	}
+	return r
+}
+
+// notOptions are flags that do not shape the run.
+var notOptions = map[string]bool{"manifest": true, "replay": true, "version": true, "v": true, "h": true, "examples": true}
+
+// Options returns the current value of every flag of fs, aliases left out.
+// Repeatable flags give lists.
+func Options(fs *flag.FlagSet) map[string]any {
+	out := make(map[string]any)
+	fs.VisitAll(func(f *flag.Flag) {
+		if notOptions[f.Name] || strings.HasPrefix(f.Usage, "alias of") {
+			return
+		}
+		if g, ok := f.Value.(flag.Getter); ok {
+			out[f.Name] = g.Get()
+			return
+		}
+		out[f.Name] = f.Value.String()
+	})
+	return out
+}
+
+// Input names files the run reads under role; Write checksums them.
+func (r *Recorder) Input(role string, paths ...string) {
+	for _, p := range paths {
+		if p != "" {
+			r.m.Inputs = append(r.m.Inputs, api.InputFileV1{Role: role, Path: p})
+		}
+	}
+}
+
+// CommonInputs names the files of the shared options.
+func (r *Recorder) CommonInputs(c *clibase.Common) {
+	r.Input("sequences", c.SeqFiles...)
+	r.Input("index", c.Index)
+	r.Input("primers", c.PrimerFile)
+	r.Input("regions", c.Regions)
+	r.Input("exclude_regions", c.ExcludeRegions)
+	r.Input("vcf", c.VCF)
+	r.Input("assembly_map", c.AssemblyMap)
+}
+
+// Thermo records the effective thermodynamic model and solution conditions.
+func (r *Recorder) Thermo(model string, c thermo.Conditions) {
+	r.m.Thermo = &api.ThermoRunV1{
+		Model: model,
+		Conditions: api.ConditionsV1{
+			AnnealTempC:  c.AnnealC,
+			NaM:          c.NaM,
+			MgM:          c.MgM,
+			DntpM:        c.DntpM,
+			PrimerConcM:  c.PrimerTotalM,
+			SaltModel:    c.SaltModel.String(),
+			EffectiveNaM: c.EffectiveNaM(),
+		},
+	}
+}
+
+// Visit wraps visit to count the products it keeps, per pair, and collect
+// the mismatch parameter sets behind their thermodynamic scores.
+func (r *Recorder) Visit(visit func(engine.Product) (bool, engine.Product, error)) func(engine.Product) (bool, engine.Product, error) {
+	return func(p engine.Product) (bool, engine.Product, error) {
+		keep, out, err := visit(p)
+		if keep && err == nil {
+			r.product(out)
+		}
+		return keep, out, err
+	}
+}
+
+func (r *Recorder) product(p engine.Product) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.m.Products++
+	if i, ok := r.pair[p.ExperimentID]; ok {
+		r.m.Pairs[i].Products++
+	}
+	t := p.Thermo
+	if t == nil {
+		return
+	}
+	for _, e := range []engine.ThermoEndpoint{t.Fwd, t.Rev} {
+		add(r.sets, e.MismatchParameterSets, e.TerminalMismatchParameterSets)
+		add(r.cites, e.MismatchCitations, e.TerminalMismatchCitations)
+	}
+	if pr := t.Probe; pr != nil {
+		add(r.sets, pr.MismatchParameterSets, pr.TerminalMismatchParameterSets)
+		add(r.cites, pr.MismatchCitations, pr.TerminalMismatchCitations)
+	}
+}
+
+func add(set map[string]bool, lists ...[]string) {
+	for _, l := range lists {
+		for _, s := range l {
+			if s != "" {
+				set[s] = true
+			}
+		}
+	}
+}
+
+func sorted(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for s := range set {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Write finishes the manifest with the exit code, timing and input
+// checksums, and writes it to path as indented JSON.
+func (r *Recorder) Write(path string, exitCode int) error {
+	end := time.Now()
+	r.m.ExitCode = exitCode
+	r.m.Started = r.start.UTC().Format(time.RFC3339)
+	r.m.Finished = end.UTC().Format(time.RFC3339)
+	r.m.WallSeconds = math.Round(end.Sub(r.start).Seconds()*1000) / 1000
+	if r.m.Thermo != nil {
+		r.m.Thermo.ParameterSets = sorted(r.sets)
+		r.m.Thermo.Citations = sorted(r.cites)
+	}
+	for i := range r.m.Inputs {
+		if err := describe(&r.m.Inputs[i]); err != nil {
+			return fmt.Errorf("--manifest: %w", err)
+		}
+	}
+	b, err := json.MarshalIndent(r.m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// describe fills in the size, SHA-256 and record count of in. Standard
+// input cannot be read again and is left as is.
+func describe(in *api.InputFileV1) error {
+	if in.Path == "-" {
+		return nil
+	}
+	f, err := os.Open(in.Path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	switch in.Role {
+	case "sequences":
+		if in.Records, err = countRecords(io.TeeReader(f, h), in.Path); err != nil {
+			return err
+		}
+	case "index":
+		ix, err := refindex.Open(in.Path)
+		if err != nil {
+			return err
+		}
+		in.Records = len(ix.Records)
+		_ = ix.Close()
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	in.Bytes = st.Size()
+	in.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// countRecords counts the FASTA headers of r, gunzipping it as the FASTA
+// readers do (gzip magic or a .gz name).
+func countRecords(r io.Reader, path string) (int, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+	var src io.Reader = br
+	if sig, _ := br.Peek(2); (len(sig) == 2 && sig[0] == 0x1f && sig[1] == 0x8b) || strings.HasSuffix(path, ".gz") {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+		defer func() { _ = gr.Close() }()
+		src = gr
+	}
+	n, lineStart := 0, true
+	buf := make([]byte, 1<<16)
+	for {
+		k, err := src.Read(buf)
+		for _, b := range buf[:k] {
+			if lineStart && b == '>' {
+				n++
+			}
+			lineStart = b == '\n'
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+}
+
+// Read loads a manifest written by tool, for --replay.
+func Read(path, tool string) (*api.ManifestV1, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	dec := json.NewDecoder(f)
+	dec.UseNumber() // keep integer options integers
+	var m api.ManifestV1
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("--replay %s: %w", path, err)
+	}
+	switch {
+	case m.Schema != api.ManifestSchemaV1:
+		return nil, fmt.Errorf("--replay %s: not an %s manifest", path, api.ManifestSchemaV1)
+	case m.Tool != tool:
+		return nil, fmt.Errorf("--replay %s: written by %s; replay it with %s", path, m.Tool, m.Tool)
+	case len(m.Options) == 0:
+		return nil, fmt.Errorf("--replay %s: no options recorded", path)
+	}
+	return &m, nil
+}
+
+// Enter switches to the working directory of the run m records, so its
+// relative input paths (and the source_file labels taken from them) read
+// as they did; leave switches back.
+func Enter(m *api.ManifestV1) (leave func(), err error) {
+	if m.Cwd == "" {
+		return func() {}, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(m.Cwd); err != nil {
+		return nil, err
+	}
+	return func() { _ = os.Chdir(wd) }, nil
+}
+
+// Args rebuilds the command line of m from its resolved options, so a
+// replay does not depend on the defaults of this build.
+func Args(m *api.ManifestV1) []string {
+	names := make([]string, 0, len(m.Options))
+	for n := range m.Options {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var args []string
+	for _, n := range names {
+		switch v := m.Options[n].(type) {
+		case nil:
+		case []any:
+			for _, x := range v {
+				args = append(args, fmt.Sprintf("--%s=%v", n, x))
+			}
+		default:
+			args = append(args, fmt.Sprintf("--%s=%v", n, v))
+		}
+	}
+	return args
+}
+
+// Check compares a manifest with this build and the inputs on disk, and
+// returns a warning for each difference.
+func Check(m *api.ManifestV1) []string {
+	var warns []string
+	if m.Version != version.Version {
+		warns = append(warns, fmt.Sprintf("replaying a manifest from %s %s with %s", m.Tool, m.Version, version.Version))
+	}
+	for _, in := range m.Inputs {
+		if in.SHA256 == "" {
+			continue
+		}
+		now := api.InputFileV1{Role: in.Role, Path: in.Path}
+		if err := describe(&now); err != nil {
+			warns = append(warns, fmt.Sprintf("input %s: %v", in.Path, err))
+		} else if now.SHA256 != in.SHA256 {
+			warns = append(warns, fmt.Sprintf("input %s changed since the manifest was written", in.Path))
+		}
+	}
+	return warns
+}
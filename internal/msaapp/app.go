@@ -0,0 +1,159 @@
+// internal/msaapp/app.go
+package msaapp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/msa"
+	"ipcr-core/primer"
+	"ipcr/internal/cliutil"
+	"ipcr/internal/cmdutil"
+	"ipcr/internal/conservation"
+	"ipcr/internal/output"
+	"ipcr/internal/writers"
+	"ipcr/pkg/api"
+)
+
+func usage(out io.Writer) {
+	_, _ = fmt.Fprintln(out, "Usage:")
+	_, _ = fmt.Fprintln(out, "  ipcr msa [options] --forward AAA --reverse TTT alignment.aln")
+	_, _ = fmt.Fprintln(out, "  ipcr msa [options] --primers panel.tsv alignment.sto")
+	_, _ = fmt.Fprintln(out, "\nProfile primer-site conservation in a multiple sequence alignment (aligned FASTA,")
+	_, _ = fmt.Fprintln(out, "Clustal or Stockholm; '-' reads standard input). Each primer is located in every")
+	_, _ = fmt.Fprintln(out, "sequence and mapped to alignment columns; per column the Shannon entropy and consensus")
+	_, _ = fmt.Fprintln(out, "are reported, with the degenerate IUPAC primer covering --cover percent of sequences.")
+	_, _ = fmt.Fprintln(out, "\nOptions:")
+	_, _ = fmt.Fprintln(out, "  -f, --forward string        Forward primer (5'→3')")
+	_, _ = fmt.Fprintln(out, "  -r, --reverse string        Reverse primer (5'→3')")
+	_, _ = fmt.Fprintln(out, "  -p, --primers string        TSV primer file")
+	_, _ = fmt.Fprintln(out, "      --format string         Alignment format: auto | fasta | clustal | stockholm [auto]")
+	_, _ = fmt.Fprintln(out, "  -m, --mismatches int        Max mismatches where a primer is located in a sequence [3]")
+	_, _ = fmt.Fprintln(out, "      --cover float           Percent of sequences the degenerate primer must match [95]")
+	_, _ = fmt.Fprintln(out, "  -o, --output string         Output: text | json | jsonl [text]")
+	_, _ = fmt.Fprintln(out, "      --no-header             Suppress header lines [false]")
+	_, _ = fmt.Fprintln(out, "  -q, --quiet                 Suppress warnings [false]")
+}
+
+// RunContext implements `ipcr msa`. argv excludes "msa".
+func RunContext(_ context.Context, argv []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ipcr msa", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var (
+		fwd, rev, primerFile string
+		format               string
+		o                    conservation.Options
+		outFormat            string
+		noHeader, quiet      bool
+	)
+	fs.StringVar(&fwd, "forward", "", "forward primer (5'→3')")
+	fs.StringVar(&fwd, "f", "", "alias of --forward")
+	fs.StringVar(&rev, "reverse", "", "reverse primer (5'→3')")
+	fs.StringVar(&rev, "r", "", "alias of --reverse")
+	fs.StringVar(&primerFile, "primers", "", "TSV primer file")
+	fs.StringVar(&primerFile, "p", "", "alias of --primers")
+	fs.StringVar(&format, "format", msa.FormatAuto, "alignment format")
+	fs.IntVar(&o.MaxMM, "mismatches", 3, "max mismatches where a primer is located")
+	fs.IntVar(&o.MaxMM, "m", 3, "alias of --mismatches")
+	fs.Float64Var(&o.Percent, "cover", 95, "percent of sequences the degenerate primer must match")
+	fs.StringVar(&outFormat, "output", output.FormatText, "output format")
+	fs.StringVar(&outFormat, "o", output.FormatText, "alias of --output")
+	fs.BoolVar(&noHeader, "no-header", false, "suppress header lines")
+	fs.BoolVar(&quiet, "quiet", false, "suppress warnings")
+	fs.BoolVar(&quiet, "q", false, "alias of --quiet")
+
+	flagArgs, posArgs := cliutil.SplitFlagsAndPositionals(fs, argv)
+	if err := fs.Parse(flagArgs); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			usage(stdout)
+			return 0
+		}
+		_, _ = fmt.Fprintln(stderr, err)
+		usage(stderr)
+		return 2
+	}
+	switch {
+	case len(posArgs) != 1:
+		_, _ = fmt.Fprintln(stderr, "ipcr msa profiles one alignment file")
+		usage(stderr)
+		return 2
+	case primerFile == "" && (fwd == "" || rev == ""):
+		_, _ = fmt.Fprintln(stderr, "need --primers or both --forward and --reverse")
+		return 2
+	case primerFile != "" && (fwd != "" || rev != ""):
+		_, _ = fmt.Fprintln(stderr, "--primers cannot be combined with --forward/--reverse")
+		return 2
+	case format != msa.FormatAuto && format != msa.FormatFASTA && format != msa.FormatClustal && format != msa.FormatStockholm:
+		_, _ = fmt.Fprintf(stderr, "invalid --format %q (want auto, fasta, clustal or stockholm)\n", format)
+		return 2
+	case o.MaxMM < 0:
+		_, _ = fmt.Fprintln(stderr, "--mismatches must be ≥ 0")
+		return 2
+	case o.Percent <= 0 || o.Percent > 100:
+		_, _ = fmt.Fprintln(stderr, "--cover must be in (0,100]")
+		return 2
+	case !output.IsTabular(outFormat):
+		_, _ = fmt.Fprintf(stderr, "invalid --output %q (want text, json or jsonl)\n", outFormat)
+		return 2
+	}
+
+	var pairs []primer.Pair
+	if primerFile != "" {
+		var err error
+		if pairs, err = primer.LoadTSV(primerFile); err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+	} else {
+		f, err := primer.Validate(fwd)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "--forward: %v\n", err)
+			return 2
+		}
+		r, err := primer.Validate(rev)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "--reverse: %v\n", err)
+			return 2
+		}
+		pairs = []primer.Pair{{ID: "manual", Forward: f, Reverse: r}}
+	}
+
+	aln, err := msa.Load(posArgs[0], format)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+	rows := conservation.Profile(aln, pairs, o)
+
+	outw := bufio.NewWriter(stdout)
+	switch outFormat {
+	case output.FormatJSON:
+		err = conservation.WriteJSON(outw, rows)
+	case output.FormatJSONL:
+		err = conservation.WriteJSONL(outw, rows)
+	default:
+		err = conservation.WriteTSV(outw, rows, !noHeader)
+	}
+	if err == nil {
+		err = outw.Flush()
+	}
+	if err != nil && !writers.IsBrokenPipe(err) {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 3
+	}
+	for _, r := range rows {
+		for _, pc := range []api.PrimerConservationV1{r.Forward, r.Reverse} {
+			if pc.Located == 0 {
+				cmdutil.Warnf(stderr, quiet, "%s: primer %s not found in any sequence within %d mismatches", r.ExperimentID, pc.Primer, o.MaxMM)
+			}
+		}
+	}
+	return 0
+}
+
+func Run(argv []string, stdout, stderr io.Writer) int {
+	return RunContext(context.Background(), argv, stdout, stderr)
+}
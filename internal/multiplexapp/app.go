@@ -11,13 +11,17 @@ import (
 	"ipcr-core/engine"
 	"ipcr-core/primer"
 	"ipcr/internal/appcore"
-	"ipcr/internal/cli"
 	"ipcr/internal/clibase"
+	"ipcr/internal/cmdutil"
 	"ipcr/internal/common"
+	"ipcr/internal/multiplexcli"
+	"ipcr/internal/multiplexoutput"
+	"ipcr/internal/output"
 	"ipcr/internal/runutil"
 	"ipcr/internal/version"
 	"ipcr/internal/visitors"
 	"ipcr/internal/writers"
+	"os"
 	"strings"
 )
 
@@ -125,12 +129,12 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 	outw := bufio.NewWriter(stdout)
 	defer func() { _ = outw.Flush() }()
 
-	fs := cli.NewFlagSet("ipcr-multiplex")
+	fs := multiplexcli.NewFlagSet("ipcr-multiplex")
 	fs.SetOutput(io.Discard)
 
 	// No args → help
 	if len(argv) == 0 {
-		_, _ = cli.ParseArgs(fs, []string{"-h"})
+		_, _ = multiplexcli.ParseArgs(fs, []string{"-h"})
 		fs.SetOutput(outw)
 		fs.Usage()
 		if err := outw.Flush(); writers.IsBrokenPipe(err) {
@@ -145,11 +149,11 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 	// Collect repeated inline primers before parsing (ParseArgs keeps only the last).
 	fPool, rPool := collectPools(argv)
 
-	opts, err := cli.ParseArgs(fs, argv)
+	opts, err := multiplexcli.ParseArgs(fs, argv)
 	if err != nil {
 		if errors.Is(err, clibase.ErrPrintedAndExitOK) {
 			fs.SetOutput(outw)
-			cli.PrintExamples(outw, "ipcr-multiplex")
+			multiplexcli.PrintExamples(outw)
 			if e := outw.Flush(); writers.IsBrokenPipe(e) {
 				return 0
 			} else if e != nil {
@@ -192,7 +196,7 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 		return 0
 	}
 
-	var pairs []primer.Pair
+	var pairs, panel []primer.Pair // panel: pairs without self pairs, which are not assays to report on
 
 	switch {
 	case opts.PrimerFile != "":
@@ -203,7 +207,9 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 			_, _ = fmt.Fprintln(stderr, e)
 			return 2
 		}
-		if opts.Self {
+		panel = pairs
+		if opts.Self && opts.Partition == 0 && opts.MaxPoolSize == 0 {
+			// Self products amplify whatever the pooling; --partition skips them.
 			pairs = common.AddSelfPairsUnique(pairs)
 		}
 
@@ -230,6 +236,7 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 		switch {
 		case len(fPool) > 0 && len(rPool) > 0:
 			pairs = append(pairs, expandPairsFromPools(fPool, rPool, opts.MinLen, opts.MaxLen)...)
+			panel = pairs
 			if opts.Self {
 				pairs = append(pairs, expandSelfAcrossPools(fPool, rPool)...)
 			}
@@ -254,7 +261,12 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 	termWin := runutil.EffectiveTerminalWindow(opts.TerminalWindow)
 	coreOpts := appcore.Options{
 		SeqFiles:        opts.SeqFiles,
+		Index:           opts.Index,
+		Regions:         opts.Regions,
+		ExcludeRegions:  opts.ExcludeRegions,
+		MaskPolicy:      opts.MaskPolicy,
 		MaxMM:           opts.Mismatches,
+		MaxIndels:       opts.MaxIndels,
 		TerminalWindow:  termWin,
 		MinLen:          opts.MinLen,
 		MaxLen:          opts.MaxLen,
@@ -267,11 +279,79 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 		Quiet:           opts.Quiet,
 		NoMatchExitCode: opts.NoMatchExitCode,
 	}
+	if opts.Partition > 0 || opts.MaxPoolSize > 0 {
+		return runPartition(parent, outw, stderr, opts, coreOpts, pairs)
+	}
+	if opts.Gel {
+		if opts.PrimerFile != "" {
+			// Every oligo of the tube primes with every other: add the
+			// cross-assay pairs the panel rows leave out.
+			scan, _ := crossPairs(panel)
+			pairs = append(scan, pairs[len(panel):]...)
+		}
+		res, _ := multiplexoutput.ParseResolution(opts.Resolution) // validated by multiplexcli
+		wf := appcore.GelWriterFactory{Format: opts.Output, Header: opts.Header, Pretty: opts.Pretty, Gel: multiplexoutput.NewGel(res)}
+		return appcore.Run[engine.Product](parent, outw, stderr, coreOpts, pairs, visitors.PassThrough{}.Visit, wf)
+	}
+	if probes := visitors.NewPanelProbes(pairs, opts.ProbeMaxMM); len(probes.Probes) > 0 && !opts.Summary {
+		switch opts.Output {
+		case output.FormatText, output.FormatJSON, output.FormatJSONL:
+			return runProbed(parent, outw, stderr, opts, coreOpts, pairs, probes)
+		}
+		cmdutil.Warnf(stderr, opts.Quiet, "panel probes are only reported with --output text, json or jsonl")
+	} else if opts.ChannelReport != "" {
+		_, _ = fmt.Fprintln(stderr, "error: --channel-report needs panel rows with probes (and no --summary)")
+		return 2
+	}
+
 	vis := visitors.PassThrough{}
-	wf := appcore.NewProductWriterFactory(opts.Output, opts.Sort, opts.Header, opts.Pretty, opts.Products, false, false)
+	var wf appcore.WriterFactory[engine.Product] = appcore.NewProductWriterFactory(opts.Output, opts.Sort, opts.Header, opts.Pretty, opts.Products, false, false)
+	if opts.Summary {
+		sum, err := appcore.NewSummary(coreOpts, opts.AssemblyMap, panel)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		wf = appcore.NewSummaryWriterFactory(opts.Output, opts.Header, sum, func(p engine.Product) engine.Product { return p })
+	}
 	return appcore.Run[engine.Product](parent, outw, stderr, coreOpts, pairs, vis.Visit, wf)
 }
 
+// runProbed scans with every panel probe overlaid on each amplicon, then
+// reports channels lit by more than one assay on the same template.
+func runProbed(parent context.Context, stdout io.Writer, stderr io.Writer, opts multiplexcli.Options, coreOpts appcore.Options, pairs []primer.Pair, probes visitors.PanelProbes) int {
+	channels := multiplexoutput.NewChannels()
+	wf := appcore.ProbedWriterFactory{
+		Format: opts.Output, Sort: opts.Sort, Header: opts.Header, Pretty: opts.Pretty, Channels: channels,
+	}
+	code := appcore.Run[multiplexoutput.ProbedProduct](parent, stdout, stderr, coreOpts, pairs, probes.Visit, wf)
+	if code != 0 && code != coreOpts.NoMatchExitCode {
+		return code
+	}
+
+	collisions := channels.Collisions()
+	if opts.ChannelReport == "" {
+		if len(collisions) > 0 {
+			cmdutil.Warnf(stderr, opts.Quiet, "%d channel collision(s) across assays; write them with --channel-report FILE", len(collisions))
+		}
+		return code
+	}
+	fh, err := os.Create(opts.ChannelReport)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 3
+	}
+	err = multiplexoutput.WriteCollisions(fh, opts.Output, collisions, opts.Header)
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 3
+	}
+	return code
+}
+
 func Run(argv []string, stdout, stderr io.Writer) int {
 	return RunContext(context.Background(), argv, stdout, stderr)
 }
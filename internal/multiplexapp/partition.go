@@ -0,0 +1,213 @@
+// internal/multiplexapp/partition.go
+package multiplexapp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr-core/multiplex"
+	"ipcr-core/primer"
+	"ipcr-core/thermo"
+	"ipcr/internal/appcore"
+	"ipcr/internal/multiplexcli"
+	"ipcr/internal/multiplexoutput"
+	"ipcr/internal/visitors"
+	"ipcr/pkg/api"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// runPartition splits a panel into reaction pools. The references are
+// scanned with every panel row plus every primer combination across two
+// assays; assays are then placed so that cross-assay products, then primer
+// dimer burden, stay out of shared pools. With --partition-resolve, assays
+// whose own products are not separable on a shared template weigh like a
+// cross product.
+func runPartition(parent context.Context, stdout, stderr io.Writer, opts multiplexcli.Options, coreOpts appcore.Options, pairs []primer.Pair) int {
+	seen := make(map[string]bool, len(pairs))
+	assays := make([]string, len(pairs))
+	for i, p := range pairs {
+		if seen[p.ID] {
+			_, _ = fmt.Fprintf(stderr, "--partition: duplicate panel id %q\n", p.ID)
+			return 2
+		}
+		seen[p.ID] = true
+		assays[i] = p.ID
+	}
+	k := opts.Partition
+	if k == 0 {
+		k = (len(pairs) + opts.MaxPoolSize - 1) / opts.MaxPoolSize
+	}
+	if opts.MaxPoolSize > 0 && k*opts.MaxPoolSize < len(pairs) {
+		_, _ = fmt.Fprintf(stderr, "--partition %d × --max-pool-size %d cannot hold %d assays\n", k, opts.MaxPoolSize, len(pairs))
+		return 2
+	}
+
+	res, _ := multiplexoutput.ParseResolution(opts.Resolution) // validated by multiplexcli
+	scan, origin := crossPairs(pairs)
+	crossings := multiplexoutput.NewCrossings(res, origin)
+	wf := appcore.PartitionWriterFactory{Crossings: crossings}
+	if code := appcore.Run[engine.Product](parent, stdout, stderr, coreOpts, scan, visitors.PassThrough{}.Visit, wf); code != 0 && code != coreOpts.NoMatchExitCode {
+		return code
+	}
+
+	cond := thermo.DefaultConditions()
+	cond.AnnealC = multiplex.DefaultTempC
+	dopt := multiplex.DefaultOptions(cond)
+	m, err := multiplex.Analyze(multiplex.Oligos(pairs), dopt)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+	burden := m.AssayBurden(assays, dopt)
+	products := crossings.Products()
+	var unresolved map[multiplexoutput.AssayPair]bool
+	if opts.PartitionResolve {
+		unresolved = crossings.Unresolved()
+	}
+
+	// One cross product (or size clash) outweighs all dimers together.
+	weight := 1.0
+	for i := range burden {
+		for j := i + 1; j < len(burden); j++ {
+			weight += burden[i][j]
+		}
+	}
+	cost := make([][]float64, len(assays))
+	for i := range cost {
+		cost[i] = make([]float64, len(assays))
+		for j := range cost[i] {
+			if i == j {
+				continue
+			}
+			ap := multiplexoutput.NewAssayPair(assays[i], assays[j])
+			n := products[ap]
+			if unresolved[ap] {
+				n++
+			}
+			cost[i][j] = float64(n)*weight + burden[i][j]
+		}
+	}
+	pool, _ := multiplex.Partition(cost, k, opts.MaxPoolSize)
+
+	prefix := opts.PartitionPrefix
+	if prefix == "" {
+		prefix = strings.TrimSuffix(opts.PrimerFile, filepath.Ext(opts.PrimerFile))
+	}
+	rep := api.PartitionV1{}
+	for pi := 0; pi < k; pi++ {
+		var members []primer.Pair
+		p := api.PartitionPoolV1{Pool: pi + 1, Assays: []string{}}
+		for i, a := range assays {
+			if pool[i] == pi {
+				members = append(members, pairs[i])
+				p.Assays = append(p.Assays, a)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		p.File = fmt.Sprintf("%s.pool%d.tsv", prefix, pi+1)
+		if err := writePool(p.File, members); err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 3
+		}
+		rep.Pools = append(rep.Pools, p)
+	}
+	for i := range assays {
+		for j := i + 1; j < len(assays); j++ {
+			ap := multiplexoutput.NewAssayPair(assays[i], assays[j])
+			in := api.AssayInteractionV1{
+				AssayA: ap.A, AssayB: ap.B,
+				CrossProducts: products[ap], DimerBurden: burden[i][j], Unresolved: unresolved[ap],
+				SamePool: pool[i] == pool[j],
+			}
+			if in.CrossProducts == 0 && in.DimerBurden == 0 && !in.Unresolved {
+				continue
+			}
+			rep.SinglePoolProducts += in.CrossProducts
+			rep.SinglePoolBurden += in.DimerBurden
+			if in.SamePool {
+				rep.SplitProducts += in.CrossProducts
+				rep.SplitBurden += in.DimerBurden
+				p := &rep.Pools[poolIndex(rep.Pools, pool[i]+1)]
+				p.CrossProducts += in.CrossProducts
+				p.DimerBurden += in.DimerBurden
+				if in.Unresolved {
+					p.Unresolved++
+				}
+			}
+			rep.Interactions = append(rep.Interactions, in)
+		}
+	}
+	sort.SliceStable(rep.Interactions, func(a, b int) bool {
+		x, y := rep.Interactions[a], rep.Interactions[b]
+		if x.CrossProducts != y.CrossProducts {
+			return x.CrossProducts > y.CrossProducts
+		}
+		return x.DimerBurden > y.DimerBurden
+	})
+	if err := multiplexoutput.WritePartition(stdout, opts.Output, rep, opts.Header); err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 3
+	}
+	return 0
+}
+
+// crossPairs returns the panel rows followed by one pair per combination of
+// oligos from two different assays, and the assays behind each added pair.
+// Combinations that repeat a row (shared primers) or pair an oligo with
+// itself are skipped: they amplify whatever the pooling.
+func crossPairs(pairs []primer.Pair) ([]primer.Pair, map[string]multiplexoutput.AssayPair) {
+	type oligo struct {
+		name, seq string
+		maxMM     *int
+	}
+	oligos := func(p primer.Pair) []oligo {
+		return []oligo{{p.ID + ".fwd", p.Forward, p.MaxMMFwd}, {p.ID + ".rev", p.Reverse, p.MaxMMRev}}
+	}
+	isRow := func(x, y string, p primer.Pair) bool {
+		return (x == p.Forward && y == p.Reverse) || (x == p.Reverse && y == p.Forward)
+	}
+	scan := append([]primer.Pair(nil), pairs...)
+	origin := make(map[string]multiplexoutput.AssayPair)
+	for i, a := range pairs {
+		for _, b := range pairs[i+1:] {
+			for _, x := range oligos(a) {
+				for _, y := range oligos(b) {
+					if x.seq == y.seq || isRow(x.seq, y.seq, a) || isRow(x.seq, y.seq, b) {
+						continue
+					}
+					id := x.name + "+" + y.name
+					origin[id] = multiplexoutput.NewAssayPair(a.ID, b.ID)
+					scan = append(scan, primer.Pair{ID: id, Forward: x.seq, Reverse: y.seq, MaxMMFwd: x.maxMM, MaxMMRev: y.maxMM})
+				}
+			}
+		}
+	}
+	return scan, origin
+}
+
+func poolIndex(pools []api.PartitionPoolV1, pool int) int {
+	for i, p := range pools {
+		if p.Pool == pool {
+			return i
+		}
+	}
+	return -1
+}
+
+func writePool(path string, pairs []primer.Pair) error {
+	fh, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = primer.WriteTSV(fh, pairs)
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
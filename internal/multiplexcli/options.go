@@ -0,0 +1,154 @@
+package multiplexcli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"ipcr/internal/clibase"
+	"ipcr/internal/cliutil"
+	"ipcr/internal/multiplexoutput"
+	"ipcr/internal/output"
+)
+
+type Options struct {
+	clibase.Common
+
+	// Panel probes (extended --primers TSV "probe" column)
+	ProbeMaxMM    int
+	ChannelReport string
+
+	// Size resolvability report
+	Gel        bool
+	Resolution string
+
+	// Pool partitioning
+	Partition        int
+	MaxPoolSize      int
+	PartitionPrefix  string
+	PartitionResolve bool
+}
+
+func NewFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	clibase.UsageCommon(fs, name, func(out io.Writer, def func(string) string) {
+		_, _ = fmt.Fprintln(out, "Usage:")
+		_, _ = fmt.Fprintf(out, "  %s [options] --forward AAA --forward CCC --reverse TTT ref.fa\n", name)
+		_, _ = fmt.Fprintf(out, "  %s [options] --primers panel.tsv ref*.fa gz/*.fa.gz\n", name)
+
+		_, _ = fmt.Fprintln(out, "\nPanel probes (probe column of an extended --primers TSV):")
+		_, _ = fmt.Fprintf(out, "      --probe-max-mm int      Max mismatches allowed in probe matches [%s]\n", def("probe-max-mm"))
+		_, _ = fmt.Fprintln(out, "      --channel-report FILE   Write channels lit by more than one assay per template")
+
+		_, _ = fmt.Fprintln(out, "\nSize resolvability (gel / capillary readouts):")
+		_, _ = fmt.Fprintf(out, "      --gel                   Report bands per template instead of products; --pretty draws a lane [%s]\n", def("gel"))
+		_, _ = fmt.Fprintf(out, "      --resolution N|N%%       Flag bands closer than N bp or N%% of the longer band [%s]\n", def("resolution"))
+
+		_, _ = fmt.Fprintln(out, "\nPool partitioning (--primers panels):")
+		_, _ = fmt.Fprintf(out, "      --partition K           Split the panel into K pools; writes one primer TSV per pool [%s]\n", def("partition"))
+		_, _ = fmt.Fprintf(out, "      --max-pool-size N       Assays per pool (alone: as many pools as needed) [%s]\n", def("max-pool-size"))
+		_, _ = fmt.Fprintln(out, "      --partition-prefix P    Pool files are P.poolN.tsv [--primers path without extension]")
+		_, _ = fmt.Fprintf(out, "      --partition-resolve     Keep assays with unresolvable product sizes apart (--resolution) [%s]\n", def("partition-resolve"))
+	})
+	return fs
+}
+
+func Parse() (Options, error) { return ParseArgs(NewFlagSet("ipcr-multiplex"), nil) }
+
+// PrintExamples prints a tiny, focused quickstart for ipcr-multiplex.
+func PrintExamples(out io.Writer) {
+	clibase.PrintExamples(out, "ipcr-multiplex", func(w io.Writer) {
+		_, _ = fmt.Fprintln(out, "Multiplex PCR: amplification with primer pools.")
+		_, _ = fmt.Fprintln(out, "Build primer pools from repeated -f/-r flags or a --primers TSV.")
+		_, _ = fmt.Fprintln(out, "Use --self to include A×rc(A) and B×rc(B) self-pairs.")
+		_, _ = fmt.Fprintln(out, "\nExample:")
+		_, _ = fmt.Fprintln(out, "  ipcr-multiplex \\")
+		_, _ = fmt.Fprintln(out, "    --primers multiplex-pcr-assay.tsv \\")
+		_, _ = fmt.Fprintln(out, "    --output jsonl \\")
+		_, _ = fmt.Fprintln(out, "    --products \\")
+		_, _ = fmt.Fprintln(out, "    --sort \\")
+		_, _ = fmt.Fprintln(out, "    Salmonella-Typhimurium.fna.gz")
+	})
+}
+
+func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
+	var o Options
+	var help bool
+	var showExamples bool
+
+	// Shared flags via clibase
+	var c clibase.Common
+	noHeader := clibase.Register(fs, &c)
+
+	// Probe flags
+	fs.IntVar(&o.ProbeMaxMM, "probe-max-mm", 0, "max mismatches allowed for panel probes [0]")
+	fs.StringVar(&o.ChannelReport, "channel-report", "", "write per-template channel collisions to FILE")
+
+	// Gel flags
+	fs.BoolVar(&o.Gel, "gel", false, "report product sizes per template [false]")
+	fs.StringVar(&o.Resolution, "resolution", "5%", "size resolution in bp or % [5%]")
+
+	// Partition flags
+	fs.IntVar(&o.Partition, "partition", 0, "split the panel into K pools [0]")
+	fs.IntVar(&o.MaxPoolSize, "max-pool-size", 0, "assays per pool [0]")
+	fs.StringVar(&o.PartitionPrefix, "partition-prefix", "", "pool file prefix")
+	fs.BoolVar(&o.PartitionResolve, "partition-resolve", false, "keep unresolvable product sizes in separate pools [false]")
+
+	// Help / examples
+	fs.BoolVar(&help, "h", false, "show this help [false]")
+	fs.BoolVar(&showExamples, "examples", false, "show quickstart examples and exit [false]")
+
+	// Split & parse
+	flagArgs, posArgs := cliutil.SplitFlagsAndPositionals(fs, argv)
+	if err := fs.Parse(flagArgs); err != nil {
+		return o, err
+	}
+	if showExamples {
+		return o, clibase.ErrPrintedAndExitOK
+	}
+	if help {
+		return o, flag.ErrHelp
+	}
+	if c.Version {
+		o.Common = c
+		return o, nil
+	}
+
+	// Finalize header, expand pos, shared validation
+	if err := clibase.AfterParse(fs, &c, noHeader, posArgs); err != nil {
+		return o, err
+	}
+	if o.ProbeMaxMM < 0 {
+		return o, fmt.Errorf("--probe-max-mm must be >= 0")
+	}
+	if o.Gel && (c.Summary || o.ChannelReport != "") {
+		return o, fmt.Errorf("--gel cannot be combined with --summary or --channel-report")
+	}
+	if o.Gel && !output.IsTabular(c.Output) {
+		return o, fmt.Errorf("--gel reports as text, json or jsonl, not %q", c.Output)
+	}
+	if _, err := multiplexoutput.ParseResolution(o.Resolution); err != nil {
+		return o, fmt.Errorf("--resolution: %w", err)
+	}
+	if o.ChannelReport != "" && c.PrimerFile == "" {
+		return o, fmt.Errorf("--channel-report needs --primers with a probe column")
+	}
+	if o.Partition < 0 || o.MaxPoolSize < 0 {
+		return o, fmt.Errorf("--partition and --max-pool-size must be >= 0")
+	}
+	if o.Partition > 0 || o.MaxPoolSize > 0 {
+		switch {
+		case c.PrimerFile == "":
+			return o, fmt.Errorf("--partition needs a --primers panel")
+		case o.Gel || c.Summary || o.ChannelReport != "":
+			return o, fmt.Errorf("--partition cannot be combined with --gel, --summary or --channel-report")
+		case c.Output != output.FormatText && c.Output != output.FormatJSON:
+			return o, fmt.Errorf("--partition reports as text or json, not %q", c.Output)
+		}
+	} else if o.PartitionPrefix != "" || o.PartitionResolve {
+		return o, fmt.Errorf("--partition-prefix and --partition-resolve need --partition or --max-pool-size")
+	}
+
+	// Embed shared options
+	o.Common = c
+	return o, nil
+}
@@ -0,0 +1,89 @@
+package multiplexintegration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/internal/multiplexapp"
+	"ipcr/pkg/api"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGelReportFlagsUnresolvedPoolProducts(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(13)
+	rc := func(s string) string { return string(primer.RevComp([]byte(s))) }
+	f1, r1, f2, r2 := randSeq(20), randSeq(20), randSeq(20), randSeq(20)
+	// F1+R1 is 200 bp, F2+R2 205 bp and the cross product F1+R2 705 bp.
+	chr := randSeq(100) + f1 + randSeq(160) + rc(r1) + randSeq(300) + f2 + randSeq(165) + rc(r2) + randSeq(100)
+	fa := filepath.Join(dir, "ref.fa")
+	if err := os.WriteFile(fa, []byte(">chr1\n"+chr+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(extra ...string) (string, int, string) {
+		var out, errB bytes.Buffer
+		args := append([]string{"-f", f1, "-f", f2, "-r", r1, "-r", r2, "--self=false", "--gel"}, extra...)
+		code := multiplexapp.Run(append(args, fa), &out, &errB)
+		return out.String(), code, errB.String()
+	}
+
+	out, code, errS := run("--output", "json")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, errS)
+	}
+	var lanes []api.GelLaneV1
+	if err := json.Unmarshal([]byte(out), &lanes); err != nil {
+		t.Fatal(err)
+	}
+	if len(lanes) != 1 || lanes[0].Resolved || len(lanes[0].Bands) != 3 {
+		t.Fatalf("lanes: %+v", lanes)
+	}
+	got := map[string]string{}
+	for _, b := range lanes[0].Bands {
+		got[b.ExperimentID] = strings.Join(b.Unresolved, ",")
+	}
+	want := map[string]string{"F1+R2": "", "F2+R2": "F1+R1:200", "F1+R1": "F2+R2:205"}
+	for id, w := range want {
+		if g, ok := got[id]; !ok || g != w {
+			t.Fatalf("band %s: got %q want %q (all %v)", id, g, w, got)
+		}
+	}
+
+	// 2 bp resolution (capillary) separates every band.
+	if out, code, errS = run("--output", "json", "--resolution", "2"); code != 0 || !strings.Contains(out, `"resolved": true`) {
+		t.Fatalf("--resolution 2: exit %d %s\n%s", code, errS, out)
+	}
+	if out, code, _ = run("--pretty"); code != 0 || !strings.Contains(out, "+--------+") {
+		t.Fatalf("--pretty should draw a gel lane:\n%s", out)
+	}
+
+	// A TSV panel draws the cross-assay bands too.
+	panel := filepath.Join(dir, "panel.tsv")
+	if err := os.WriteFile(panel, []byte("A\t"+f1+"\t"+r1+"\nB\t"+f2+"\t"+r2+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var tsvOut, errB bytes.Buffer
+	if code := multiplexapp.Run([]string{"--primers", panel, "--self=false", "--gel", "--output", "json", fa}, &tsvOut, &errB); code != 0 {
+		t.Fatalf("--primers: exit %d: %s", code, errB.String())
+	}
+	lanes = nil
+	if err := json.Unmarshal(tsvOut.Bytes(), &lanes); err != nil {
+		t.Fatal(err)
+	}
+	if len(lanes) != 1 || len(lanes[0].Bands) != 3 || lanes[0].Bands[0].ExperimentID != "A.fwd+B.rev" || lanes[0].Bands[0].Length != 705 {
+		t.Fatalf("--primers lanes: %+v", lanes)
+	}
+	if _, code, _ = run("--resolution", "abc"); code != 2 {
+		t.Fatalf("bad --resolution should exit 2, got %d", code)
+	}
+	for _, format := range []string{"fasta", "bed", "bed12", "gff3", "sam"} {
+		if _, code, _ = run("--output", format); code != 2 {
+			t.Fatalf("--gel --output %s should exit 2, got %d", format, code)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package multiplexintegration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/internal/multiplexapp"
+	"ipcr/pkg/api"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPartitionSeparatesCrossAmplifyingAssays(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(14)
+	rc := func(s string) string { return string(primer.RevComp([]byte(s))) }
+	type assay struct{ id, fwd, rev string }
+	var panel []assay
+	for _, id := range []string{"A", "B", "C", "D"} {
+		panel = append(panel, assay{id, randSeq(20), randSeq(20)})
+	}
+	amp := func(a assay, n int) string { return a.fwd + randSeq(n-40) + rc(a.rev) }
+	// A.fwd also primes 100 bp upstream of B (a 700 bp A.fwd+B.rev product);
+	// C and D give 200 and 205 bp products on the same template.
+	chr := randSeq(200) + amp(panel[0], 400) + randSeq(1000) +
+		panel[0].fwd + randSeq(80) + amp(panel[1], 600) + randSeq(1000) +
+		amp(panel[2], 200) + randSeq(1000) + amp(panel[3], 205) + randSeq(200)
+	fa := filepath.Join(dir, "ref.fa")
+	if err := os.WriteFile(fa, []byte(">chr1\n"+chr+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tsv := filepath.Join(dir, "panel.tsv")
+	var rows strings.Builder
+	rows.WriteString("id\tfwd\trev\tnotes\n")
+	for _, a := range panel {
+		rows.WriteString(a.id + "\t" + a.fwd + "\t" + a.rev + "\tassay " + a.id + "\n")
+	}
+	if err := os.WriteFile(tsv, []byte(rows.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(extra ...string) api.PartitionV1 {
+		t.Helper()
+		var out, errB bytes.Buffer
+		args := append([]string{"--primers", tsv, "--output", "json", "--partition", "2", "--max-length", "800"}, extra...)
+		if code := multiplexapp.Run(append(args, fa), &out, &errB); code != 0 {
+			t.Fatalf("%v: exit %d: %s", extra, code, errB.String())
+		}
+		var rep api.PartitionV1
+		if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+			t.Fatalf("bad JSON: %v\n%s", err, out.String())
+		}
+		return rep
+	}
+	poolOf := func(rep api.PartitionV1) map[string]int {
+		m := map[string]int{}
+		for _, p := range rep.Pools {
+			for _, a := range p.Assays {
+				m[a] = p.Pool
+			}
+		}
+		return m
+	}
+
+	rep := run()
+	if len(rep.Pools) != 2 || rep.SinglePoolProducts < 1 || rep.SplitProducts != 0 {
+		t.Fatalf("report: %+v", rep)
+	}
+	if in := rep.Interactions[0]; in.AssayA != "A" || in.AssayB != "B" || in.CrossProducts < 1 || in.SamePool {
+		t.Fatalf("first interaction should be the A/B cross product: %+v", rep.Interactions)
+	}
+	if p := poolOf(rep); p["A"] == p["B"] {
+		t.Fatalf("A and B share a pool: %+v", rep.Pools)
+	}
+	for _, in := range rep.Interactions {
+		if in.Unresolved {
+			t.Fatalf("sizes are only checked with --partition-resolve: %+v", in)
+		}
+	}
+
+	prefix := filepath.Join(dir, "split")
+	rep = run("--partition-resolve", "--partition-prefix", prefix)
+	if p := poolOf(rep); p["A"] == p["B"] || p["C"] == p["D"] {
+		t.Fatalf("want A/B and C/D apart: %+v", rep.Pools)
+	}
+	for _, p := range rep.Pools {
+		if p.File != prefix+".pool"+string(rune('0'+p.Pool))+".tsv" {
+			t.Fatalf("pool file %q", p.File)
+		}
+		loaded, err := primer.LoadTSV(p.File)
+		if err != nil || len(loaded) != len(p.Assays) || loaded[0].ID != p.Assays[0] || loaded[0].Notes != "assay "+p.Assays[0] {
+			t.Fatalf("pool %d file: %+v %v", p.Pool, loaded, err)
+		}
+	}
+
+	var out, errB bytes.Buffer
+	if code := multiplexapp.Run([]string{"--primers", tsv, "--max-pool-size", "1", "--max-length", "800", fa}, &out, &errB); code != 0 {
+		t.Fatalf("--max-pool-size: exit %d: %s", code, errB.String())
+	}
+	if !strings.HasPrefix(out.String(), "# 4 pools:") {
+		t.Fatalf("text report:\n%s", out.String())
+	}
+	for _, bad := range [][]string{
+		{"-f", panel[0].fwd, "-r", panel[0].rev, "--partition", "2"},
+		{"--primers", tsv, "--partition", "2", "--gel"},
+		{"--primers", tsv, "--partition", "2", "--output", "bed"},
+		{"--primers", tsv, "--partition-resolve"},
+	} {
+		if code := multiplexapp.Run(append(bad, fa), &out, &errB); code != 2 {
+			t.Fatalf("%v: want exit 2, got %d", bad, code)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package multiplexintegration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/internal/multiplexapp"
+	"ipcr/pkg/api"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPanelProbesChannelsAndCrossReactivity(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(12)
+	rc := func(s string) string { return string(primer.RevComp([]byte(s))) }
+	fA, rA, fB, rB := randSeq(20), randSeq(20), randSeq(20), randSeq(20)
+	pA, pB, pX := randSeq(22), randSeq(22), randSeq(22)
+	// Assay B's HEX probe pX also binds inside amplicon A.
+	ampA := fA + randSeq(30) + pA + randSeq(10) + pX + randSeq(30) + rc(rA)
+	ampB := fB + randSeq(40) + rc(pB) + randSeq(40) + rc(rB)
+	fa := filepath.Join(dir, "ref.fa")
+	if err := os.WriteFile(fa, []byte(">chr1\n"+randSeq(100)+ampA+randSeq(200)+ampB+randSeq(100)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	panel := filepath.Join(dir, "panel.tsv")
+	if err := os.WriteFile(panel, []byte("id\tfwd\trev\tprobes\n"+
+		"A\t"+fA+"\t"+rA+"\ttaqA="+pA+"@FAM\n"+
+		"B\t"+fB+"\t"+rB+"\ttaqB="+pB+"@FAM;hexB="+pX+"@HEX\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(dir, "channels.tsv")
+
+	var out, errB bytes.Buffer
+	code := multiplexapp.Run([]string{
+		"--primers", panel, "--self=false", "--output", "jsonl", "--sort",
+		"--channel-report", report, fa,
+	}, &out, &errB)
+	if code != 0 {
+		t.Fatalf("exit %d err=%s", code, errB.String())
+	}
+
+	hits := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var p api.ProbedProductV1
+		if err := json.Unmarshal([]byte(line), &p); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, h := range p.Probes {
+			n := h.Name + "@" + h.Channel
+			if h.Cross {
+				n += "!"
+			}
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		hits[p.ExperimentID] = strings.Join(names, ",")
+	}
+	want := map[string]string{"A": "hexB@HEX!,taqA@FAM", "B": "taqB@FAM"}
+	if len(hits) != 2 || hits["A"] != want["A"] || hits["B"] != want["B"] {
+		t.Fatalf("probe hits: got %v want %v", hits, want)
+	}
+
+	rep, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var col api.ChannelCollisionV1 // the report follows --output
+	if err := json.Unmarshal(rep, &col); err != nil {
+		t.Fatalf("channel report %q: %v", rep, err)
+	}
+	if col.SequenceID != "chr1" || col.Channel != "FAM" ||
+		strings.Join(col.Assays, ",") != "A,B" || strings.Join(col.Probes, ",") != "taqA,taqB" {
+		t.Fatalf("channel report: %+v", col)
+	}
+
+	// Without a report file the collision is still flagged.
+	out.Reset()
+	errB.Reset()
+	if code := multiplexapp.Run([]string{"--primers", panel, fa}, &out, &errB); code != 0 {
+		t.Fatalf("exit %d err=%s", code, errB.String())
+	}
+	if !strings.Contains(errB.String(), "1 channel collision") {
+		t.Fatalf("expected collision warning, got %q", errB.String())
+	}
+	if !strings.HasPrefix(out.String(), "source_file\t") || !strings.Contains(out.String(), "\ttaqA:+:") {
+		t.Fatalf("text output:\n%s", out.String())
+	}
+}
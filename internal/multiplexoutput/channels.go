@@ -0,0 +1,131 @@
+// internal/multiplexoutput/channels.go
+package multiplexoutput
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"ipcr/internal/jsonutil"
+	"ipcr/internal/output"
+	"ipcr/pkg/api"
+	"sort"
+	"strings"
+)
+
+// Collision is one detection channel lit by products of more than one assay
+// on the same template.
+type Collision struct {
+	SourceFile string
+	SequenceID string
+	Channel    string
+	Assays     []string // sorted
+	Probes     []string // sorted names of the probes that bound
+}
+
+// CollisionTSVHeader is the header row of the text channel report.
+const CollisionTSVHeader = "source_file\tsequence_id\tchannel\tassays\tprobes"
+
+type channelKey struct{ file, seq, channel string }
+
+// Channels accumulates which assays light each channel per template. It is
+// not safe for concurrent use; feed it from the writer goroutine.
+type Channels struct {
+	lit map[channelKey]*channelHits
+}
+
+type channelHits struct {
+	assays map[string]bool
+	probes map[string]bool
+}
+
+func NewChannels() *Channels { return &Channels{lit: make(map[channelKey]*channelHits)} }
+
+// Add records the channels lit by pp. Probes without a channel are ignored.
+func (c *Channels) Add(pp ProbedProduct) {
+	for _, h := range pp.Probes {
+		if h.Channel == "" {
+			continue
+		}
+		k := channelKey{pp.Product.SourceFile, pp.Product.SequenceID, h.Channel}
+		ch := c.lit[k]
+		if ch == nil {
+			ch = &channelHits{assays: make(map[string]bool), probes: make(map[string]bool)}
+			c.lit[k] = ch
+		}
+		ch.assays[pp.Assay] = true
+		ch.probes[h.Name] = true
+	}
+}
+
+// Collisions lists the channels lit by two or more assays, ordered by
+// source file, sequence and channel.
+func (c *Channels) Collisions() []Collision {
+	var out []Collision
+	for k, ch := range c.lit {
+		if len(ch.assays) < 2 {
+			continue
+		}
+		out = append(out, Collision{
+			SourceFile: k.file, SequenceID: k.seq, Channel: k.channel,
+			Assays: sortedKeys(ch.assays), Probes: sortedKeys(ch.probes),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		switch {
+		case a.SourceFile != b.SourceFile:
+			return a.SourceFile < b.SourceFile
+		case a.SequenceID != b.SequenceID:
+			return a.SequenceID < b.SequenceID
+		default:
+			return a.Channel < b.Channel
+		}
+	})
+	return out
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// WriteCollisions writes the channel report in the given output format
+// (json, jsonl, otherwise TSV).
+func WriteCollisions(w io.Writer, format string, list []Collision, header bool) error {
+	switch format {
+	case output.FormatJSON, output.FormatJSONL:
+		out := make([]api.ChannelCollisionV1, len(list))
+		for i, c := range list {
+			out[i] = api.ChannelCollisionV1{
+				SourceFile: c.SourceFile, SequenceID: c.SequenceID, Channel: c.Channel,
+				Assays: c.Assays, Probes: c.Probes,
+			}
+		}
+		if format == output.FormatJSON {
+			return jsonutil.EncodePretty(w, out)
+		}
+		enc := json.NewEncoder(w)
+		for _, c := range out {
+			if err := enc.Encode(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if header {
+		if _, err := io.WriteString(w, CollisionTSVHeader+"\n"); err != nil {
+			return err
+		}
+	}
+	for _, c := range list {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.SourceFile, c.SequenceID, c.Channel,
+			strings.Join(c.Assays, ","), strings.Join(c.Probes, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// internal/multiplexoutput/gel.go
+package multiplexoutput
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr/internal/jsonutil"
+	"ipcr/internal/output"
+	"ipcr/internal/pretty"
+	"ipcr/pkg/api"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Resolution is the smallest size difference a readout separates: an
+// absolute bp difference or a percentage of the longer band.
+type Resolution struct {
+	BP  int
+	Pct float64
+}
+
+// ParseResolution reads "N" (bp) or "N%" (of the longer band).
+func ParseResolution(s string) (Resolution, error) {
+	s = strings.TrimSpace(s)
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		v, err := strconv.ParseFloat(pct, 64)
+		if err != nil || v <= 0 || v >= 100 {
+			return Resolution{}, fmt.Errorf("bad resolution %q (want N or N%% with 0<N<100)", s)
+		}
+		return Resolution{Pct: v}, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v <= 0 {
+		return Resolution{}, fmt.Errorf("bad resolution %q (want N or N%% with N>0)", s)
+	}
+	return Resolution{BP: v}, nil
+}
+
+// Separates reports whether bands of lengths a and b are told apart.
+func (r Resolution) Separates(a, b int) bool {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	if r.Pct > 0 {
+		return float64(d) >= r.Pct/100*float64(max(a, b))
+	}
+	return d >= r.BP
+}
+
+// Band is one product in a lane with the bands it is not resolved from.
+type Band struct {
+	Product    engine.Product
+	Unresolved []string // "experiment_id:length"
+}
+
+// Lane holds every product predicted on one template, longest first.
+type Lane struct {
+	SourceFile string
+	SequenceID string
+	Bands      []Band
+}
+
+// Resolved reports whether every band of the lane separates from the rest.
+func (l Lane) Resolved() bool {
+	for _, b := range l.Bands {
+		if len(b.Unresolved) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type laneKey struct{ file, seq string }
+
+// Gel groups products into per-template lanes. It is not safe for
+// concurrent use; feed it from the writer goroutine.
+type Gel struct {
+	Res   Resolution
+	lanes map[laneKey][]engine.Product
+}
+
+func NewGel(res Resolution) *Gel { return &Gel{Res: res, lanes: make(map[laneKey][]engine.Product)} }
+
+func (g *Gel) Add(p engine.Product) {
+	k := laneKey{p.SourceFile, p.SequenceID}
+	p.Seq = "" // only sizes are reported
+	g.lanes[k] = append(g.lanes[k], p)
+}
+
+// Lanes returns the lanes ordered by source file and sequence ID, with
+// unresolved band pairs filled in.
+func (g *Gel) Lanes() []Lane {
+	out := make([]Lane, 0, len(g.lanes))
+	for k, ps := range g.lanes {
+		sort.SliceStable(ps, func(i, j int) bool {
+			a, b := ps[i], ps[j]
+			switch {
+			case a.Length != b.Length:
+				return a.Length > b.Length
+			case a.ExperimentID != b.ExperimentID:
+				return a.ExperimentID < b.ExperimentID
+			default:
+				return a.Start < b.Start
+			}
+		})
+		lane := Lane{SourceFile: k.file, SequenceID: k.seq, Bands: make([]Band, len(ps))}
+		for i := range ps {
+			lane.Bands[i].Product = ps[i]
+		}
+		// Lengths are sorted, so unresolved partners are contiguous.
+		for i := range ps {
+			for j := i + 1; j < len(ps) && !g.Res.Separates(ps[i].Length, ps[j].Length); j++ {
+				lane.Bands[i].Unresolved = append(lane.Bands[i].Unresolved, bandName(ps[j]))
+				lane.Bands[j].Unresolved = append(lane.Bands[j].Unresolved, bandName(ps[i]))
+			}
+		}
+		out = append(out, lane)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SourceFile != out[j].SourceFile {
+			return out[i].SourceFile < out[j].SourceFile
+		}
+		return out[i].SequenceID < out[j].SequenceID
+	})
+	return out
+}
+
+func bandName(p engine.Product) string { return p.ExperimentID + ":" + strconv.Itoa(p.Length) }
+
+// GelTSVHeader is the header row of the text gel report.
+const GelTSVHeader = "source_file\tsequence_id\texperiment_id\tstart\tend\tlength\ttype\tunresolved_with"
+
+// WriteGel writes the lanes in the given output format (json, jsonl,
+// otherwise TSV; the CLI rejects the other formats). Text output appends an ASCII lane per template when
+// prettyMode is set.
+func WriteGel(w io.Writer, format string, lanes []Lane, header, prettyMode bool) error {
+	switch format {
+	case output.FormatJSON:
+		out := make([]api.GelLaneV1, len(lanes))
+		for i, l := range lanes {
+			out[i] = laneToAPI(l)
+		}
+		return jsonutil.EncodePretty(w, out)
+	case output.FormatJSONL:
+		enc := json.NewEncoder(w)
+		for _, l := range lanes {
+			if err := enc.Encode(laneToAPI(l)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if header {
+		if _, err := io.WriteString(w, GelTSVHeader+"\n"); err != nil {
+			return err
+		}
+	}
+	for _, l := range lanes {
+		for _, b := range l.Bands {
+			p := b.Product
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\n",
+				l.SourceFile, l.SequenceID, p.ExperimentID, p.Start, p.End, p.Length, p.Type, list(b.Unresolved)); err != nil {
+				return err
+			}
+		}
+		if prettyMode {
+			if _, err := io.WriteString(w, RenderLane(l)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RenderLane draws l as an ASCII gel lane; unresolved bands are marked '!'.
+func RenderLane(l Lane) string {
+	bands := make([]pretty.GelBand, len(l.Bands))
+	for i, b := range l.Bands {
+		bands[i] = pretty.GelBand{Length: b.Product.Length, Label: b.Product.ExperimentID, Flag: len(b.Unresolved) > 0}
+	}
+	title := l.SequenceID
+	if l.SourceFile != "" {
+		title = l.SourceFile + " " + title
+	}
+	return pretty.RenderGelLane(title, bands)
+}
+
+func laneToAPI(l Lane) api.GelLaneV1 {
+	out := api.GelLaneV1{SourceFile: l.SourceFile, SequenceID: l.SequenceID, Resolved: l.Resolved()}
+	out.Bands = make([]api.GelBandV1, len(l.Bands))
+	for i, b := range l.Bands {
+		p := b.Product
+		out.Bands[i] = api.GelBandV1{
+			ExperimentID: p.ExperimentID, Start: p.Start, End: p.End, Length: p.Length, Type: p.Type,
+			Unresolved: b.Unresolved,
+		}
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+// internal/multiplexoutput/json.go
+package multiplexoutput
+
+import (
+	"encoding/json"
+	"io"
+	"ipcr/internal/jsonutil"
+	"ipcr/internal/output"
+	"ipcr/pkg/api"
+)
+
+// ToAPI converts a probed product to its wire form.
+func ToAPI(pp ProbedProduct) api.ProbedProductV1 {
+	hits := make([]api.ProbeHitV1, len(pp.Probes))
+	for i, h := range pp.Probes {
+		hits[i] = api.ProbeHitV1{
+			Name: h.Name, Assay: h.Assay, Channel: h.Channel, Seq: h.Seq,
+			Strand: h.Strand, Pos: h.Pos, MM: h.MM, Site: h.Site, Cross: h.Cross,
+		}
+	}
+	return api.ProbedProductV1{ProductV1: output.ToAPIProduct(pp.Product), Probes: hits}
+}
+
+// WriteJSON writes probed products as one JSON array.
+func WriteJSON(w io.Writer, list []ProbedProduct) error {
+	out := make([]api.ProbedProductV1, len(list))
+	for i, pp := range list {
+		out[i] = ToAPI(pp)
+	}
+	return jsonutil.EncodePretty(w, out)
+}
+
+// WriteJSONL writes one JSON object per line.
+func WriteJSONL(w io.Writer, list []ProbedProduct) error {
+	enc := json.NewEncoder(w)
+	for _, pp := range list {
+		if err := enc.Encode(ToAPI(pp)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamJSONL writes one JSON object per product as it arrives.
+func StreamJSONL(w io.Writer, in <-chan ProbedProduct) error {
+	enc := json.NewEncoder(w)
+	for pp := range in {
+		if err := enc.Encode(ToAPI(pp)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
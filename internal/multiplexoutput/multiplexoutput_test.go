@@ -0,0 +1,124 @@
+package multiplexoutput
+
+import (
+	"bytes"
+	"ipcr-core/engine"
+	"strings"
+	"testing"
+)
+
+func TestFormatRowTSVProbeColumns(t *testing.T) {
+	pp := ProbedProduct{
+		Product: engine.Product{ExperimentID: "A", SequenceID: "s", Start: 1, End: 50, Length: 49, Type: "forward"},
+		Assay:   "A",
+		Probes: []ProbeHit{
+			{Name: "taqA", Assay: "A", Channel: "FAM", Strand: "+", Pos: 10},
+			{Name: "hexB", Assay: "B", Channel: "HEX", Strand: "-", Pos: 20, MM: 1, Cross: true},
+		},
+	}
+	cols := strings.Split(FormatRowTSV(pp), "\t")
+	if got := strings.Join(cols[len(cols)-3:], "|"); got != "taqA:+:10:0,hexB:-:20:1|FAM,HEX|hexB" {
+		t.Fatalf("probe columns: %q", got)
+	}
+	if n := len(strings.Split(TSVHeader, "\t")); n != len(cols) {
+		t.Fatalf("header has %d columns, row %d", n, len(cols))
+	}
+	pp.Probes = nil
+	if !strings.HasSuffix(FormatRowTSV(pp), "\t-\t-\t-") {
+		t.Fatalf("empty overlay: %q", FormatRowTSV(pp))
+	}
+}
+
+func TestChannelsCollisions(t *testing.T) {
+	c := NewChannels()
+	add := func(seq, assay string, hits ...ProbeHit) {
+		c.Add(ProbedProduct{Product: engine.Product{SequenceID: seq}, Assay: assay, Probes: hits})
+	}
+	add("s2", "A", ProbeHit{Name: "taqA", Channel: "FAM"})
+	add("s2", "B", ProbeHit{Name: "taqB", Channel: "FAM"}, ProbeHit{Name: "plain"})
+	add("s1", "A", ProbeHit{Name: "taqA", Channel: "FAM"}, ProbeHit{Name: "hexB", Channel: "HEX"})
+	add("s1", "A", ProbeHit{Name: "taqA", Channel: "FAM"})
+	add("s1", "B", ProbeHit{Name: "hexB", Channel: "HEX"})
+
+	var buf bytes.Buffer
+	if err := WriteCollisions(&buf, "text", c.Collisions(), true); err != nil {
+		t.Fatal(err)
+	}
+	want := CollisionTSVHeader + "\n" +
+		"\ts1\tHEX\tA,B\thexB\n" +
+		"\ts2\tFAM\tA,B\ttaqA,taqB\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestParseResolution(t *testing.T) {
+	for in, want := range map[string]Resolution{"10": {BP: 10}, "5%": {Pct: 5}, " 2.5% ": {Pct: 2.5}} {
+		got, err := ParseResolution(in)
+		if err != nil || got != want {
+			t.Errorf("ParseResolution(%q) = %+v, %v", in, got, err)
+		}
+	}
+	for _, bad := range []string{"", "0", "-3", "100%", "x%", "5bp"} {
+		if _, err := ParseResolution(bad); err == nil {
+			t.Errorf("ParseResolution(%q) should fail", bad)
+		}
+	}
+	if r := (Resolution{Pct: 10}); r.Separates(300, 280) || !r.Separates(300, 270) {
+		t.Fatal("10% of the longer band")
+	}
+	if r := (Resolution{BP: 5}); r.Separates(100, 96) || !r.Separates(100, 95) {
+		t.Fatal("5 bp")
+	}
+}
+
+func TestGelLanesFlagUnresolvedBands(t *testing.T) {
+	g := NewGel(Resolution{BP: 10})
+	for _, p := range []engine.Product{
+		{SequenceID: "s1", ExperimentID: "A", Length: 300},
+		{SequenceID: "s1", ExperimentID: "B", Length: 150},
+		{SequenceID: "s1", ExperimentID: "C", Length: 295},
+		{SequenceID: "s0", ExperimentID: "A", Length: 300},
+	} {
+		g.Add(p)
+	}
+	lanes := g.Lanes()
+	if len(lanes) != 2 || lanes[0].SequenceID != "s0" || !lanes[0].Resolved() || lanes[1].Resolved() {
+		t.Fatalf("lanes: %+v", lanes)
+	}
+	var buf bytes.Buffer
+	if err := WriteGel(&buf, "text", lanes[1:], false, true); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"\ts1\tA\t0\t0\t300\t\tC:295\n",
+		"\ts1\tC\t0\t0\t295\t\tA:300\n",
+		"\ts1\tB\t0\t0\t150\t\t-\n",
+		"300/295 |!!!!!!!!| A, C\n",
+		"    150 |========| B\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestCrossingsSortsCrossAndOwnProducts(t *testing.T) {
+	c := NewCrossings(Resolution{BP: 10}, map[string]AssayPair{"a.fwd+b.rev": NewAssayPair("b", "a")})
+	for _, p := range []engine.Product{
+		{ExperimentID: "a.fwd+b.rev", SequenceID: "s1", Length: 700},
+		{ExperimentID: "a.fwd+b.rev", SequenceID: "s2", Length: 690},
+		{ExperimentID: "a", SequenceID: "s1", Length: 200},
+		{ExperimentID: "b", SequenceID: "s1", Length: 205},
+		{ExperimentID: "c", SequenceID: "s2", Length: 204},
+	} {
+		c.Add(p)
+	}
+	if got := c.Products(); len(got) != 1 || got[AssayPair{"a", "b"}] != 2 {
+		t.Fatalf("products %v", got)
+	}
+	if got := c.Unresolved(); len(got) != 1 || !got[AssayPair{"a", "b"}] {
+		t.Fatalf("unresolved %v (c is on another template)", got)
+	}
+}
@@ -0,0 +1,97 @@
+// internal/multiplexoutput/partition.go
+package multiplexoutput
+
+import (
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr/internal/jsonutil"
+	"ipcr/internal/output"
+	"ipcr/pkg/api"
+	"strings"
+)
+
+// AssayPair names two assays, A < B.
+type AssayPair struct{ A, B string }
+
+// NewAssayPair orders a and b.
+func NewAssayPair(a, b string) AssayPair {
+	if b < a {
+		a, b = b, a
+	}
+	return AssayPair{a, b}
+}
+
+// Crossings sorts the products of a partition scan: products primed by
+// oligos of two assays are counted per assay pair, the rest (a panel row's
+// own products) go to a Gel for the size check. It is not safe for
+// concurrent use; feed it from the writer goroutine.
+type Crossings struct {
+	origin map[string]AssayPair // cross-pair experiment ID → assays
+	counts map[AssayPair]int
+	gel    *Gel
+}
+
+func NewCrossings(res Resolution, origin map[string]AssayPair) *Crossings {
+	return &Crossings{origin: origin, counts: make(map[AssayPair]int), gel: NewGel(res)}
+}
+
+func (c *Crossings) Add(p engine.Product) {
+	if ap, ok := c.origin[p.ExperimentID]; ok {
+		c.counts[ap]++
+		return
+	}
+	c.gel.Add(p)
+}
+
+// Products returns the number of cross-assay products per assay pair.
+func (c *Crossings) Products() map[AssayPair]int { return c.counts }
+
+// Unresolved returns the assay pairs whose own products fall closer than the
+// resolution on at least one template.
+func (c *Crossings) Unresolved() map[AssayPair]bool {
+	out := make(map[AssayPair]bool)
+	for _, l := range c.gel.Lanes() {
+		for _, b := range l.Bands {
+			for _, other := range b.Unresolved {
+				id := other[:strings.LastIndexByte(other, ':')]
+				if id != b.Product.ExperimentID {
+					out[NewAssayPair(b.Product.ExperimentID, id)] = true
+				}
+			}
+		}
+	}
+	return out
+}
+
+// PoolTSVHeader and InteractionTSVHeader head the two blocks of the text
+// partition report.
+const (
+	PoolTSVHeader        = "pool\tfile\tassays\tcross_products\tdimer_burden\tunresolved"
+	InteractionTSVHeader = "assay_a\tassay_b\tcross_products\tdimer_burden\tunresolved\tsame_pool"
+)
+
+// WritePartition writes the partition report as JSON or as two TSV blocks
+// (pools, then interacting assay pairs) separated by a blank line.
+func WritePartition(w io.Writer, format string, rep api.PartitionV1, header bool) error {
+	if format == output.FormatJSON {
+		return jsonutil.EncodePretty(w, rep)
+	}
+	var b strings.Builder
+	if header {
+		fmt.Fprintf(&b, "# %d pools: cross products %d -> %d, dimer burden %.2f -> %.2f\n%s\n",
+			len(rep.Pools), rep.SinglePoolProducts, rep.SplitProducts, rep.SinglePoolBurden, rep.SplitBurden, PoolTSVHeader)
+	}
+	for _, p := range rep.Pools {
+		fmt.Fprintf(&b, "%d\t%s\t%s\t%d\t%.2f\t%d\n", p.Pool, p.File, strings.Join(p.Assays, ","), p.CrossProducts, p.DimerBurden, p.Unresolved)
+	}
+	b.WriteByte('\n')
+	if header {
+		b.WriteString(InteractionTSVHeader + "\n")
+	}
+	for _, in := range rep.Interactions {
+		fmt.Fprintf(&b, "%s\t%s\t%d\t%.2f\t%t\t%t\n", in.AssayA, in.AssayB, in.CrossProducts, in.DimerBurden, in.Unresolved, in.SamePool)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
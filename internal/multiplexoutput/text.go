@@ -0,0 +1,78 @@
+// internal/multiplexoutput/text.go
+package multiplexoutput
+
+import (
+	"fmt"
+	"io"
+	"ipcr/internal/output"
+	"strings"
+)
+
+// FormatRowTSV renders a probed product as one TSV row (no trailing newline).
+// probes lists "name:strand:pos:mm" per hit, channels the distinct channels
+// lit and cross_probes the hits that belong to other assays; "-" when empty.
+func FormatRowTSV(pp ProbedProduct) string {
+	var hits, chans, cross []string
+	seen := make(map[string]bool)
+	for _, h := range pp.Probes {
+		hits = append(hits, fmt.Sprintf("%s:%s:%d:%d", h.Name, h.Strand, h.Pos, h.MM))
+		if h.Channel != "" && !seen[h.Channel] {
+			seen[h.Channel] = true
+			chans = append(chans, h.Channel)
+		}
+		if h.Cross {
+			cross = append(cross, h.Name)
+		}
+	}
+	return strings.Join([]string{output.FormatBaseRowTSV(pp.Product), list(hits), list(chans), list(cross)}, "\t")
+}
+
+func list(v []string) string {
+	if len(v) == 0 {
+		return "-"
+	}
+	return strings.Join(v, ",")
+}
+
+// WriteText writes probed products as TSV rows, each optionally followed by
+// a pretty block (render may be nil).
+func WriteText(w io.Writer, list []ProbedProduct, header bool, render func(ProbedProduct) string) error {
+	if header {
+		if _, err := io.WriteString(w, TSVHeader+"\n"); err != nil {
+			return err
+		}
+	}
+	for _, pp := range list {
+		if err := writeRow(w, pp, render); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamText is WriteText for a channel of products.
+func StreamText(w io.Writer, in <-chan ProbedProduct, header bool, render func(ProbedProduct) string) error {
+	if header {
+		if _, err := io.WriteString(w, TSVHeader+"\n"); err != nil {
+			return err
+		}
+	}
+	for pp := range in {
+		if err := writeRow(w, pp, render); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeRow(w io.Writer, pp ProbedProduct, render func(ProbedProduct) string) error {
+	if _, err := io.WriteString(w, FormatRowTSV(pp)+"\n"); err != nil {
+		return err
+	}
+	if render != nil {
+		if _, err := io.WriteString(w, render(pp)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+// internal/multiplexoutput/types.go
+package multiplexoutput
+
+import (
+	"ipcr-core/engine"
+	"ipcr/internal/output"
+)
+
+// ProbeHit is one panel probe found inside an amplicon.
+type ProbeHit struct {
+	Name    string
+	Assay   string // panel row the probe belongs to
+	Channel string
+	Seq     string
+	Strand  string // "+"/"-"
+	Pos     int
+	MM      int
+	Site    string
+	Cross   bool // the probe belongs to a different assay than the product
+}
+
+// ProbedProduct = base Product + every panel probe that binds it.
+type ProbedProduct struct {
+	Product engine.Product
+	Assay   string // panel row the product came from (self pairs resolved)
+	Probes  []ProbeHit
+}
+
+// TSVHeader extends the canonical base header with the probe overlay.
+const TSVHeader = output.TSVHeader + "\tprobes\tchannels\tcross_probes"
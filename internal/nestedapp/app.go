@@ -10,11 +10,15 @@ import (
 	"io"
 	"ipcr-core/engine"
 	"ipcr-core/primer"
+	"ipcr-core/thermo"
 	"ipcr/internal/appcore"
 	"ipcr/internal/clibase"
 	"ipcr/internal/common"
 	"ipcr/internal/nestedcli"
+	"ipcr/internal/nestedoutput"
 	"ipcr/internal/runutil"
+	"ipcr/internal/thermomodel"
+	"ipcr/internal/thermovisitors"
 	"ipcr/internal/version"
 	"ipcr/internal/visitors"
 	"ipcr/internal/writers"
@@ -108,6 +112,7 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 			MaxProduct: opts.MaxLen,
 		}}
 	}
+	panel := outer // self pairs are single-primer side reactions, not assays to report on
 	if opts.Self {
 		outer = common.AddSelfPairs(outer)
 	}
@@ -127,16 +132,43 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 			Reverse: strings.ToUpper(opts.InnerRev),
 		}}
 	}
-	if opts.Self {
+	if opts.Self && opts.SemiNested == "" {
 		inner = common.AddSelfPairs(inner)
 	}
 
+	// Rounds 3..N
+	var rounds []visitors.NestedRound
+	for i, spec := range opts.Rounds {
+		var r visitors.NestedRound
+		switch {
+		case spec.File != "":
+			if r.Pairs, e = primer.LoadTSV(spec.File); e != nil {
+				_, _ = fmt.Fprintln(stderr, e)
+				return 2
+			}
+		default:
+			p := primer.Pair{ID: fmt.Sprintf("round%d", i+3), Forward: spec.Fwd, Reverse: spec.Rev}
+			if spec.Fwd == "-" {
+				r.Reuse, p.Forward = visitors.ReuseForward, ""
+			} else if spec.Rev == "-" {
+				r.Reuse, p.Reverse = visitors.ReuseReverse, ""
+			}
+			r.Pairs = []primer.Pair{p}
+		}
+		rounds = append(rounds, r)
+	}
+
 	// ----- Core execution config -----
 	termWin := runutil.EffectiveTerminalWindow(opts.TerminalWindow)
 
 	coreOpts := appcore.Options{
 		SeqFiles:        opts.SeqFiles,
+		Index:           opts.Index,
+		Regions:         opts.Regions,
+		ExcludeRegions:  opts.ExcludeRegions,
+		MaskPolicy:      opts.MaskPolicy,
 		MaxMM:           opts.Mismatches,
+		MaxIndels:       opts.MaxIndels,
 		TerminalWindow:  termWin,
 		MinLen:          opts.MinLen,
 		MaxLen:          opts.MaxLen,
@@ -150,23 +182,104 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 		NoMatchExitCode: opts.NoMatchExitCode,
 	}
 
-	writer := appcore.NewNestedWriterFactory(opts.Output, opts.Sort, opts.Header, opts.Pretty)
-
 	visitor := visitors.Nested{
 		InnerPairs: inner,
 		EngineCfg: engine.Config{
 			MaxMM:          opts.Mismatches,
+			MaxIndels:      opts.MaxIndels,
 			TerminalWindow: termWin,
 			SeedLen:        opts.SeedLength,
 			Circular:       false,       // inner scan runs on linearized outer products
 			NeedSites:      opts.Pretty, // only pretty mode needs per-base sites
 		},
-		RequireInner: opts.RequireInner,
+		RequireInner: opts.RequireInner || opts.Summary, // --summary counts nested products
+		AllInner:     opts.AllInner,
+		InnerReuse:   opts.SemiNested,
+		OuterPairs:   outer,
+		Rounds:       rounds,
+	}
+
+	nwf := appcore.NewNestedWriterFactory(opts.Output, opts.Sort, opts.Header, opts.Pretty)
+	nwf.Rounds = visitor.Extended()
+	if opts.Thermo {
+		scorer, err := nestedScore(opts)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		visitor.Scorer = scorer
+		nwf.Scores = true
+		nwf.Rank = opts.Rank == "score"
+		nwf.Sort = nwf.Sort || nwf.Rank // ranking needs the full list, as in ipcr-thermo
+	}
+	var writer appcore.WriterFactory[nestedoutput.NestedProduct] = nwf
+	if opts.Summary {
+		sum, err := appcore.NewSummary(coreOpts, opts.AssemblyMap, panel)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		wf := appcore.NewSummaryWriterFactory(opts.Output, opts.Header, sum, func(np nestedoutput.NestedProduct) engine.Product { return np.Product })
+		wf.Seq = true // inner scan runs on the outer amplicon
+		writer = wf
 	}
 
 	return appcore.Run(parent, stdout, stderr, coreOpts, outer, visitor.Visit, writer)
 }
 
+// nestedScore builds the per-round scorers: both rounds share the buffer,
+// each has its own annealing temperature and primer concentration.
+func nestedScore(o nestedcli.Options) (thermovisitors.NestedScore, error) {
+	mode, err := thermomodel.Parse(o.ThermoModel)
+	if err != nil {
+		return thermovisitors.NestedScore{}, err
+	}
+	saltModel, err := thermo.ParseSaltModel(o.SaltModel)
+	if err != nil {
+		return thermovisitors.NestedScore{}, fmt.Errorf("--salt-model: %w", err)
+	}
+	var buf thermo.Conditions
+	var outerCt, innerCt float64
+	for _, f := range []struct {
+		name, spec string
+		dst        *float64
+	}{
+		{"--na", o.NaSpec, &buf.NaM},
+		{"--mg", o.MgSpec, &buf.MgM},
+		{"--dntp", o.DntpSpec, &buf.DntpM},
+		{"--primer-conc", o.PrimerConcSpec, &outerCt},
+		{"--inner-primer-conc", o.InnerPrimerConcSpec, &innerCt},
+	} {
+		v, err := thermo.ParseConc(f.spec)
+		if err != nil {
+			return thermovisitors.NestedScore{}, fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.dst = v
+	}
+	buf.SaltModel = saltModel
+
+	round := func(annealC, ct float64) thermovisitors.Score {
+		cond := buf
+		cond.AnnealC, cond.PrimerTotalM = annealC, ct
+		return thermovisitors.Score{
+			Model:                    mode,
+			Conditions:               cond,
+			AnnealTempC:              annealC,
+			Na_M:                     cond.EffectiveNaM(),
+			PrimerConc_M:             ct,
+			StructHairpin:            true,
+			StructDimer:              true,
+			StructScale:              1.0,
+			IUPACThermoPolicy:        thermo.IUPACThermoPolicyWorst,
+			IUPACThermoMaxExpansions: 256,
+		}
+	}
+	return thermovisitors.NestedScore{
+		Outer: round(o.AnnealTempC, outerCt),
+		Inner: round(o.InnerAnnealTempC, innerCt),
+	}, nil
+}
+
 func Run(argv []string, stdout, stderr io.Writer) int {
 	return RunContext(context.Background(), argv, stdout, stderr)
 }
@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"io"
 	"ipcr-core/primer"
+	"ipcr-core/thermo"
 	"ipcr/internal/clibase"
 	"ipcr/internal/cliutil"
+	"ipcr/internal/output"
+	"ipcr/internal/thermomodel"
+	"slices"
+	"strings"
 )
 
 type Options struct {
@@ -17,7 +22,48 @@ type Options struct {
 	InnerFwd        string
 	InnerRev        string
 	RequireInner    bool
+
+	// All inner products, semi-nested and N-round nesting
+	AllInner   bool
+	SemiNested string      // "", "forward" or "reverse": outer primer reused by the inner round
+	Rounds     []RoundSpec // rounds 3..N, in order
+
+	// Thermo scoring of both rounds
+	Thermo              bool
+	ThermoModel         string
+	AnnealTempC         float64
+	InnerAnnealTempC    float64 // 0: same as AnnealTempC
+	PrimerConcSpec      string
+	InnerPrimerConcSpec string // "": same as PrimerConcSpec
+	NaSpec              string
+	MgSpec              string
+	DntpSpec            string
+	SaltModel           string
+	Rank                string // score | coord
+}
+
+// thermoFlags only apply with --thermo.
+var thermoFlags = []string{
+	"thermo-model", "anneal-temp", "inner-anneal-temp", "primer-conc", "inner-primer-conc",
+	"na", "mg", "dntp", "salt-model", "rank",
+}
+
+// RoundSpec is one --round value: a primer TSV, or an inline pair where "-"
+// reuses that side's primer from the round before (semi-nested).
+type RoundSpec struct {
+	File     string
+	Fwd, Rev string
+}
+
+type roundsValue struct{ dst *[]string }
+
+func (r *roundsValue) String() string {
+	if r.dst == nil {
+		return ""
+	}
+	return strings.Join(*r.dst, " ")
 }
+func (r *roundsValue) Set(v string) error { *r.dst = append(*r.dst, v); return nil }
 
 func NewFlagSet(name string) *flag.FlagSet {
 	fs := flag.NewFlagSet(name, flag.ContinueOnError)
@@ -30,7 +76,25 @@ func NewFlagSet(name string) *flag.FlagSet {
 		_, _ = fmt.Fprintln(out, "      --inner-forward string   Inner forward primer (5'→3')")
 		_, _ = fmt.Fprintln(out, "      --inner-reverse string   Inner reverse primer (5'→3')")
 		_, _ = fmt.Fprintln(out, "      --inner-primers string   Inner primer TSV (id fwd rev [min] [max])")
-		_, _ = fmt.Fprintf(out, "      --require-inner          Only keep outer amplicons that contain an inner product [%s]\n", def("require-inner"))
+		_, _ = fmt.Fprintf(out, "      --require-inner          Only keep outer amplicons that contain an inner product (every round) [%s]\n", def("require-inner"))
+		_, _ = fmt.Fprintf(out, "      --all-inner              Report every inner product, not only the best [%s]\n", def("all-inner"))
+		_, _ = fmt.Fprintln(out, "      --semi-nested forward|reverse")
+		_, _ = fmt.Fprintln(out, "                               The inner round reuses that outer primer; give only the other inner primer")
+		_, _ = fmt.Fprintln(out, "      --round FWD,REV|FILE     Another round on the previous round's products (repeatable);")
+		_, _ = fmt.Fprintln(out, "                               '-' for FWD or REV reuses the previous round's primer")
+
+		_, _ = fmt.Fprintln(out, "\nThermo (both rounds):")
+		_, _ = fmt.Fprintf(out, "      --thermo                 Score outer and inner products; nested score = weaker round [%s]\n", def("thermo"))
+		_, _ = fmt.Fprintf(out, "      --thermo-model string    Scoring model: %s [%s]\n", thermomodel.KnownList(), def("thermo-model"))
+		_, _ = fmt.Fprintf(out, "      --anneal-temp float      Outer-round annealing temperature (°C) [%s]\n", def("anneal-temp"))
+		_, _ = fmt.Fprintln(out, "      --inner-anneal-temp float")
+		_, _ = fmt.Fprintln(out, "                               Inner-round annealing temperature (°C) [--anneal-temp]")
+		_, _ = fmt.Fprintf(out, "      --primer-conc string     Outer-round primer concentration [%s]\n", def("primer-conc"))
+		_, _ = fmt.Fprintln(out, "      --inner-primer-conc string")
+		_, _ = fmt.Fprintln(out, "                               Inner-round primer concentration [--primer-conc]")
+		_, _ = fmt.Fprintf(out, "      --na/--mg/--dntp string  Buffer, shared by both rounds [%s/%s/%s]\n", def("na"), def("mg"), def("dntp"))
+		_, _ = fmt.Fprintf(out, "      --salt-model string      Salt model: %s [%s]\n", thermo.KnownSaltModels(), def("salt-model"))
+		_, _ = fmt.Fprintf(out, "      --rank string            Order by: score | coord [%s]\n", def("rank"))
 	})
 	return fs
 }
@@ -68,6 +132,23 @@ func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
 	fs.StringVar(&o.InnerRev, "inner-reverse", "", "inner reverse primer (5'→3')")
 	fs.BoolVar(&o.RequireInner, "require-inner", false, "only keep outer amplicons that contain an inner product [false]")
 
+	fs.BoolVar(&o.AllInner, "all-inner", false, "report every inner product [false]")
+	fs.StringVar(&o.SemiNested, "semi-nested", "", "inner round reuses the outer forward|reverse primer")
+	var rounds []string
+	fs.Var(&roundsValue{dst: &rounds}, "round", "another nested round: FWD,REV or a primer TSV (repeatable)")
+
+	fs.BoolVar(&o.Thermo, "thermo", false, "score both rounds thermodynamically [false]")
+	fs.StringVar(&o.ThermoModel, "thermo-model", thermomodel.Default().String(), "scoring model: "+thermomodel.KnownList())
+	fs.Float64Var(&o.AnnealTempC, "anneal-temp", 60, "outer-round annealing temperature (°C)")
+	fs.Float64Var(&o.InnerAnnealTempC, "inner-anneal-temp", 0, "inner-round annealing temperature (°C) [--anneal-temp]")
+	fs.StringVar(&o.PrimerConcSpec, "primer-conc", "250nM", "outer-round primer concentration")
+	fs.StringVar(&o.InnerPrimerConcSpec, "inner-primer-conc", "", "inner-round primer concentration [--primer-conc]")
+	fs.StringVar(&o.NaSpec, "na", "50mM", "monovalent salt (e.g., 50mM)")
+	fs.StringVar(&o.MgSpec, "mg", "3mM", "Mg2+ (e.g., 3mM)")
+	fs.StringVar(&o.DntpSpec, "dntp", "0mM", "total dNTP concentration (e.g., 200uM)")
+	fs.StringVar(&o.SaltModel, "salt-model", thermo.SaltModelMonovalent.String(), "salt model: "+thermo.KnownSaltModels())
+	fs.StringVar(&o.Rank, "rank", "score", "order by: score | coord")
+
 	fs.StringVar(&o.InnerPrimerFile, "P", "", "alias of --inner-primers")
 	fs.StringVar(&o.InnerFwd, "F", "", "alias of --inner-forward")
 	fs.StringVar(&o.InnerRev, "R", "", "alias of --inner-reverse")
@@ -98,27 +179,116 @@ func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
 	// Validate inner
 	usingFile := o.InnerPrimerFile != ""
 	usingInline := o.InnerFwd != "" || o.InnerRev != ""
-	switch {
-	case usingFile && usingInline:
-		return o, fmt.Errorf("--inner-primers conflicts with --inner-forward/--inner-reverse")
-	case usingInline && (o.InnerFwd == "" || o.InnerRev == ""):
-		return o, fmt.Errorf("--inner-forward and --inner-reverse must be supplied together")
-	case !usingFile && !usingInline:
-		return o, fmt.Errorf("provide --inner-primers or --inner-forward/--inner-reverse")
+	switch o.SemiNested {
+	case "":
+		switch {
+		case usingFile && usingInline:
+			return o, fmt.Errorf("--inner-primers conflicts with --inner-forward/--inner-reverse")
+		case usingInline && (o.InnerFwd == "" || o.InnerRev == ""):
+			return o, fmt.Errorf("--inner-forward and --inner-reverse must be supplied together")
+		case !usingFile && !usingInline:
+			return o, fmt.Errorf("provide --inner-primers or --inner-forward/--inner-reverse")
+		}
+	case "forward", "reverse":
+		// The reused side comes from the outer pair: inline, give only the
+		// other primer; in --inner-primers rows that column is ignored.
+		reused, other := o.InnerFwd, o.InnerRev
+		if o.SemiNested == "reverse" {
+			reused, other = other, reused
+		}
+		switch {
+		case usingFile && usingInline:
+			return o, fmt.Errorf("--inner-primers conflicts with --inner-forward/--inner-reverse")
+		case reused != "":
+			return o, fmt.Errorf("--semi-nested %s reuses the outer %s primer; drop --inner-%s", o.SemiNested, o.SemiNested, o.SemiNested)
+		case !usingFile && other == "":
+			return o, fmt.Errorf("--semi-nested needs --inner-primers or the other inner primer")
+		}
+	default:
+		return o, fmt.Errorf("invalid --semi-nested %q (forward or reverse)", o.SemiNested)
 	}
-	if usingInline {
+	if o.InnerFwd != "" {
 		fwd, err := primer.Validate(o.InnerFwd)
 		if err != nil {
 			return o, fmt.Errorf("--inner-forward: %w", err)
 		}
+		o.InnerFwd = fwd
+	}
+	if o.InnerRev != "" {
 		rev, err := primer.Validate(o.InnerRev)
 		if err != nil {
 			return o, fmt.Errorf("--inner-reverse: %w", err)
 		}
-		o.InnerFwd = fwd
 		o.InnerRev = rev
 	}
+	for i, r := range rounds {
+		spec, err := parseRound(r)
+		if err != nil {
+			return o, fmt.Errorf("--round %d: %w", i+3, err)
+		}
+		o.Rounds = append(o.Rounds, spec)
+	}
+
+	if o.Thermo {
+		if !output.IsTabular(c.Output) {
+			return o, fmt.Errorf("--thermo supports text, json or jsonl output")
+		}
+		if c.Summary {
+			return o, fmt.Errorf("--thermo cannot be combined with --summary")
+		}
+		if o.Rank != "score" && o.Rank != "coord" {
+			return o, fmt.Errorf("invalid --rank %q (score or coord)", o.Rank)
+		}
+		if o.InnerAnnealTempC == 0 {
+			o.InnerAnnealTempC = o.AnnealTempC
+		}
+		if o.InnerPrimerConcSpec == "" {
+			o.InnerPrimerConcSpec = o.PrimerConcSpec
+		}
+	} else {
+		var err error
+		fs.Visit(func(f *flag.Flag) {
+			if err == nil && slices.Contains(thermoFlags, f.Name) {
+				err = fmt.Errorf("--%s requires --thermo", f.Name)
+			}
+		})
+		if err != nil {
+			return o, err
+		}
+	}
 
 	o.Common = c
 	return o, nil
 }
+
+// parseRound reads "FWD,REV" (either side may be "-") or a primer TSV path.
+func parseRound(v string) (RoundSpec, error) {
+	fwd, rev, ok := strings.Cut(v, ",")
+	if !ok {
+		if strings.TrimSpace(v) == "" {
+			return RoundSpec{}, fmt.Errorf("empty value")
+		}
+		return RoundSpec{File: v}, nil
+	}
+	var spec RoundSpec
+	for _, side := range []struct {
+		name string
+		raw  string
+		dst  *string
+	}{{"forward", fwd, &spec.Fwd}, {"reverse", rev, &spec.Rev}} {
+		raw := strings.TrimSpace(side.raw)
+		if raw == "-" {
+			*side.dst = "-"
+			continue
+		}
+		seq, err := primer.Validate(raw)
+		if err != nil {
+			return RoundSpec{}, fmt.Errorf("%s primer: %w", side.name, err)
+		}
+		*side.dst = seq
+	}
+	if spec.Fwd == "-" && spec.Rev == "-" {
+		return RoundSpec{}, fmt.Errorf("at most one side may reuse the previous primer")
+	}
+	return spec, nil
+}
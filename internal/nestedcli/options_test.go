@@ -49,3 +49,35 @@ func TestInnerInlinePrimersRejectInvalidBase(t *testing.T) {
 		t.Fatal("expected invalid inner primer error")
 	}
 }
+
+func TestSemiNestedAndRounds(t *testing.T) {
+	o, err := ParseArgs(newFS(), []string{
+		"-f", "aaa", "-r", "ttt",
+		"--semi-nested", "reverse", "--inner-forward", "ccc",
+		"--round", "-,gga", "--round", "round4.tsv",
+		"ref.fa",
+	})
+	if err != nil {
+		t.Fatalf("parse err: %v", err)
+	}
+	want := []RoundSpec{{Fwd: "-", Rev: "GGA"}, {File: "round4.tsv"}}
+	if o.SemiNested != "reverse" || o.InnerFwd != "CCC" || len(o.Rounds) != 2 || o.Rounds[0] != want[0] || o.Rounds[1] != want[1] {
+		t.Fatalf("unexpected options: %+v", o)
+	}
+	if _, err := ParseArgs(newFS(), []string{"-f", "aaa", "-r", "ttt", "--semi-nested", "reverse", "--inner-reverse", "ggg", "ref.fa"}); err == nil {
+		t.Fatal("expected error when the reused inner primer is also given")
+	}
+}
+
+func TestThermoRoundDefaults(t *testing.T) {
+	o, err := ParseArgs(newFS(), []string{"-f", "aaa", "-r", "ttt", "-F", "ccc", "-R", "ggg", "--thermo", "--anneal-temp", "55", "--primer-conc", "400nM", "ref.fa"})
+	if err != nil {
+		t.Fatalf("parse err: %v", err)
+	}
+	if o.InnerAnnealTempC != 55 || o.InnerPrimerConcSpec != "400nM" || o.Rank != "score" {
+		t.Fatalf("inner round should inherit the outer conditions: %+v", o)
+	}
+	if _, err := ParseArgs(newFS(), []string{"-f", "aaa", "-r", "ttt", "-F", "ccc", "-R", "ggg", "--anneal-temp", "55", "ref.fa"}); err == nil {
+		t.Fatal("expected error for a thermo flag without --thermo")
+	}
+}
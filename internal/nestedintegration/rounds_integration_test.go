@@ -0,0 +1,109 @@
+package nestedintegration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/internal/nestedapp"
+	"ipcr/internal/nestedoutput"
+	"ipcr/pkg/api"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNestedAllInnerSemiNestedAndRounds(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(15)
+	rc := func(s string) string { return string(primer.RevComp([]byte(s))) }
+	of, or, inF, inR, tf, tr := randSeq(20), randSeq(20), randSeq(20), randSeq(20), randSeq(20), randSeq(20)
+	// Outer product (600 bp): IF at 50 with IR sites ending at 320 and 470
+	// (the second is nonspecific); TF/TR span 100-250 inside both.
+	amp := of + randSeq(30) + inF + randSeq(30) + tf + randSeq(110) + rc(tr) + randSeq(50) + rc(inR) +
+		randSeq(130) + rc(inR) + randSeq(110) + rc(or)
+	if len(amp) != 600 {
+		t.Fatalf("amplicon length %d", len(amp))
+	}
+	fa := write(t, filepath.Join(dir, "ref.fa"), ">s\n"+randSeq(100)+amp+randSeq(100)+"\n")
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out, errB bytes.Buffer
+		args = append([]string{"-f", of, "-r", or, "--self=false"}, args...)
+		if code := nestedapp.Run(append(args, fa), &out, &errB); code != 0 {
+			t.Fatalf("%v: exit %d: %s", args, code, errB.String())
+		}
+		return out.String()
+	}
+	decode := func(s string) []api.NestedProductV1 {
+		t.Helper()
+		var v []api.NestedProductV1
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatalf("bad JSON: %v\n%s", err, s)
+		}
+		return v
+	}
+	span := func(h api.NestedInnerV1) [2]int { return [2]int{h.Start, h.End} }
+
+	// Default: best inner product only, schema unchanged.
+	got := decode(run("--inner-forward", inF, "--inner-reverse", inR, "-o", "json"))
+	if len(got) != 1 || got[0].InnerProducts != nil || got[0].InnerStart != 50 || got[0].InnerEnd != 470 {
+		t.Fatalf("best inner: %+v", got)
+	}
+
+	got = decode(run("--inner-forward", inF, "--inner-reverse", inR, "--all-inner", "-o", "json"))
+	if in := got[0].InnerProducts; len(in) != 2 || span(in[0]) != [2]int{50, 470} || span(in[1]) != [2]int{50, 320} || in[1].Round != 2 {
+		t.Fatalf("all inner: %+v", in)
+	}
+
+	got = decode(run("--semi-nested", "forward", "--inner-reverse", inR, "--all-inner", "-o", "json"))
+	if in := got[0].InnerProducts; len(in) != 2 || span(in[1]) != [2]int{0, 320} || in[1].Reused != "forward" {
+		t.Fatalf("semi-nested: %+v", in)
+	}
+
+	// Three rounds: TF/TR inside every inner product, then a semi-nested
+	// round reusing the inner forward primer.
+	for _, round := range []string{tf + "," + tr, "-," + tr} {
+		got = decode(run("-F", inF, "-R", inR, "--all-inner", "--round", round, "-o", "json"))
+		want := [2]int{100, 250}
+		if round[0] == '-' {
+			want = [2]int{50, 250}
+		}
+		for _, h := range got[0].InnerProducts {
+			if len(h.Inner) != 1 || span(h.Inner[0]) != want || h.Inner[0].Round != 3 {
+				t.Fatalf("--round %s: %+v", round, h)
+			}
+		}
+	}
+
+	text := run("-F", inF, "-R", inR, "--round", tf+","+tr)
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if lines[0] != nestedoutput.TSVHeaderNestedRounds || len(lines) != 3 ||
+		!strings.HasSuffix(lines[2], "\t3\t\tinner:50-470") {
+		t.Fatalf("rounds text:\n%s", text)
+	}
+
+	if out := run("-F", inF, "-R", inR, "--round", randSeq(20)+","+randSeq(20), "--require-inner", "--no-header"); out != "" {
+		t.Fatalf("--require-inner should drop outers without a product in every round:\n%s", out)
+	}
+
+	// --summary counts nested products, not bare outer ones.
+	if out := run("-F", randSeq(20), "-R", randSeq(20), "--summary", "--no-header"); !strings.HasSuffix(out, "\touter\tno\t0\t-\t-\n") {
+		t.Fatalf("--summary without an inner product:\n%s", out)
+	}
+	if out := run("-F", inF, "-R", inR, "--summary", "--no-header"); !strings.HasSuffix(out, "\touter\tyes\t1\t600\t0\n") {
+		t.Fatalf("--summary:\n%s", out)
+	}
+
+	var out, errB bytes.Buffer
+	for _, bad := range [][]string{
+		{"--semi-nested", "forward", "-F", inF, "-R", inR},
+		{"--semi-nested", "both", "-R", inR},
+		{"-F", inF, "-R", inR, "--round", "-,-"},
+	} {
+		if code := nestedapp.Run(append(append([]string{"-f", of, "-r", or}, bad...), fa), &out, &errB); code != 2 {
+			t.Fatalf("%v: want exit 2, got %d", bad, code)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package nestedintegration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/internal/nestedapp"
+	"ipcr/internal/nestedoutput"
+	"ipcr/pkg/api"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNestedThermoScoresBothRounds(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(16)
+	rc := func(s string) string { return string(primer.RevComp([]byte(s))) }
+	of, or := "AGAGTTTGATCCTGGCTCAG", "GGTTACCTTGTTACGACTTC"
+	inF, inR := "GCTAACGCATTAAGTACTCC", "CGTGCTTGTAGGCATTCAGC"
+	// Template "a" carries an internal mismatch in the inner forward site, so
+	// ranking reverses coordinate order.
+	inFmm := inF[:8] + "T" + inF[9:]
+	amp := func(f string) string {
+		return of + randSeq(40) + f + randSeq(160) + rc(inR) + randSeq(40) + rc(or)
+	}
+	fa := write(t, filepath.Join(dir, "ref.fa"),
+		">a\n"+randSeq(60)+amp(inFmm)+randSeq(60)+"\n>b\n"+randSeq(60)+amp(inF)+randSeq(60)+"\n")
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out, errB bytes.Buffer
+		args = append([]string{"-f", of, "-r", or, "-F", inF, "-R", inR, "--self=false", "-m", "1", "--thermo"}, args...)
+		if code := nestedapp.Run(append(args, fa), &out, &errB); code != 0 {
+			t.Fatalf("%v: exit %d: %s", args, code, errB.String())
+		}
+		return out.String()
+	}
+	decode := func(s string) []api.NestedProductV1 {
+		t.Helper()
+		var v []api.NestedProductV1
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatalf("bad JSON: %v\n%s", err, s)
+		}
+		return v
+	}
+
+	got := decode(run("-o", "json", "--inner-anneal-temp", "64"))
+	if len(got) != 2 {
+		t.Fatalf("want 2 nested products, got %+v", got)
+	}
+	for _, np := range got {
+		if np.OuterScore == nil || np.InnerScore == nil || np.NestedScore == nil {
+			t.Fatalf("missing round scores: %+v", np)
+		}
+		if *np.NestedScore != min(*np.OuterScore, *np.InnerScore) {
+			t.Fatalf("nested score %g is not the weaker of %g and %g", *np.NestedScore, *np.OuterScore, *np.InnerScore)
+		}
+	}
+	// Ranked best first: the mismatched inner site scores lower.
+	if got[0].SequenceID != "b" || *got[0].InnerScore <= *got[1].InnerScore || *got[0].NestedScore < *got[1].NestedScore {
+		t.Fatalf("ranking: %s %g, %s %g", got[0].SequenceID, *got[0].NestedScore, got[1].SequenceID, *got[1].NestedScore)
+	}
+
+	// Each round has its own conditions: a hotter inner round lowers only
+	// the inner score.
+	hot := decode(run("-o", "json", "--inner-anneal-temp", "70"))
+	if hot[0].SequenceID != "b" || *hot[0].InnerScore >= *got[0].InnerScore || *hot[0].OuterScore != *got[0].OuterScore {
+		t.Fatalf("inner anneal temp: outer %g→%g inner %g→%g",
+			*got[0].OuterScore, *hot[0].OuterScore, *got[0].InnerScore, *hot[0].InnerScore)
+	}
+	low := decode(run("-o", "json", "--inner-anneal-temp", "64", "--inner-primer-conc", "50nM", "--rank", "coord", "--sort"))
+	if low[0].SequenceID != "a" || *low[1].InnerScore >= *got[0].InnerScore {
+		t.Fatalf("inner primer conc: inner %g→%g", *got[0].InnerScore, *low[1].InnerScore)
+	}
+
+	text := run("--all-inner")
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if lines[0] != nestedoutput.TSVHeaderNestedRounds+nestedoutput.TSVScoreColumns || len(lines) != 3 {
+		t.Fatalf("scored rounds text:\n%s", text)
+	}
+	if cols := strings.Split(lines[1], "\t"); len(cols) != 21 || cols[18] == "" || cols[20] == "" {
+		t.Fatalf("score cells: %q", lines[1])
+	}
+
+	var out, errB bytes.Buffer
+	for _, bad := range [][]string{
+		{"--inner-anneal-temp", "64"},
+		{"--thermo", "--rank", "mm"},
+		{"--thermo", "-o", "bed"},
+	} {
+		args := append([]string{"-f", of, "-r", or, "-F", inF, "-R", inR}, bad...)
+		if code := nestedapp.Run(append(args, fa), &out, &errB); code != 2 {
+			t.Fatalf("%v: want exit 2, got %d", bad, code)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package nestedoutput
 
 import (
 	"ipcr-core/engine"
+	"ipcr/internal/output"
 	"ipcr/pkg/api"
+	"math"
 )
 
 // ToAPINested converts a NestedProduct into the public wire type.
@@ -21,6 +23,10 @@ func ToAPINested(np NestedProduct) api.NestedProductV1 {
 		RevMismatchIdx: append([]int(nil), p.RevMismatchIdx...),
 		Seq:            p.Seq,
 		SourceFile:     p.SourceFile,
+		FwdIndels:      output.ToAPIIndels(p.FwdIndels),
+		RevIndels:      output.ToAPIIndels(p.RevIndels),
+		FwdMasked:      p.FwdMasked,
+		RevMasked:      p.RevMasked,
 
 		InnerFound:  np.InnerFound,
 		InnerPairID: np.InnerPairID,
@@ -30,12 +36,44 @@ func ToAPINested(np NestedProduct) api.NestedProductV1 {
 		InnerType:   np.InnerType,
 		InnerFwdMM:  np.InnerFwdMM,
 		InnerRevMM:  np.InnerRevMM,
+
+		InnerProducts: toAPIInner(np.Inner, np.Scored),
+	}
+	if np.Scored {
+		v.OuterScore = scorePtr(p.Score)
+		v.InnerScore = scorePtr(np.InnerScore)
+		v.NestedScore = scorePtr(np.NestedScore)
 	}
 	// Conditionally attach score (thermo-only; no-op otherwise).
 	applyScoreToNested(&v, p)
 	return v
 }
 
+func toAPIInner(hits []InnerHit, scored bool) []api.NestedInnerV1 {
+	if hits == nil {
+		return nil
+	}
+	out := make([]api.NestedInnerV1, len(hits))
+	for i, h := range hits {
+		out[i] = api.NestedInnerV1{
+			Round: h.Round, ExperimentID: h.PairID, Start: h.Start, End: h.End, Length: h.Length, Type: h.Type,
+			FwdMM: h.FwdMM, RevMM: h.RevMM, Reused: h.Reused, Inner: toAPIInner(h.Inner, scored),
+		}
+		if scored {
+			out[i].Score = scorePtr(h.Score)
+		}
+	}
+	return out
+}
+
+// scorePtr boxes a round score; NaN (no product to score) becomes nil.
+func scorePtr(x float64) *float64 {
+	if math.IsNaN(x) {
+		return nil
+	}
+	return &x
+}
+
 // ToAPINestedSlice converts a slice of NestedProduct to the v1 wire schema.
 func ToAPINestedSlice(list []NestedProduct) []api.NestedProductV1 {
 	out := make([]api.NestedProductV1, 0, len(list))
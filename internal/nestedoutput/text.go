@@ -4,6 +4,7 @@ package nestedoutput
 import (
 	"fmt"
 	"io"
+	"math"
 	"strconv"
 )
 
@@ -19,16 +20,32 @@ func writeRowTSV(w io.Writer, np NestedProduct) error {
 	innerRevMM := emptyIf(!np.InnerFound, strconv.Itoa(np.InnerRevMM))
 
 	_, err := fmt.Fprintf(
-		w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\t%t\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\t%t\t%s\t%s\t%s\t%s\t%s\t%s",
 		p.SourceFile, p.SequenceID, p.ExperimentID,
 		p.Start, p.End, p.Length, p.Type,
 		np.InnerPairID, np.InnerFound,
 		innerStart, innerEnd, innerLen,
 		innerType, innerFwdMM, innerRevMM,
 	)
+	if err != nil {
+		return err
+	}
+	return endRow(w, np, np.InnerScore)
+}
+
+// endRow ends a text row, after the TSVScoreColumns when np is scored.
+func endRow(w io.Writer, np NestedProduct, inner float64) error {
+	if !np.Scored {
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\t%s\t%s\t%s\n", scoreCell(np.Product.Score), scoreCell(inner), scoreCell(np.NestedScore))
 	return err
 }
 
+// scoreCell formats a score; NaN (nothing to score) is an empty cell.
+func scoreCell(x float64) string { return emptyIf(math.IsNaN(x), strconv.FormatFloat(x, 'g', -1, 64)) }
+
 // New: renderer-capable streaming writer (keeps parity with products/probe)
 func StreamTextWithRenderer(w io.Writer, in <-chan NestedProduct, header bool, render func(NestedProduct) string) error {
 	if header {
@@ -69,6 +86,82 @@ func WriteTextWithRenderer(w io.Writer, list []NestedProduct, header bool, rende
 	return nil
 }
 
+// writeRoundsRows writes one TSVHeaderNestedRounds row per inner product of
+// np (depth first), or a single inner_found=false row when there is none.
+// Scored rows carry that product's own inner_score; nested_score is the
+// product's.
+func writeRoundsRows(w io.Writer, np NestedProduct) error {
+	p := np.Product
+	if len(np.Inner) == 0 {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t\tfalse\t\t\t\t\t\t\t\t\t",
+			p.SourceFile, p.SequenceID, p.ExperimentID, p.Start, p.End, p.Length, p.Type); err != nil {
+			return err
+		}
+		return endRow(w, np, np.InnerScore)
+	}
+	var walk func(hits []InnerHit, parent string) error
+	walk = func(hits []InnerHit, parent string) error {
+		for _, h := range hits {
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\ttrue\t%d\t%d\t%d\t%s\t%d\t%d\t%d\t%s\t%s",
+				p.SourceFile, p.SequenceID, p.ExperimentID, p.Start, p.End, p.Length, p.Type,
+				h.PairID, h.Start, h.End, h.Length, h.Type, h.FwdMM, h.RevMM,
+				h.Round, h.Reused, parent); err != nil {
+				return err
+			}
+			if err := endRow(w, np, h.Score); err != nil {
+				return err
+			}
+			if err := walk(h.Inner, fmt.Sprintf("%s:%d-%d", h.PairID, h.Start, h.End)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(np.Inner, "")
+}
+
+// StreamRoundsText streams the one-row-per-inner-product layout.
+func StreamRoundsText(w io.Writer, in <-chan NestedProduct, header bool, render func(NestedProduct) string) error {
+	if header {
+		if _, err := io.WriteString(w, TSVHeaderNestedRounds+"\n"); err != nil {
+			return err
+		}
+	}
+	for np := range in {
+		if err := writeRoundsProduct(w, np, render); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteRoundsText is the buffered form of StreamRoundsText.
+func WriteRoundsText(w io.Writer, list []NestedProduct, header bool, render func(NestedProduct) string) error {
+	if header {
+		if _, err := io.WriteString(w, TSVHeaderNestedRounds+"\n"); err != nil {
+			return err
+		}
+	}
+	for _, np := range list {
+		if err := writeRoundsProduct(w, np, render); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeRoundsProduct(w io.Writer, np NestedProduct, render func(NestedProduct) string) error {
+	if err := writeRoundsRows(w, np); err != nil {
+		return err
+	}
+	if render != nil {
+		if _, err := io.WriteString(w, render(np)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Back-compat wrappers
 func StreamText(w io.Writer, in <-chan NestedProduct, header bool) error {
 	return StreamTextWithRenderer(w, in, header, nil)
@@ -16,7 +16,64 @@ type NestedProduct struct {
 	InnerType   string `json:"inner_type,omitempty"`
 	InnerFwdMM  int    `json:"inner_fwd_mm,omitempty"`
 	InnerRevMM  int    `json:"inner_rev_mm,omitempty"`
+
+	// Every inner product, best first, when the run reports all inner
+	// products, semi-nested rounds or more than two rounds; nil otherwise.
+	Inner []InnerHit `json:"inner_products,omitempty"`
+
+	// Thermo scores of both rounds (higher is better), set when Scored.
+	// Product.Score holds the outer round; InnerScore is the weakest round
+	// of the reported inner chain (Inner[0], or the summary hit) and
+	// NestedScore the weaker of the two. Both are NaN without an inner product.
+	Scored      bool    `json:"-"`
+	InnerScore  float64 `json:"-"`
+	NestedScore float64 `json:"-"`
+}
+
+// InnerHit is one product of an inner round found inside the product of the
+// round before. Coordinates are relative to the outer amplicon.
+type InnerHit struct {
+	Round  int // 2 for the first inner round
+	PairID string
+	Start  int
+	End    int
+	Length int
+	Type   string
+	FwdMM  int
+	RevMM  int
+	Reused string     // "forward"/"reverse": primer taken from the enclosing product's pair
+	Inner  []InnerHit // products of the next round inside this one
+
+	// Primers in product orientation (as engine.Product), kept for scoring.
+	FwdPrimer string
+	RevPrimer string
+	Score     float64 // thermo score of this round alone, when scored
+}
+
+// Depth is the number of rounds below h's round that reached a product,
+// plus one for h itself.
+func (h InnerHit) Depth() int {
+	d := 0
+	for _, c := range h.Inner {
+		d = max(d, c.Depth())
+	}
+	return d + 1
+}
+
+// ChainScore is the weakest round score along h's best chain of rounds.
+func (h InnerHit) ChainScore() float64 {
+	if len(h.Inner) == 0 {
+		return h.Score
+	}
+	return min(h.Score, h.Inner[0].ChainScore())
 }
 
 const TSVHeaderNested = "source_file\tsequence_id\touter_experiment_id\touter_start\touter_end\touter_length\touter_type\t" +
 	"inner_experiment_id\tinner_found\tinner_start\tinner_end\tinner_length\tinner_type\tinner_fwd_mm\tinner_rev_mm"
+
+// TSVHeaderNestedRounds heads the one-row-per-inner-product layout; the
+// inner columns then describe each product of every round in turn.
+const TSVHeaderNestedRounds = TSVHeaderNested + "\tinner_round\tinner_reused\tinner_parent"
+
+// TSVScoreColumns is appended to either header when both rounds are scored.
+const TSVScoreColumns = "\touter_score\tinner_score\tnested_score"
@@ -0,0 +1,319 @@
+// internal/output/coords.go
+package output
+
+import (
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"strconv"
+	"strings"
+)
+
+// Genome-coordinate writers (BED6, BED12, GFF3, SAM). Products arrive in
+// reference-global coordinates (the pipeline undoes chunk offsets). A circular
+// wrap-around product has Start > End; its record length is recovered from
+// Length = (recordLen - Start) + End.
+
+// productStrand maps a product orientation to a genome strand.
+func productStrand(p engine.Product) string {
+	if p.Type == "revcomp" {
+		return "-"
+	}
+	return "+"
+}
+
+// primerSiteLens returns the template spans of the left (5'-most on the plus
+// strand) and right primer sites, or 0 when the primer sequence is unknown.
+func primerSiteLens(p engine.Product) (left, right int) {
+	if p.FwdPrimer != "" {
+		left = primer.SiteLen(len(p.FwdPrimer), p.FwdIndels)
+	}
+	if p.RevPrimer != "" {
+		right = primer.SiteLen(len(p.RevPrimer), p.RevIndels)
+	}
+	return left, right
+}
+
+func wraps(p engine.Product) bool { return p.Start > p.End }
+
+func recordLen(p engine.Product) int { return p.Length + p.Start - p.End }
+
+// ---------------- BED ----------------
+
+// WriteBED writes one BED6 line per product, or two for a wrap-around product
+// (one piece on each side of the origin).
+func WriteBED(w io.Writer, p engine.Product) error {
+	for _, sp := range bedPieces(p) {
+		if _, err := fmt.Fprintf(w, "%s\t%d\t%d\t%s\t0\t%s\n",
+			p.SequenceID, sp[0], sp[1], p.ExperimentID, productStrand(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteBED12 writes products with the primer-binding sites as blocks and the
+// region between them as the thick part. Wrap-around products are split at
+// the origin into single-block pieces, keeping the thick region.
+func WriteBED12(w io.Writer, p engine.Product) error {
+	left, right := primerSiteLens(p)
+	for i, sp := range bedPieces(p) {
+		start, end := sp[0], sp[1]
+		ts, te := start+left, end-right
+		if wraps(p) {
+			// Before the origin the thick part starts after the forward
+			// site; after it, the thick part ends at the reverse site.
+			if i == 0 {
+				ts, te = min(start+left, end), end
+			} else {
+				ts, te = start, max(end-right, start)
+			}
+		}
+		if ts > te {
+			ts, te = start, start
+		}
+
+		sizes, starts := strconv.Itoa(end-start)+",", "0,"
+		if !wraps(p) && left > 0 && right > 0 && left+right < end-start {
+			sizes = fmt.Sprintf("%d,%d,", left, right)
+			starts = fmt.Sprintf("0,%d,", end-start-right)
+		}
+		count := strings.Count(sizes, ",")
+		if _, err := fmt.Fprintf(w, "%s\t%d\t%d\t%s\t0\t%s\t%d\t%d\t0\t%d\t%s\t%s\n",
+			p.SequenceID, start, end, p.ExperimentID, productStrand(p),
+			ts, te, count, sizes, starts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bedPieces(p engine.Product) [][2]int {
+	if wraps(p) {
+		return [][2]int{{p.Start, recordLen(p)}, {0, p.End}}
+	}
+	return [][2]int{{p.Start, p.End}}
+}
+
+// ---------------- GFF3 ----------------
+
+// GFF3Header is the mandatory first line of a GFF3 stream.
+const GFF3Header = "##gff-version 3"
+
+// WriteGFF3 writes a PCR_product feature with primer_binding_site children.
+// n numbers the feature IDs and must be unique within the stream; extra
+// holds already-formatted key=value attributes for the product line.
+// Wrap-around products follow the GFF3 circular convention: end coordinates
+// past the origin are offset by the record length.
+func WriteGFF3(w io.Writer, p engine.Product, n int, extra ...string) error {
+	left, right := primerSiteLens(p)
+	end := p.End
+	if wraps(p) {
+		end += recordLen(p)
+	}
+	id := fmt.Sprintf("amplicon_%d", n)
+
+	attrs := []string{
+		"ID=" + id,
+		"Name=" + GFFEscape(p.ExperimentID),
+		"length=" + strconv.Itoa(p.Length),
+	}
+	if p.SourceFile != "" {
+		attrs = append(attrs, "source_file="+GFFEscape(p.SourceFile))
+	}
+	attrs = append(attrs, extra...)
+	if err := gffLine(w, p.SequenceID, "PCR_product", p.Start+1, end, productStrand(p), attrs); err != nil {
+		return err
+	}
+
+	// The left site is primed by FwdPrimer on the plus strand, the right by
+	// RevPrimer on the minus strand; which pair primer that is depends on Type.
+	leftRole, rightRole := "forward", "reverse"
+	if p.Type == "revcomp" {
+		leftRole, rightRole = "reverse", "forward"
+	}
+	sites := []struct {
+		suffix, role, strand string
+		start, end           int
+		seq                  string
+		mm                   int
+		idx                  []int
+		indels               []primer.Indel
+	}{
+		{"fwd", leftRole, "+", p.Start, p.Start + left, p.FwdPrimer, p.FwdMM, p.FwdMismatchIdx, p.FwdIndels},
+		{"rev", rightRole, "-", end - right, end, p.RevPrimer, p.RevMM, p.RevMismatchIdx, p.RevIndels},
+	}
+	for _, s := range sites {
+		if s.seq == "" {
+			continue
+		}
+		attrs := []string{
+			"ID=" + id + "." + s.suffix,
+			"Parent=" + id,
+			"Name=" + GFFEscape(p.ExperimentID) + "_" + s.role,
+			"primer=" + s.role,
+			"primer_seq=" + s.seq,
+			"mismatches=" + strconv.Itoa(s.mm),
+		}
+		if len(s.idx) > 0 {
+			attrs = append(attrs, "mismatch_idx="+IntsCSV(s.idx))
+		}
+		if len(s.indels) > 0 {
+			attrs = append(attrs, "indels="+indelsCSV(s.indels))
+		}
+		if err := gffLine(w, p.SequenceID, "primer_binding_site", s.start+1, s.end, s.strand, attrs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gffLine(w io.Writer, seqID, kind string, start, end int, strand string, attrs []string) error {
+	_, err := fmt.Fprintf(w, "%s\tipcr\t%s\t%d\t%d\t.\t%s\t.\t%s\n",
+		GFFEscape(seqID), kind, start, end, strand, strings.Join(attrs, ";"))
+	return err
+}
+
+var gffEscaper = strings.NewReplacer("%", "%25", ";", "%3B", "=", "%3D", "&", "%26", ",", "%2C", "\t", "%09")
+
+// GFFEscape percent-encodes GFF3 reserved characters in a column-9 value.
+func GFFEscape(s string) string { return gffEscaper.Replace(s) }
+
+func indelsCSV(in []primer.Indel) string {
+	parts := make([]string, len(in))
+	for i, d := range in {
+		op := "del"
+		if d.Ins {
+			op = "ins"
+		}
+		parts[i] = op + strconv.Itoa(d.Pos)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ---------------- SAM ----------------
+
+// SAMHeader opens a SAM stream. Reference lengths are not known to the
+// writer, so @SQ lines are left to tools such as `samtools view -t ref.fai`.
+const SAMHeader = "@HD\tVN:1.6\tSO:unsorted\n@PG\tID:ipcr\tPN:ipcr\n"
+
+// WriteSAM writes the two primer alignments of a product as a read pair
+// named <experiment_id>_<n>. SEQ is the primer in plus-strand orientation;
+// CIGAR carries primer indels, NM the edit distance and, when the template
+// sites are known (engine NeedSites), MD the mismatched reference bases.
+// XP:Z tells which pair primer an alignment is.
+func WriteSAM(w io.Writer, p engine.Product, n int) error {
+	left, right := primerSiteLens(p)
+	if left == 0 || right == 0 {
+		return nil
+	}
+	qname := fmt.Sprintf("%s_%d", p.ExperimentID, n)
+	leftPos, rightPos := p.Start, p.End-right
+	tlen := p.Length
+	if wraps(p) {
+		tlen = 0 // template spans the origin
+	}
+	leftRole, rightRole := "forward", "reverse"
+	if p.Type == "revcomp" {
+		leftRole, rightRole = "reverse", "forward"
+	}
+
+	// Left primer as written matches the plus strand.
+	lq := []byte(p.FwdPrimer)
+	lcigar, lmd := samAlign(lq, []byte(p.FwdSite), p.FwdIndels)
+	// Right primer matches the minus strand: report its reverse complement.
+	rq := primer.RevComp([]byte(p.RevPrimer))
+	var rref []byte
+	if p.RevSite != "" {
+		rref = primer.RevComp([]byte(p.RevSite))
+	}
+	rcigar, rmd := samAlign(rq, rref, primer.FlipIndels(len(rq), p.RevIndels))
+
+	rec := func(flag, pos, mpos, tl int, cigar string, seq []byte, nm int, md, role string) error {
+		tags := "NM:i:" + strconv.Itoa(nm)
+		if md != "" {
+			tags += "\tMD:Z:" + md
+		}
+		_, err := fmt.Fprintf(w, "%s\t%d\t%s\t%d\t255\t%s\t=\t%d\t%d\t%s\t*\t%s\tXP:Z:%s\n",
+			qname, flag, p.SequenceID, pos+1, cigar, mpos+1, tl, seq, tags, role)
+		return err
+	}
+	// 99/147: paired, properly paired, first/second in pair, mate/self reversed.
+	if err := rec(99, leftPos, rightPos, tlen, lcigar, lq, p.FwdMM+len(p.FwdIndels), lmd, leftRole); err != nil {
+		return err
+	}
+	return rec(147, rightPos, leftPos, -tlen, rcigar, rq, p.RevMM+len(p.RevIndels), rmd, rightRole)
+}
+
+// samAlign builds the CIGAR and MD strings of query q against the reference
+// site ref (both plus-strand). Indel semantics follow primer.Indel: Ins is a
+// template (reference) base the primer lacks, i.e. a SAM deletion. MD is empty
+// when ref is unknown or inconsistent with the alignment.
+func samAlign(q, ref []byte, indels []primer.Indel) (cigar, md string) {
+	var cb strings.Builder
+	op, run := byte(0), 0
+	push := func(o byte) {
+		if o != op && run > 0 {
+			cb.WriteString(strconv.Itoa(run))
+			cb.WriteByte(op)
+			run = 0
+		}
+		op = o
+		run++
+	}
+
+	var mb strings.Builder
+	matches, inDel, ri := 0, false, 0
+	haveRef := len(ref) == primer.SiteLen(len(q), indels)
+	refAt := func() byte {
+		if haveRef && ri < len(ref) {
+			return ref[ri]
+		}
+		haveRef = false
+		return 'N'
+	}
+
+	k := 0
+	for qi := 0; qi < len(q); qi++ {
+		deleted := false
+		for ; k < len(indels) && indels[k].Pos == qi; k++ {
+			if indels[k].Ins {
+				push('D')
+				if !inDel {
+					mb.WriteString(strconv.Itoa(matches))
+					mb.WriteByte('^')
+					matches = 0
+				}
+				mb.WriteByte(refAt())
+				inDel = true
+				ri++
+			} else {
+				deleted = true
+			}
+		}
+		if deleted {
+			push('I')
+			continue
+		}
+		push('M')
+		inDel = false
+		if b := refAt(); primer.BaseMatch(b, q[qi]) {
+			matches++
+		} else {
+			mb.WriteString(strconv.Itoa(matches))
+			mb.WriteByte(b)
+			matches = 0
+		}
+		ri++
+	}
+	if run > 0 {
+		cb.WriteString(strconv.Itoa(run))
+		cb.WriteByte(op)
+	}
+	mb.WriteString(strconv.Itoa(matches))
+	if !haveRef {
+		return cb.String(), ""
+	}
+	return cb.String(), mb.String()
+}
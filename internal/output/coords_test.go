@@ -0,0 +1,101 @@
+package output
+
+import (
+	"bytes"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"strings"
+	"testing"
+)
+
+func TestCoordFormats_Stable(t *testing.T) {
+	if FormatBED != "bed" || FormatBED12 != "bed12" || FormatGFF3 != "gff3" || FormatSAM != "sam" {
+		t.Fatalf("coordinate format constants changed")
+	}
+}
+
+func TestSAMAlignCigarAndMD(t *testing.T) {
+	cases := []struct {
+		q, ref string
+		indels []primer.Indel
+		cigar  string
+		md     string
+	}{
+		{"ACGTACGT", "ACGTACGT", nil, "8M", "8"},
+		{"ACGTACGT", "TCGTACGA", nil, "8M", "0T6A0"},
+		// Template carries an extra G before primer base 4: SAM deletion.
+		{"ACGTACGT", "ACGTGACGT", []primer.Indel{{Pos: 4, Ins: true}}, "4M1D4M", "4^G4"},
+		// Primer base 4 has no template partner: SAM insertion.
+		{"ACGTACGT", "ACGTCGT", []primer.Indel{{Pos: 4}}, "4M1I3M", "7"},
+		// IUPAC primer bases match compatible template bases.
+		{"ACGNACRT", "ACGTACAT", nil, "8M", "8"},
+		// Unknown site: CIGAR only.
+		{"ACGTACGT", "", nil, "8M", ""},
+	}
+	for _, c := range cases {
+		cigar, md := samAlign([]byte(c.q), []byte(c.ref), c.indels)
+		if cigar != c.cigar || md != c.md {
+			t.Errorf("samAlign(%s,%s,%v) = %s,%s want %s,%s", c.q, c.ref, c.indels, cigar, md, c.cigar, c.md)
+		}
+	}
+}
+
+func TestWriteBED12Blocks(t *testing.T) {
+	p := engine.Product{
+		ExperimentID: "x", SequenceID: "chr", Start: 100, End: 200, Length: 100, Type: "revcomp",
+		FwdPrimer: strings.Repeat("A", 20), RevPrimer: strings.Repeat("C", 18),
+		RevIndels: []primer.Indel{{Pos: 3, Ins: true}},
+	}
+	var b bytes.Buffer
+	if err := WriteBED12(&b, p); err != nil {
+		t.Fatal(err)
+	}
+	want := "chr\t100\t200\tx\t0\t-\t120\t181\t0\t2\t20,19,\t0,81,\n"
+	if b.String() != want {
+		t.Fatalf("got  %q\nwant %q", b.String(), want)
+	}
+}
+
+func TestWrapAroundProductCoordinates(t *testing.T) {
+	// Record of 175 bp; product runs 125→175 then 0→25.
+	p := engine.Product{
+		ExperimentID: "x", SequenceID: "circ", Start: 125, End: 25, Length: 75, Type: "forward",
+		FwdPrimer: strings.Repeat("A", 20), RevPrimer: strings.Repeat("C", 20),
+	}
+	var b bytes.Buffer
+	if err := WriteBED(&b, p); err != nil {
+		t.Fatal(err)
+	}
+	if want := "circ\t125\t175\tx\t0\t+\ncirc\t0\t25\tx\t0\t+\n"; b.String() != want {
+		t.Fatalf("BED got %q want %q", b.String(), want)
+	}
+
+	b.Reset()
+	if err := WriteGFF3(&b, p, 1); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("GFF3 lines: %q", lines)
+	}
+	amp, rev := strings.Split(lines[0], "\t"), strings.Split(lines[2], "\t")
+	if amp[3] != "126" || amp[4] != "200" || rev[3] != "181" || rev[4] != "200" || rev[6] != "-" {
+		t.Fatalf("GFF3 wrap coordinates wrong:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := WriteSAM(&b, p, 1); err != nil {
+		t.Fatal(err)
+	}
+	recs := strings.Split(strings.TrimSpace(b.String()), "\n")
+	f, r := strings.Split(recs[0], "\t"), strings.Split(recs[1], "\t")
+	if f[3] != "126" || r[3] != "6" || f[8] != "0" || f[7] != "6" || r[7] != "126" {
+		t.Fatalf("SAM wrap records wrong:\n%s", b.String())
+	}
+}
+
+func TestGFFEscape(t *testing.T) {
+	if got := GFFEscape("a;b=c,d%"); got != "a%3Bb%3Dc%2Cd%25" {
+		t.Fatalf("GFFEscape = %q", got)
+	}
+}
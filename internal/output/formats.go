@@ -6,4 +6,16 @@ const (
 	FormatJSON  = "json"
 	FormatJSONL = "jsonl"
 	FormatFASTA = "fasta"
+
+	// Genome-coordinate formats for browsers and interval tools.
+	FormatBED   = "bed"
+	FormatBED12 = "bed12"
+	FormatGFF3  = "gff3"
+	FormatSAM   = "sam"
 )
+
+// IsTabular reports whether format is one of the row/record formats that
+// summary-style reports (text, json, jsonl) can be written in.
+func IsTabular(format string) bool {
+	return format == FormatText || format == FormatJSON || format == FormatJSONL
+}
@@ -4,6 +4,7 @@ package output
 import (
 	"io"
 	"ipcr-core/engine"
+	"ipcr-core/primer"
 	"ipcr/internal/jsonutil"
 	"ipcr/pkg/api"
 )
@@ -24,6 +25,15 @@ func ToAPIProduct(p engine.Product) api.ProductV1 {
 		RevMismatchIdx: append([]int(nil), p.RevMismatchIdx...),
 		Seq:            p.Seq,
 		SourceFile:     p.SourceFile,
+		FwdIndels:      ToAPIIndels(p.FwdIndels),
+		RevIndels:      ToAPIIndels(p.RevIndels),
+		FwdMasked:      p.FwdMasked,
+		RevMasked:      p.RevMasked,
+
+		BisulfiteStrand: p.BisulfiteStrand,
+		Methylation:     p.Methylation,
+		Variants:        ToAPIVariants(p.Variants),
+		AltAllele:       p.AltAllele,
 	}
 	if p.Thermo != nil {
 		v.Thermo = &api.ThermoDetailsV1{
@@ -70,6 +80,42 @@ func ToAPIProduct(p engine.Product) api.ProductV1 {
 	return v
 }
 
+// ToAPIIndels converts primer-site gaps to the wire schema (nil when ungapped).
+func ToAPIIndels(in []primer.Indel) []api.IndelV1 {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]api.IndelV1, len(in))
+	for i, d := range in {
+		op := "del"
+		if d.Ins {
+			op = "ins"
+		}
+		out[i] = api.IndelV1{Pos: d.Pos, Op: op}
+	}
+	return out
+}
+
+// ToAPIVariants converts primer-site variant hits to the wire schema; an
+// unknown allele frequency is omitted.
+func ToAPIVariants(in []engine.VariantHit) []api.VariantHitV1 {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]api.VariantHitV1, len(in))
+	for i, h := range in {
+		out[i] = api.VariantHitV1{
+			ID: h.ID, Pos: h.Pos, Ref: h.Ref, Alt: h.Alt,
+			Site: h.Site, PrimerPos: h.PrimerPos, ThreePrime: h.ThreePrime, Effect: h.Effect,
+		}
+		if h.AF >= 0 {
+			af := h.AF
+			out[i].AF = &af
+		}
+	}
+	return out
+}
+
 func toAPIProbeThermo(src *engine.ProbeThermoDetails) *api.ProbeThermoV1 {
 	if src == nil {
 		return nil
@@ -28,6 +28,38 @@ func FormatBaseRowTSV(p engine.Product) string {
 	)
 }
 
+// IndelsTSVHeader names the --max-indels columns of text output.
+const IndelsTSVHeader = "fwd_indels\trev_indels"
+
+// FormatIndelsTSV returns the --max-indels columns: the gaps of each primer
+// site as a comma-separated list of "ins<pos>"/"del<pos>" (0-based primer
+// positions, see primer.Indel).
+func FormatIndelsTSV(p engine.Product) string {
+	return indelsCSV(p.FwdIndels) + "\t" + indelsCSV(p.RevIndels)
+}
+
+// BisulfiteTSVHeader names the --bisulfite columns of text output.
+const BisulfiteTSVHeader = "bisulfite_strand\tmethylation"
+
+// VariantsTSVHeader names the --vcf columns of text output.
+const VariantsTSVHeader = "alt_allele\tvariants"
+
+// FormatVariantsTSV returns the --vcf columns: the allele a product needs
+// (see engine.Product.AltAllele) and its variant hits as a comma-separated
+// list of "allele@site:primer_pos:effect", with ":3p" added for hits in
+// the 3' window.
+func FormatVariantsTSV(p engine.Product) string {
+	hits := make([]string, len(p.Variants))
+	for i, h := range p.Variants {
+		a := engine.Allele{ID: h.ID, Pos: h.Pos, Ref: h.Ref, Alt: h.Alt}
+		hits[i] = fmt.Sprintf("%s@%s:%d:%s", a.Label(), h.Site, h.PrimerPos, h.Effect)
+		if h.ThreePrime {
+			hits[i] += ":3p"
+		}
+	}
+	return p.AltAllele + "\t" + strings.Join(hits, ",")
+}
+
 // NEW: append score as a trailing column (no trailing newline).
 func FormatRowTSVWithScore(p engine.Product) string {
 	base := FormatBaseRowTSV(p)
@@ -0,0 +1,129 @@
+// internal/pipeline/alleles.go
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"ipcr-core/engine"
+	"ipcr-core/fasta"
+	"ipcr-core/primer"
+	"ipcr/internal/common"
+	"sort"
+)
+
+// AlleleLookup returns the alleles on record seqID whose REF overlaps
+// [start, end) (see vcf.Set.Overlap).
+type AlleleLookup func(seqID string, start, end int) []engine.Allele
+
+// SiteFinder returns the start positions of the candidate primer sites on
+// seq in ascending order.
+type SiteFinder func(seq []byte) []int
+
+// AlleleSource passes on every input of src followed by one window per
+// allele lookup finds in it: the allele applied with flank reference bases
+// either side, clipped to the input and named "id:start-end" in
+// record-global reference coordinates like RegionSource. Only alleles with a
+// primer site (sites) starting in their window are rescanned, as a product
+// needs a partner site there; products whose both primer sites lie on the
+// allele are not looked for. Alleles whose REF does not match the sequence,
+// or runs past the input, are skipped. Windows keep the soft-mask only for
+// substitutions. The windows are linear, so a product wrapping a circular
+// record is not looked for on them.
+func AlleleSource(src Source, lookup AlleleLookup, flank int, sites SiteFinder) Source {
+	return func(ctx context.Context, emit func(Input) error) error {
+		return src(ctx, func(in Input) error {
+			if err := emit(in); err != nil {
+				return err
+			}
+			base, off, ok := common.SplitChunkSuffix(in.Rec.ID)
+			if !ok {
+				base, off = in.Rec.ID, 0
+			}
+			end := off + len(in.Rec.Seq)
+			alleles := lookup(base, off, end)
+			if len(alleles) == 0 {
+				return nil
+			}
+			starts := sites(in.Rec.Seq)
+			for _, a := range alleles {
+				if a.Pos < off || a.Pos+len(a.Ref) > end || !bytes.EqualFold(in.Rec.Seq[a.Pos-off:a.Pos-off+len(a.Ref)], []byte(a.Ref)) {
+					continue
+				}
+				ws, we := max(off, a.Pos-flank), min(end, a.Pos+len(a.Ref)+flank)
+				if i := sort.SearchInts(starts, ws-off); i == len(starts) || starts[i] >= we-off {
+					continue
+				}
+				ref := in.Rec.Seq[ws-off : we-off]
+				at := a.Pos - ws
+				seq := make([]byte, 0, len(ref)-len(a.Ref)+len(a.Alt))
+				seq = append(seq, ref[:at]...)
+				seq = append(seq, a.Alt...)
+				seq = append(seq, ref[at+len(a.Ref):]...)
+				alt := Input{
+					Rec:        fasta.Record{ID: fmt.Sprintf("%s:%d-%d", base, ws, we), Seq: seq},
+					SourceFile: in.SourceFile,
+					Allele:     &a,
+					AlleleAt:   at,
+					Reference:  ref,
+				}
+				if len(a.Ref) == len(a.Alt) && in.Rec.Mask.Any() {
+					alt.Rec.Mask = in.Rec.Mask.Slice(ws-off, we-off)
+				}
+				if err := emit(alt); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+// siteKey is a product's place in window-local reference coordinates.
+type siteKey struct {
+	start, end int
+	typ, exp   string
+}
+
+// applyAllele maps a product found on an alternate-haplotype window back to
+// reference coordinates and labels it with the allele. Only products with a
+// primer site on the allele (or, for an indel, on the junction after it)
+// that do not form on the reference window (onRef) are kept; it reports
+// whether p is kept.
+func applyAllele(p *engine.Product, in Input, onRef map[siteKey]bool) bool {
+	if in.Allele == nil {
+		return true
+	}
+	if p.Start > p.End {
+		return false
+	}
+	a := in.Allele
+	lo, hi := in.AlleleAt, in.AlleleAt+len(a.Alt)
+	if len(a.Ref) != len(a.Alt) {
+		hi++
+	}
+	left := primer.SiteLen(len(p.FwdPrimer), p.FwdIndels)
+	right := primer.SiteLen(len(p.RevPrimer), p.RevIndels)
+	if (p.Start >= hi || p.Start+left <= lo) && (p.End-right >= hi || p.End <= lo) {
+		return false
+	}
+	p.Start, p.End = refPos(p.Start, in.AlleleAt, len(a.Alt), len(a.Ref)), refPos(p.End, in.AlleleAt, len(a.Alt), len(a.Ref))
+	if onRef[siteKey{p.Start, p.End, p.Type, p.ExperimentID}] {
+		return false
+	}
+	p.AltAllele = a.Label()
+	return true
+}
+
+// refPos maps window position q from the alternate haplotype (alt bases at
+// at) to the reference (ref bases at at).
+func refPos(q, at, altLen, refLen int) int {
+	switch {
+	case q <= at:
+		return q
+	case q >= at+altLen:
+		return q - altLen + refLen
+	default:
+		return at + min(q-at, refLen)
+	}
+}
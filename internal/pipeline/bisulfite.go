@@ -0,0 +1,72 @@
+// internal/pipeline/bisulfite.go
+package pipeline
+
+import (
+	"context"
+	"ipcr-core/bisulfite"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+)
+
+// BisulfiteSource passes on the four bisulfite-converted templates of every
+// input from src (see bisulfite.Conversions) in place of the input itself.
+// CpG context is that of the emitted record, so a CpG split by a chunk or
+// region edge counts as unmethylable.
+func BisulfiteSource(src Source) Source {
+	return func(ctx context.Context, emit func(Input) error) error {
+		return src(ctx, func(in Input) error {
+			for _, c := range bisulfite.Conversions {
+				conv := in
+				conv.Rec.Seq = bisulfite.Convert(in.Rec.Seq, c)
+				conv.Conversion = &bisulfite.Conversion{Strand: c.Strand, Methylated: c.Methylated}
+				conv.Original = in.Rec.Seq
+				if in.Reference != nil {
+					conv.Reference = bisulfite.Convert(in.Reference, c)
+				}
+				conv.Seeds = nil // seeds index the unconverted sequence
+				if err := emit(conv); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+// applyBisulfite labels a product found on a converted template with its
+// strand and methylation state, in record-local coordinates. A product
+// without a CpG under its primers is found identically on the methylated
+// and unmethylated templates; it is kept once, from the unmethylated one,
+// as methylation-independent. It reports whether the product is kept.
+func applyBisulfite(p *engine.Product, in Input) bool {
+	if in.Conversion == nil {
+		return true
+	}
+	left := primer.SiteLen(len(p.FwdPrimer), p.FwdIndels)
+	right := primer.SiteLen(len(p.RevPrimer), p.RevIndels)
+	if !cpgIn(in.Original, in.Conversion.Strand, p.Start, left) && !cpgIn(in.Original, in.Conversion.Strand, p.End-right, right) {
+		if in.Conversion.Methylated {
+			return false
+		}
+		p.Methylation = bisulfite.Any
+	} else {
+		p.Methylation = in.Conversion.State()
+	}
+	p.BisulfiteStrand = string(in.Conversion.Strand)
+	return true
+}
+
+// cpgIn reports whether the n bases from start (wrapping, for circular
+// records) hold a methylable CpG base on strand s.
+func cpgIn(seq []byte, s bisulfite.Strand, start, n int) bool {
+	if len(seq) == 0 {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		j := ((start+i)%len(seq) + len(seq)) % len(seq)
+		if bisulfite.CpG(seq, s, j) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+// internal/pipeline/mask.go
+package pipeline
+
+import (
+	"fmt"
+	"ipcr-core/engine"
+	"ipcr-core/fasta"
+	"ipcr-core/primer"
+)
+
+// MaskPolicy says how soft-masked (lowercase) reference bases affect results.
+type MaskPolicy string
+
+const (
+	MaskIgnore   MaskPolicy = "ignore"            // lowercase is scanned like uppercase
+	MaskNoPrimer MaskPolicy = "no-primer-in-mask" // drop results with a primer site on masked bases
+	MaskReport   MaskPolicy = "report"            // count masked bases under each primer site
+)
+
+// ParseMaskPolicy validates a --mask-policy value; "" means MaskIgnore.
+func ParseMaskPolicy(s string) (MaskPolicy, error) {
+	switch p := MaskPolicy(s); p {
+	case "":
+		return MaskIgnore, nil
+	case MaskIgnore, MaskNoPrimer, MaskReport:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid --mask-policy %q (want ignore, no-primer-in-mask or report)", s)
+}
+
+// applyProductMask applies policy to a product found in a record with mask m,
+// in record-local coordinates. It reports whether the product is kept.
+func applyProductMask(p *engine.Product, m fasta.Mask, policy MaskPolicy) bool {
+	if policy == "" || policy == MaskIgnore || !m.Any() {
+		return true
+	}
+	left := primer.SiteLen(len(p.FwdPrimer), p.FwdIndels)
+	right := primer.SiteLen(len(p.RevPrimer), p.RevIndels)
+	fwd, rev := m.Count(p.Start, p.Start+left), m.Count(p.End-right, p.End)
+	if policy == MaskNoPrimer {
+		return fwd == 0 && rev == 0
+	}
+	p.FwdMasked, p.RevMasked = fwd, rev
+	return true
+}
+
+// applySiteMask is applyProductMask for a single binding site.
+func applySiteMask(s *engine.BindingSite, m fasta.Mask, policy MaskPolicy) bool {
+	if policy == "" || policy == MaskIgnore || !m.Any() {
+		return true
+	}
+	n := m.Count(s.Start, s.End)
+	if policy == MaskNoPrimer {
+		return n == 0
+	}
+	s.Masked = n
+	return true
+}
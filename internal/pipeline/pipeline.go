@@ -4,7 +4,6 @@ package pipeline
 import (
 	"context"
 	"ipcr-core/engine"
-	"ipcr-core/fasta"
 	"ipcr-core/primer"
 	"ipcr/internal/common"
 	"ipcr/internal/runutil"
@@ -19,6 +18,13 @@ type Config struct {
 	Circular  bool // treat sequences as circular
 	NeedSeq   bool // fill Product.Seq by slicing record sequence
 	DedupCap  int  // NEW: capacity for LRU de-dup window (0=default)
+
+	// MaskPolicy applies to inputs carrying a soft-mask (see MaskedFASTASource).
+	MaskPolicy MaskPolicy
+
+	// Source overrides the FASTA inputs (e.g. a prebuilt reference index).
+	// When nil, seqFiles are streamed with ChunkSize/Overlap.
+	Source Source
 }
 
 // Key uniquely identifies a product in reference-global coordinates to
@@ -27,6 +33,8 @@ type Key struct {
 	Base, File string
 	Start, End int
 	Type, Exp  string
+	Bisulfite  string // converted strand and methylation state, if any
+	AltAllele  string // the allele an alternate-haplotype product needs, if any
 }
 
 // ForEachProduct ...
@@ -42,16 +50,13 @@ func ForEachProduct(
 		cfg.Threads = 1
 	}
 
-	type job struct {
-		rec        fasta.Record
-		sourceFile string
-	}
-	jobs := make(chan job, cfg.Threads*2)
+	jobs := make(chan Input, cfg.Threads*2)
 	results := make(chan engine.Product, cfg.Threads*2)
 
 	compiledSim, useCompiled := sim.(CompiledSimulator)
 	scratchCompiledSim, useScratchCompiled := sim.(ScratchCompiledSimulator)
 	streamingSim, useStreaming := sim.(StreamingCompiledSimulator)
+	indexedSim, useIndexed := sim.(IndexedCompiledSimulator)
 	var compiledPanel *engine.CompiledPanel
 	if useCompiled {
 		compiledPanel = compiledSim.CompilePanel(pairs)
@@ -67,6 +72,33 @@ func ForEachProduct(
 			if useScratchCompiled {
 				scratch = scratchCompiledSim.NewSimulationScratch(compiledPanel)
 			}
+			scan := func(id string, seq []byte, seeds engine.SeedLocator, send func(engine.Product) error) error {
+				switch {
+				case useIndexed && seeds != nil:
+					return indexedSim.ForEachCompiledProductIndexed(id, seq, compiledPanel, scratch, seeds, send)
+				case useStreaming:
+					return streamingSim.ForEachCompiledProduct(id, seq, compiledPanel, scratch, send)
+				case useScratchCompiled:
+					for _, p := range scratchCompiledSim.SimulateCompiledWithScratch(id, seq, compiledPanel, scratch) {
+						if err := send(p); err != nil {
+							return err
+						}
+					}
+				case useCompiled:
+					for _, p := range compiledSim.SimulateCompiled(id, seq, compiledPanel) {
+						if err := send(p); err != nil {
+							return err
+						}
+					}
+				default:
+					for _, p := range sim.SimulateBatch(id, seq, pairs) {
+						if err := send(p); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			}
 
 			for {
 				select {
@@ -77,16 +109,38 @@ func ForEachProduct(
 						return
 					}
 
+					// Products on an alternate-haplotype window count only
+					// where they do not form on the reference window.
+					var onRef map[siteKey]bool
+					if j.Allele != nil {
+						onRef = make(map[siteKey]bool)
+						if err := scan(j.Rec.ID, j.Reference, nil, func(p engine.Product) error {
+							onRef[siteKey{p.Start, p.End, p.Type, p.ExperimentID}] = true
+							return nil
+						}); err != nil {
+							return
+						}
+					}
+
 					sendProduct := func(p engine.Product) error {
+						if !applyProductMask(&p, j.Rec.Mask, cfg.MaskPolicy) {
+							return nil
+						}
+						if !applyBisulfite(&p, j) {
+							return nil
+						}
 						if cfg.NeedSeq {
 							if cfg.Circular && p.Start > p.End {
-								seqBytes := j.rec.Seq
+								seqBytes := j.Rec.Seq
 								p.Seq = string(seqBytes[p.Start:]) + string(seqBytes[:p.End])
 							} else {
-								p.Seq = string(j.rec.Seq[p.Start:p.End])
+								p.Seq = string(j.Rec.Seq[p.Start:p.End])
 							}
 						}
-						p.SourceFile = j.sourceFile
+						if !applyAllele(&p, j, onRef) {
+							return nil
+						}
+						p.SourceFile = j.SourceFile
 						select {
 						case results <- p:
 							return nil
@@ -94,30 +148,8 @@ func ForEachProduct(
 							return ctx.Err()
 						}
 					}
-
-					switch {
-					case useStreaming:
-						if err := streamingSim.ForEachCompiledProduct(j.rec.ID, j.rec.Seq, compiledPanel, scratch, sendProduct); err != nil {
-							return
-						}
-					case useScratchCompiled:
-						for _, p := range scratchCompiledSim.SimulateCompiledWithScratch(j.rec.ID, j.rec.Seq, compiledPanel, scratch) {
-							if err := sendProduct(p); err != nil {
-								return
-							}
-						}
-					case useCompiled:
-						for _, p := range compiledSim.SimulateCompiled(j.rec.ID, j.rec.Seq, compiledPanel) {
-							if err := sendProduct(p); err != nil {
-								return
-							}
-						}
-					default:
-						for _, p := range sim.SimulateBatch(j.rec.ID, j.rec.Seq, pairs) {
-							if err := sendProduct(p); err != nil {
-								return
-							}
-						}
+					if err := scan(j.Rec.ID, j.Rec.Seq, j.Seeds, sendProduct); err != nil {
+						return
 					}
 				}
 			}
@@ -143,7 +175,8 @@ func ForEachProduct(
 				off = 0
 			}
 			gs, ge := p.Start+off, p.End+off
-			k := Key{Base: base, File: p.SourceFile, Start: gs, End: ge, Type: p.Type, Exp: p.ExperimentID}
+			k := Key{Base: base, File: p.SourceFile, Start: gs, End: ge, Type: p.Type, Exp: p.ExperimentID,
+				Bisulfite: p.BisulfiteStrand + "/" + p.Methylation, AltAllele: p.AltAllele}
 			if seen.Add(k) { // already seen recently
 				continue
 			}
@@ -161,26 +194,18 @@ func ForEachProduct(
 	}()
 
 	// Feed work
-feed:
-	for _, fa := range seqFiles {
-		err := fasta.StreamChunksPathCtx(ctx, fa, cfg.ChunkSize, cfg.Overlap, func(rec fasta.Record) error {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case jobs <- job{rec: rec, sourceFile: fa}:
-				return nil
-			}
-		})
-		if err != nil {
-			if ctx.Err() != nil {
-				break feed
-			}
-			if cerr == nil {
-				cerr = err
-			}
-			continue
-		}
+	src := cfg.Source
+	if src == nil {
+		src = FASTASource(seqFiles, cfg.ChunkSize, cfg.Overlap)
 	}
+	ferr := src(ctx, func(in Input) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case jobs <- in:
+			return nil
+		}
+	})
 
 	close(jobs)
 	wg.Wait()
@@ -190,5 +215,8 @@ feed:
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
+	if cerr == nil {
+		cerr = ferr
+	}
 	return cerr
 }
@@ -0,0 +1,91 @@
+// internal/pipeline/rt.go
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"ipcr-core/fasta"
+	"ipcr-core/rt"
+)
+
+// RNASource reads RNA (or cDNA) FASTA files, rewrites U as T and passes on
+// the parts of each record that pr copies into first-strand cDNA. Partial
+// segments are named "id:start-end" so products come back in record
+// coordinates.
+func RNASource(files []string, pr rt.Priming) Source {
+	return func(ctx context.Context, emit func(Input) error) error {
+		return fastaSource(files, 0, 0, fasta.StreamChunksPathCtx)(ctx, func(in Input) error {
+			rt.ToDNA(in.Rec.Seq)
+			return emitCDNA(in, pr, emit)
+		})
+	}
+}
+
+// TranscriptSource streams genome FASTA files. Each record is passed on as
+// genomic DNA (unless noGDNA), followed by the primed cDNA of every
+// transcript on it, spliced from the record and named by transcript ID with
+// SourceFile set to gtf. Transcripts that cannot be spliced, or whose
+// chromosome never appears, are reported to warn and skipped.
+func TranscriptSource(genome []string, ts []rt.Transcript, gtf string, pr rt.Priming, noGDNA bool, warn func(string)) Source {
+	byChrom := make(map[string][]rt.Transcript)
+	for _, t := range ts {
+		byChrom[t.Chrom] = append(byChrom[t.Chrom], t)
+	}
+	return func(ctx context.Context, emit func(Input) error) error {
+		seen := make(map[string]bool)
+		err := fastaSource(genome, 0, 0, fasta.StreamChunksPathCtx)(ctx, func(in Input) error {
+			if !noGDNA {
+				if err := emit(in); err != nil {
+					return err
+				}
+			}
+			if seen[in.Rec.ID] {
+				return nil // a record repeated across genome files is spliced once
+			}
+			seen[in.Rec.ID] = true
+			for _, t := range byChrom[in.Rec.ID] {
+				seq, err := t.Splice(in.Rec.Seq)
+				if err != nil {
+					warn(err.Error())
+					continue
+				}
+				cdna := Input{Rec: fasta.Record{ID: t.ID, Seq: seq}, SourceFile: gtf}
+				if err := emitCDNA(cdna, pr, emit); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		missing := 0
+		for chrom, list := range byChrom {
+			if !seen[chrom] {
+				missing += len(list)
+			}
+		}
+		if missing > 0 {
+			warn(fmt.Sprintf("%d transcript(s) lie on records missing from the genome and were skipped", missing))
+		}
+		return nil
+	}
+}
+
+// emitCDNA passes on the cDNA segments of in, whole records unchanged.
+func emitCDNA(in Input, pr rt.Priming, emit func(Input) error) error {
+	segs, err := pr.Segments(in.Rec.Seq)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in.Rec.ID, err)
+	}
+	for _, s := range segs {
+		sub := in
+		if s.Start > 0 || s.End < len(in.Rec.Seq) {
+			sub.Rec = fasta.Record{ID: fmt.Sprintf("%s:%d-%d", in.Rec.ID, s.Start, s.End), Seq: in.Rec.Seq[s.Start:s.End]}
+		}
+		if err := emit(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -37,3 +37,19 @@ type StreamingCompiledSimulator interface {
 	ScratchCompiledSimulator
 	ForEachCompiledProduct(seqID string, seq []byte, cp *engine.CompiledPanel, scratch *engine.SimulationScratch, emit func(engine.Product) error) error
 }
+
+// IndexedCompiledSimulator is an optional streaming fast path that takes seed
+// hits from a prebuilt index (Input.Seeds) instead of a linear automaton pass.
+type IndexedCompiledSimulator interface {
+	StreamingCompiledSimulator
+	ForEachCompiledProductIndexed(seqID string, seq []byte, cp *engine.CompiledPanel, scratch *engine.SimulationScratch, seeds engine.SeedLocator, emit func(engine.Product) error) error
+}
+
+// SiteSimulator is the capability ForEachSite needs: a compiled panel whose
+// per-primer binding sites are streamed without joining them into products.
+type SiteSimulator interface {
+	CompilePanel(pairs []primer.Pair) *engine.CompiledPanel
+	NewSimulationScratch(cp *engine.CompiledPanel) *engine.SimulationScratch
+	ForEachCompiledSite(seqID string, seq []byte, cp *engine.CompiledPanel, scratch *engine.SimulationScratch, emit func(engine.BindingSite) error) error
+	ForEachCompiledSiteIndexed(seqID string, seq []byte, cp *engine.CompiledPanel, scratch *engine.SimulationScratch, seeds engine.SeedLocator, emit func(engine.BindingSite) error) error
+}
@@ -0,0 +1,139 @@
+// internal/pipeline/sites.go
+package pipeline
+
+import (
+	"context"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"ipcr/internal/common"
+	"ipcr/internal/runutil"
+	"sync"
+)
+
+// SiteKey uniquely identifies a binding site in reference-global coordinates
+// to deduplicate sites reported by two overlapping chunks.
+type SiteKey struct {
+	Base, File     string
+	Start, End     int
+	Primer, Strand string
+	Exp            string
+}
+
+// ForEachSite is the binding-site counterpart of ForEachProduct: every
+// single-primer site is visited once, in reference-global coordinates.
+// cfg.Circular and cfg.NeedSeq do not apply to sites.
+func ForEachSite(
+	ctx context.Context,
+	cfg Config,
+	seqFiles []string,
+	pairs []primer.Pair,
+	sim SiteSimulator,
+	visit func(engine.BindingSite) error,
+) error {
+	if cfg.Threads < 1 {
+		cfg.Threads = 1
+	}
+
+	jobs := make(chan Input, cfg.Threads*2)
+	results := make(chan engine.BindingSite, cfg.Threads*2)
+	cp := sim.CompilePanel(pairs)
+
+	var wg sync.WaitGroup
+	wg.Add(cfg.Threads)
+	for w := 0; w < cfg.Threads; w++ {
+		go func() {
+			defer wg.Done()
+			scratch := sim.NewSimulationScratch(cp)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j, ok := <-jobs:
+					if !ok {
+						return
+					}
+					send := func(s engine.BindingSite) error {
+						if !applySiteMask(&s, j.Rec.Mask, cfg.MaskPolicy) {
+							return nil
+						}
+						s.SourceFile = j.SourceFile
+						select {
+						case results <- s:
+							return nil
+						case <-ctx.Done():
+							return ctx.Err()
+						}
+					}
+					var err error
+					if j.Seeds != nil {
+						err = sim.ForEachCompiledSiteIndexed(j.Rec.ID, j.Rec.Seq, cp, scratch, j.Seeds, send)
+					} else {
+						err = sim.ForEachCompiledSite(j.Rec.ID, j.Rec.Seq, cp, scratch, send)
+					}
+					if err != nil {
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	var (
+		cerr error
+		cwg  sync.WaitGroup
+		seen = runutil.NewLRUSet[SiteKey](cfg.DedupCap)
+	)
+	cwg.Add(1)
+	go func() {
+		defer cwg.Done()
+		for s := range results {
+			if cerr != nil {
+				continue
+			}
+			base, off, ok := common.SplitChunkSuffix(s.SequenceID)
+			if !ok {
+				base = s.SequenceID
+				off = 0
+			}
+			gs, ge := s.Start+off, s.End+off
+			k := SiteKey{Base: base, File: s.SourceFile, Start: gs, End: ge, Primer: s.Primer, Strand: s.Strand, Exp: s.ExperimentID}
+			if seen.Add(k) {
+				continue
+			}
+			if ok {
+				s.SequenceID = base
+				s.Start = gs
+				s.End = ge
+			}
+			if err := visit(s); err != nil && cerr == nil {
+				cerr = err
+			}
+		}
+	}()
+
+	src := cfg.Source
+	if src == nil {
+		src = FASTASource(seqFiles, cfg.ChunkSize, cfg.Overlap)
+	}
+	ferr := src(ctx, func(in Input) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case jobs <- in:
+			return nil
+		}
+	})
+
+	close(jobs)
+	wg.Wait()
+	close(results)
+	cwg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if cerr == nil {
+		cerr = ferr
+	}
+	return cerr
+}
@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"context"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestForEachSiteChunkedMatchesWhole(t *testing.T) {
+	fwd := "ACGTACGGATTCAG"
+	site := string(primer.RevComp([]byte(fwd)))
+	seq := strings.Repeat("T", 40) + fwd + strings.Repeat("T", 37) + site + strings.Repeat("T", 25) + fwd + strings.Repeat("T", 30)
+	fn := filepath.Join(t.TempDir(), "ref.fa")
+	if err := os.WriteFile(fn, []byte(">s\n"+seq+"\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	pairs := []primer.Pair{{ID: "x", Forward: fwd, Reverse: "GGGGGGGGGGGG"}}
+	eng := engine.New(engine.Config{MaxMM: 1})
+
+	run := func(chunk, overlap int) []engine.BindingSite {
+		var out []engine.BindingSite
+		err := ForEachSite(context.Background(), Config{Threads: 2, ChunkSize: chunk, Overlap: overlap},
+			[]string{fn}, pairs, eng, func(s engine.BindingSite) error {
+				out = append(out, s)
+				return nil
+			})
+		if err != nil {
+			t.Fatalf("ForEachSite: %v", err)
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+		return out
+	}
+
+	whole := run(0, 0)
+	if len(whole) != 3 {
+		t.Fatalf("want 3 sites, got %+v", whole)
+	}
+	if got := run(50, len(fwd)-1); !reflect.DeepEqual(got, whole) {
+		t.Fatalf("chunked sites differ\n got: %+v\nwant: %+v", got, whole)
+	}
+}
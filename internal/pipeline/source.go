@@ -0,0 +1,209 @@
+// internal/pipeline/source.go
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"ipcr-core/bisulfite"
+	"ipcr-core/engine"
+	"ipcr-core/fasta"
+	"ipcr-core/refindex"
+	"ipcr/internal/common"
+	"ipcr/internal/regions"
+)
+
+// Input is one unit of scan work: a record (or chunk) and where it came from.
+type Input struct {
+	Rec        fasta.Record
+	SourceFile string
+	Seeds      engine.SeedLocator // optional prebuilt seed index covering Rec
+
+	// Conversion marks a bisulfite-converted template (BisulfiteSource);
+	// Original is the unconverted sequence of Rec, for CpG context.
+	Conversion *bisulfite.Conversion
+	Original   []byte
+
+	// Allele marks an alternate-haplotype window (AlleleSource): Rec holds
+	// the allele applied at AlleleAt, Reference the same window without it.
+	Allele    *engine.Allele
+	AlleleAt  int
+	Reference []byte
+}
+
+// Source feeds Inputs to the pipeline until exhausted or emit fails.
+type Source func(ctx context.Context, emit func(Input) error) error
+
+// FASTASource streams (optionally chunked) records from FASTA paths. A file
+// that fails to read does not stop later files; the first such error is
+// returned once every file has been tried.
+func FASTASource(seqFiles []string, chunkSize, overlap int) Source {
+	return fastaSource(seqFiles, chunkSize, overlap, fasta.StreamChunksPathCtx)
+}
+
+// MaskedFASTASource is FASTASource with each record's soft-masked (lowercase)
+// bases kept in Rec.Mask, for Config.MaskPolicy.
+func MaskedFASTASource(seqFiles []string, chunkSize, overlap int) Source {
+	return fastaSource(seqFiles, chunkSize, overlap, fasta.StreamMaskedChunksPathCtx)
+}
+
+func fastaSource(seqFiles []string, chunkSize, overlap int,
+	stream func(context.Context, string, int, int, func(fasta.Record) error) error,
+) Source {
+	return func(ctx context.Context, emit func(Input) error) error {
+		var first error
+		for _, fa := range seqFiles {
+			err := stream(ctx, fa, chunkSize, overlap, func(rec fasta.Record) error {
+				return emit(Input{Rec: rec, SourceFile: fa})
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if first == nil {
+					first = err
+				}
+			}
+		}
+		return first
+	}
+}
+
+// IndexSource feeds whole records from a reference index built by
+// `ipcr index build`, each carrying its k-mer table as the seed locator.
+func IndexSource(path string) Source {
+	return func(ctx context.Context, emit func(Input) error) error {
+		ix, err := refindex.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = ix.Close() }()
+
+		for i := range ix.Records {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			rec, err := ix.Load(i)
+			if err != nil {
+				return err
+			}
+			in := Input{
+				Rec:        fasta.Record{ID: rec.Info.ID, Seq: rec.Seq},
+				SourceFile: rec.Info.SourceFile,
+				Seeds:      rec,
+			}
+			if err := emit(in); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Preload collects every input of src, e.g. to keep a reference in memory
+// for many scans (MemorySource).
+func Preload(ctx context.Context, src Source) ([]Input, error) {
+	var out []Input
+	err := src(ctx, func(in Input) error {
+		out = append(out, in)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MemorySource replays preloaded inputs. Scans only read the records, so
+// one slice can feed concurrent scans.
+func MemorySource(inputs []Input) Source {
+	return func(ctx context.Context, emit func(Input) error) error {
+		for _, in := range inputs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := emit(in); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// UngapSource strips alignment gap characters ('-' and '.') from the
+// records of src, so an aligned FASTA scans as plain sequence in ungapped
+// coordinates. Records with gaps lose their seed index and soft-mask.
+func UngapSource(src Source) Source {
+	return func(ctx context.Context, emit func(Input) error) error {
+		return src(ctx, func(in Input) error {
+			if bytes.IndexAny(in.Rec.Seq, "-.") < 0 {
+				return emit(in)
+			}
+			seq := make([]byte, 0, len(in.Rec.Seq))
+			for _, b := range in.Rec.Seq {
+				if b != '-' && b != '.' {
+					seq = append(seq, b)
+				}
+			}
+			in.Rec = fasta.Record{ID: in.Rec.ID, Seq: seq}
+			in.Seeds = nil
+			return emit(in)
+		})
+	}
+}
+
+// RegionSource restricts src to BED intervals: the include intervals (whole
+// records when include is nil) minus the exclude intervals. Records without an
+// include interval are dropped. Every kept span is passed on as its own record
+// named "id:start-end" in record-global coordinates, so results come back
+// record-global through the usual chunk-suffix handling; inputs kept whole
+// pass through unchanged.
+func RegionSource(src Source, include, exclude *regions.Set) Source {
+	return func(ctx context.Context, emit func(Input) error) error {
+		return src(ctx, func(in Input) error {
+			base, off, ok := common.SplitChunkSuffix(in.Rec.ID)
+			if !ok {
+				base, off = in.Rec.ID, 0
+			}
+			end := off + len(in.Rec.Seq)
+			spans := regions.Spans(include, exclude, base, off, end)
+			if len(spans) == 1 && spans[0] == (regions.Interval{Start: off, End: end}) {
+				return emit(in)
+			}
+			for _, sp := range spans {
+				sub := in
+				sub.Rec = fasta.Record{
+					ID:  fmt.Sprintf("%s:%d-%d", base, sp.Start, sp.End),
+					Seq: in.Rec.Seq[sp.Start-off : sp.End-off],
+				}
+				if in.Rec.Mask.Any() {
+					sub.Rec.Mask = in.Rec.Mask.Slice(sp.Start-off, sp.End-off)
+				}
+				if in.Seeds != nil {
+					sub.Seeds = windowLocator{loc: in.Seeds, start: sp.Start - off, end: sp.End - off}
+				}
+				if err := emit(sub); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+// windowLocator narrows a seed locator to seq[start:end], reporting hits
+// relative to start.
+type windowLocator struct {
+	loc        engine.SeedLocator
+	start, end int
+}
+
+func (w windowLocator) MinSeedLen() int { return w.loc.MinSeedLen() }
+
+func (w windowLocator) ForEachOccurrence(pat []byte, fn func(start int)) {
+	w.loc.ForEachOccurrence(pat, func(p int) {
+		if p >= w.start && p+len(pat) <= w.end {
+			fn(p - w.start)
+		}
+	})
+}
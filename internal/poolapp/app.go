@@ -0,0 +1,302 @@
+// internal/poolapp/app.go
+package poolapp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/multiplex"
+	"ipcr-core/primer"
+	"ipcr-core/thermo"
+	"ipcr/internal/clibase"
+	"ipcr/internal/cmdutil"
+	"ipcr/internal/jsonutil"
+	"ipcr/internal/output"
+	"ipcr/internal/poolcli"
+	"ipcr/internal/version"
+	"ipcr/internal/writers"
+	"ipcr/pkg/api"
+	"strings"
+)
+
+func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer) int {
+	outw := bufio.NewWriter(stdout)
+	defer func() { _ = outw.Flush() }()
+
+	fs := poolcli.NewFlagSet("ipcr-pool")
+	fs.SetOutput(io.Discard)
+
+	if len(argv) == 0 {
+		_, _ = poolcli.ParseArgs(fs, []string{"-h"})
+		fs.SetOutput(outw)
+		fs.Usage()
+		if err := outw.Flush(); writers.IsBrokenPipe(err) {
+			return 0
+		} else if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 3
+		}
+		return 0
+	}
+
+	opts, err := poolcli.ParseArgs(fs, argv)
+	if err != nil {
+		if errors.Is(err, clibase.ErrPrintedAndExitOK) {
+			poolcli.PrintExamples(outw)
+			if e := outw.Flush(); writers.IsBrokenPipe(e) {
+				return 0
+			} else if e != nil {
+				_, _ = fmt.Fprintln(stderr, e)
+				return 3
+			}
+			return 0
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(outw)
+			fs.Usage()
+			if e := outw.Flush(); writers.IsBrokenPipe(e) {
+				return 0
+			} else if e != nil {
+				_, _ = fmt.Fprintln(stderr, e)
+				return 3
+			}
+			return 0
+		}
+		_, _ = fmt.Fprintln(stderr, err)
+		fs.SetOutput(outw)
+		fs.Usage()
+		if e := outw.Flush(); writers.IsBrokenPipe(e) {
+			return 0
+		} else if e != nil {
+			_, _ = fmt.Fprintln(stderr, e)
+			return 3
+		}
+		return 2
+	}
+
+	if opts.Version {
+		version.Write(outw, "ipcr-pool")
+		if e := outw.Flush(); writers.IsBrokenPipe(e) {
+			return 0
+		} else if e != nil {
+			_, _ = fmt.Fprintln(stderr, e)
+			return 3
+		}
+		return 0
+	}
+
+	mopt, err := options(opts)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+	pairs, err := primer.LoadTSV(opts.PrimerFile)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if len(pairs) == 0 {
+		_, _ = fmt.Fprintln(stderr, "no primer pairs loaded")
+		return 2
+	}
+	if opts.NoProbes {
+		for i := range pairs {
+			pairs[i].Probes = nil
+		}
+	}
+	if opts.Pools > len(pairs) {
+		cmdutil.Warnf(stderr, opts.Quiet, "--pools %d exceeds the %d panel assays", opts.Pools, len(pairs))
+	}
+	if parent.Err() != nil {
+		return 130
+	}
+
+	m, err := multiplex.Analyze(multiplex.Oligos(pairs), mopt)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+	rep := report(pairs, m, mopt, opts)
+
+	if opts.Output == output.FormatJSON {
+		err = jsonutil.EncodePretty(outw, rep)
+	} else {
+		err = writeText(outw, rep)
+	}
+	if err == nil {
+		err = outw.Flush()
+	}
+	if writers.IsBrokenPipe(err) {
+		return 0
+	} else if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 3
+	}
+	return 0
+}
+
+func Run(argv []string, stdout, stderr io.Writer) int {
+	return RunContext(context.Background(), argv, stdout, stderr)
+}
+
+func options(o poolcli.Options) (multiplex.Options, error) {
+	cond := thermo.DefaultConditions()
+	cond.AnnealC = o.TempC
+	for _, f := range []struct {
+		name, spec string
+		dst        *float64
+	}{
+		{"--na", o.NaSpec, &cond.NaM},
+		{"--mg", o.MgSpec, &cond.MgM},
+		{"--dntp", o.DntpSpec, &cond.DntpM},
+		{"--primer-conc", o.PrimerConcSpec, &cond.PrimerTotalM},
+	} {
+		v, err := thermo.ParseConc(f.spec)
+		if err != nil {
+			return multiplex.Options{}, fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.dst = v
+	}
+	model, err := thermo.ParseSaltModel(o.SaltModel)
+	if err != nil {
+		return multiplex.Options{}, fmt.Errorf("--salt-model: %w", err)
+	}
+	cond.SaltModel = model
+	opt := multiplex.DefaultOptions(cond)
+	opt.ThresholdKcal = o.DimerDG
+	opt.MaxExpansions = o.MaxExpansions
+	return opt, nil
+}
+
+// report assembles the matrix, the dimers that count toward the burden and
+// the suggested pools.
+func report(pairs []primer.Pair, m multiplex.Matrix, opt multiplex.Options, o poolcli.Options) api.PoolAnalysisV1 {
+	n := len(m.Oligos)
+	rep := api.PoolAnalysisV1{
+		TempC:         opt.Structure.Conditions.AnnealC,
+		ThresholdKcal: opt.ThresholdKcal,
+		Oligos:        make([]api.PoolOligoV1, n),
+		DeltaG:        make([][]*float64, n),
+		TmC:           make([][]*float64, n),
+		ThreePrime:    make([][]bool, n),
+		Dimers:        []api.PoolDimerV1{},
+	}
+	for i, ol := range m.Oligos {
+		rep.Oligos[i] = api.PoolOligoV1{ID: ol.ID, Assay: ol.Assay, Role: ol.Role, Seq: ol.Seq}
+		rep.DeltaG[i] = make([]*float64, n)
+		rep.TmC[i] = make([]*float64, n)
+		rep.ThreePrime[i] = make([]bool, n)
+		for j, d := range m.Cells[i] {
+			if !d.Found {
+				continue
+			}
+			dg, tm := d.DeltaGKcal, d.TmC
+			rep.DeltaG[i][j], rep.TmC[i][j] = &dg, &tm
+			rep.ThreePrime[i][j] = d.ThreePrime
+			if b := opt.Burden(d); j >= i && b > 0 {
+				rep.Dimers = append(rep.Dimers, api.PoolDimerV1{
+					A: ol.ID, B: m.Oligos[j].ID, DeltaG: dg, TmC: tm, ThreePrime: d.ThreePrime, Burden: b,
+				})
+			}
+		}
+	}
+
+	assays := make([]string, len(pairs))
+	for i, p := range pairs {
+		assays[i] = p.ID
+	}
+	cost := m.AssayBurden(assays, opt)
+	pool, _ := multiplex.Partition(cost, o.Pools, o.MaxPoolSize)
+	rep.SinglePoolBurden = poolBurden(cost, make([]int, len(assays)), 0)
+	for k := 0; k < o.Pools; k++ {
+		p := api.PoolV1{Pool: k + 1, Assays: []string{}}
+		for i, a := range assays {
+			if pool[i] == k {
+				p.Assays = append(p.Assays, a)
+			}
+		}
+		if len(p.Assays) == 0 {
+			continue
+		}
+		p.Burden = poolBurden(cost, pool, k)
+		rep.SplitBurden += p.Burden
+		rep.Pools = append(rep.Pools, p)
+	}
+	return rep
+}
+
+// poolBurden sums the assay burden inside pool k, counting each assay's own
+// oligo interactions once.
+func poolBurden(cost [][]float64, pool []int, k int) float64 {
+	var b float64
+	for i := range cost {
+		if pool[i] != k {
+			continue
+		}
+		b += cost[i][i]
+		for j := i + 1; j < len(cost); j++ {
+			if pool[j] == k {
+				b += cost[i][j]
+			}
+		}
+	}
+	return b
+}
+
+// writeText emits the ΔG and Tm matrices, the burden-counted dimers and the
+// suggested pools as '#'-titled TSV blocks separated by blank lines.
+func writeText(w io.Writer, rep api.PoolAnalysisV1) error {
+	var b strings.Builder
+	matrix := func(title string, cell func(i, j int) string) {
+		b.WriteString(title)
+		b.WriteString("\noligo")
+		for _, o := range rep.Oligos {
+			b.WriteString("\t" + o.ID)
+		}
+		b.WriteByte('\n')
+		for i, o := range rep.Oligos {
+			b.WriteString(o.ID)
+			for j := range rep.Oligos {
+				b.WriteString("\t" + cell(i, j))
+			}
+			b.WriteByte('\n')
+		}
+	}
+	// Only dimers at or below the threshold are shown; the rest do not
+	// count toward the burden.
+	counted := func(i, j int) bool {
+		return rep.DeltaG[i][j] != nil && *rep.DeltaG[i][j] <= rep.ThresholdKcal
+	}
+	matrix(fmt.Sprintf("# ΔG (kcal/mol) at %g °C; * = 3'-anchored, . = no dimer at or below %g kcal/mol", rep.TempC, rep.ThresholdKcal), func(i, j int) string {
+		if !counted(i, j) {
+			return "."
+		}
+		s := fmt.Sprintf("%.2f", *rep.DeltaG[i][j])
+		if rep.ThreePrime[i][j] {
+			s += "*"
+		}
+		return s
+	})
+	b.WriteByte('\n')
+	matrix("# Tm (°C)", func(i, j int) string {
+		if !counted(i, j) {
+			return "."
+		}
+		return fmt.Sprintf("%.1f", *rep.TmC[i][j])
+	})
+
+	fmt.Fprintf(&b, "\n# Dimers at or below %g kcal/mol\na\tb\tdg_kcal\ttm_c\tthree_prime\tburden\n", rep.ThresholdKcal)
+	for _, d := range rep.Dimers {
+		fmt.Fprintf(&b, "%s\t%s\t%.2f\t%.1f\t%t\t%.2f\n", d.A, d.B, d.DeltaG, d.TmC, d.ThreePrime, d.Burden)
+	}
+
+	fmt.Fprintf(&b, "\n# Suggested pools: burden %.2f in one pool, %.2f split\npool\tassays\tburden\n", rep.SinglePoolBurden, rep.SplitBurden)
+	for _, p := range rep.Pools {
+		fmt.Fprintf(&b, "%d\t%s\t%.2f\n", p.Pool, strings.Join(p.Assays, ","), p.Burden)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,161 @@
+package poolcli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/multiplex"
+	"ipcr-core/thermo"
+	"ipcr/internal/clibase"
+	"ipcr/internal/cliutil"
+	"ipcr/internal/output"
+)
+
+type Options struct {
+	PrimerFile string
+
+	TempC          float64
+	NaSpec         string
+	MgSpec         string
+	DntpSpec       string
+	PrimerConcSpec string
+	SaltModel      string
+
+	DimerDG       float64
+	Pools         int
+	MaxPoolSize   int
+	NoProbes      bool
+	MaxExpansions int
+
+	Output  string // text|json
+	Quiet   bool
+	Version bool
+}
+
+func NewFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		def := func(flagName string) string {
+			if f := fs.Lookup(flagName); f != nil {
+				return f.DefValue
+			}
+			return ""
+		}
+		clibase.UsageHeader(out, name)
+		_, _ = fmt.Fprintln(out, "Usage:")
+		_, _ = fmt.Fprintf(out, "  %s [options] --primers panel.tsv > matrix.tsv\n", name)
+		_, _ = fmt.Fprintf(out, "  %s [options] panel.tsv\n", name)
+
+		_, _ = fmt.Fprintln(out, "\nInput:")
+		_, _ = fmt.Fprintln(out, "  -p, --primers file          Multiplex panel TSV (primers and probes)")
+		_, _ = fmt.Fprintf(out, "      --no-probes             Leave panel probes out of the matrix [%s]\n", def("no-probes"))
+		_, _ = fmt.Fprintf(out, "      --max-expansions int    IUPAC variants tried per oligo [%s]\n", def("max-expansions"))
+
+		_, _ = fmt.Fprintln(out, "\nDimers:")
+		_, _ = fmt.Fprintf(out, "      --temp float            Temperature dimer ΔG is evaluated at (°C) [%s]\n", def("temp"))
+		_, _ = fmt.Fprintf(out, "      --dimer-dg float        Dimers at or below ΔG (kcal/mol) add to pool burden [%s]\n", def("dimer-dg"))
+		_, _ = fmt.Fprintf(out, "      --na string             Monovalent salt [%s]\n", def("na"))
+		_, _ = fmt.Fprintf(out, "      --mg string             Mg2+ [%s]\n", def("mg"))
+		_, _ = fmt.Fprintf(out, "      --dntp string           Total dNTP [%s]\n", def("dntp"))
+		_, _ = fmt.Fprintf(out, "      --primer-conc string    Primer concentration [%s]\n", def("primer-conc"))
+		_, _ = fmt.Fprintf(out, "      --salt-model string     %s [%s]\n", thermo.KnownSaltModels(), def("salt-model"))
+
+		_, _ = fmt.Fprintln(out, "\nPools:")
+		_, _ = fmt.Fprintf(out, "  -k, --pools int             Reaction pools to split the panel into [%s]\n", def("pools"))
+		_, _ = fmt.Fprintf(out, "      --max-pool-size int     Assays per pool (0=balanced) [%s]\n", def("max-pool-size"))
+
+		_, _ = fmt.Fprintln(out, "\nOutput:")
+		_, _ = fmt.Fprintf(out, "  -o, --output string         Output: text (TSV) | json [%s]\n", def("output"))
+
+		_, _ = fmt.Fprintln(out, "\nMiscellaneous:")
+		_, _ = fmt.Fprintf(out, "  -q, --quiet                 Suppress non-essential warnings [%s]\n", def("quiet"))
+		_, _ = fmt.Fprintln(out, "  -v, --version               Print version and exit")
+		_, _ = fmt.Fprintln(out, "  -h, --help                  Show this help and exit")
+		_, _ = fmt.Fprintln(out, "      --examples              Show quickstart examples and exit")
+	}
+	return fs
+}
+
+func PrintExamples(out io.Writer) {
+	clibase.PrintExamples(out, "ipcr-pool", func(w io.Writer) {
+		_, _ = fmt.Fprintln(out, "Pool analysis: dimer ΔG/Tm matrix over every oligo of a multiplex panel,")
+		_, _ = fmt.Fprintln(out, "with 3'-anchored dimers flagged and a suggested split into reaction pools.")
+		_, _ = fmt.Fprintln(out, "\nExample:")
+		_, _ = fmt.Fprintln(out, "  ipcr-pool --primers panel.tsv --pools 3 > pool-report.tsv")
+		_, _ = fmt.Fprintln(out, "  ipcr-pool --primers panel.tsv --temp 60 --dimer-dg -2 --output json")
+	})
+}
+
+func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
+	var o Options
+	var help, showExamples bool
+
+	fs.StringVar(&o.PrimerFile, "primers", "", "multiplex panel TSV")
+	fs.StringVar(&o.PrimerFile, "p", "", "alias of --primers")
+	fs.BoolVar(&o.NoProbes, "no-probes", false, "leave panel probes out of the matrix [false]")
+	fs.IntVar(&o.MaxExpansions, "max-expansions", 64, "IUPAC variants tried per oligo")
+
+	fs.Float64Var(&o.TempC, "temp", multiplex.DefaultTempC, "temperature dimer ΔG is evaluated at (°C)")
+	fs.Float64Var(&o.DimerDG, "dimer-dg", 0, "dimers at or below ΔG (kcal/mol) add to pool burden")
+	fs.StringVar(&o.NaSpec, "na", "50mM", "monovalent salt (e.g., 50mM)")
+	fs.StringVar(&o.MgSpec, "mg", "3mM", "Mg2+ (e.g., 3mM)")
+	fs.StringVar(&o.DntpSpec, "dntp", "0mM", "total dNTP concentration (e.g., 200uM)")
+	fs.StringVar(&o.PrimerConcSpec, "primer-conc", "250nM", "primer concentration (e.g., 250nM)")
+	fs.StringVar(&o.SaltModel, "salt-model", thermo.SaltModelMonovalent.String(), "salt model: "+thermo.KnownSaltModels())
+
+	fs.IntVar(&o.Pools, "pools", 2, "reaction pools to split the panel into")
+	fs.IntVar(&o.Pools, "k", 2, "alias of --pools")
+	fs.IntVar(&o.MaxPoolSize, "max-pool-size", 0, "assays per pool (0=balanced)")
+
+	fs.StringVar(&o.Output, "output", output.FormatText, "output: text | json")
+	fs.StringVar(&o.Output, "o", output.FormatText, "alias of --output")
+
+	fs.BoolVar(&o.Quiet, "quiet", false, "suppress non-essential warnings [false]")
+	fs.BoolVar(&o.Quiet, "q", false, "alias of --quiet")
+	fs.BoolVar(&o.Version, "v", false, "print version and exit [false]")
+	fs.BoolVar(&o.Version, "version", false, "print version and exit [false]")
+	fs.BoolVar(&help, "h", false, "show this help [false]")
+	fs.BoolVar(&showExamples, "examples", false, "show quickstart examples and exit [false]")
+
+	flagArgs, posArgs := cliutil.SplitFlagsAndPositionals(fs, argv)
+	if err := fs.Parse(flagArgs); err != nil {
+		return o, err
+	}
+	if showExamples {
+		return o, clibase.ErrPrintedAndExitOK
+	}
+	if help {
+		return o, flag.ErrHelp
+	}
+	if o.Version {
+		return o, nil
+	}
+
+	if o.PrimerFile == "" && len(posArgs) == 1 {
+		o.PrimerFile = posArgs[0]
+		posArgs = nil
+	}
+	if len(posArgs) > 0 {
+		return o, fmt.Errorf("unexpected arguments %q", posArgs)
+	}
+	if o.PrimerFile == "" {
+		return o, errors.New("--primers is required")
+	}
+	if o.Pools < 1 {
+		return o, errors.New("--pools must be ≥ 1")
+	}
+	if o.MaxPoolSize < 0 {
+		return o, errors.New("--max-pool-size must be ≥ 0")
+	}
+	if o.MaxExpansions < 1 {
+		return o, errors.New("--max-expansions must be ≥ 1")
+	}
+	switch o.Output {
+	case output.FormatText, output.FormatJSON:
+	default:
+		return o, fmt.Errorf("invalid --output %q (text or json)", o.Output)
+	}
+	return o, nil
+}
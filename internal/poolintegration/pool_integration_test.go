@@ -0,0 +1,88 @@
+package poolintegration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr/internal/poolapp"
+	"ipcr/pkg/api"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Panel where A.fwd/B.fwd and C.rev/D.rev share 14 bp 3'-complementary tails.
+const panel = "id\tfwd\trev\tprobes\n" +
+	"A\tTTAGCCTAAGTCCGATCGGC\tACTTGACTTAGCATCAGTCA\t\n" +
+	"B\tCATTCATGCCGATCGGACTTA\tTGTATCATCTAATGCTCAACTT\t\n" +
+	"C\tGATTGCAGTACCATAGTCAG\tCATACGTGGAGCTAACGCAG\tC.probe=TCAGCACTTCGTACACAGTCC@FAM\n" +
+	"D\tAAGTGCTATCAGGTCACATA\tTTAGACTCTGCGTTAGCTCCA\t\n"
+
+func TestPoolMatrixAndSplit(t *testing.T) {
+	tsv := filepath.Join(t.TempDir(), "panel.tsv")
+	if err := os.WriteFile(tsv, []byte(panel), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errB bytes.Buffer
+	if code := poolapp.Run([]string{"--primers", tsv, "--output", "json"}, &out, &errB); code != 0 {
+		t.Fatalf("exit %d: %s", code, errB.String())
+	}
+	var rep api.PoolAnalysisV1
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("bad JSON: %v\n%s", err, out.String())
+	}
+	if len(rep.Oligos) != 9 || len(rep.DeltaG) != 9 || rep.Oligos[6].ID != "C.probe" {
+		t.Fatalf("unexpected oligos %+v", rep.Oligos)
+	}
+	three := map[string]bool{}
+	for _, d := range rep.Dimers {
+		three[d.A+"|"+d.B] = d.ThreePrime
+	}
+	if !three["A.fwd|B.fwd"] || !three["C.rev|D.rev"] {
+		t.Fatalf("designed 3' dimers missing: %+v", rep.Dimers)
+	}
+	if len(rep.Pools) != 2 || rep.SplitBurden >= rep.SinglePoolBurden {
+		t.Fatalf("pools %+v (single %.2f split %.2f)", rep.Pools, rep.SinglePoolBurden, rep.SplitBurden)
+	}
+	poolOf := map[string]int{}
+	for _, p := range rep.Pools {
+		for _, a := range p.Assays {
+			poolOf[a] = p.Pool
+		}
+	}
+	if poolOf["A"] == poolOf["B"] || poolOf["C"] == poolOf["D"] {
+		t.Fatalf("interacting assays share a pool: %+v", rep.Pools)
+	}
+
+	out.Reset()
+	if code := poolapp.Run([]string{tsv, "--no-probes", "-k", "1"}, &out, &errB); code != 0 {
+		t.Fatalf("text exit %d: %s", code, errB.String())
+	}
+	text := out.String()
+	if !strings.HasPrefix(text, "# ΔG (kcal/mol) at 37 °C") || strings.Contains(text, "C.probe") {
+		t.Fatalf("unexpected text output:\n%s", text)
+	}
+	if !strings.Contains(text, "\n1\tA,B,C,D\t") {
+		t.Fatalf("single pool missing:\n%s", text)
+	}
+	// Matrix cells above the threshold are blank ("."), and every starred
+	// cell is a counted 3' dimer.
+	dg := strings.SplitN(text, "\n\n", 2)[0]
+	for _, row := range strings.Split(dg, "\n")[2:] {
+		for _, cell := range strings.Split(row, "\t")[1:] {
+			if cell == "." {
+				continue
+			}
+			v, err := strconv.ParseFloat(strings.TrimSuffix(cell, "*"), 64)
+			if err != nil || v > rep.ThresholdKcal {
+				t.Fatalf("cell %q above the %g kcal/mol threshold:\n%s", cell, rep.ThresholdKcal, dg)
+			}
+		}
+	}
+
+	if code := poolapp.Run([]string{"--pools", "0", tsv}, &out, &errB); code != 2 {
+		t.Fatalf("--pools 0 should exit 2, got %d", code)
+	}
+}
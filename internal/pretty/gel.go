@@ -0,0 +1,64 @@
+package pretty
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// GelBand is one band to draw in a virtual gel lane.
+type GelBand struct {
+	Length int
+	Label  string
+	Flag   bool // drawn with '!' (e.g. not resolved from a neighbour)
+}
+
+// gelRows is the number of migration rows of a rendered lane.
+const gelRows = 16
+
+// RenderGelLane draws bands in one ASCII agarose-style lane. Migration is
+// log-linear in length between 1.25× the longest and 0.8× the shortest band,
+// so bands that share a row would co-migrate at this resolution.
+func RenderGelLane(title string, bands []GelBand) string {
+	var b strings.Builder
+	b.WriteString(title + "\n")
+	if len(bands) == 0 {
+		b.WriteString("  (no bands)\n\n")
+		return b.String()
+	}
+	lo, hi := bands[0].Length, bands[0].Length
+	for _, g := range bands {
+		lo, hi = min(lo, g.Length), max(hi, g.Length)
+	}
+	top := math.Log(float64(hi) * 1.25)
+	span := top - math.Log(math.Max(1, float64(lo)*0.8))
+
+	rows := make([][]GelBand, gelRows)
+	for _, g := range bands {
+		r := int(math.Round((top - math.Log(math.Max(1, float64(g.Length)))) / span * (gelRows - 1)))
+		r = min(max(r, 0), gelRows-1)
+		rows[r] = append(rows[r], g)
+	}
+
+	const edge = "        +--------+\n"
+	b.WriteString(edge)
+	for _, row := range rows {
+		if len(row) == 0 {
+			b.WriteString("        |        |\n")
+			continue
+		}
+		lens := make([]string, len(row))
+		labels := make([]string, len(row))
+		fill := "========"
+		for i, g := range row {
+			lens[i] = fmt.Sprint(g.Length)
+			labels[i] = g.Label
+			if g.Flag {
+				fill = "!!!!!!!!"
+			}
+		}
+		fmt.Fprintf(&b, "%7s |%s| %s\n", strings.Join(lens, "/"), fill, strings.Join(labels, ", "))
+	}
+	b.WriteString(edge + "\n")
+	return b.String()
+}
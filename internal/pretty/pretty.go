@@ -71,30 +71,77 @@ func reverseString(s string) string {
 }
 
 // comp5to3 returns the **complement** (not reverse-complement) of a 5'→3' string.
-// Implementation: complement(s) == revcomp(reverse(s)).
+// Alignment gaps ('-') are kept as they are.
 func comp5to3(s string) string {
 	if s == "" {
 		return s
 	}
-	// reverse bytes (not runes — primer alphabet is ASCII)
 	b := []byte(s)
-	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
-		b[i], b[j] = b[j], b[i]
+	for i, c := range b {
+		if cc := primer.Complement(c); cc != 0 {
+			b[i] = cc
+		}
 	}
-	rc := primer.RevComp(b)
-	return string(rc)
+	return string(b)
 }
 
 func isACGT(b byte) bool { return b == 'A' || b == 'C' || b == 'G' || b == 'T' }
 
-// Primer bars under a site (ipcr semantics)
-func matchLineAmbig(primerSeq, site string, mismIdx []int, exactGlyph, partialGlyph string) string {
-	n := len(primerSeq)
+// alignSite lays a primer (5'→3') against its template site in the same
+// orientation: '-' fills the primer where the template carries an extra base
+// and the site where a primer base has no partner (see primer.Indel). idx
+// gives the primer base of every column, -1 for an inserted template base.
+func alignSite(primerSeq, site string, indels []primer.Indel) (p, s string, idx []int) {
+	if len(indels) == 0 {
+		idx = make([]int, len(primerSeq))
+		for i := range idx {
+			idx[i] = i
+		}
+		return primerSeq, site, idx
+	}
+	ins := make(map[int]int, len(indels))
+	del := make(map[int]bool, len(indels))
+	for _, d := range indels {
+		if d.Ins {
+			ins[d.Pos]++
+		} else {
+			del[d.Pos] = true
+		}
+	}
+	var pb, sb []byte
+	si := 0
+	next := func() byte {
+		if si >= len(site) {
+			return ' '
+		}
+		si++
+		return site[si-1]
+	}
+	for j := 0; j <= len(primerSeq); j++ {
+		for n := ins[j]; n > 0; n-- {
+			pb, sb, idx = append(pb, '-'), append(sb, next()), append(idx, -1)
+		}
+		if j == len(primerSeq) {
+			break
+		}
+		if del[j] {
+			pb, sb, idx = append(pb, primerSeq[j]), append(sb, '-'), append(idx, j)
+		} else {
+			pb, sb, idx = append(pb, primerSeq[j]), append(sb, next()), append(idx, j)
+		}
+	}
+	return string(pb), string(sb), idx
+}
+
+// Primer bars under a site (ipcr semantics); gaps get no bar.
+func matchLineAmbig(primerSeq, site string, mismIdx []int, indels []primer.Indel, exactGlyph, partialGlyph string) string {
+	p, s, idx := alignSite(primerSeq, site, indels)
+	n := len(p)
 	if n <= 0 {
 		return ""
 	}
-	if len(site) < n {
-		n = len(site)
+	if len(s) < n {
+		n = len(s)
 	}
 	mism := make(map[int]struct{}, len(mismIdx))
 	for _, i := range mismIdx {
@@ -102,12 +149,12 @@ func matchLineAmbig(primerSeq, site string, mismIdx []int, exactGlyph, partialGl
 	}
 	var b strings.Builder
 	b.Grow(n)
-	for i := 0; i < n; i++ {
-		if _, bad := mism[i]; bad {
+	for c := 0; c < n; c++ {
+		if _, bad := mism[idx[c]]; bad || idx[c] < 0 || s[c] == '-' {
 			b.WriteByte(' ')
 			continue
 		}
-		if isACGT(primerSeq[i]) {
+		if isACGT(p[c]) {
 			b.WriteString(exactGlyph)
 		} else {
 			b.WriteString(partialGlyph)
@@ -338,8 +385,11 @@ func RenderProductWithOptions(p engine.Product, opt Options) string {
 		dot = DefaultOptions.DotGlyph
 	}
 
-	aLen, bLen := len(p.FwdPrimer), len(p.RevPrimer)
-	interior := p.Length - aLen - bLen
+	// Gapped sites are drawn with '-' in the primer or the site.
+	fwdPrimer, fwdSite, _ := alignSite(p.FwdPrimer, p.FwdSite, p.FwdIndels)
+	revPrimer, revSite, _ := alignSite(p.RevPrimer, p.RevSite, p.RevIndels)
+	aLen, bLen := len(fwdPrimer), len(revPrimer)
+	interior := p.Length - len(p.FwdSite) - len(p.RevSite)
 	if interior < 0 {
 		interior = 0
 	}
@@ -368,30 +418,30 @@ func RenderProductWithOptions(p engine.Product, opt Options) string {
 	var b strings.Builder
 
 	// 1) Forward primer (5'→3')
-	_, _ = fmt.Fprintf(&b, "%s%s%s%s\n", linePrefix, prefixPlus, p.FwdPrimer, suffixPlus)
+	_, _ = fmt.Fprintf(&b, "%s%s%s%s\n", linePrefix, prefixPlus, fwdPrimer, suffixPlus)
 
 	// 2) Bars under forward primer
 	_, _ = fmt.Fprintf(&b, "%s%s%s%s\n",
 		linePrefix,
 		strings.Repeat(" ", len(prefixPlus)),
-		matchLineAmbig(p.FwdPrimer, p.FwdSite, p.FwdMismatchIdx, opt.ExactGlyphOrDefault(), opt.PartialGlyphOrDefault()),
+		matchLineAmbig(p.FwdPrimer, p.FwdSite, p.FwdMismatchIdx, p.FwdIndels, opt.ExactGlyphOrDefault(), opt.PartialGlyphOrDefault()),
 		arrowRight,
 	)
 
 	// 3) (+) genomic line
 	_, _ = fmt.Fprintf(&b, "%s%s%s%s%s # (+)\n",
-		linePrefix, prefixPlus, p.FwdSite, strings.Repeat(dot, innerPlus), suffixPlus,
+		linePrefix, prefixPlus, fwdSite, strings.Repeat(dot, innerPlus), suffixPlus,
 	)
 
 	// 4) (−) genomic line (complement, not reverse)
-	minusSite := comp5to3(p.RevSite)
+	minusSite := comp5to3(revSite)
 	_, _ = fmt.Fprintf(&b, "%s%s%s%s%s # (-)\n",
 		linePrefix, prefixMinus, strings.Repeat(dot, innerMinus), minusSite, suffixMinus,
 	)
 
 	// 5) bars for reverse primer
 	siteStart := len(prefixMinus) + innerMinus
-	revBars := reverseString(matchLineAmbig(p.RevPrimer, p.RevSite, p.RevMismatchIdx, opt.ExactGlyphOrDefault(), opt.PartialGlyphOrDefault()))
+	revBars := reverseString(matchLineAmbig(p.RevPrimer, p.RevSite, p.RevMismatchIdx, p.RevIndels, opt.ExactGlyphOrDefault(), opt.PartialGlyphOrDefault()))
 	padBars := siteStart - len(arrowLeft)
 	if padBars < 0 {
 		padBars = 0
@@ -399,7 +449,7 @@ func RenderProductWithOptions(p engine.Product, opt Options) string {
 	_, _ = fmt.Fprintf(&b, "%s%s%s%s\n", linePrefix, strings.Repeat(" ", padBars), arrowLeft, revBars)
 
 	// 6) reverse primer shown 3'→5'
-	revPrimerDisplayed := reverseString(p.RevPrimer)
+	revPrimerDisplayed := reverseString(revPrimer)
 	padPrimer := siteStart - len(prefixMinus)
 	if padPrimer < 0 {
 		padPrimer = 0
@@ -445,8 +495,11 @@ func RenderAnnotatedWithOptions(p engine.Product, ann ProbeAnnotation, opt Optio
 	exactGlyph := opt.ExactGlyphOrDefault()
 	partialGlyph := opt.PartialGlyphOrDefault()
 
-	aLen, bLen := len(p.FwdPrimer), len(p.RevPrimer)
-	interior := p.Length - aLen - bLen
+	// Gapped sites are drawn with '-' in the primer or the site.
+	fwdPrimer, fwdSite, _ := alignSite(p.FwdPrimer, p.FwdSite, p.FwdIndels)
+	revPrimer, revSite, _ := alignSite(p.RevPrimer, p.RevSite, p.RevIndels)
+	aLen, bLen := len(fwdPrimer), len(revPrimer)
+	interior := p.Length - len(p.FwdSite) - len(p.RevSite)
 	if interior < 0 {
 		interior = 0
 	}
@@ -493,10 +546,10 @@ func RenderAnnotatedWithOptions(p engine.Product, ann ProbeAnnotation, opt Optio
 
 	plusInteriorStart := len(prefixPlus) + aLen
 	minusInteriorStart := len(prefixMinus)
-	ovPlus := buildProbeOverlay(ann, aLen, interior, innerPlus, plusInteriorStart, minusProbeOffset, exactGlyph, partialGlyph)
+	ovPlus := buildProbeOverlay(ann, len(p.FwdSite), interior, innerPlus, plusInteriorStart, minusProbeOffset, exactGlyph, partialGlyph)
 	var ovMinus *probeOverlay
 	if ovPlus != nil && ovPlus.strand == "-" {
-		ovMinus = buildProbeOverlay(ann, aLen, interior, innerMinus, plusInteriorStart, minusProbeOffset, exactGlyph, partialGlyph)
+		ovMinus = buildProbeOverlay(ann, len(p.FwdSite), interior, innerMinus, plusInteriorStart, minusProbeOffset, exactGlyph, partialGlyph)
 		ovPlus = nil
 	}
 
@@ -515,8 +568,8 @@ func RenderAnnotatedWithOptions(p engine.Product, ann ProbeAnnotation, opt Optio
 		}
 	}
 
-	fwdSeqBlock := prefixPlus + p.FwdPrimer + suffixPlus
-	fwdBars := matchLineAmbig(p.FwdPrimer, p.FwdSite, p.FwdMismatchIdx, exactGlyph, partialGlyph)
+	fwdSeqBlock := prefixPlus + fwdPrimer + suffixPlus
+	fwdBars := matchLineAmbig(p.FwdPrimer, p.FwdSite, p.FwdMismatchIdx, p.FwdIndels, exactGlyph, partialGlyph)
 	fwdBarsBlock := fwdBars + arrowRight
 	topSeqSegments := []lineSegment{{col: 0, text: fwdSeqBlock}}
 	topBarsSegments := []lineSegment{{col: len(prefixPlus), text: fwdBarsBlock}}
@@ -545,15 +598,15 @@ func RenderAnnotatedWithOptions(p engine.Product, ann ProbeAnnotation, opt Optio
 		}
 	}
 
-	minusSite := comp5to3(p.RevSite)
+	minusSite := comp5to3(revSite)
 	siteStart := len(prefixMinus) + minusInteriorLen
-	revBars := reverseString(matchLineAmbig(p.RevPrimer, p.RevSite, p.RevMismatchIdx, exactGlyph, partialGlyph))
+	revBars := reverseString(matchLineAmbig(p.RevPrimer, p.RevSite, p.RevMismatchIdx, p.RevIndels, exactGlyph, partialGlyph))
 	arrowStartCol := siteStart - len(arrowLeft)
 	if arrowStartCol < 0 {
 		arrowStartCol = 0
 	}
 
-	revPrimerDisplayed := reverseString(p.RevPrimer)
+	revPrimerDisplayed := reverseString(revPrimer)
 	rightBlock := prefixMinus + revPrimerDisplayed + suffixMinus
 	rightStartCol := arrowStartCol + len(arrowLeft) - len(prefixMinus)
 	if rightStartCol < 0 {
@@ -598,7 +651,7 @@ func RenderAnnotatedWithOptions(p engine.Product, ann ProbeAnnotation, opt Optio
 	}
 
 	_, _ = fmt.Fprintf(&b, "%s%s%s%s%s # (+)\n",
-		linePrefix, prefixPlus, p.FwdSite, plusInterior, suffixPlus,
+		linePrefix, prefixPlus, fwdSite, plusInterior, suffixPlus,
 	)
 
 	if opt.ShowCaret && ovPlus != nil {
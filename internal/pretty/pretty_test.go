@@ -2,6 +2,7 @@ package pretty
 
 import (
 	"ipcr-core/engine"
+	"ipcr-core/primer"
 	"os"
 	"path/filepath"
 	"strings"
@@ -73,6 +74,29 @@ func TestRenderProductRevcomp_Golden(t *testing.T) {
 	}
 }
 
+func TestRenderProductGapped_Golden(t *testing.T) {
+	p := engine.Product{
+		FwdPrimer: "ACGTTGCA", RevPrimer: "GGATCCAT",
+		FwdSite: "AGGTTCA", RevSite: "GGATCTCAT",
+		FwdMismatchIdx: []int{1},
+		FwdIndels:      []primer.Indel{{Pos: 5}},
+		RevIndels:      []primer.Indel{{Pos: 5, Ins: true}},
+		Length:         40, Start: 0, End: 40, Type: "forward",
+	}
+	got := RenderProduct(p)
+	path := filepath.Join("testdata", "gapped.golden")
+	if created, err := writeIfMissingOrUpdate(path, got); err != nil {
+		t.Fatalf("write golden: %v", err)
+	} else if created {
+		t.Logf("wrote %s", path)
+		return
+	}
+	want := mustRead(path, t)
+	if got != want {
+		t.Fatalf("mismatch:\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
 func TestRenderAnnotated_Plus_Golden(t *testing.T) {
 	p := engine.Product{
 		FwdPrimer: "TCAG", RevPrimer: "GATC",
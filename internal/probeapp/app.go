@@ -8,11 +8,13 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"ipcr-core/engine"
 	"ipcr-core/primer"
 	"ipcr/internal/appcore"
 	"ipcr/internal/clibase"
 	"ipcr/internal/common"
 	"ipcr/internal/probecli"
+	"ipcr/internal/probeoutput"
 	"ipcr/internal/runutil"
 	"ipcr/internal/version"
 	"ipcr/internal/visitors"
@@ -97,21 +99,47 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 	} else {
 		pairs = []primer.Pair{{ID: "manual", Forward: opts.Fwd, Reverse: opts.Rev, MinProduct: opts.MinLen, MaxProduct: opts.MaxLen}}
 	}
+	perPair := make(map[string]string)
+	for _, p := range pairs {
+		switch {
+		case len(p.Probes) > 1:
+			_, _ = fmt.Fprintf(stderr, "error: pair %q lists %d probes; ipcr-probe overlays one (see ipcr-multiplex)\n", p.ID, len(p.Probes))
+			return 2
+		case len(p.Probes) == 1:
+			perPair[p.ID] = p.Probes[0].Seq
+		case opts.Probe == "":
+			_, _ = fmt.Fprintf(stderr, "error: pair %q has no probe; add a probe column value or pass --probe\n", p.ID)
+			return 2
+		}
+	}
+	panel := pairs // self pairs are single-primer side reactions, not assays to report on
 	if opts.Self {
 		pairs = common.AddSelfPairs(pairs)
 	}
 
 	termWin := runutil.EffectiveTerminalWindow(opts.TerminalWindow)
 	coreOpts := appcore.Options{
-		SeqFiles: opts.SeqFiles, MaxMM: opts.Mismatches, TerminalWindow: termWin,
+		SeqFiles: opts.SeqFiles, Index: opts.Index, Regions: opts.Regions, ExcludeRegions: opts.ExcludeRegions,
+		MaskPolicy: opts.MaskPolicy, MaxMM: opts.Mismatches, MaxIndels: opts.MaxIndels, TerminalWindow: termWin,
 		MinLen: opts.MinLen, MaxLen: opts.MaxLen, HitCap: opts.HitCap, SeedLength: opts.SeedLength,
 		Circular: opts.Circular, Threads: opts.Threads, ChunkSize: opts.ChunkSize,
 		DedupeCap: opts.DedupeCap,
 		Quiet:     opts.Quiet, NoMatchExitCode: opts.NoMatchExitCode,
 	}
-	writer := appcore.NewAnnotatedWriterFactory(opts.Output, opts.Sort, opts.Header, opts.Pretty)
+	var writer appcore.WriterFactory[probeoutput.AnnotatedProduct] = appcore.NewAnnotatedWriterFactory(opts.Output, opts.Sort, opts.Header, opts.Pretty)
+	if opts.Summary {
+		sum, err := appcore.NewSummary(coreOpts, opts.AssemblyMap, panel)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		wf := appcore.NewSummaryWriterFactory(opts.Output, opts.Header, sum, func(ap probeoutput.AnnotatedProduct) engine.Product { return ap.Product })
+		wf.Seq = true // probe overlay scans the amplicon
+		writer = wf
+	}
 	visitor := visitors.Probe{
 		Name: opts.ProbeName, Seq: strings.ToUpper(opts.Probe), MaxMM: opts.ProbeMaxMM, Require: opts.RequireProbe,
+		PerPair: perPair,
 	}
 	return appcore.Run(parent, stdout, stderr, coreOpts, pairs, visitor.Visit, writer)
 }
@@ -28,7 +28,7 @@ func NewFlagSet(name string) *flag.FlagSet {
 		_, _ = fmt.Fprintf(out, "  %s [options] --forward AAA --reverse TTT --probe PROBE ref.fa\n", name)
 
 		_, _ = fmt.Fprintln(out, "\nProbe:")
-		_, _ = fmt.Fprintln(out, "  -P, --probe string          Internal oligo sequence (5'→3') [required]")
+		_, _ = fmt.Fprintln(out, "  -P, --probe string          Internal oligo sequence (5'→3') [required unless every --primers row has a probe]")
 		_, _ = fmt.Fprintf(out, "      --probe-name string     Label for the probe [%s]\n", def("probe-name"))
 		_, _ = fmt.Fprintf(out, "  -M, --probe-max-mm int      Max mismatches allowed in probe match [%s]\n", def("probe-max-mm"))
 		_, _ = fmt.Fprintf(out, "      --require-probe         Only report amplicons that contain the probe [%s]\n", def("require-probe"))
@@ -95,14 +95,19 @@ func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
 		return o, err
 	}
 	// Probe-specific validation
+	// With --primers the panel may carry per-pair probes instead; the app
+	// checks that every pair ends up with one.
 	if o.Probe == "" {
-		return o, fmt.Errorf("--probe is required")
+		if c.PrimerFile == "" {
+			return o, fmt.Errorf("--probe is required")
+		}
+	} else {
+		probeSeq, err := oligo.Validate(o.Probe)
+		if err != nil {
+			return o, fmt.Errorf("--probe: %w", err)
+		}
+		o.Probe = probeSeq
 	}
-	probeSeq, err := oligo.Validate(o.Probe)
-	if err != nil {
-		return o, fmt.Errorf("--probe: %w", err)
-	}
-	o.Probe = probeSeq
 
 	// Embed shared options
 	o.Common = c
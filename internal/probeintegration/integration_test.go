@@ -60,3 +60,38 @@ func TestRequireProbe_NoHits_ExitZeroByDefault(t *testing.T) {
 		t.Fatalf("expected zero exit when no hits under --require-probe=true (got %d, err=%s)", code, errB.String())
 	}
 }
+
+func TestPanelProbeColumn(t *testing.T) {
+	fa := write(t, "ref.fa", ">chr1\nACGTTTACGTTTACGTTT\n")
+	panel := write(t, "panel.tsv", "id\tfwd\trev\tprobe\np1\tACG\tACG\tACGTT\np2\tTTA\tTTA\t-\n")
+
+	var out, errB bytes.Buffer
+	if code := probeapp.Run([]string{"--primers", panel, "--sequences", fa}, &out, &errB); code != 2 {
+		t.Fatalf("pair without probe and no --probe should exit 2, got %d", code)
+	}
+
+	out.Reset()
+	errB.Reset()
+	code := probeapp.Run([]string{
+		"--primers", panel, "--sequences", fa,
+		"--probe", "GGGGG", "--require-probe=false", "--self=false", "--output", "json",
+	}, &out, &errB)
+	if code != 0 {
+		t.Fatalf("exit %d err=%s", code, errB.String())
+	}
+	var v []api.AnnotatedProductV1
+	if err := json.Unmarshal(out.Bytes(), &v); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, p := range v {
+		want := map[string]string{"p1": "ACGTT", "p2": "GGGGG"}[p.ExperimentID]
+		if p.ProbeSeq != want {
+			t.Fatalf("%s: probe %q want %q", p.ExperimentID, p.ProbeSeq, want)
+		}
+		seen[p.ExperimentID] = true
+	}
+	if !seen["p1"] {
+		t.Fatalf("expected p1 products, got %+v", v)
+	}
+}
@@ -3,6 +3,7 @@ package probeoutput
 import (
 	"io"
 	"ipcr/internal/jsonutil"
+	"ipcr/internal/output"
 	"ipcr/pkg/api"
 )
 
@@ -21,6 +22,10 @@ func ToAPIAnnotated(ap AnnotatedProduct) api.AnnotatedProductV1 {
 		RevMismatchIdx: append([]int(nil), p.RevMismatchIdx...),
 		Seq:            p.Seq,
 		SourceFile:     p.SourceFile,
+		FwdIndels:      output.ToAPIIndels(p.FwdIndels),
+		RevIndels:      output.ToAPIIndels(p.RevIndels),
+		FwdMasked:      p.FwdMasked,
+		RevMasked:      p.RevMasked,
 
 		ProbeName:   ap.ProbeName,
 		ProbeSeq:    ap.ProbeSeq,
@@ -0,0 +1,139 @@
+// internal/regions/regions.go
+
+// Package regions reads BED interval files that restrict scans to (or exclude
+// them from) parts of each FASTA record.
+package regions
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Interval is a 0-based, half-open span of a record, as in BED.
+type Interval struct {
+	Start, End int
+}
+
+// Set holds sorted, merged intervals per record ID. A nil *Set is empty.
+type Set struct {
+	byID map[string][]Interval
+}
+
+// Load reads a BED file from path.
+func Load(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	s, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse reads BED records. Only the first three columns (chrom, start, end)
+// are used; blank, '#', "track" and "browser" lines are ignored. Overlapping
+// and adjacent intervals of a record are merged.
+func Parse(r io.Reader) (*Set, error) {
+	s := &Set{byID: make(map[string][]Interval)}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		txt := strings.TrimSpace(sc.Text())
+		if txt == "" || strings.HasPrefix(txt, "#") {
+			continue
+		}
+		cols := strings.Fields(txt)
+		if cols[0] == "track" || cols[0] == "browser" {
+			continue
+		}
+		if len(cols) < 3 {
+			return nil, fmt.Errorf("line %d: want <chrom>\\t<start>\\t<end>", line)
+		}
+		start, err1 := strconv.Atoi(cols[1])
+		end, err2 := strconv.Atoi(cols[2])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("line %d: start and end must be integers", line)
+		}
+		if start < 0 || end <= start {
+			return nil, fmt.Errorf("line %d: invalid interval %d-%d", line, start, end)
+		}
+		s.byID[cols[0]] = append(s.byID[cols[0]], Interval{start, end})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for id, ivs := range s.byID {
+		s.byID[id] = merge(ivs)
+	}
+	return s, nil
+}
+
+func merge(ivs []Interval) []Interval {
+	sort.Slice(ivs, func(i, j int) bool { return ivs[i].Start < ivs[j].Start })
+	out := ivs[:1]
+	for _, iv := range ivs[1:] {
+		last := &out[len(out)-1]
+		if iv.Start <= last.End {
+			last.End = max(last.End, iv.End)
+			continue
+		}
+		out = append(out, iv)
+	}
+	return out
+}
+
+// Intervals returns the merged intervals of record id in ascending order.
+func (s *Set) Intervals(id string) []Interval {
+	if s == nil {
+		return nil
+	}
+	return s.byID[id]
+}
+
+// Has reports whether the set lists any interval on record id.
+func (s *Set) Has(id string) bool {
+	return len(s.Intervals(id)) > 0
+}
+
+// Spans returns the parts of the window [start,end) of record id left to
+// scan: the include intervals (the whole window when include is nil) minus
+// the exclude intervals, in ascending order.
+func Spans(include, exclude *Set, id string, start, end int) []Interval {
+	var keep []Interval
+	if include == nil {
+		keep = []Interval{{start, end}}
+	} else {
+		for _, iv := range include.Intervals(id) {
+			if s, e := max(iv.Start, start), min(iv.End, end); s < e {
+				keep = append(keep, Interval{s, e})
+			}
+		}
+	}
+	for _, ex := range exclude.Intervals(id) {
+		var next []Interval
+		for _, iv := range keep {
+			if ex.End <= iv.Start || ex.Start >= iv.End {
+				next = append(next, iv)
+				continue
+			}
+			if ex.Start > iv.Start {
+				next = append(next, Interval{iv.Start, ex.Start})
+			}
+			if ex.End < iv.End {
+				next = append(next, Interval{ex.End, iv.End})
+			}
+		}
+		keep = next
+	}
+	return keep
+}
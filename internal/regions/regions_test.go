@@ -0,0 +1,54 @@
+package regions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMergesAndSkipsHeaders(t *testing.T) {
+	bed := "track name=loci\nbrowser position chr1:1-100\n# comment\n\n" +
+		"chr1\t50\t80\tgeneB\nchr1\t10\t20\nchr1\t15\t30\nchr1\t30\t35\nchr2 0 5\n"
+	s, err := Parse(strings.NewReader(bed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Intervals("chr1"), []Interval{{10, 35}, {50, 80}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("chr1 = %v, want %v", got, want)
+	}
+	if !s.Has("chr2") || s.Has("chr3") {
+		t.Fatal("Has mismatch")
+	}
+}
+
+func TestParseRejectsBadLines(t *testing.T) {
+	for _, bed := range []string{"chr1\t10\n", "chr1\tx\t20\n", "chr1\t20\t10\n", "chr1\t-1\t10\n"} {
+		if _, err := Parse(strings.NewReader(bed)); err == nil {
+			t.Errorf("expected error for %q", bed)
+		}
+	}
+}
+
+func TestSpans(t *testing.T) {
+	inc, _ := Parse(strings.NewReader("r\t10\t50\nr\t80\t120\n"))
+	exc, _ := Parse(strings.NewReader("r\t20\t30\nr\t100\t200\nq\t0\t10\n"))
+	cases := []struct {
+		name       string
+		inc, exc   *Set
+		id         string
+		start, end int
+		want       []Interval
+	}{
+		{"include", inc, nil, "r", 0, 200, []Interval{{10, 50}, {80, 120}}},
+		{"include window", inc, nil, "r", 40, 90, []Interval{{40, 50}, {80, 90}}},
+		{"include unlisted", inc, nil, "q", 0, 200, nil},
+		{"exclude only", nil, exc, "r", 0, 150, []Interval{{0, 20}, {30, 100}}},
+		{"exclude unlisted", nil, exc, "z", 0, 40, []Interval{{0, 40}}},
+		{"both", inc, exc, "r", 0, 200, []Interval{{10, 20}, {30, 50}, {80, 100}}},
+	}
+	for _, c := range cases {
+		if got := Spans(c.inc, c.exc, c.id, c.start, c.end); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// internal/rtapp/app.go
+package rtapp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/primer"
+	"ipcr-core/rt"
+	"ipcr/internal/appcore"
+	"ipcr/internal/clibase"
+	"ipcr/internal/cmdutil"
+	"ipcr/internal/common"
+	"ipcr/internal/pipeline"
+	"ipcr/internal/rtcli"
+	"ipcr/internal/runutil"
+	"ipcr/internal/version"
+	"ipcr/internal/visitors"
+	"ipcr/internal/writers"
+)
+
+func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer) int {
+	outw := bufio.NewWriter(stdout)
+	defer outw.Flush()
+
+	fs := rtcli.NewFlagSet("ipcr-rt")
+	fs.SetOutput(io.Discard)
+
+	if len(argv) == 0 {
+		_, _ = rtcli.ParseArgs(fs, []string{"-h"})
+		fs.SetOutput(outw)
+		fs.Usage()
+		if err := outw.Flush(); writers.IsBrokenPipe(err) {
+			return 0
+		} else if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 3
+		}
+		return 0
+	}
+
+	opts, err := rtcli.ParseArgs(fs, argv)
+	if err != nil {
+		if errors.Is(err, clibase.ErrPrintedAndExitOK) {
+			rtcli.PrintExamples(outw)
+			if e := outw.Flush(); writers.IsBrokenPipe(e) {
+				return 0
+			} else if e != nil {
+				_, _ = fmt.Fprintln(stderr, e)
+				return 3
+			}
+			return 0
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(outw)
+			fs.Usage()
+			if e := outw.Flush(); writers.IsBrokenPipe(e) {
+				return 0
+			} else if e != nil {
+				_, _ = fmt.Fprintln(stderr, e)
+				return 3
+			}
+			return 0
+		}
+		_, _ = fmt.Fprintln(stderr, err)
+		fs.SetOutput(outw)
+		fs.Usage()
+		if e := outw.Flush(); writers.IsBrokenPipe(e) {
+			return 0
+		} else if e != nil {
+			_, _ = fmt.Fprintln(stderr, e)
+			return 3
+		}
+		return 2
+	}
+
+	if opts.Version {
+		version.Write(outw, "ipcr-rt")
+		if e := outw.Flush(); writers.IsBrokenPipe(e) {
+			return 0
+		} else if e != nil {
+			_, _ = fmt.Fprintln(stderr, e)
+			return 3
+		}
+		return 0
+	}
+
+	var pairs []primer.Pair
+	if opts.PrimerFile != "" {
+		var e error
+		pairs, e = primer.LoadTSV(opts.PrimerFile)
+		if e != nil {
+			_, _ = fmt.Fprintln(stderr, e)
+			return 2
+		}
+	} else {
+		pairs = []primer.Pair{{ID: "manual", Forward: opts.Fwd, Reverse: opts.Rev, MinProduct: opts.MinLen, MaxProduct: opts.MaxLen}}
+	}
+	if opts.Self {
+		pairs = common.AddSelfPairs(pairs)
+	}
+
+	termWin := runutil.EffectiveTerminalWindow(opts.TerminalWindow)
+	pr := opts.Priming
+	pr.TerminalWindow = termWin
+
+	// RNA FASTA, or transcripts spliced from the genome (plus the genome
+	// itself as gDNA) with --gtf.
+	var visitor visitors.RT
+	source := pipeline.RNASource(opts.SeqFiles, pr)
+	if opts.GTF != "" {
+		ts, err := rt.LoadGTF(opts.GTF)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		visitor = visitors.RT{GTF: opts.GTF, Transcripts: make(map[string]rt.Transcript, len(ts))}
+		for _, t := range ts {
+			visitor.Transcripts[t.ID] = t
+		}
+		warn := func(msg string) { cmdutil.Warnf(stderr, opts.Quiet, "%s", msg) }
+		source = pipeline.TranscriptSource(opts.SeqFiles, ts, opts.GTF, pr, opts.NoGDNA, warn)
+	}
+
+	coreOpts := appcore.Options{
+		SeqFiles: opts.SeqFiles, Source: source,
+		MaxMM: opts.Mismatches, MaxIndels: opts.MaxIndels, TerminalWindow: termWin,
+		MinLen: opts.MinLen, MaxLen: opts.MaxLen, HitCap: opts.HitCap, SeedLength: opts.SeedLength,
+		Threads: opts.Threads, DedupeCap: opts.DedupeCap,
+		Quiet: opts.Quiet, NoMatchExitCode: opts.NoMatchExitCode,
+	}
+	writer := appcore.RTWriterFactory{Format: opts.Output, Sort: opts.Sort, Header: opts.Header, Pretty: opts.Pretty, Products: opts.Products}
+	return appcore.Run(parent, stdout, stderr, coreOpts, pairs, visitor.Visit, writer)
+}
+
+func Run(argv []string, stdout, stderr io.Writer) int {
+	return RunContext(context.Background(), argv, stdout, stderr)
+}
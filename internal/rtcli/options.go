@@ -0,0 +1,153 @@
+package rtcli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/rt"
+	"ipcr/internal/clibase"
+	"ipcr/internal/cliutil"
+	"ipcr/internal/output"
+	"ipcr/internal/pipeline"
+	"slices"
+)
+
+type Options struct {
+	clibase.Common
+
+	// Reverse transcription
+	RT           string // oligo-dt | random | gene-specific primer
+	Priming      rt.Priming
+	RTMismatches int
+	RTLength     int
+
+	// Transcript models: positional files are the genome
+	GTF    string
+	NoGDNA bool
+}
+
+// unsupported lists shared flags that do not apply to cDNA templates, which
+// are built in memory and scanned whole.
+var unsupported = []string{"index", "regions", "exclude-regions", "circular", "c", "chunk-size", "summary"}
+
+// usageScope hides the shared flags ipcr-rt rejects or ignores, including
+// --mask-policy and --summary's --assembly-map.
+var usageScope = clibase.UsageScope{
+	Hide:        []string{"index", "regions", "exclude-regions", "circular", "chunk-size", "summary", "assembly-map", "mask-policy"},
+	TabularOnly: true,
+}
+
+func NewFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	clibase.UsageCommonScoped(fs, name, usageScope, func(out io.Writer, def func(string) string) {
+		_, _ = fmt.Fprintln(out, "Usage:")
+		_, _ = fmt.Fprintf(out, "  %s [options] --forward AAA --reverse TTT --rt oligo-dt transcripts.fa\n", name)
+		_, _ = fmt.Fprintf(out, "  %s [options] --forward AAA --reverse TTT --rt random --gtf genes.gtf genome.fa\n", name)
+
+		_, _ = fmt.Fprintln(out, "\nReverse transcription:")
+		_, _ = fmt.Fprintln(out, "      --rt string              RT primer: oligo-dt | random | gene-specific sequence (5'→3') [required]")
+		_, _ = fmt.Fprintf(out, "      --rt-mismatches int      Max mismatches for a gene-specific RT primer [%s]\n", def("rt-mismatches"))
+		_, _ = fmt.Fprintf(out, "      --rt-length int          Max cDNA length from the RT primer (0 = to the RNA 5' end) [%s]\n", def("rt-length"))
+
+		_, _ = fmt.Fprintln(out, "\nTranscripts:")
+		_, _ = fmt.Fprintln(out, "      --gtf string             Transcript models (exons); positional files are then the genome")
+		_, _ = fmt.Fprintf(out, "      --no-gdna                With --gtf, scan only cDNA, not genomic DNA [%s]\n", def("no-gdna"))
+	})
+	return fs
+}
+
+func Parse() (Options, error) { return ParseArgs(NewFlagSet("ipcr-rt"), nil) }
+
+// PrintExamples prints a tiny, focused quickstart for ipcr-rt.
+func PrintExamples(out io.Writer) {
+	clibase.PrintExamples(out, "ipcr-rt", func(w io.Writer) {
+		_, _ = fmt.Fprintln(out, "RT-PCR: reverse transcription, then PCR on the cDNA.")
+		_, _ = fmt.Fprintln(out, "Give RNA FASTA (U or T), or a genome with --gtf to splice transcripts;")
+		_, _ = fmt.Fprintln(out, "primers spanning an exon-exon junction are reported as cDNA-specific.")
+		_, _ = fmt.Fprintln(out, "\nExample:")
+		_, _ = fmt.Fprintln(out, "  ipcr-rt \\")
+		_, _ = fmt.Fprintln(out, "    -f GAAGGTGAAGGTCGGAGTCA \\")
+		_, _ = fmt.Fprintln(out, "    -r TTGAGGTCAATGAAGGGGTC \\")
+		_, _ = fmt.Fprintln(out, "    --rt oligo-dt \\")
+		_, _ = fmt.Fprintln(out, "    --gtf gencode.gtf.gz \\")
+		_, _ = fmt.Fprintln(out, "    GRCh38.fa.gz")
+	})
+}
+
+func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
+	var o Options
+	var help bool
+	var showExamples bool
+
+	var c clibase.Common
+	noHeader := clibase.Register(fs, &c)
+
+	fs.StringVar(&o.RT, "rt", "", "RT primer: oligo-dt | random | sequence (5'→3') [required]")
+	fs.IntVar(&o.RTMismatches, "rt-mismatches", 0, "max mismatches for a gene-specific RT primer [0]")
+	fs.IntVar(&o.RTLength, "rt-length", 0, "max cDNA length (0 = unlimited) [0]")
+	fs.StringVar(&o.GTF, "gtf", "", "transcript models (GTF); positional files are the genome")
+	fs.BoolVar(&o.NoGDNA, "no-gdna", false, "with --gtf, skip genomic DNA [false]")
+
+	// Help / examples
+	fs.BoolVar(&help, "h", false, "show this help [false]")
+	fs.BoolVar(&showExamples, "examples", false, "show quickstart examples and exit [false]")
+
+	flagArgs, posArgs := cliutil.SplitFlagsAndPositionals(fs, argv)
+	if err := fs.Parse(flagArgs); err != nil {
+		return o, err
+	}
+	if showExamples {
+		return o, clibase.ErrPrintedAndExitOK
+	}
+	if help {
+		return o, flag.ErrHelp
+	}
+	if c.Version {
+		o.Common = c
+		return o, nil
+	}
+
+	if err := clibase.AfterParse(fs, &c, noHeader, posArgs); err != nil {
+		return o, err
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if err == nil && slices.Contains(unsupported, f.Name) {
+			err = fmt.Errorf("--%s is not supported by ipcr-rt", f.Name)
+		}
+	})
+	if err != nil {
+		return o, err
+	}
+	if c.MaskPolicy != string(pipeline.MaskIgnore) {
+		return o, fmt.Errorf("--mask-policy is not supported by ipcr-rt")
+	}
+	if !output.IsTabular(c.Output) {
+		return o, fmt.Errorf("ipcr-rt supports text, json or jsonl output")
+	}
+
+	if o.RT == "" {
+		return o, fmt.Errorf("--rt is required (oligo-dt, random or a primer sequence)")
+	}
+	if o.Priming, err = rt.ParsePriming(o.RT); err != nil {
+		return o, fmt.Errorf("--rt: %w", err)
+	}
+	if o.RTMismatches < 0 {
+		return o, fmt.Errorf("--rt-mismatches must be ≥ 0")
+	}
+	if o.RTMismatches > 0 && o.Priming.Kind != rt.Specific {
+		return o, fmt.Errorf("--rt-mismatches needs a gene-specific --rt primer")
+	}
+	if o.RTLength < 0 {
+		return o, fmt.Errorf("--rt-length must be ≥ 0")
+	}
+	o.Priming.MaxMM = o.RTMismatches
+	o.Priming.MaxLen = o.RTLength
+	if o.NoGDNA && o.GTF == "" {
+		return o, fmt.Errorf("--no-gdna requires --gtf")
+	}
+
+	o.Common = c
+	return o, nil
+}
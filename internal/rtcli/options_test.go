@@ -0,0 +1,63 @@
+package rtcli
+
+import (
+	"bytes"
+	"flag"
+	"ipcr-core/rt"
+	"strings"
+	"testing"
+)
+
+func newFS() *flag.FlagSet { return NewFlagSet("test") }
+
+func TestRTPriming(t *testing.T) {
+	o, err := ParseArgs(newFS(), []string{"-f", "aaa", "-r", "ttt", "--rt", "acgtacgt", "--rt-mismatches", "1", "--rt-length", "500", "rna.fa"})
+	if err != nil {
+		t.Fatalf("parse err: %v", err)
+	}
+	if o.Priming.Kind != rt.Specific || string(o.Priming.Primer) != "ACGTACGT" || o.Priming.MaxMM != 1 || o.Priming.MaxLen != 500 {
+		t.Fatalf("unexpected priming: %+v", o.Priming)
+	}
+	o, err = ParseArgs(newFS(), []string{"-f", "aaa", "-r", "ttt", "--rt", "oligo-dT", "--gtf", "genes.gtf", "--no-gdna", "genome.fa"})
+	if err != nil || o.Priming.Kind != rt.OligoDT || o.GTF != "genes.gtf" || !o.NoGDNA {
+		t.Fatalf("unexpected options: %+v (%v)", o, err)
+	}
+}
+
+func TestRTRejects(t *testing.T) {
+	for _, bad := range [][]string{
+		{},
+		{"--rt", "ACGX"},
+		{"--rt", "random", "--rt-mismatches", "1"},
+		{"--rt", "random", "--no-gdna"},
+		{"--rt", "random", "--circular"},
+		{"--rt", "random", "--regions", "x.bed"},
+		{"--rt", "random", "--summary"},
+		{"--rt", "random", "--mask-policy", "report"},
+		{"--rt", "random", "-o", "bed"},
+	} {
+		args := append([]string{"-f", "aaa", "-r", "ttt"}, bad...)
+		if _, err := ParseArgs(newFS(), append(args, "rna.fa")); err == nil {
+			t.Errorf("%v: expected an error", bad)
+		}
+	}
+}
+
+func TestHelpListsOnlyHonouredFlags(t *testing.T) {
+	fs := newFS()
+	_, _ = ParseArgs(fs, []string{"-h"}) // registers the flags
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.Usage()
+	help := buf.String()
+	for _, want := range []string{"--rt string", "--gtf", "--threads", "text | json | jsonl ["} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help lacks %q", want)
+		}
+	}
+	for _, bad := range []string{"--index", "--regions", "--exclude-regions", "--chunk-size", "--circular", "--summary", "--assembly-map", "--mask-policy", "bed12"} {
+		if strings.Contains(help, bad) {
+			t.Errorf("help lists %q", bad)
+		}
+	}
+}
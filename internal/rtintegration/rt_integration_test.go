@@ -0,0 +1,125 @@
+package rtintegration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/internal/rtapp"
+	"ipcr/internal/rtoutput"
+	"ipcr/pkg/api"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, name, data string) string {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return name
+}
+
+func rc(s string) string { return string(primer.RevComp([]byte(s))) }
+
+func run(t *testing.T, args ...string) []api.RTProductV1 {
+	t.Helper()
+	var out, errB bytes.Buffer
+	if code := rtapp.Run(append([]string{"--self=false", "-o", "json"}, args...), &out, &errB); code != 0 {
+		t.Fatalf("%v: exit %d: %s", args, code, errB.String())
+	}
+	var v []api.RTProductV1
+	if err := json.Unmarshal(out.Bytes(), &v); err != nil {
+		t.Fatalf("bad JSON: %v\n%s", err, out.String())
+	}
+	return v
+}
+
+func TestRNAFastaAndRTPriming(t *testing.T) {
+	randSeq := seqtest.Random(17)
+	dna := randSeq(300)
+	rna := strings.ReplaceAll(dna, "T", "U")
+	fa := write(t, filepath.Join(t.TempDir(), "rna.fa"), ">r\n"+rna+"\n")
+	fwd, rev := dna[20:40], rc(dna[100:120])
+	gsp := rc(dna[200:220]) // gene-specific RT primer, antisense to the RNA
+
+	got := run(t, "-f", fwd, "-r", rev, "--rt", "oligo-dt", fa)
+	if len(got) != 1 || got[0].SequenceID != "r" || got[0].Start != 20 || got[0].End != 120 || got[0].Template != rtoutput.TemplateCDNA {
+		t.Fatalf("RNA template: %+v", got)
+	}
+	// The RT copies 150 nt upstream of the gene-specific primer, which
+	// misses the forward site; without a limit it reaches the 5' end.
+	if got := run(t, "-f", fwd, "-r", rev, "--rt", gsp, "--rt-length", "150", fa); len(got) != 0 {
+		t.Fatalf("short cDNA should not amplify: %+v", got)
+	}
+	if got := run(t, "-f", fwd, "-r", rev, "--rt", gsp, fa); len(got) != 1 || got[0].Start != 20 {
+		t.Fatalf("gene-specific cDNA: %+v", got)
+	}
+	if got := run(t, "-f", fwd, "-r", rev, "--rt", "oligo-dt", "--rt-length", "100", fa); len(got) != 0 {
+		t.Fatalf("oligo-dT cDNA covers only the 3' end: %+v", got)
+	}
+}
+
+func TestGTFJunctionsAndGDNA(t *testing.T) {
+	randSeq := seqtest.Random(117)
+	dir := t.TempDir()
+	e1, e2, e3 := randSeq(100), randSeq(100), randSeq(100)
+	genome := randSeq(50) + e1 + randSeq(300) + e2 + randSeq(300) + e3 + randSeq(50)
+	fa := write(t, filepath.Join(dir, "genome.fa"), ">chr1\n"+genome+"\n")
+	gtf := write(t, filepath.Join(dir, "genes.gtf"), ""+
+		"chr1\tt\texon\t51\t150\t.\t+\t.\tgene_id \"g1\"; transcript_id \"tx1\"; gene_name \"GENE1\";\n"+
+		"chr1\tt\texon\t451\t550\t.\t+\t.\tgene_id \"g1\"; transcript_id \"tx1\"; gene_name \"GENE1\";\n"+
+		"chr1\tt\texon\t851\t950\t.\t+\t.\tgene_id \"g1\"; transcript_id \"tx1\"; gene_name \"GENE1\";\n")
+
+	// Forward primer across the exon 1/2 junction: cDNA only.
+	span := run(t, "-f", e1[90:]+e2[:10], "-r", rc(e3[80:]), "--rt", "random", "--gtf", gtf, fa)
+	if len(span) != 1 {
+		t.Fatalf("junction primer: want one cDNA product, got %+v", span)
+	}
+	p := span[0]
+	if p.SequenceID != "tx1" || p.Template != rtoutput.TemplateCDNA || p.Gene != "GENE1" || !p.CDNASpecific ||
+		p.Junctions != 2 || strings.Join(p.JunctionPrimers, ",") != "forward" || p.Start != 90 || p.End != 300 || p.GenomicLength != 810 {
+		t.Fatalf("junction product: %+v", p)
+	}
+
+	// Swapped pair: the junction primer is now the pair's reverse primer.
+	swapped := run(t, "-f", rc(e3[80:]), "-r", e1[90:]+e2[:10], "--rt", "random", "--gtf", gtf, fa)
+	if len(swapped) != 1 || strings.Join(swapped[0].JunctionPrimers, ",") != "reverse" {
+		t.Fatalf("swapped junction pair: %+v", swapped)
+	}
+
+	// Primers inside exons 1 and 2 also amplify genomic DNA across the intron.
+	both := run(t, "-f", e1[20:40], "-r", rc(e2[60:80]), "--rt", "random", "--gtf", gtf, "--sort", fa)
+	if len(both) != 2 {
+		t.Fatalf("intron-spanning pair: want cDNA and gDNA products, got %+v", both)
+	}
+	var cdna, gdna api.RTProductV1
+	for _, p := range both {
+		switch p.Template {
+		case rtoutput.TemplateCDNA:
+			cdna = p
+		case rtoutput.TemplateGDNA:
+			gdna = p
+		}
+	}
+	if cdna.Length != 160 || cdna.CDNASpecific || cdna.Junctions != 1 || cdna.GenomicLength != 460 {
+		t.Fatalf("cDNA product: %+v", cdna)
+	}
+	if gdna.SequenceID != "chr1" || gdna.Length != 460 || gdna.CDNASpecific || gdna.Gene != "" {
+		t.Fatalf("gDNA product: %+v", gdna)
+	}
+	if got := run(t, "-f", e1[20:40], "-r", rc(e2[60:80]), "--rt", "random", "--gtf", gtf, "--no-gdna", fa); len(got) != 1 || got[0].Template != rtoutput.TemplateCDNA {
+		t.Fatalf("--no-gdna: %+v", got)
+	}
+
+	var out, errB bytes.Buffer
+	if code := rtapp.Run([]string{"--self=false", "-f", e1[20:40], "-r", rc(e2[60:80]), "--rt", "random", "--gtf", gtf, fa}, &out, &errB); code != 0 {
+		t.Fatalf("text: exit %d: %s", code, errB.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if lines[0] != rtoutput.TSVHeader || len(lines) != 3 {
+		t.Fatalf("text output:\n%s", out.String())
+	}
+}
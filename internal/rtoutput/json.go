@@ -0,0 +1,50 @@
+// internal/rtoutput/json.go
+package rtoutput
+
+import (
+	"encoding/json"
+	"io"
+	"ipcr/internal/jsonutil"
+	"ipcr/internal/output"
+	"ipcr/pkg/api"
+)
+
+// ToAPI converts an RT product to its wire form.
+func ToAPI(p RTProduct) api.RTProductV1 {
+	return api.RTProductV1{
+		ProductV1: output.ToAPIProduct(p.Product),
+		Template:  p.Template, Gene: p.Gene, Junctions: p.Junctions, JunctionPrimers: p.JunctionPrimers(),
+		GenomicLength: p.GenomicLength, CDNASpecific: p.CDNASpecific(),
+	}
+}
+
+// WriteJSON writes RT products as one JSON array.
+func WriteJSON(w io.Writer, list []RTProduct) error {
+	out := make([]api.RTProductV1, len(list))
+	for i, p := range list {
+		out[i] = ToAPI(p)
+	}
+	return jsonutil.EncodePretty(w, out)
+}
+
+// WriteJSONL writes one JSON object per line.
+func WriteJSONL(w io.Writer, list []RTProduct) error {
+	enc := json.NewEncoder(w)
+	for _, p := range list {
+		if err := enc.Encode(ToAPI(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamJSONL writes one JSON object per product as it arrives.
+func StreamJSONL(w io.Writer, in <-chan RTProduct) error {
+	enc := json.NewEncoder(w)
+	for p := range in {
+		if err := enc.Encode(ToAPI(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// internal/rtoutput/text.go
+package rtoutput
+
+import (
+	"io"
+	"ipcr/internal/output"
+	"strconv"
+	"strings"
+)
+
+// FormatRowTSV renders an RT product as one TSV row (no trailing newline).
+// Empty values are "-"; genomic_length is "-" unless transcript models
+// give one.
+func FormatRowTSV(p RTProduct) string {
+	gl := "-"
+	if p.GenomicLength > 0 {
+		gl = strconv.Itoa(p.GenomicLength)
+	}
+	return strings.Join([]string{
+		output.FormatBaseRowTSV(p.Product), p.Template, dash(p.Gene), strconv.Itoa(p.Junctions),
+		dash(strings.Join(p.JunctionPrimers(), ",")), gl, strconv.FormatBool(p.CDNASpecific()),
+	}, "\t")
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// WriteText writes RT products as TSV rows, each optionally followed by a
+// pretty block (render may be nil).
+func WriteText(w io.Writer, list []RTProduct, header bool, render func(RTProduct) string) error {
+	if header {
+		if _, err := io.WriteString(w, TSVHeader+"\n"); err != nil {
+			return err
+		}
+	}
+	for _, p := range list {
+		if err := writeRow(w, p, render); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamText is WriteText for a channel of products.
+func StreamText(w io.Writer, in <-chan RTProduct, header bool, render func(RTProduct) string) error {
+	if header {
+		if _, err := io.WriteString(w, TSVHeader+"\n"); err != nil {
+			return err
+		}
+	}
+	for p := range in {
+		if err := writeRow(w, p, render); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeRow(w io.Writer, p RTProduct, render func(RTProduct) string) error {
+	if _, err := io.WriteString(w, FormatRowTSV(p)+"\n"); err != nil {
+		return err
+	}
+	if render != nil {
+		if _, err := io.WriteString(w, render(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// internal/rtoutput/types.go
+package rtoutput
+
+import (
+	"ipcr-core/engine"
+	"ipcr/internal/output"
+)
+
+// Templates an RT-PCR product can come from.
+const (
+	TemplateCDNA = "cdna"
+	TemplateGDNA = "gdna"
+)
+
+// RTProduct = base Product + the template it was amplified from. cDNA
+// products are in transcript coordinates; the junction fields need
+// transcript models (GTF) and stay zero for RNA FASTA templates.
+type RTProduct struct {
+	Product  engine.Product
+	Template string // TemplateCDNA or TemplateGDNA
+	Gene     string
+
+	Junctions     int  // exon-exon junctions inside the product
+	FwdJunction   bool // the pair's forward primer site spans a junction
+	RevJunction   bool // the pair's reverse primer site spans a junction
+	GenomicLength int  // genomic span of a cDNA product, introns included
+}
+
+// CDNASpecific reports whether a primer spans an exon-exon junction, so the
+// product cannot arise from genomic DNA at this locus.
+func (p RTProduct) CDNASpecific() bool { return p.FwdJunction || p.RevJunction }
+
+// JunctionPrimers names the primers spanning a junction.
+func (p RTProduct) JunctionPrimers() []string {
+	var out []string
+	if p.FwdJunction {
+		out = append(out, "forward")
+	}
+	if p.RevJunction {
+		out = append(out, "reverse")
+	}
+	return out
+}
+
+// TSVHeader extends the canonical base header with the template columns.
+const TSVHeader = output.TSVHeader + "\ttemplate\tgene\tjunctions\tjunction_primers\tgenomic_length\tcdna_specific"
@@ -0,0 +1,138 @@
+// internal/serveapp/app.go
+package serveapp
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr/internal/server"
+	"net"
+	"net/http"
+	"time"
+)
+
+func usage(out io.Writer) {
+	_, _ = fmt.Fprintln(out, "Usage:")
+	_, _ = fmt.Fprintln(out, "  ipcr serve --ref NAME=ref.fa[,more.fa] [--ref NAME=ref.ipcridx ...] [options]")
+	_, _ = fmt.Fprintln(out, "\nLoad reference collections once and run primer panels against them over HTTP.")
+	_, _ = fmt.Fprintln(out, "Jobs: POST /v1/jobs, GET /v1/jobs/{id}, GET /v1/jobs/{id}/results (JSONL),")
+	_, _ = fmt.Fprintln(out, "DELETE /v1/jobs/{id}; GET /v1/references lists the loaded references.")
+	_, _ = fmt.Fprintln(out, "\nOptions:")
+	_, _ = fmt.Fprintln(out, "      --ref NAME=FILE[,FILE]  Reference collection, FASTA or one index (repeatable, required)")
+	_, _ = fmt.Fprintln(out, "      --listen addr           Address to listen on [127.0.0.1:8080]")
+	_, _ = fmt.Fprintln(out, "  -t, --threads int           Worker threads per job (0=all CPUs) [0]")
+	_, _ = fmt.Fprintln(out, "      --max-running int       Jobs scanning at once; later jobs queue [1]")
+	_, _ = fmt.Fprintln(out, "      --max-jobs int          Finished jobs kept for status and results [100]")
+	_, _ = fmt.Fprintln(out, "      --panel-cache int       Compiled primer panels kept across jobs [64]")
+	_, _ = fmt.Fprintln(out, "      --max-results int       Products kept per job; a job producing more fails (0=unlimited) [1000000]")
+	_, _ = fmt.Fprintln(out, "  -q, --quiet                 Suppress startup messages [false]")
+}
+
+// refList is a flag.Value collecting repeated --ref values.
+type refList []string
+
+func (r *refList) String() string     { return fmt.Sprint(*r) }
+func (r *refList) Set(v string) error { *r = append(*r, v); return nil }
+
+// RunContext implements `ipcr serve`. argv excludes "serve". It serves
+// until ctx is cancelled, then cancels running jobs.
+func RunContext(ctx context.Context, argv []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ipcr serve", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var (
+		refs   refList
+		listen string
+		cfg    server.Config
+		quiet  bool
+	)
+	fs.Var(&refs, "ref", "reference collection NAME=FILE[,FILE...]")
+	fs.StringVar(&listen, "listen", "127.0.0.1:8080", "address to listen on")
+	fs.IntVar(&cfg.Threads, "threads", 0, "worker threads per job")
+	fs.IntVar(&cfg.Threads, "t", 0, "alias of --threads")
+	fs.IntVar(&cfg.MaxRunning, "max-running", 1, "jobs scanning at once")
+	fs.IntVar(&cfg.MaxJobs, "max-jobs", 100, "finished jobs kept")
+	fs.IntVar(&cfg.PanelCache, "panel-cache", 64, "compiled primer panels kept")
+	fs.IntVar(&cfg.MaxResults, "max-results", 1000000, "products kept per job")
+	fs.BoolVar(&quiet, "quiet", false, "suppress startup messages")
+	fs.BoolVar(&quiet, "q", false, "alias of --quiet")
+
+	if err := fs.Parse(argv); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			usage(stdout)
+			return 0
+		}
+		_, _ = fmt.Fprintln(stderr, err)
+		usage(stderr)
+		return 2
+	}
+	switch {
+	case fs.NArg() > 0:
+		_, _ = fmt.Fprintf(stderr, "unexpected argument %q; give references with --ref\n", fs.Arg(0))
+		return 2
+	case len(refs) == 0:
+		_, _ = fmt.Fprintln(stderr, "at least one --ref is required")
+		return 2
+	case cfg.Threads < 0 || cfg.MaxResults < 0:
+		_, _ = fmt.Fprintln(stderr, "--threads and --max-results must be ≥ 0")
+		return 2
+	case cfg.MaxRunning < 1 || cfg.MaxJobs < 1 || cfg.PanelCache < 1:
+		_, _ = fmt.Fprintln(stderr, "--max-running, --max-jobs and --panel-cache must be ≥ 1")
+		return 2
+	}
+
+	var loaded []*server.Reference
+	for _, spec := range refs {
+		name, files, err := server.ParseRef(spec)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		ref, err := server.LoadReference(ctx, name, files)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return 130
+			}
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		r := ref.API()
+		if !quiet {
+			_, _ = fmt.Fprintf(stderr, "loaded reference %s: %d record(s), %d bp\n", r.Name, r.Records, r.Bases)
+		}
+		loaded = append(loaded, ref)
+	}
+	srv, err := server.New(loaded, cfg)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer srv.Close()
+
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+	hs := &http.Server{Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	served := make(chan error, 1)
+	go func() { served <- hs.Serve(ln) }()
+	if !quiet {
+		_, _ = fmt.Fprintf(stderr, "listening on http://%s\n", ln.Addr())
+	}
+
+	select {
+	case err := <-served:
+		_, _ = fmt.Fprintln(stderr, err)
+		return 3
+	case <-ctx.Done():
+	}
+	// Result streams follow running jobs: cancel those first so the streams
+	// end, then let in-flight responses finish.
+	srv.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = hs.Shutdown(shutdownCtx)
+	return 0
+}
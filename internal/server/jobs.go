@@ -0,0 +1,117 @@
+// internal/server/jobs.go
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ipcr/pkg/api"
+	"sync"
+	"time"
+)
+
+// job is one submitted scan. Its products are kept for result readers,
+// who wait on changed for more, up to limit of them.
+type job struct {
+	id        string
+	ref       string
+	cancel    context.CancelFunc
+	submitted time.Time
+	limit     int // products kept; <1 means unlimited
+
+	mu       sync.Mutex
+	state    string
+	products []api.ProductV1
+	warnings []string
+	err      string
+	started  time.Time
+	finished time.Time
+	changed  chan struct{} // closed (and replaced) on every update
+}
+
+func newJob(ref string, cancel context.CancelFunc, limit int) *job {
+	return &job{ref: ref, cancel: cancel, submitted: time.Now(), limit: limit, state: api.JobQueued, changed: make(chan struct{})}
+}
+
+// notify wakes result readers. Callers hold j.mu.
+func (j *job) notify() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+func (j *job) warn(msg string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.warnings = append(j.warnings, msg)
+}
+
+func (j *job) start() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state, j.started = api.JobRunning, time.Now()
+}
+
+// add keeps p for result readers. Past the limit it keeps nothing more
+// and fails the scan, which then ends with the job failed.
+func (j *job) add(p api.ProductV1) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.limit > 0 && len(j.products) >= j.limit {
+		return fmt.Errorf("more than %d products; narrow the scan or raise --max-results", j.limit)
+	}
+	j.products = append(j.products, p)
+	j.notify()
+	return nil
+}
+
+// finish records how the scan ended: a cancelled context (DELETE, or the
+// server closing) is a cancellation rather than a failure.
+func (j *job) finish(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch {
+	case err == nil:
+		j.state = api.JobDone
+	case errors.Is(err, context.Canceled):
+		j.state = api.JobCancelled
+	default:
+		j.state, j.err = api.JobFailed, err.Error()
+	}
+	j.finished = time.Now()
+	j.notify()
+}
+
+func (j *job) done() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return !j.finished.IsZero()
+}
+
+// since returns the products from index i on, whether the job has
+// finished, and a channel closed on the next update.
+func (j *job) since(i int) ([]api.ProductV1, bool, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.products[i:], !j.finished.IsZero(), j.changed
+}
+
+func (j *job) api() api.JobV1 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	v := api.JobV1{
+		ID:        j.id,
+		Reference: j.ref,
+		State:     j.state,
+		Products:  len(j.products),
+		Error:     j.err,
+		Warnings:  append([]string(nil), j.warnings...),
+		Submitted: j.submitted.UTC().Format(time.RFC3339),
+	}
+	if !j.started.IsZero() {
+		v.Started = j.started.UTC().Format(time.RFC3339)
+	}
+	if !j.finished.IsZero() {
+		v.Finished = j.finished.UTC().Format(time.RFC3339)
+	}
+	return v
+}
@@ -0,0 +1,72 @@
+// internal/server/refs.go
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ipcr/internal/pipeline"
+	"ipcr/pkg/api"
+	"strings"
+)
+
+// IndexSuffix marks a reference index file (ipcr index build) in --ref.
+const IndexSuffix = ".ipcridx"
+
+// Reference is a sequence collection held in memory for every job.
+type Reference struct {
+	Name    string
+	Files   []string
+	Indexed bool // loaded from a reference index: seeds kept, no soft-mask
+
+	inputs []pipeline.Input
+	bases  int
+}
+
+// ParseRef reads a --ref value, "NAME=FILE[,FILE...]".
+func ParseRef(s string) (name string, files []string, err error) {
+	name, list, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.TrimSpace(list) == "" {
+		return "", nil, fmt.Errorf("--ref %q: want NAME=FILE[,FILE...]", s)
+	}
+	for _, f := range strings.Split(list, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			files = append(files, f)
+		}
+	}
+	return name, files, nil
+}
+
+// LoadReference reads files into memory: FASTA records whole, soft-masking
+// kept, or one reference index with its seed tables.
+func LoadReference(ctx context.Context, name string, files []string) (*Reference, error) {
+	ref := &Reference{Name: name, Files: files}
+	var src pipeline.Source
+	switch {
+	case len(files) == 1 && strings.HasSuffix(files[0], IndexSuffix):
+		ref.Indexed = true
+		src = pipeline.IndexSource(files[0])
+	default:
+		for _, f := range files {
+			if strings.HasSuffix(f, IndexSuffix) {
+				return nil, errors.New("--ref: an index must be the only file of its reference")
+			}
+		}
+		src = pipeline.MaskedFASTASource(files, 0, 0)
+	}
+	in, err := pipeline.Preload(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("reference %s: %w", name, err)
+	}
+	ref.inputs = in
+	for _, x := range in {
+		ref.bases += len(x.Rec.Seq)
+	}
+	return ref, nil
+}
+
+// API describes ref on the wire.
+func (r *Reference) API() api.ReferenceV1 {
+	return api.ReferenceV1{Name: r.Name, Files: r.Files, Records: len(r.inputs), Bases: r.bases, Indexed: r.Indexed}
+}
@@ -0,0 +1,342 @@
+// internal/server/server.go
+
+// Package server is the HTTP job API of `ipcr serve`: references are loaded
+// once, compiled primer panels are cached, and each submitted panel runs as
+// a job whose products stream back as JSONL.
+//
+//	GET    /v1/references          loaded references
+//	POST   /v1/jobs                submit an api.JobRequestV1 → 202 api.JobV1
+//	GET    /v1/jobs                every job kept, oldest first
+//	GET    /v1/jobs/{id}           job status
+//	GET    /v1/jobs/{id}/results   products as JSONL, following a running job
+//	DELETE /v1/jobs/{id}           cancel
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"ipcr/internal/appcore"
+	"ipcr/internal/clibase"
+	"ipcr/internal/common"
+	"ipcr/internal/jsonlutil"
+	"ipcr/internal/output"
+	"ipcr/internal/pipeline"
+	"ipcr/internal/runutil"
+	"ipcr/internal/writers"
+	"ipcr/pkg/api"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Config tunes a Server.
+type Config struct {
+	Threads    int // worker threads per job (0 = all CPUs)
+	MaxRunning int // jobs scanning at once; later ones queue (<1 means 1)
+	MaxJobs    int // finished jobs kept for status and results (<1 means 1)
+	PanelCache int // compiled primer panels kept across jobs
+	MaxResults int // products kept per job; a job producing more fails (<1 means unlimited)
+}
+
+// Server runs jobs against preloaded references.
+type Server struct {
+	cfg    Config
+	refs   map[string]*Reference
+	names  []string
+	panels *appcore.PanelCache
+	slots  chan struct{} // one per running job
+
+	ctx  context.Context // parent of every job; cancelled by Close
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+
+	mu    sync.Mutex
+	jobs  map[string]*job
+	order []string // submission order
+	seq   int
+}
+
+// New serves refs, which must have distinct names.
+func New(refs []*Reference, cfg Config) (*Server, error) {
+	s := &Server{
+		cfg:    cfg,
+		refs:   make(map[string]*Reference, len(refs)),
+		panels: appcore.NewPanelCache(cfg.PanelCache),
+		slots:  make(chan struct{}, max(1, cfg.MaxRunning)),
+		jobs:   make(map[string]*job),
+	}
+	for _, r := range refs {
+		if s.refs[r.Name] != nil {
+			return nil, fmt.Errorf("reference %q given twice", r.Name)
+		}
+		s.refs[r.Name] = r
+		s.names = append(s.names, r.Name)
+	}
+	s.ctx, s.stop = context.WithCancel(context.Background())
+	return s, nil
+}
+
+// Close cancels every job and waits for them to stop.
+func (s *Server) Close() {
+	s.stop()
+	s.wg.Wait()
+}
+
+// Handler routes the job API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/references", s.handleReferences)
+	mux.HandleFunc("POST /v1/jobs", s.handleSubmit)
+	mux.HandleFunc("GET /v1/jobs", s.handleJobs)
+	mux.HandleFunc("GET /v1/jobs/{id}", s.handleJob)
+	mux.HandleFunc("GET /v1/jobs/{id}/results", s.handleResults)
+	mux.HandleFunc("DELETE /v1/jobs/{id}", s.handleCancel)
+	return mux
+}
+
+func (s *Server) handleReferences(w http.ResponseWriter, _ *http.Request) {
+	out := make([]api.ReferenceV1, 0, len(s.names))
+	for _, n := range s.names {
+		out = append(out, s.refs[n].API())
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	req := DefaultJobRequest()
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("bad job request: %w", err))
+		return
+	}
+	j, err := s.submit(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/jobs/"+j.id)
+	writeJSON(w, http.StatusAccepted, j.api())
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	list := make([]*job, 0, len(s.order))
+	for _, id := range s.order {
+		list = append(list, s.jobs[id])
+	}
+	s.mu.Unlock()
+	out := make([]api.JobV1, 0, len(list))
+	for _, j := range list {
+		out = append(out, j.api())
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	if j := s.lookup(w, r); j != nil {
+		writeJSON(w, http.StatusOK, j.api())
+	}
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if j := s.lookup(w, r); j != nil {
+		j.cancel()
+		writeJSON(w, http.StatusOK, j.api())
+	}
+}
+
+// handleResults streams the job's products as JSONL, each batch flushed as
+// it arrives, until the job finishes or the client goes away. A failed or
+// cancelled job ends the stream early; its status tells why.
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	j := s.lookup(w, r)
+	if j == nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encode := func(enc *json.Encoder, p api.ProductV1) error { return enc.Encode(p) }
+	next := 0
+	for {
+		batch, final, changed := j.since(next)
+		if len(batch) > 0 {
+			// Room for the whole batch, so a failed write cannot block the sender.
+			in, done := jsonlutil.Start(w, len(batch), encode, writers.IsBrokenPipe)
+			for _, p := range batch {
+				in <- p
+			}
+			close(in)
+			if err := <-done; err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			next += len(batch)
+		}
+		if final {
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) *job {
+	s.mu.Lock()
+	j := s.jobs[r.PathValue("id")]
+	s.mu.Unlock()
+	if j == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no job %q", r.PathValue("id")))
+	}
+	return j
+}
+
+// DefaultJobRequest is a job request with the CLI defaults.
+func DefaultJobRequest() api.JobRequestV1 {
+	var c clibase.Common
+	clibase.Register(flag.NewFlagSet("ipcr serve", flag.ContinueOnError), &c)
+	return api.JobRequestV1{
+		Mismatches: c.Mismatches, MaxIndels: c.MaxIndels, MinLength: c.MinLen, MaxLength: c.MaxLen,
+		HitCap: c.HitCap, TerminalWindow: c.TerminalWindow, Self: c.Self, SeedLength: c.SeedLength,
+		Circular: c.Circular, MaskPolicy: c.MaskPolicy, Bisulfite: c.Bisulfite, Products: c.Products,
+	}
+}
+
+// submit checks req as the CLI checks its flags, prepares the scan and
+// queues it.
+func (s *Server) submit(req api.JobRequestV1) (*job, error) {
+	ref := s.refs[req.Reference]
+	if ref == nil {
+		return nil, fmt.Errorf("unknown reference %q", req.Reference)
+	}
+	pairs, err := jobPairs(req)
+	if err != nil {
+		return nil, err
+	}
+	c := clibase.Common{
+		Mismatches: req.Mismatches, MaxIndels: req.MaxIndels, MinLen: req.MinLength, MaxLen: req.MaxLength,
+		HitCap: req.HitCap, TerminalWindow: req.TerminalWindow, SeedLength: req.SeedLength,
+		Circular: req.Circular, MaskPolicy: req.MaskPolicy, Bisulfite: req.Bisulfite,
+		Threads: s.cfg.Threads, Output: output.FormatJSONL,
+	}
+	if ref.Indexed {
+		c.Index = ref.Files[0]
+	} else {
+		c.SeqFiles = ref.Files
+	}
+	if err := clibase.ValidateScan(&c); err != nil {
+		return nil, err
+	}
+	if req.Self {
+		pairs = common.AddSelfPairs(pairs)
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	j := newJob(req.Reference, cancel, s.cfg.MaxResults)
+	scan, err := appcore.NewScan(appcore.Options{
+		Source: pipeline.MemorySource(ref.inputs), MaskPolicy: c.MaskPolicy, Bisulfite: c.Bisulfite,
+		MaxMM: c.Mismatches, MaxIndels: c.MaxIndels, TerminalWindow: runutil.EffectiveTerminalWindow(c.TerminalWindow),
+		MinLen: c.MinLen, MaxLen: c.MaxLen, HitCap: c.HitCap, SeedLength: c.SeedLength, Circular: c.Circular,
+		Threads: c.Threads, Panels: s.panels,
+	}, pairs, false, req.Products, j.warn)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.seq++
+	j.id = strconv.Itoa(s.seq)
+	s.jobs[j.id] = j
+	s.order = append(s.order, j.id)
+	s.evict()
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			j.finish(ctx.Err())
+			return
+		}
+		defer func() { <-s.slots }()
+		j.start()
+		_, err := appcore.Stream(ctx, scan, func(p engine.Product) (bool, api.ProductV1, error) {
+			return true, output.ToAPIProduct(p), nil
+		}, j.add)
+		j.finish(err)
+	}()
+	return j, nil
+}
+
+// jobPairs reads the primers of req: a panel, or one inline pair.
+func jobPairs(req api.JobRequestV1) ([]primer.Pair, error) {
+	inline := req.Forward != "" || req.Reverse != ""
+	switch {
+	case req.Panel != "" && inline:
+		return nil, errors.New("panel conflicts with forward/reverse")
+	case req.Panel != "":
+		pairs, err := primer.ReadTSV(strings.NewReader(req.Panel), "panel")
+		if err == nil && len(pairs) == 0 {
+			err = errors.New("panel has no primer pairs")
+		}
+		return pairs, err
+	case !inline:
+		return nil, errors.New("provide panel or forward/reverse")
+	case req.Forward == "" || req.Reverse == "":
+		return nil, errors.New("forward and reverse must be supplied together")
+	}
+	fwd, err := primer.Validate(req.Forward)
+	if err != nil {
+		return nil, fmt.Errorf("forward: %w", err)
+	}
+	rev, err := primer.Validate(req.Reverse)
+	if err != nil {
+		return nil, fmt.Errorf("reverse: %w", err)
+	}
+	return []primer.Pair{{ID: "manual", Forward: fwd, Reverse: rev, MinProduct: req.MinLength, MaxProduct: req.MaxLength}}, nil
+}
+
+// evict drops the oldest finished jobs beyond MaxJobs. Callers hold s.mu.
+func (s *Server) evict() {
+	finished := 0
+	for _, id := range s.order {
+		if s.jobs[id].done() {
+			finished++
+		}
+	}
+	keep := s.order[:0]
+	for _, id := range s.order {
+		if finished > max(1, s.cfg.MaxJobs) && s.jobs[id].done() {
+			delete(s.jobs, id)
+			finished--
+			continue
+		}
+		keep = append(keep, id)
+	}
+	s.order = keep
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
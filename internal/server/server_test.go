@@ -0,0 +1,219 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/pkg/api"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJobAPI(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(22)
+	const fwd, revSite = "GATTCAACCTTAGCCATTGA", "TTGACCATGATCCAAGTCAT"
+	rev := string(primer.RevComp([]byte(revSite)))
+	amp := fwd + randSeq(100) + revSite
+	fa := filepath.Join(dir, "ref.fa")
+	if err := os.WriteFile(fa, []byte(">a\n"+randSeq(40)+amp+randSeq(40)+"\n>b\n"+amp+"\n>c\n"+randSeq(300)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := LoadReference(context.Background(), "ref", []string{fa})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New([]*Reference{ref}, Config{Threads: 2, MaxRunning: 1, MaxJobs: 10, PanelCache: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	do := func(method, path, body string, want int, v any) {
+		t.Helper()
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != want {
+			t.Fatalf("%s %s: status %d, want %d", method, path, resp.StatusCode, want)
+		}
+		if v != nil {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatalf("%s %s: %v", method, path, err)
+			}
+		}
+	}
+	results := func(id string) []api.ProductV1 {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/v1/jobs/" + id + "/results")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		var out []api.ProductV1
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			var p api.ProductV1
+			if err := json.Unmarshal(sc.Bytes(), &p); err != nil {
+				t.Fatalf("bad JSONL line %q: %v", sc.Text(), err)
+			}
+			out = append(out, p)
+		}
+		return out
+	}
+
+	var refs []api.ReferenceV1
+	do("GET", "/v1/references", "", http.StatusOK, &refs)
+	if len(refs) != 1 || refs[0].Name != "ref" || refs[0].Records != 3 || refs[0].Indexed {
+		t.Fatalf("references: %+v", refs)
+	}
+
+	// The results stream follows the job to the end.
+	var job api.JobV1
+	do("POST", "/v1/jobs", `{"reference":"ref","forward":"`+fwd+`","reverse":"`+rev+`","self":false}`, http.StatusAccepted, &job)
+	got := results(job.ID)
+	if len(got) != 2 || got[0].ExperimentID != "manual" || got[0].Length != len(amp) || got[0].Seq != "" {
+		t.Fatalf("results: %+v", got)
+	}
+	do("GET", "/v1/jobs/"+job.ID, "", http.StatusOK, &job)
+	if job.State != api.JobDone || job.Products != 2 || job.Finished == "" {
+		t.Fatalf("status: %+v", job)
+	}
+
+	// A panel job reuses the compiled panel of an identical earlier one.
+	panel := `{"reference":"ref","panel":"p1\t` + fwd + `\t` + rev + `\n","products":true}`
+	do("POST", "/v1/jobs", panel, http.StatusAccepted, &job)
+	first := results(job.ID)
+	do("POST", "/v1/jobs", panel, http.StatusAccepted, &job)
+	again := results(job.ID)
+	if len(first) != 2 || len(again) != 2 || again[0].ExperimentID != "p1" || again[0].Seq != amp {
+		t.Fatalf("panel results: %+v / %+v", first, again)
+	}
+	if hits, _ := s.panels.Stats(); hits != 1 {
+		t.Fatalf("panel cache hits = %d, want 1", hits)
+	}
+
+	// A queued job can be cancelled before it runs.
+	s.slots <- struct{}{}
+	do("POST", "/v1/jobs", `{"reference":"ref","forward":"`+fwd+`","reverse":"`+rev+`"}`, http.StatusAccepted, &job)
+	do("GET", "/v1/jobs/"+job.ID, "", http.StatusOK, &job)
+	if job.State != api.JobQueued {
+		t.Fatalf("want a queued job, got %+v", job)
+	}
+	do("DELETE", "/v1/jobs/"+job.ID, "", http.StatusOK, nil)
+	if got := results(job.ID); len(got) != 0 {
+		t.Fatalf("cancelled job streamed %d products", len(got))
+	}
+	<-s.slots
+	do("GET", "/v1/jobs/"+job.ID, "", http.StatusOK, &job)
+	if job.State != api.JobCancelled {
+		t.Fatalf("want a cancelled job, got %+v", job)
+	}
+
+	var list []api.JobV1
+	do("GET", "/v1/jobs", "", http.StatusOK, &list)
+	if len(list) != 4 || list[0].ID != "1" {
+		t.Fatalf("jobs: %+v", list)
+	}
+
+	// Requests are checked as the CLI checks its flags.
+	for _, bad := range []string{
+		`{"reference":"nope","forward":"` + fwd + `","reverse":"` + rev + `"}`,
+		`{"reference":"ref","forward":"` + fwd + `"}`,
+		`{"reference":"ref"}`,
+		`{"reference":"ref","forward":"ACGZ","reverse":"` + rev + `"}`,
+		`{"reference":"ref","forward":"` + fwd + `","reverse":"` + rev + `","mask_policy":"hide"}`,
+		`{"reference":"ref","forward":"` + fwd + `","reverse":"` + rev + `","mismatch":1}`,
+		`not json`,
+	} {
+		var e map[string]string
+		do("POST", "/v1/jobs", bad, http.StatusBadRequest, &e)
+		if e["error"] == "" {
+			t.Fatalf("%s: no error message", bad)
+		}
+	}
+	do("GET", "/v1/jobs/99", "", http.StatusNotFound, nil)
+	do("PUT", "/v1/jobs", "", http.StatusMethodNotAllowed, nil)
+}
+
+func TestJobResultCap(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(23)
+	const fwd, revSite = "GATTCAACCTTAGCCATTGA", "TTGACCATGATCCAAGTCAT"
+	rev := string(primer.RevComp([]byte(revSite)))
+	amp := fwd + randSeq(100) + revSite
+	fa := filepath.Join(dir, "ref.fa")
+	if err := os.WriteFile(fa, []byte(">a\n"+amp+"\n>b\n"+amp+"\n>c\n"+amp+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := LoadReference(context.Background(), "ref", []string{fa})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New([]*Reference{ref}, Config{Threads: 1, MaxRunning: 1, MaxJobs: 10, PanelCache: 4, MaxResults: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/v1/jobs", "application/json",
+		strings.NewReader(`{"reference":"ref","forward":"`+fwd+`","reverse":"`+rev+`","self":false}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var job api.JobV1
+	err = json.NewDecoder(resp.Body).Decode(&job)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Three products against a cap of two: the job fails and keeps two.
+	resp, err = http.Get(ts.URL + "/v1/jobs/" + job.ID + "/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := 0
+	for sc := bufio.NewScanner(resp.Body); sc.Scan(); {
+		lines++
+	}
+	_ = resp.Body.Close()
+	resp, err = http.Get(ts.URL + "/v1/jobs/" + job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&job)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != api.JobFailed || job.Products != 2 || lines != 2 || !strings.Contains(job.Error, "more than 2 products") {
+		t.Fatalf("want a failed job with 2 products, got %+v (%d streamed)", job, lines)
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	name, files, err := ParseRef("hg38 = a.fa, b.fa.gz")
+	if err != nil || name != "hg38" || len(files) != 2 || files[1] != "b.fa.gz" {
+		t.Fatalf("ParseRef: %q %q %v", name, files, err)
+	}
+	for _, bad := range []string{"a.fa", "=a.fa", "x="} {
+		if _, _, err := ParseRef(bad); err == nil {
+			t.Fatalf("ParseRef(%q) accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// internal/siteoutput/siteoutput.go
+package siteoutput
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr/internal/jsonutil"
+	"ipcr/internal/output"
+	"ipcr/pkg/api"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TSVHeader is the header row for text binding-site output.
+const TSVHeader = "source_file\tsequence_id\texperiment_id\tprimer\tstrand\tstart\tend\tmm\tmm_i\tthree_prime_mm\tthree_prime_dist\tsite"
+
+// DefaultThreePrimeWindow is the 3' window used for three_prime_mm.
+const DefaultThreePrimeWindow = 5
+
+// ToAPI converts a site to its wire form; window sizes the 3' mismatch count.
+func ToAPI(s engine.BindingSite, window int) api.BindingSiteV1 {
+	return api.BindingSiteV1{
+		ExperimentID:   s.ExperimentID,
+		SequenceID:     s.SequenceID,
+		Primer:         s.Primer,
+		PrimerSeq:      s.PrimerSeq,
+		Strand:         s.Strand,
+		Start:          s.Start,
+		End:            s.End,
+		Mismatches:     s.Mismatches,
+		MismatchIdx:    s.MismatchIdx,
+		Indels:         output.ToAPIIndels(s.Indels),
+		ThreePrimeMM:   s.ThreePrimeMismatches(window),
+		ThreePrimeDist: s.ThreePrimeDist(),
+		Site:           s.Site,
+		Masked:         s.Masked,
+		SourceFile:     s.SourceFile,
+	}
+}
+
+// FormatRowTSV renders one site as a TSV row (no trailing newline).
+func FormatRowTSV(s engine.BindingSite, window int) string {
+	idx := "-"
+	if len(s.MismatchIdx) > 0 {
+		parts := make([]string, len(s.MismatchIdx))
+		for i, v := range s.MismatchIdx {
+			parts[i] = strconv.Itoa(v)
+		}
+		idx = strings.Join(parts, ",")
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\t%d\t%d\t%s",
+		s.SourceFile, s.SequenceID, s.ExperimentID, s.Primer, s.Strand,
+		s.Start, s.End, s.Mismatches, idx,
+		s.ThreePrimeMismatches(window), s.ThreePrimeDist(), s.Site)
+}
+
+// Sort orders sites by source, sequence, coordinates, pair, primer and strand.
+func Sort(list []engine.BindingSite) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		switch {
+		case a.SourceFile != b.SourceFile:
+			return a.SourceFile < b.SourceFile
+		case a.SequenceID != b.SequenceID:
+			return a.SequenceID < b.SequenceID
+		case a.Start != b.Start:
+			return a.Start < b.Start
+		case a.End != b.End:
+			return a.End < b.End
+		case a.ExperimentID != b.ExperimentID:
+			return a.ExperimentID < b.ExperimentID
+		case a.Primer != b.Primer:
+			return a.Primer < b.Primer
+		default:
+			return a.Strand < b.Strand
+		}
+	})
+}
+
+// WriteTSV writes sites as TSV rows.
+func WriteTSV(w io.Writer, list []engine.BindingSite, header bool, window int) error {
+	if header {
+		if _, err := io.WriteString(w, TSVHeader+"\n"); err != nil {
+			return err
+		}
+	}
+	for _, s := range list {
+		if _, err := io.WriteString(w, FormatRowTSV(s, window)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes sites as one JSON array.
+func WriteJSON(w io.Writer, list []engine.BindingSite, window int) error {
+	out := make([]api.BindingSiteV1, len(list))
+	for i, s := range list {
+		out[i] = ToAPI(s, window)
+	}
+	return jsonutil.EncodePretty(w, out)
+}
+
+// WriteJSONL writes one JSON object per line.
+func WriteJSONL(w io.Writer, list []engine.BindingSite, window int) error {
+	enc := json.NewEncoder(w)
+	for _, s := range list {
+		if err := enc.Encode(ToAPI(s, window)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
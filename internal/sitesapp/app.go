@@ -0,0 +1,107 @@
+// internal/sitesapp/app.go
+package sitesapp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/primer"
+	"ipcr/internal/appcore"
+	"ipcr/internal/clibase"
+	"ipcr/internal/runutil"
+	"ipcr/internal/sitescli"
+	"ipcr/internal/version"
+	"ipcr/internal/writers"
+)
+
+// RunContext implements `ipcr sites`. argv excludes "sites".
+func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer) int {
+	outw := bufio.NewWriter(stdout)
+	defer func() { _ = outw.Flush() }()
+
+	fs := sitescli.NewFlagSet("ipcr sites")
+	fs.SetOutput(io.Discard)
+
+	if len(argv) == 0 {
+		_, _ = sitescli.ParseArgs(fs, []string{"-h"})
+		fs.SetOutput(outw)
+		fs.Usage()
+		if err := outw.Flush(); writers.IsBrokenPipe(err) {
+			return 0
+		} else if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 3
+		}
+		return 0
+	}
+
+	opts, err := sitescli.ParseArgs(fs, argv)
+	if err != nil {
+		if errors.Is(err, clibase.ErrPrintedAndExitOK) {
+			sitescli.PrintExamples(outw)
+			if e := outw.Flush(); writers.IsBrokenPipe(e) {
+				return 0
+			} else if e != nil {
+				_, _ = fmt.Fprintln(stderr, e)
+				return 3
+			}
+			return 0
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(outw)
+			fs.Usage()
+			if e := outw.Flush(); writers.IsBrokenPipe(e) {
+				return 0
+			} else if e != nil {
+				_, _ = fmt.Fprintln(stderr, e)
+				return 3
+			}
+			return 0
+		}
+		_, _ = fmt.Fprintln(stderr, err)
+		_, _ = fmt.Fprintln(stderr, "run 'ipcr sites -h' for usage")
+		return 2
+	}
+
+	if opts.Version {
+		version.Write(outw, "ipcr")
+		if e := outw.Flush(); writers.IsBrokenPipe(e) {
+			return 0
+		} else if e != nil {
+			_, _ = fmt.Fprintln(stderr, e)
+			return 3
+		}
+		return 0
+	}
+
+	// Self pairs are not added: every primer's sites are already reported
+	// on both strands.
+	var pairs []primer.Pair
+	if opts.PrimerFile != "" {
+		pairs, err = primer.LoadTSV(opts.PrimerFile)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+	} else {
+		pairs = []primer.Pair{{ID: "manual", Forward: opts.Fwd, Reverse: opts.Rev}} // either may be empty
+	}
+
+	coreOpts := appcore.Options{
+		SeqFiles: opts.SeqFiles, Index: opts.Index, Regions: opts.Regions, ExcludeRegions: opts.ExcludeRegions,
+		MaskPolicy: opts.MaskPolicy, MaxMM: opts.Mismatches, MaxIndels: opts.MaxIndels,
+		TerminalWindow: runutil.EffectiveTerminalWindow(opts.TerminalWindow),
+		HitCap:         opts.HitCap, SeedLength: opts.SeedLength,
+		Threads: opts.Threads, ChunkSize: opts.ChunkSize, DedupeCap: opts.DedupeCap,
+		Quiet: opts.Quiet, NoMatchExitCode: opts.NoMatchExitCode,
+	}
+	writer := appcore.SiteWriterFactory{Format: opts.Output, Sort: opts.Sort, Header: opts.Header, Window: opts.ThreePrimeWindow}
+	return appcore.RunSites(parent, stdout, stderr, coreOpts, pairs, writer)
+}
+
+func Run(argv []string, stdout, stderr io.Writer) int {
+	return RunContext(context.Background(), argv, stdout, stderr)
+}
@@ -0,0 +1,119 @@
+package sitescli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr/internal/clibase"
+	"ipcr/internal/cliutil"
+	"ipcr/internal/output"
+	"ipcr/internal/siteoutput"
+)
+
+type Options struct {
+	clibase.Common
+
+	ThreePrimeWindow int // 3' bases counted in three_prime_mm
+}
+
+// usageScope hides the shared flags ipcr sites rejects or ignores: there
+// are no products, so no product lengths, topology, summary or self pairs.
+var usageScope = clibase.UsageScope{
+	Hide:        []string{"min-length", "max-length", "self", "circular", "products", "pretty", "summary", "assembly-map"},
+	TabularOnly: true,
+}
+
+func NewFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	clibase.UsageCommonScoped(fs, name, usageScope, func(out io.Writer, def func(string) string) {
+		_, _ = fmt.Fprintln(out, "Usage:")
+		_, _ = fmt.Fprintf(out, "  %s [options] --forward AAA [--reverse TTT] host.fa\n", name)
+		_, _ = fmt.Fprintf(out, "  %s [options] --primers designed.tsv --index host.ipcridx\n", name)
+		_, _ = fmt.Fprintln(out, "\nReports every single-primer binding site, whether or not an amplicon forms.")
+		_, _ = fmt.Fprintln(out, "One primer (--forward or --reverse) is enough.")
+		_, _ = fmt.Fprintln(out, "--terminal-window defaults to 0 here so sites with 3' mismatches are reported.")
+
+		_, _ = fmt.Fprintln(out, "\nSites:")
+		_, _ = fmt.Fprintf(out, "      --three-prime-window int  3' bases counted in three_prime_mm [%s]\n", def("three-prime-window"))
+	})
+	return fs
+}
+
+func PrintExamples(out io.Writer) {
+	clibase.PrintExamples(out, "ipcr sites", func(w io.Writer) {
+		_, _ = fmt.Fprintln(out, "Off-target specificity: list every binding site of each primer in a background.")
+		_, _ = fmt.Fprintln(out, "Sites with three_prime_mm = 0 can prime even when no amplicon forms.")
+		_, _ = fmt.Fprintln(out, "\nExample:")
+		_, _ = fmt.Fprintln(out, "  ipcr sites \\")
+		_, _ = fmt.Fprintln(out, "    --primers designed.tsv \\")
+		_, _ = fmt.Fprintln(out, "    --mismatches 3 \\")
+		_, _ = fmt.Fprintln(out, "    --index human.ipcridx")
+	})
+}
+
+func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
+	var o Options
+	var help bool
+	var showExamples bool
+
+	var c clibase.Common
+	noHeader := clibase.Register(fs, &c)
+	fs.IntVar(&o.ThreePrimeWindow, "three-prime-window", siteoutput.DefaultThreePrimeWindow, "3' bases counted in three_prime_mm")
+	fs.BoolVar(&help, "h", false, "show this help [false]")
+	fs.BoolVar(&showExamples, "examples", false, "show quickstart examples and exit [false]")
+	// Off-target screening is about 3' mismatches, so do not hide them by default.
+	_ = fs.Set("terminal-window", "0")
+	fs.Lookup("terminal-window").DefValue = "0"
+
+	flagArgs, posArgs := cliutil.SplitFlagsAndPositionals(fs, argv)
+	if err := fs.Parse(flagArgs); err != nil {
+		return o, err
+	}
+	if showExamples {
+		return o, clibase.ErrPrintedAndExitOK
+	}
+	if help {
+		return o, flag.ErrHelp
+	}
+	if c.Version {
+		o.Common = c
+		return o, nil
+	}
+	// A single primer is enough; hide the missing one from the shared check
+	// that asks for both.
+	fwd, rev := c.Fwd, c.Rev
+	if (fwd == "") != (rev == "") {
+		c.Fwd, c.Rev = fwd+rev, fwd+rev
+	}
+	err := clibase.AfterParse(fs, &c, noHeader, posArgs)
+	switch {
+	case fwd == "":
+		c.Fwd = ""
+	case rev == "":
+		c.Rev = ""
+	}
+	if err != nil {
+		return o, err
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	switch {
+	case c.Summary:
+		return o, errors.New("--summary is not supported by ipcr sites")
+	case c.Products || c.Pretty:
+		return o, errors.New("--products/--pretty do not apply to binding sites")
+	case c.Circular:
+		return o, errors.New("--circular does not apply to binding sites")
+	case set["min-length"] || set["max-length"]:
+		return o, errors.New("--min-length/--max-length do not apply to binding sites")
+	case !output.IsTabular(c.Output):
+		return o, errors.New("ipcr sites supports text, json or jsonl output")
+	case o.ThreePrimeWindow < 0:
+		return o, errors.New("--three-prime-window must be ≥ 0")
+	}
+
+	o.Common = c
+	return o, nil
+}
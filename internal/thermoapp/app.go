@@ -11,16 +11,22 @@ import (
 	"ipcr-core/oligo"
 	"ipcr-core/primer"
 	"ipcr-core/thermo"
+	"ipcr-core/vcf"
 	"ipcr/internal/appcore"
 	"ipcr/internal/clibase"
 	"ipcr/internal/cmdutil"
 	"ipcr/internal/common"
+	"ipcr/internal/manifest"
+	"ipcr/internal/output"
+	"ipcr/internal/pretty"
 	"ipcr/internal/thermocli"
 	"ipcr/internal/thermomodel"
 	"ipcr/internal/thermovisitors"
 	"ipcr/internal/version"
+	"ipcr/internal/visitors"
 	"ipcr/internal/writers"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -168,12 +174,13 @@ type thermoWF struct {
 	IncludeScore  bool
 	RankByScore   bool
 	ThermoDetails bool
+	Columns       writers.TextColumns
 }
 
-func (w thermoWF) NeedSites() bool { return false }
+func (w thermoWF) NeedSites() bool { return w.Format == output.FormatSAM } // MD tags
 func (w thermoWF) NeedSeq() bool   { return true }
 func (w thermoWF) Start(out io.Writer, bufSize int) (chan<- engine.Product, <-chan error) {
-	return writers.StartProductWriterWithThermoDetails(out, w.Format, w.Sort, w.Header, w.Pretty, w.IncludeScore, w.RankByScore, w.ThermoDetails, bufSize)
+	return writers.StartProductWriterWithColumns(out, w.Format, w.Sort, w.Header, w.Pretty, w.IncludeScore, w.RankByScore, w.ThermoDetails, pretty.DefaultOptions, w.Columns, bufSize)
 }
 
 /* ----------------------------- main app ----------------------------- */
@@ -245,6 +252,40 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 		return 0
 	}
 
+	if opts.Replay != "" {
+		path, out := opts.Replay, opts.Manifest
+		prev, err := manifest.Read(path, "ipcr-thermo")
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		// Inputs are read from the directory of the recorded run; the new
+		// manifest still goes where this command line says.
+		if out != "" {
+			if out, err = filepath.Abs(out); err != nil {
+				_, _ = fmt.Fprintln(stderr, err)
+				return 2
+			}
+		}
+		leave, err := manifest.Enter(prev)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "--replay %s: %v\n", path, err)
+			return 2
+		}
+		defer leave()
+		argv = manifest.Args(prev)
+		fs = thermocli.NewFlagSet("ipcr-thermo")
+		fs.SetOutput(io.Discard)
+		if opts, err = thermocli.ParseArgs(fs, argv); err != nil {
+			_, _ = fmt.Fprintf(stderr, "--replay %s: %v\n", path, err)
+			return 2
+		}
+		opts.Manifest = out
+		for _, w := range manifest.Check(prev) {
+			cmdutil.Warnf(stderr, opts.Quiet, "%s", w)
+		}
+	}
+
 	// Input: either oligo mode or classic primer mode
 	hasOligoMode := len(opts.OligoInline) > 0 || opts.OligosTSV != ""
 	hasPairMode := opts.PrimerFile != "" || (opts.Fwd != "" && opts.Rev != "")
@@ -258,7 +299,7 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 		return 2
 	}
 
-	var pairs []primer.Pair
+	var pairs, panel []primer.Pair // panel: pairs without self pairs, which are not assays to report on
 	var panelRefs []thermovisitors.PrimerRef
 	if hasOligoMode {
 		var oligs []primer.Oligo
@@ -283,6 +324,7 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 			return 2
 		}
 		pairs = pairsFromOligos(oligs, opts.MinLen, opts.MaxLen, opts.Self)
+		panel = pairsFromOligos(oligs, opts.MinLen, opts.MaxLen, false)
 		panelRefs = panelRefsFromOligos(oligs)
 		if len(pairs) == 0 {
 			_, _ = fmt.Fprintln(stderr, "error: need ≥2 oligos for pairing (or enable --self)")
@@ -305,12 +347,17 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 				{ID: "manual", Forward: strings.ToUpper(opts.Fwd), Reverse: strings.ToUpper(opts.Rev), MinProduct: opts.MinLen, MaxProduct: opts.MaxLen},
 			}
 		}
+		panel = pairs
 		if opts.Self {
 			pairs = common.AddSelfPairsUnique(pairs)
 		}
 		panelRefs = panelRefsFromPairs(pairs)
 	}
 
+	if opts.MaxIndels > 0 {
+		cmdutil.Warnf(stderr, opts.Quiet, "--max-indels: thermo scoring treats gapped primer sites as ungapped")
+	}
+
 	// Parse solution conditions (warn and default on errors)
 	naM, errNa := parseMolar(opts.NaSpec)
 	mgM, errMg := parseMolar(opts.MgSpec)
@@ -412,7 +459,13 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 	}
 	coreOpts := appcore.Options{
 		SeqFiles:        opts.SeqFiles,
+		Index:           opts.Index,
+		Regions:         opts.Regions,
+		ExcludeRegions:  opts.ExcludeRegions,
+		MaskPolicy:      opts.MaskPolicy,
+		Bisulfite:       opts.Bisulfite,
 		MaxMM:           opts.Mismatches,
+		MaxIndels:       opts.MaxIndels,
 		TerminalWindow:  termWin,
 		MinLen:          opts.MinLen,
 		MaxLen:          opts.MaxLen,
@@ -428,7 +481,7 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 
 	// Writer: always include score; rank-by-score if requested
 	rankByScore := strings.ToLower(opts.Rank) != "coord"
-	wf := thermoWF{
+	var wf appcore.WriterFactory[engine.Product] = thermoWF{
 		Format:        opts.Output,
 		Sort:          true,
 		Header:        opts.Header,
@@ -436,9 +489,48 @@ func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer)
 		IncludeScore:  true,
 		RankByScore:   rankByScore,
 		ThermoDetails: opts.ThermoDetails,
+		Columns:       writers.TextColumns{Indels: opts.MaxIndels > 0, Bisulfite: opts.Bisulfite, Variants: opts.VCF != ""},
+	}
+	if opts.Summary {
+		sum, err := appcore.NewSummary(coreOpts, opts.AssemblyMap, panel)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		wf = appcore.NewSummaryWriterFactory(opts.Output, opts.Header, sum, func(p engine.Product) engine.Product { return p })
 	}
 
-	return appcore.Run[engine.Product](parent, outw, stderr, coreOpts, pairs, scorer.Visit, wf)
+	visit := scorer.Visit
+	if opts.VCF != "" {
+		set, err := vcf.Load(opts.VCF)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		visit = visitors.Variants{Set: set, Window: termWin, FailAF: opts.VCFFailAF, Next: scorer.Visit}.Visit
+		if !opts.Summary {
+			// Allele-only products are listed, not counted as reference hits.
+			coreOpts.Alleles = set.Overlap
+		}
+	}
+	var rec *manifest.Recorder
+	if opts.Manifest != "" {
+		rec = manifest.New("ipcr-thermo", argv, fs, pairs)
+		rec.CommonInputs(&opts.Common)
+		rec.Input("oligos", opts.OligosTSV)
+		rec.Thermo(mode.String(), conditions)
+		visit = rec.Visit(visit)
+	}
+	code := appcore.Run[engine.Product](parent, outw, stderr, coreOpts, pairs, visit, wf)
+	if rec != nil {
+		if err := rec.Write(opts.Manifest, code); err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			if code == 0 {
+				return 3
+			}
+		}
+	}
+	return code
 }
 
 func Run(argv []string, stdout, stderr io.Writer) int {
@@ -9,6 +9,8 @@ import (
 	"ipcr-core/thermo"
 	"ipcr/internal/clibase"
 	"ipcr/internal/cliutil"
+	"ipcr/internal/output"
+	"ipcr/internal/pipeline"
 	"ipcr/internal/thermomodel"
 	"strings"
 )
@@ -22,6 +24,7 @@ func (s *sliceValue) String() string {
 	return strings.Join(*s.dst, ",")
 }
 func (s *sliceValue) Set(v string) error { *s.dst = append(*s.dst, v); return nil }
+func (s *sliceValue) Get() any           { return append([]string{}, *s.dst...) }
 
 type Options struct {
 	clibase.Common
@@ -101,6 +104,9 @@ func NewFlagSet(name string) *flag.FlagSet {
 		_, _ = fmt.Fprintf(out, "      --salt-model string    Salt model: %s [%s]\n", thermo.KnownSaltModels(), thermo.SaltModelMonovalent)
 		_, _ = fmt.Fprintln(out, "      --allow-indel          Allow a single 1-nt gap (bulge) per primer [false]")
 		_, _ = fmt.Fprintln(out, "      --single-stranded      Treat target as ssDNA (BS-PCR): tiny dangling-end bonus + target-hairpin penalty [false]")
+		_, _ = fmt.Fprintln(out, "      --bisulfite            Scan the four bisulfite-converted strands (MSP/BSP; text/json/jsonl) [false]")
+		_, _ = fmt.Fprintln(out, "      --vcf string           Known variants (.vcf/.vcf.gz) reported under primer sites (text/json/jsonl)")
+		_, _ = fmt.Fprintln(out, "      --vcf-fail-af float    Drop products with a variant of AF ≥ this in a primer 3' window (<0 off) [-1]")
 		_, _ = fmt.Fprintf(out, "      --thermo-model string  Scoring model: %s [%s]\n", thermomodel.KnownList(), thermomodel.Default())
 		_, _ = fmt.Fprintln(out, "      --iupac-thermo-policy string  Degenerate-primer NN policy: strict | worst | best | mean | enumerate [worst]")
 		_, _ = fmt.Fprintln(out, "      --iupac-thermo-max-expansions int  Max concrete primer-pair expansions [256]")
@@ -133,6 +139,10 @@ func NewFlagSet(name string) *flag.FlagSet {
 		_, _ = fmt.Fprintf(out, "      --rank string          Order by: score | coord [%s]\n", "score")
 		_, _ = fmt.Fprintln(out, "      --thermo-details       Add NN thermo component columns to text/TSV output [false]")
 		_, _ = fmt.Fprintln(out, "      (default is score; pass --rank coord to keep coordinate order.)")
+
+		_, _ = fmt.Fprintln(out, "\nProvenance:")
+		_, _ = fmt.Fprintln(out, "      --manifest file        Write options, conditions, parameter sets, input checksums and per-pair counts as JSON")
+		_, _ = fmt.Fprintln(out, "      --replay file          Rerun with the options and inputs of a manifest")
 	})
 	return fs
 }
@@ -150,12 +160,14 @@ func PrintExamples(out io.Writer) {
 	})
 }
 
-func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
-	var o Options
-	var help bool
-	var showExamples bool
-
+// Register adds the ipcr-thermo flags to fs, storing into o, and returns
+// the --no-header flag value (see clibase.Register). Their defaults are
+// ipcr-thermo's scoring defaults, shared with the library (pkg/ipcr).
+func Register(fs *flag.FlagSet, o *Options) *bool {
 	noHeader := clibase.Register(fs, &o.Common)
+	clibase.RegisterBisulfite(fs, &o.Common)
+	clibase.RegisterVCF(fs, &o.Common)
+	clibase.RegisterManifest(fs, &o.Common)
 
 	oligoFlag := &sliceValue{dst: &o.OligoInline}
 	fs.Var(oligoFlag, "oligo", "oligo (ID:SEQ or SEQ); repeatable")
@@ -186,8 +198,6 @@ func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
 
 	fs.StringVar(&o.Rank, "rank", "score", "order by: score | coord")
 	fs.BoolVar(&o.ThermoDetails, "thermo-details", false, "add NN thermo component columns to text/TSV output")
-	fs.BoolVar(&help, "h", false, "show this help [false]")
-	fs.BoolVar(&showExamples, "examples", false, "show quickstart examples and exit [false]")
 
 	// Thermo addons (with defaults)
 	fs.StringVar(&o.ScoreProfile, "score-profile", "binding", "score profile: binding | pcr | gel")
@@ -201,6 +211,17 @@ func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
 	fs.Float64Var(&o.StructScale, "struct-scale", 1.0, "scale for structural penalties")
 	fs.Float64Var(&o.BindWeight, "bind-weight", 1.0, "bind weight (reserved)")
 	fs.Float64Var(&o.ExtWeight, "ext-weight", 1.0, "extension weight")
+	return noHeader
+}
+
+func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
+	var o Options
+	var help bool
+	var showExamples bool
+
+	noHeader := Register(fs, &o)
+	fs.BoolVar(&help, "h", false, "show this help [false]")
+	fs.BoolVar(&showExamples, "examples", false, "show quickstart examples and exit [false]")
 
 	flagArgs, posArgs := cliutil.SplitFlagsAndPositionals(fs, argv)
 	if err := fs.Parse(flagArgs); err != nil {
@@ -215,6 +236,9 @@ func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
 	if help {
 		return o, flag.ErrHelp
 	}
+	if o.Replay != "" {
+		return o, clibase.ValidateReplay(fs, posArgs)
+	}
 
 	// Manual finalize (thermo): positionals → seq files; allow oligo OR primers
 	o.Header = !*noHeader
@@ -247,9 +271,35 @@ func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
 	if !hasOligoMode && !hasPairMode {
 		return o, fmt.Errorf("provide --oligo/--oligos OR --primers/--forward+--reverse")
 	}
-	if len(o.SeqFiles) == 0 {
+	switch {
+	case o.Index != "" && len(o.SeqFiles) > 0:
+		return o, fmt.Errorf("--index conflicts with FASTA sequence inputs")
+	case o.Index == "" && len(o.SeqFiles) == 0:
 		return o, fmt.Errorf("at least one sequence file is required (positional or --sequences)")
 	}
+	mp, err := pipeline.ParseMaskPolicy(o.MaskPolicy)
+	if err != nil {
+		return o, err
+	}
+	o.MaskPolicy = string(mp)
+	if o.Index != "" && mp != pipeline.MaskIgnore {
+		return o, fmt.Errorf("--mask-policy needs FASTA input; the index does not keep soft-masking")
+	}
+	if o.Circular && (o.Regions != "" || o.ExcludeRegions != "") {
+		return o, fmt.Errorf("--regions/--exclude-regions cannot be combined with --circular")
+	}
+	if o.Summary && !output.IsTabular(o.Output) {
+		return o, fmt.Errorf("--summary supports text, json or jsonl output")
+	}
+	if o.AssemblyMap != "" && !o.Summary {
+		return o, fmt.Errorf("--assembly-map requires --summary")
+	}
+	if err := clibase.ValidateBisulfite(&o.Common); err != nil {
+		return o, err
+	}
+	if err := clibase.ValidateVCF(&o.Common); err != nil {
+		return o, err
+	}
 	if strings.TrimSpace(o.Probe) != "" {
 		probeSeq, err := oligo.Validate(o.Probe)
 		if err != nil {
@@ -248,3 +248,18 @@ func TestParseArgs_RejectsInvalidInlinePrimerAndProbe(t *testing.T) {
 		t.Fatal("expected invalid probe error")
 	}
 }
+
+func TestParseArgs_BisulfiteNeedsTabularOutput(t *testing.T) {
+	for _, out := range []string{"text", "json", "jsonl"} {
+		opts, err := parseArgsForTest(append(minimalArgs(), "--bisulfite", "--output", out)...)
+		if err != nil {
+			t.Fatalf("--output %s: ParseArgs returned error: %v", out, err)
+		}
+		if !opts.Bisulfite {
+			t.Fatal("expected --bisulfite to be enabled")
+		}
+	}
+	if _, err := parseArgsForTest(append(minimalArgs(), "--bisulfite", "--output", "bed")...); err == nil {
+		t.Fatal("expected --bisulfite with BED output to be rejected")
+	}
+}
@@ -2,8 +2,11 @@ package thermointegration
 
 import (
 	"bytes"
+	"encoding/json"
 	"ipcr/internal/thermoapp"
+	"ipcr/pkg/api"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -264,3 +267,54 @@ func TestThermo_ProbeThermoDefaultModelUsesNNStructure(t *testing.T) {
 		t.Fatalf("expected populated probe thermo detail columns:\n%s", s)
 	}
 }
+
+func TestThermo_ManifestRecordsConditionsAndParameterSets(t *testing.T) {
+	fwd := "ACGTACGTACGTACGTACGT"
+	rev := "TTGCGTATCGATCGTACGTA"
+	leftSite := []byte(fwd)
+	leftSite[6] = 'A'
+	fa := writeFA(t, "thermo_manifest.fa", ">s\n"+string(leftSite)+"AAAA"+rc5to3IT(rev)+"\n")
+	defer func() { _ = os.Remove(fa) }()
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "run.json")
+
+	var want, errB bytes.Buffer
+	code := thermoapp.Run([]string{
+		"--forward", fwd, "--reverse", rev, "--sequences", fa, "--mismatches", "1",
+		"--thermo-model", "nn-duplex-v1", "--mg", "bogus", "--salt-model", "owczarzy08",
+		"--output", "jsonl", "--manifest", manifest,
+	}, &want, &errB)
+	if code != 0 {
+		t.Fatalf("exit %d err=%s", code, errB.String())
+	}
+	b, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m api.ManifestV1
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Tool != "ipcr-thermo" || m.Thermo == nil || m.Thermo.Model != "nn-duplex-v1" {
+		t.Fatalf("thermo section: %+v", m.Thermo)
+	}
+	// The defaulted Mg is recorded, not the unparsable flag value.
+	if c := m.Thermo.Conditions; c.MgM != 0.003 || c.SaltModel != "owczarzy08" || c.AnnealTempC != 60 || c.EffectiveNaM == 0 {
+		t.Fatalf("conditions: %+v", c)
+	}
+	if len(m.Thermo.ParameterSets) == 0 || m.Thermo.ParameterSets[0] != "santalucia-hicks-2004-internal-mismatch-compiled-dimer-gauge-v1" {
+		t.Fatalf("parameter sets: %v", m.Thermo.ParameterSets)
+	}
+	if len(m.Thermo.Citations) == 0 || !strings.Contains(m.Thermo.Citations[0], "SantaLucia & Hicks 2004") {
+		t.Fatalf("citations: %v", m.Thermo.Citations)
+	}
+	if m.Products != 1 || m.Pairs[0].ID != "manual" || m.Pairs[0].Products != 1 {
+		t.Fatalf("counts: %d %+v", m.Products, m.Pairs)
+	}
+
+	var got bytes.Buffer
+	errB.Reset()
+	if code := thermoapp.Run([]string{"--replay", manifest}, &got, &errB); code != 0 || got.String() != want.String() {
+		t.Fatalf("replay exit %d err=%s\n%s\nwant\n%s", code, errB.String(), got.String(), want.String())
+	}
+}
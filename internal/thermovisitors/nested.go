@@ -0,0 +1,60 @@
+// internal/thermovisitors/nested.go
+package thermovisitors
+
+import (
+	"ipcr-core/engine"
+	"ipcr/internal/nestedoutput"
+	"math"
+)
+
+// NestedScore scores both rounds of a nested PCR, each under its own
+// conditions: Outer on the outer amplicon, Inner on every inner-round
+// product (rounds 3..N included), sliced from the outer amplicon.
+type NestedScore struct {
+	Outer Score
+	Inner Score
+}
+
+// ScoreRounds implements visitors.RoundScorer.
+func (v NestedScore) ScoreRounds(outer engine.Product, hits []nestedoutput.InnerHit) (engine.Product, error) {
+	_, scored, err := v.Outer.Visit(outer)
+	if err != nil {
+		return outer, err
+	}
+	return scored, v.scoreHits(outer, hits)
+}
+
+func (v NestedScore) scoreHits(outer engine.Product, hits []nestedoutput.InnerHit) error {
+	for i := range hits {
+		h := &hits[i]
+		if h.Start < 0 || h.End > len(outer.Seq) || h.Start >= h.End {
+			h.Score = math.NaN()
+			continue
+		}
+		ok, p, err := v.Inner.Visit(engine.Product{
+			ExperimentID: h.PairID,
+			SequenceID:   outer.SequenceID,
+			SourceFile:   outer.SourceFile,
+			Start:        h.Start,
+			End:          h.End,
+			Length:       h.Length,
+			Type:         h.Type,
+			FwdMM:        h.FwdMM,
+			RevMM:        h.RevMM,
+			FwdPrimer:    h.FwdPrimer,
+			RevPrimer:    h.RevPrimer,
+			Seq:          outer.Seq[h.Start:h.End],
+		})
+		if err != nil {
+			return err
+		}
+		h.Score = p.Score
+		if !ok {
+			h.Score = math.NaN() // rejected by scoring (e.g. a probe gate)
+		}
+		if err := v.scoreHits(outer, h.Inner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// internal/thermovisitors/nested_test.go
+package thermovisitors
+
+import (
+	"ipcr-core/engine"
+	"ipcr/internal/nestedoutput"
+	"ipcr/internal/thermomodel"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNestedScore_RejectedInnerProductIsUnscored(t *testing.T) {
+	fwd := "ACGTACGTACGTACGTACGT"
+	rev := "TGCATGCATGCATGCATGCA"
+	inner := fwd + strings.Repeat("A", 40) + rc5to3(rev)
+	outerSeq := strings.Repeat("C", 30) + inner + strings.Repeat("C", 30)
+	outer := engine.Product{FwdPrimer: fwd, RevPrimer: rev, Seq: outerSeq, Length: len(outerSeq), Type: "forward"}
+	hits := func() []nestedoutput.InnerHit {
+		return []nestedoutput.InnerHit{{PairID: "in", Start: 30, End: 30 + len(inner), Length: len(inner), Type: "forward", FwdPrimer: fwd, RevPrimer: rev}}
+	}
+	base := Score{Model: thermomodel.NNDuplexV1, AnnealTempC: 60, Na_M: 0.05, PrimerConc_M: 2.5e-7}
+
+	h := hits()
+	if _, err := (NestedScore{Outer: base, Inner: base}).ScoreRounds(outer, h); err != nil {
+		t.Fatal(err)
+	}
+	if math.IsNaN(h[0].Score) || h[0].Score == 0 {
+		t.Fatalf("kept inner product: want a score, got %v", h[0].Score)
+	}
+
+	// A probe gate that the inner amplicon fails rejects it.
+	gated := base
+	gated.ProbeSeq, gated.ProbeThermo, gated.ProbeScoreMode = "GATTACAGATTACAGATTAC", true, probeScoreModeGate
+	h = hits()
+	if _, err := (NestedScore{Outer: base, Inner: gated}).ScoreRounds(outer, h); err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsNaN(h[0].Score) {
+		t.Fatalf("rejected inner product: want NaN, got %v", h[0].Score)
+	}
+}
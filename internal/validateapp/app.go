@@ -0,0 +1,301 @@
+// internal/validateapp/app.go
+package validateapp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"ipcr/internal/appcore"
+	"ipcr/internal/assembly"
+	"ipcr/internal/clibase"
+	"ipcr/internal/common"
+	"ipcr/internal/nestedoutput"
+	"ipcr/internal/output"
+	"ipcr/internal/probeoutput"
+	"ipcr/internal/runutil"
+	"ipcr/internal/validatecli"
+	"ipcr/internal/validation"
+	"ipcr/internal/version"
+	"ipcr/internal/visitors"
+	"ipcr/internal/writers"
+	"strings"
+)
+
+// validationWF collects the products an assay keeps and writes the
+// per-assay report once the scan finishes.
+type validationWF[T any] struct {
+	Format    string
+	Header    bool
+	Validator *validation.Validator
+	Product   func(T) engine.Product
+	Seq       bool
+}
+
+func (w validationWF[T]) NeedSites() bool { return false }
+func (w validationWF[T]) NeedSeq() bool   { return w.Seq }
+
+func (w validationWF[T]) Start(out io.Writer, bufSize int) (chan<- T, <-chan error) {
+	in := make(chan T, bufSize)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		for x := range in {
+			w.Validator.Add(w.Product(x))
+		}
+		rows, err := w.Validator.Report()
+		if err == nil {
+			switch w.Format {
+			case output.FormatJSON:
+				err = validation.WriteJSON(out, rows)
+			case output.FormatJSONL:
+				err = validation.WriteJSONL(out, rows)
+			default:
+				err = validation.WriteTSV(out, rows, w.Header)
+			}
+		}
+		errc <- err
+	}()
+	return in, errc
+}
+
+func RunContext(parent context.Context, argv []string, stdout, stderr io.Writer) int {
+	outw := bufio.NewWriter(stdout)
+	defer func() { _ = outw.Flush() }()
+
+	fs := validatecli.NewFlagSet("ipcr-validate")
+	fs.SetOutput(io.Discard)
+
+	if len(argv) == 0 {
+		_, _ = validatecli.ParseArgs(fs, []string{"-h"})
+		fs.SetOutput(outw)
+		fs.Usage()
+		if err := outw.Flush(); writers.IsBrokenPipe(err) {
+			return 0
+		} else if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 3
+		}
+		return 0
+	}
+
+	opts, err := validatecli.ParseArgs(fs, argv)
+	if err != nil {
+		if errors.Is(err, clibase.ErrPrintedAndExitOK) {
+			validatecli.PrintExamples(outw)
+			if e := outw.Flush(); writers.IsBrokenPipe(e) {
+				return 0
+			} else if e != nil {
+				_, _ = fmt.Fprintln(stderr, e)
+				return 3
+			}
+			return 0
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(outw)
+			fs.Usage()
+			if e := outw.Flush(); writers.IsBrokenPipe(e) {
+				return 0
+			} else if e != nil {
+				_, _ = fmt.Fprintln(stderr, e)
+				return 3
+			}
+			return 0
+		}
+		_, _ = fmt.Fprintln(stderr, err)
+		fs.SetOutput(outw)
+		fs.Usage()
+		if e := outw.Flush(); writers.IsBrokenPipe(e) {
+			return 0
+		} else if e != nil {
+			_, _ = fmt.Fprintln(stderr, e)
+			return 3
+		}
+		return 2
+	}
+
+	if opts.Version {
+		version.Write(outw, "ipcr-validate")
+		if e := outw.Flush(); writers.IsBrokenPipe(e) {
+			return 0
+		} else if e != nil {
+			_, _ = fmt.Fprintln(stderr, e)
+			return 3
+		}
+		return 0
+	}
+
+	var pairs []primer.Pair
+	if opts.PrimerFile != "" {
+		pairs, err = primer.LoadTSV(opts.PrimerFile)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+	} else {
+		pairs = []primer.Pair{{ID: "manual", Forward: opts.Fwd, Reverse: opts.Rev, MinProduct: opts.MinLen, MaxProduct: opts.MaxLen}}
+	}
+	ids := make([]string, len(pairs))
+	for i, p := range pairs {
+		ids[i] = p.ID
+	}
+
+	// Panel rows bring their own probe (the "probe" column) or inner
+	// primers (--inner-primers rows named after the assay).
+	perProbe := make(map[string]string)
+	var inner []primer.Pair
+	var perOuter map[string][]primer.Pair
+	switch {
+	case opts.InnerPrimerFile != "":
+		inner, err = primer.LoadTSV(opts.InnerPrimerFile)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+		if perOuter, err = innerByAssay(pairs, inner, opts.Self); err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+	case opts.InnerFwd != "":
+		if len(pairs) > 1 {
+			_, _ = fmt.Fprintln(stderr, "error: --inner-forward/--inner-reverse serve a single assay; name --inner-primers rows after the panel assays instead")
+			return 2
+		}
+		inner = []primer.Pair{{ID: "inner", Forward: opts.InnerFwd, Reverse: opts.InnerRev}}
+		if opts.Self {
+			inner = common.AddSelfPairs(inner)
+		}
+	default:
+		for _, p := range pairs {
+			if len(p.Probes) > 1 {
+				_, _ = fmt.Fprintf(stderr, "error: pair %q lists %d probes; ipcr-validate checks one per assay\n", p.ID, len(p.Probes))
+				return 2
+			}
+			if len(p.Probes) == 1 {
+				perProbe[p.ID] = p.Probes[0].Seq
+			}
+		}
+		for _, p := range pairs {
+			if _, ok := perProbe[p.ID]; !ok && opts.Probe == "" && len(perProbe) > 0 {
+				_, _ = fmt.Fprintf(stderr, "error: pair %q has no probe; add a probe column value or pass --probe\n", p.ID)
+				return 2
+			}
+		}
+	}
+	if opts.Self {
+		pairs = common.AddSelfPairs(pairs)
+	}
+
+	var amap *assembly.Map
+	if opts.AssemblyMap != "" {
+		if amap, err = assembly.Load(opts.AssemblyMap); err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+	kind := validation.AssayPlain
+	switch {
+	case opts.Probe != "" || len(perProbe) > 0:
+		kind = validation.AssayProbe
+	case len(inner) > 0:
+		kind = validation.AssayNested
+	}
+	val, err := validation.New(assembly.NewResolver(amap, nil), kind, ids, opts.Targets, opts.NonTargets)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	termWin := runutil.EffectiveTerminalWindow(opts.TerminalWindow)
+	coreOpts := appcore.Options{
+		SeqFiles:        opts.SeqFiles,
+		Regions:         opts.Regions,
+		ExcludeRegions:  opts.ExcludeRegions,
+		MaskPolicy:      opts.MaskPolicy,
+		MaxMM:           opts.Mismatches,
+		MaxIndels:       opts.MaxIndels,
+		TerminalWindow:  termWin,
+		MinLen:          opts.MinLen,
+		MaxLen:          opts.MaxLen,
+		HitCap:          opts.HitCap,
+		SeedLength:      opts.SeedLength,
+		Circular:        opts.Circular,
+		Threads:         opts.Threads,
+		ChunkSize:       opts.ChunkSize,
+		DedupeCap:       opts.DedupeCap,
+		Quiet:           opts.Quiet,
+		NoMatchExitCode: opts.NoMatchExitCode,
+	}
+
+	switch kind {
+	case validation.AssayProbe:
+		visitor := visitors.Probe{
+			Name: opts.ProbeName, Seq: strings.ToUpper(opts.Probe), MaxMM: opts.ProbeMaxMM, Require: true,
+			PerPair: perProbe,
+		}
+		wf := validationWF[probeoutput.AnnotatedProduct]{
+			Format: opts.Output, Header: opts.Header, Validator: val, Seq: true,
+			Product: func(ap probeoutput.AnnotatedProduct) engine.Product { return ap.Product },
+		}
+		return appcore.Run(parent, outw, stderr, coreOpts, pairs, visitor.Visit, wf)
+	case validation.AssayNested:
+		visitor := visitors.Nested{
+			InnerPairs: inner,
+			PerOuter:   perOuter,
+			EngineCfg: engine.Config{
+				MaxMM:          opts.Mismatches,
+				MaxIndels:      opts.MaxIndels,
+				TerminalWindow: termWin,
+				SeedLen:        opts.SeedLength,
+				Circular:       false, // inner scan runs on linearized outer products
+			},
+			RequireInner: true,
+		}
+		wf := validationWF[nestedoutput.NestedProduct]{
+			Format: opts.Output, Header: opts.Header, Validator: val, Seq: true,
+			Product: func(np nestedoutput.NestedProduct) engine.Product { return np.Product },
+		}
+		return appcore.Run(parent, outw, stderr, coreOpts, pairs, visitor.Visit, wf)
+	default:
+		wf := validationWF[engine.Product]{
+			Format: opts.Output, Header: opts.Header, Validator: val,
+			Product: func(p engine.Product) engine.Product { return p },
+		}
+		return appcore.Run(parent, outw, stderr, coreOpts, pairs, visitors.PassThrough{}.Visit, wf)
+	}
+}
+
+func Run(argv []string, stdout, stderr io.Writer) int {
+	return RunContext(context.Background(), argv, stdout, stderr)
+}
+
+// innerByAssay groups the inner primer rows by the outer assay they name
+// (their ID), with their self pairs when self is set. Every row must name
+// an assay and every assay needs at least one row.
+func innerByAssay(outer, inner []primer.Pair, self bool) (map[string][]primer.Pair, error) {
+	by := make(map[string][]primer.Pair, len(outer))
+	for _, p := range outer {
+		by[p.ID] = nil
+	}
+	for _, p := range inner {
+		if _, ok := by[p.ID]; !ok {
+			return nil, fmt.Errorf("--inner-primers: row %q names no assay of the panel", p.ID)
+		}
+		by[p.ID] = append(by[p.ID], p)
+	}
+	for _, p := range outer {
+		if len(by[p.ID]) == 0 {
+			return nil, fmt.Errorf("--inner-primers: no inner primers for assay %q", p.ID)
+		}
+	}
+	if self {
+		for id, ps := range by {
+			by[id] = common.AddSelfPairs(ps)
+		}
+	}
+	return by, nil
+}
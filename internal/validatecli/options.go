@@ -0,0 +1,194 @@
+package validatecli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ipcr-core/oligo"
+	"ipcr-core/primer"
+	"ipcr/internal/clibase"
+	"ipcr/internal/cliutil"
+	"ipcr/internal/output"
+)
+
+type Options struct {
+	clibase.Common
+
+	// Labelled reference sets
+	Targets    []string
+	NonTargets []string
+
+	// Probe assays
+	Probe      string
+	ProbeName  string
+	ProbeMaxMM int
+
+	// Nested assays
+	InnerPrimerFile string
+	InnerFwd        string
+	InnerRev        string
+}
+
+type sliceValue struct{ dst *[]string }
+
+func (s *sliceValue) String() string {
+	if s.dst == nil {
+		return ""
+	}
+	return fmt.Sprint(*s.dst)
+}
+func (s *sliceValue) Set(v string) error { *s.dst = append(*s.dst, v); return nil }
+
+// usageScope hides the shared flags ipcr-validate rejects or ignores:
+// genomes come only from the labelled sets and the output is a report.
+var usageScope = clibase.UsageScope{
+	Hide:        []string{"sequences", "index", "summary", "products", "pretty", "sort", "assembly-map"},
+	TabularOnly: true,
+}
+
+func NewFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	clibase.UsageCommonScoped(fs, name, usageScope, func(out io.Writer, def func(string) string) {
+		_, _ = fmt.Fprintln(out, "Usage:")
+		_, _ = fmt.Fprintf(out, "  %s --primers panel.tsv --targets 'target/*.fa' --non-targets 'other/*.fa'\n", name)
+		_, _ = fmt.Fprintf(out, "  %s --primers panel.tsv --probe PROBE --targets t.fa --non-targets n.fa\n", name)
+		_, _ = fmt.Fprintf(out, "  %s --primers outer.tsv --inner-primers inner.tsv --targets t.fa --non-targets n.fa\n", name)
+
+		_, _ = fmt.Fprintln(out, "\nReference sets:")
+		_, _ = fmt.Fprintln(out, "      --targets file          Target genome FASTA(s) (repeatable, globs) [*]")
+		_, _ = fmt.Fprintln(out, "      --non-targets file      Non-target genome FASTA(s) (repeatable, globs) [*]")
+		_, _ = fmt.Fprintln(out, "      --assembly-map file     TSV: file or record → genome ID")
+		_, _ = fmt.Fprintln(out, "  Each file is one genome unless --assembly-map groups them.")
+
+		_, _ = fmt.Fprintln(out, "\nAssay type (default: plain PCR):")
+		_, _ = fmt.Fprintln(out, "  -P, --probe string          Probe assay: amplicon must contain this oligo")
+		_, _ = fmt.Fprintf(out, "      --probe-name string     Label for the probe [%s]\n", def("probe-name"))
+		_, _ = fmt.Fprintf(out, "  -M, --probe-max-mm int      Max mismatches allowed in probe match [%s]\n", def("probe-max-mm"))
+		_, _ = fmt.Fprintln(out, "      --inner-forward string  Nested assay: inner forward primer (5'→3')")
+		_, _ = fmt.Fprintln(out, "      --inner-reverse string  Nested assay: inner reverse primer (5'→3')")
+		_, _ = fmt.Fprintln(out, "      --inner-primers string  Nested assay: inner primer TSV (id fwd rev [min] [max])")
+		_, _ = fmt.Fprintln(out, "  Inner rows are matched to the panel assay of the same id.")
+	})
+	return fs
+}
+
+func Parse() (Options, error) { return ParseArgs(NewFlagSet("ipcr-validate"), nil) }
+
+func PrintExamples(out io.Writer) {
+	clibase.PrintExamples(out, "ipcr-validate", func(w io.Writer) {
+		_, _ = fmt.Fprintln(out, "Assay validation: sensitivity/specificity against labelled genome sets.")
+		_, _ = fmt.Fprintln(out, "Reports missed targets and off-target hits per assay.")
+		_, _ = fmt.Fprintln(out, "\nExample:")
+		_, _ = fmt.Fprintln(out, "  ipcr-validate \\")
+		_, _ = fmt.Fprintln(out, "    --primers panel.tsv \\")
+		_, _ = fmt.Fprintln(out, "    --mismatches 1 \\")
+		_, _ = fmt.Fprintln(out, "    --targets 'inclusivity/*.fna.gz' \\")
+		_, _ = fmt.Fprintln(out, "    --non-targets 'exclusivity/*.fna.gz'")
+	})
+}
+
+func ParseArgs(fs *flag.FlagSet, argv []string) (Options, error) {
+	var o Options
+	var help bool
+	var showExamples bool
+
+	var c clibase.Common
+	noHeader := clibase.Register(fs, &c)
+
+	fs.Var(&sliceValue{dst: &o.Targets}, "targets", "target genome FASTA(s) (repeatable)")
+	fs.Var(&sliceValue{dst: &o.NonTargets}, "non-targets", "non-target genome FASTA(s) (repeatable)")
+
+	fs.StringVar(&o.Probe, "probe", "", "probe assay oligo (5'→3')")
+	fs.StringVar(&o.ProbeName, "probe-name", "probe", "probe label")
+	fs.IntVar(&o.ProbeMaxMM, "probe-max-mm", 0, "max mismatches allowed for probe [0]")
+	fs.StringVar(&o.Probe, "P", "", "alias of --probe")
+	fs.IntVar(&o.ProbeMaxMM, "M", 0, "alias of --probe-max-mm")
+
+	fs.StringVar(&o.InnerPrimerFile, "inner-primers", "", "inner primer TSV")
+	fs.StringVar(&o.InnerFwd, "inner-forward", "", "inner forward primer (5'→3')")
+	fs.StringVar(&o.InnerRev, "inner-reverse", "", "inner reverse primer (5'→3')")
+
+	fs.BoolVar(&help, "h", false, "show this help [false]")
+	fs.BoolVar(&showExamples, "examples", false, "show quickstart examples and exit [false]")
+
+	flagArgs, posArgs := cliutil.SplitFlagsAndPositionals(fs, argv)
+	if err := fs.Parse(flagArgs); err != nil {
+		return o, err
+	}
+	if showExamples {
+		return o, clibase.ErrPrintedAndExitOK
+	}
+	if help {
+		return o, flag.ErrHelp
+	}
+	if c.Version {
+		o.Common = c
+		return o, nil
+	}
+
+	// Sequences come only from the labelled sets.
+	c.Header = !*noHeader
+	if len(posArgs) > 0 || len(c.SeqFiles) > 0 {
+		return o, errors.New("pass genomes with --targets/--non-targets")
+	}
+	if c.Index != "" {
+		return o, errors.New("--index is not supported by ipcr-validate")
+	}
+	if c.Summary {
+		return o, errors.New("--summary is not supported by ipcr-validate")
+	}
+	var err error
+	if o.Targets, err = cliutil.ExpandPositionals(o.Targets); err != nil {
+		return o, err
+	}
+	if o.NonTargets, err = cliutil.ExpandPositionals(o.NonTargets); err != nil {
+		return o, err
+	}
+	if len(o.Targets) == 0 || len(o.NonTargets) == 0 {
+		return o, errors.New("provide both --targets and --non-targets")
+	}
+	c.SeqFiles = append(append([]string(nil), o.Targets...), o.NonTargets...)
+	// --assembly-map groups genomes here without --summary; hide it from the
+	// shared check that ties the two together.
+	amap := c.AssemblyMap
+	c.AssemblyMap = ""
+	err = clibase.Validate(&c)
+	c.AssemblyMap = amap
+	if err != nil {
+		return o, err
+	}
+	if !output.IsTabular(c.Output) {
+		return o, errors.New("ipcr-validate supports text, json or jsonl output")
+	}
+
+	// Assay type
+	usingInner := o.InnerPrimerFile != "" || o.InnerFwd != "" || o.InnerRev != ""
+	if o.Probe != "" && usingInner {
+		return o, errors.New("--probe conflicts with nested (--inner-*) assays")
+	}
+	if o.Probe != "" {
+		if o.Probe, err = oligo.Validate(o.Probe); err != nil {
+			return o, fmt.Errorf("--probe: %w", err)
+		}
+	}
+	if usingInner {
+		switch {
+		case o.InnerPrimerFile != "" && (o.InnerFwd != "" || o.InnerRev != ""):
+			return o, errors.New("--inner-primers conflicts with --inner-forward/--inner-reverse")
+		case o.InnerPrimerFile == "" && (o.InnerFwd == "" || o.InnerRev == ""):
+			return o, errors.New("--inner-forward and --inner-reverse must be supplied together")
+		}
+		if o.InnerPrimerFile == "" {
+			if o.InnerFwd, err = primer.Validate(o.InnerFwd); err != nil {
+				return o, fmt.Errorf("--inner-forward: %w", err)
+			}
+			if o.InnerRev, err = primer.Validate(o.InnerRev); err != nil {
+				return o, fmt.Errorf("--inner-reverse: %w", err)
+			}
+		}
+	}
+
+	o.Common = c
+	return o, nil
+}
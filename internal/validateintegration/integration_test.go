@@ -0,0 +1,204 @@
+package validateintegration
+
+import (
+	"bytes"
+	"encoding/json"
+	"ipcr-core/primer"
+	"ipcr/internal/validateapp"
+	"ipcr/pkg/api"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, name, data string) string {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return name
+}
+
+const (
+	fwd   = "GATTACAGATTACAGG"
+	rev   = "CCTTGGAACCTTGGTT"
+	probe = "ACGTTGCAACGTTGCA"
+)
+
+// fixture writes three targets (one lacking the probe region, one without
+// the assay) and two non-targets (one cross-reacting).
+func fixture(t *testing.T) (dir string, targets, nonTargets []string) {
+	dir = t.TempDir()
+	pad := strings.Repeat("T", 40)
+	rcRev := string(primer.RevComp([]byte(rev)))
+	withProbe := pad + fwd + "CCCC" + probe + "CCCC" + rcRev + pad
+	noProbe := pad + fwd + strings.Repeat("C", 24) + rcRev + pad
+	blank := pad + pad
+
+	targets = []string{
+		write(t, filepath.Join(dir, "t1.fa"), ">c\n"+withProbe+"\n"),
+		write(t, filepath.Join(dir, "t2.fa"), ">c\n"+noProbe+"\n"),
+		write(t, filepath.Join(dir, "t3.fa"), ">c\n"+blank+"\n"),
+	}
+	nonTargets = []string{
+		write(t, filepath.Join(dir, "n1.fa"), ">c\n"+noProbe+"\n"),
+		write(t, filepath.Join(dir, "n2.fa"), ">c\n"+blank+"\n"),
+	}
+	return dir, targets, nonTargets
+}
+
+func run(t *testing.T, targets, nonTargets []string, extra ...string) api.ValidationV1 {
+	t.Helper()
+	args := []string{"--forward", fwd, "--reverse", rev, "--output", "json"}
+	for _, f := range targets {
+		args = append(args, "--targets", f)
+	}
+	for _, f := range nonTargets {
+		args = append(args, "--non-targets", f)
+	}
+	var out, errB bytes.Buffer
+	if code := validateapp.Run(append(args, extra...), &out, &errB); code != 0 {
+		t.Fatalf("exit %d err=%s", code, errB.String())
+	}
+	var rows []api.ValidationV1
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatalf("bad JSON: %v\n%s", err, out.String())
+	}
+	if len(rows) != 1 {
+		t.Fatalf("want one assay row, got %d", len(rows))
+	}
+	return rows[0]
+}
+
+func TestValidatePlainAssay(t *testing.T) {
+	_, tg, nt := fixture(t)
+	r := run(t, tg, nt)
+	if r.Assay != "plain" || r.Targets != 3 || r.TruePositives != 2 || r.NonTargets != 2 || r.FalsePositives != 1 {
+		t.Fatalf("unexpected counts: %+v", r)
+	}
+	if len(r.MissedTargets) != 1 || r.MissedTargets[0] != tg[2] {
+		t.Fatalf("missed targets = %v", r.MissedTargets)
+	}
+	if len(r.OffTargetHits) != 1 || r.OffTargetHits[0] != nt[0] {
+		t.Fatalf("off-target hits = %v", r.OffTargetHits)
+	}
+	if r.Specificity == nil || *r.Specificity != 0.5 {
+		t.Fatalf("specificity = %v", r.Specificity)
+	}
+}
+
+func TestValidateProbeAssay(t *testing.T) {
+	_, tg, nt := fixture(t)
+	r := run(t, tg, nt, "--probe", probe)
+	if r.Assay != "probe" || r.TruePositives != 1 || r.FalsePositives != 0 {
+		t.Fatalf("unexpected counts: %+v", r)
+	}
+	if r.Specificity == nil || *r.Specificity != 1 {
+		t.Fatalf("specificity = %v", r.Specificity)
+	}
+}
+
+func TestValidateNestedAssayWithAssemblyMap(t *testing.T) {
+	dir, tg, nt := fixture(t)
+	// Probe region doubles as an inner amplicon: inner fwd = first half,
+	// inner rev = reverse complement of the second half.
+	innerF := probe[:8]
+	innerR := string(primer.RevComp([]byte(probe[8:])))
+	amap := write(t, filepath.Join(dir, "map.tsv"), "t1.fa\tTA\nt2.fa\tTA\nt3.fa\tTB\nn1.fa\tNA\nn2.fa\tNA\n")
+	r := run(t, tg, nt, "--inner-forward", innerF, "--inner-reverse", innerR, "--terminal-window", "0", "--assembly-map", amap)
+	if r.Assay != "nested" || r.Targets != 2 || r.TruePositives != 1 || r.NonTargets != 1 || r.FalsePositives != 0 {
+		t.Fatalf("unexpected counts: %+v", r)
+	}
+	if len(r.MissedTargets) != 1 || r.MissedTargets[0] != "TB" {
+		t.Fatalf("missed targets = %v", r.MissedTargets)
+	}
+}
+
+// runPanel validates the panel TSV and returns the rows by assay.
+func runPanel(t *testing.T, panel string, targets, nonTargets []string, extra ...string) map[string]api.ValidationV1 {
+	t.Helper()
+	args := []string{"--primers", panel, "--output", "json", "--terminal-window", "0"}
+	for _, f := range targets {
+		args = append(args, "--targets", f)
+	}
+	for _, f := range nonTargets {
+		args = append(args, "--non-targets", f)
+	}
+	var out, errB bytes.Buffer
+	if code := validateapp.Run(append(args, extra...), &out, &errB); code != 0 {
+		t.Fatalf("exit %d err=%s", code, errB.String())
+	}
+	var rows []api.ValidationV1
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatalf("bad JSON: %v\n%s", err, out.String())
+	}
+	by := make(map[string]api.ValidationV1)
+	for _, r := range rows {
+		by[r.ExperimentID] = r
+	}
+	return by
+}
+
+func TestValidatePanelProbeColumn(t *testing.T) {
+	dir, tg, nt := fixture(t)
+	panel := write(t, filepath.Join(dir, "panel.tsv"), "id\tfwd\trev\tprobe\n"+
+		"A\t"+fwd+"\t"+rev+"\t"+probe+"\n"+
+		"B\t"+fwd+"\t"+rev+"\tGGGGAAAACCCCTTTT\n")
+	rows := runPanel(t, panel, tg, nt)
+	if a := rows["A"]; a.Assay != "probe" || a.TruePositives != 1 || a.FalsePositives != 0 {
+		t.Fatalf("A: %+v", a)
+	}
+	if b := rows["B"]; b.Assay != "probe" || b.TruePositives != 0 {
+		t.Fatalf("B checked another assay's probe: %+v", b)
+	}
+}
+
+func TestValidateNestedInnerPerAssay(t *testing.T) {
+	dir, tg, nt := fixture(t)
+	innerF := probe[:8]
+	innerR := string(primer.RevComp([]byte(probe[8:])))
+	panel := write(t, filepath.Join(dir, "outer.tsv"), "A\t"+fwd+"\t"+rev+"\nB\t"+fwd+"\t"+rev+"\n")
+	inner := write(t, filepath.Join(dir, "inner.tsv"), "A\t"+innerF+"\t"+innerR+"\nB\tGGGGAAAA\tTTTTCCCC\n")
+	rows := runPanel(t, panel, tg, nt, "--inner-primers", inner)
+	if a := rows["A"]; a.Assay != "nested" || a.TruePositives != 1 {
+		t.Fatalf("A: %+v", a)
+	}
+	if b := rows["B"]; b.TruePositives != 0 {
+		t.Fatalf("B validated by another assay's inner primers: %+v", b)
+	}
+
+	// Inner rows must name a panel assay.
+	stray := write(t, filepath.Join(dir, "stray.tsv"), "A\t"+innerF+"\t"+innerR+"\nC\t"+innerF+"\t"+innerR+"\n")
+	var out, errB bytes.Buffer
+	args := []string{"--primers", panel, "--inner-primers", stray, "--targets", tg[0], "--non-targets", nt[0]}
+	if code := validateapp.Run(args, &out, &errB); code != 2 || !strings.Contains(errB.String(), `"C"`) {
+		t.Fatalf("want usage error, got exit %d: %s", code, errB.String())
+	}
+}
+
+func TestValidateRequiresBothSets(t *testing.T) {
+	var out, errB bytes.Buffer
+	code := validateapp.Run([]string{"--forward", fwd, "--reverse", rev, "--targets", "t.fa"}, &out, &errB)
+	if code != 2 || !strings.Contains(errB.String(), "--non-targets") {
+		t.Fatalf("want usage error, got exit %d: %s", code, errB.String())
+	}
+}
+
+func TestHelpListsOnlyHonouredFlags(t *testing.T) {
+	var out, errB bytes.Buffer
+	if code := validateapp.Run([]string{"-h"}, &out, &errB); code != 0 {
+		t.Fatalf("exit %d: %s", code, errB.String())
+	}
+	help := out.String()
+	for _, want := range []string{"--targets", "--assembly-map", "--regions", "text | json | jsonl ["} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help lacks %q", want)
+		}
+	}
+	for _, bad := range []string{"--sequences", "--index", "--summary", "--products", "--pretty", "--sort", "bed12"} {
+		if strings.Contains(help, bad) {
+			t.Errorf("help lists %q", bad)
+		}
+	}
+}
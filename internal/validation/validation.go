@@ -0,0 +1,222 @@
+// internal/validation/validation.go
+
+// Package validation scores assays against labelled target and non-target
+// genome sets (inclusivity/exclusivity) for ipcr-validate.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr-core/fasta"
+	"ipcr/internal/assembly"
+	"ipcr/internal/common"
+	"ipcr/internal/jsonutil"
+	"ipcr/pkg/api"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Assay kinds.
+const (
+	AssayPlain  = "plain"
+	AssayProbe  = "probe"
+	AssayNested = "nested"
+)
+
+// TSVHeader is the header row of the text report.
+const TSVHeader = "experiment_id\tassay\ttargets\ttrue_positives\tsensitivity\tnon_targets\tfalse_positives\tspecificity\tmissed_targets\toff_target_hits"
+
+// Validator accumulates products and labels the genomes they come from.
+type Validator struct {
+	r      *assembly.Resolver
+	kind   string
+	assays []string
+
+	fileTarget map[string]bool // source file → is target set
+	target     map[string]bool // genome → is target
+	order      []string        // genomes in first-seen order
+	hits       map[string]map[string]bool
+	err        error
+}
+
+// New labels every genome reachable from the target and non-target files,
+// so genomes without any product are still counted.
+// Self-amplification pairs are folded into the assay they were derived from.
+func New(r *assembly.Resolver, kind string, assays, targets, nonTargets []string) (*Validator, error) {
+	v := &Validator{
+		r:          r,
+		kind:       kind,
+		fileTarget: make(map[string]bool),
+		target:     make(map[string]bool),
+		hits:       make(map[string]map[string]bool),
+	}
+	seen := make(map[string]bool)
+	for _, id := range assays {
+		if base, _ := common.SelfPairBase(id); !seen[base] {
+			seen[base] = true
+			v.assays = append(v.assays, base)
+		}
+	}
+	for _, set := range []struct {
+		files  []string
+		target bool
+	}{{targets, true}, {nonTargets, false}} {
+		for _, f := range set.files {
+			if prev, ok := v.fileTarget[f]; ok && prev != set.target {
+				return nil, fmt.Errorf("%s is listed as both target and non-target", f)
+			}
+			v.fileTarget[f] = set.target
+			genomes, err := fileGenomes(r, f)
+			if err != nil {
+				return nil, err
+			}
+			for _, g := range genomes {
+				if err := v.label(g, set.target); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return v, nil
+}
+
+// fileGenomes lists the genomes of one input file. With an assembly map
+// records may be mapped one by one, so every record ID is looked up;
+// standard input cannot be read twice and is labelled from its products.
+func fileGenomes(r *assembly.Resolver, file string) ([]string, error) {
+	if !r.Mapped() {
+		g, _ := r.FileGenome(file)
+		return []string{g}, nil
+	}
+	if file == "-" {
+		return nil, nil
+	}
+	ids, err := fasta.RecordIDs(file)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, id := range ids {
+		out = append(out, r.Genome(file, id))
+	}
+	return out, nil
+}
+
+func (v *Validator) label(genome string, target bool) error {
+	if prev, ok := v.target[genome]; ok {
+		if prev != target {
+			return fmt.Errorf("genome %q has records in both target and non-target sets", genome)
+		}
+		return nil
+	}
+	v.target[genome] = target
+	v.order = append(v.order, genome)
+	return nil
+}
+
+// Add records one amplifying product.
+func (v *Validator) Add(p engine.Product) {
+	g := v.r.Genome(p.SourceFile, p.SequenceID)
+	if err := v.label(g, v.fileTarget[p.SourceFile]); err != nil && v.err == nil {
+		v.err = err
+	}
+	assay, _ := common.SelfPairBase(p.ExperimentID)
+	m := v.hits[assay]
+	if m == nil {
+		m = make(map[string]bool)
+		v.hits[assay] = m
+	}
+	m[g] = true
+}
+
+// Report computes one row per assay in panel order.
+func (v *Validator) Report() ([]api.ValidationV1, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	rows := make([]api.ValidationV1, 0, len(v.assays))
+	for _, id := range v.assays {
+		row := api.ValidationV1{ExperimentID: id, Assay: v.kind, MissedTargets: []string{}, OffTargetHits: []string{}}
+		for _, g := range v.order {
+			hit := v.hits[id][g]
+			if v.target[g] {
+				row.Targets++
+				if hit {
+					row.TruePositives++
+				} else {
+					row.MissedTargets = append(row.MissedTargets, g)
+				}
+				continue
+			}
+			row.NonTargets++
+			if hit {
+				row.FalsePositives++
+				row.OffTargetHits = append(row.OffTargetHits, g)
+			}
+		}
+		sort.Strings(row.MissedTargets)
+		sort.Strings(row.OffTargetHits)
+		if row.Targets > 0 {
+			s := float64(row.TruePositives) / float64(row.Targets)
+			row.Sensitivity = &s
+		}
+		if row.NonTargets > 0 {
+			s := float64(row.NonTargets-row.FalsePositives) / float64(row.NonTargets)
+			row.Specificity = &s
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// WriteTSV writes the report as text. Lists are comma-separated; empty lists
+// and undefined rates show "-".
+func WriteTSV(w io.Writer, rows []api.ValidationV1, header bool) error {
+	if header {
+		if _, err := io.WriteString(w, TSVHeader+"\n"); err != nil {
+			return err
+		}
+	}
+	for _, r := range rows {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%d\t%d\t%s\t%s\t%s\n",
+			r.ExperimentID, r.Assay, r.Targets, r.TruePositives, rate(r.Sensitivity),
+			r.NonTargets, r.FalsePositives, rate(r.Specificity),
+			list(r.MissedTargets), list(r.OffTargetHits)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the report as an indented JSON array.
+func WriteJSON(w io.Writer, rows []api.ValidationV1) error {
+	return jsonutil.EncodePretty(w, rows)
+}
+
+// WriteJSONL writes one JSON object per assay.
+func WriteJSONL(w io.Writer, rows []api.ValidationV1) error {
+	enc := json.NewEncoder(w)
+	for _, r := range rows {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rate(p *float64) string {
+	if p == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*p, 'f', 4, 64)
+}
+
+func list(v []string) string {
+	if len(v) == 0 {
+		return "-"
+	}
+	return strings.Join(v, ",")
+}
@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"bytes"
+	"ipcr-core/engine"
+	"ipcr/internal/assembly"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReportFoldsSelfPairsAndLabelsGenomes(t *testing.T) {
+	v, err := New(assembly.NewResolver(nil, nil), AssayPlain, []string{"A1", "A1+A:self", "A1+B:self", "A2"}, []string{"t1.fa", "t2.fa"}, []string{"n1.fa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Add(engine.Product{ExperimentID: "A1", SourceFile: "t1.fa"})
+	v.Add(engine.Product{ExperimentID: "A1+A:self", SourceFile: "n1.fa"})
+	v.Add(engine.Product{ExperimentID: "A2", SourceFile: "t2.fa"})
+
+	rows, err := v.Report()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteTSV(&buf, rows, true); err != nil {
+		t.Fatal(err)
+	}
+	want := TSVHeader + "\n" +
+		"A1\tplain\t2\t1\t0.5000\t1\t1\t0.0000\tt2.fa\tn1.fa\n" +
+		"A2\tplain\t2\t1\t0.5000\t1\t0\t1.0000\tt1.fa\t-\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestNewRejectsGenomeInBothSets(t *testing.T) {
+	dir := t.TempDir()
+	tFa, nFa := writeFASTA(t, dir, "t.fa", ">t1\nACGT\n"), writeFASTA(t, dir, "n.fa", ">n1\nACGT\n")
+	m, err := assembly.Parse(strings.NewReader("t.fa\tG\nn.fa\tG\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(assembly.NewResolver(m, nil), AssayPlain, []string{"A"}, []string{tFa}, []string{nFa}); err == nil {
+		t.Fatal("expected label conflict")
+	}
+}
+
+// Genomes mapped by record ID are labelled before the scan, so a target
+// genome without products is still counted as missed.
+func TestNewLabelsRecordMappedGenomesWithoutHits(t *testing.T) {
+	dir := t.TempDir()
+	tFa := writeFASTA(t, dir, "t.fa", ">g1\nACGT\n>g2\nACGT\n")
+	nFa := writeFASTA(t, dir, "n.fa", ">n\nACGT\n")
+	m, err := assembly.Parse(strings.NewReader("g1\tG1\ng2\tG2\nn\tN1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := New(assembly.NewResolver(m, nil), AssayPlain, []string{"A"}, []string{tFa}, []string{nFa})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Add(engine.Product{ExperimentID: "A", SourceFile: tFa, SequenceID: "g1"})
+	rows, err := v.Report()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rows[0]
+	if r.Targets != 2 || r.TruePositives != 1 || *r.Sensitivity != 0.5 || r.NonTargets != 1 || *r.Specificity != 1 ||
+		strings.Join(r.MissedTargets, ",") != "G2" {
+		t.Fatalf("row: %+v", r)
+	}
+}
+
+func writeFASTA(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
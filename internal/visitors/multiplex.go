@@ -0,0 +1,53 @@
+package visitors
+
+import (
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"ipcr-core/probe"
+	"ipcr/internal/common"
+	"ipcr/internal/multiplexoutput"
+)
+
+// PanelProbe is a panel probe tagged with the assay (pair ID) it belongs to.
+type PanelProbe struct {
+	Assay string
+	primer.Probe
+}
+
+// PanelProbes annotates each multiplex amplicon with every panel probe that
+// binds it, flagging probes that belong to another assay.
+type PanelProbes struct {
+	Probes []PanelProbe
+	MaxMM  int
+}
+
+// NewPanelProbes collects the probes listed on the panel rows.
+func NewPanelProbes(pairs []primer.Pair, maxMM int) PanelProbes {
+	v := PanelProbes{MaxMM: maxMM}
+	for _, p := range pairs {
+		for _, pr := range p.Probes {
+			v.Probes = append(v.Probes, PanelProbe{Assay: p.ID, Probe: pr})
+		}
+	}
+	return v
+}
+
+func (v PanelProbes) Visit(p engine.Product) (bool, multiplexoutput.ProbedProduct, error) {
+	assay := p.ExperimentID
+	if base, ok := common.SelfPairBase(assay); ok {
+		assay = base
+	}
+	out := multiplexoutput.ProbedProduct{Product: p, Assay: assay}
+	for _, pr := range v.Probes {
+		ann := probe.AnnotateAmplicon(p.Seq, pr.Seq, v.MaxMM)
+		if !ann.Found {
+			continue
+		}
+		out.Probes = append(out.Probes, multiplexoutput.ProbeHit{
+			Name: pr.Name, Assay: pr.Assay, Channel: pr.Channel, Seq: pr.Seq,
+			Strand: ann.Strand, Pos: ann.Pos, MM: ann.MM, Site: ann.Site,
+			Cross: pr.Assay != assay,
+		})
+	}
+	return true, out, nil
+}
@@ -4,57 +4,109 @@ package visitors
 import (
 	"ipcr-core/engine"
 	"ipcr-core/primer"
+	"ipcr/internal/common"
 	"ipcr/internal/nestedoutput"
+	"math"
 	"sort"
 )
 
+// Semi-nested rounds reuse one primer of the pair that made the enclosing
+// product.
+const (
+	ReuseForward = "forward"
+	ReuseReverse = "reverse"
+)
+
+// NestedRound is one round after the first inner round: its pairs are run on
+// every product of the round before. With Reuse set, the reused side of each
+// pair is taken from the enclosing product's pair.
+type NestedRound struct {
+	Pairs []primer.Pair
+	Reuse string // "", ReuseForward or ReuseReverse
+}
+
+// RoundScorer scores the outer product and, in place, every inner hit of a
+// nested product (thermovisitors.NestedScore).
+type RoundScorer interface {
+	ScoreRounds(outer engine.Product, hits []nestedoutput.InnerHit) (engine.Product, error)
+}
+
 type Nested struct {
 	InnerPairs   []primer.Pair
 	EngineCfg    engine.Config
 	RequireInner bool
+
+	// AllInner reports every inner product in NestedProduct.Inner instead of
+	// only the best one.
+	AllInner bool
+	// InnerReuse makes the first inner round semi-nested; OuterPairs then
+	// supplies the reused primer by outer experiment ID.
+	InnerReuse string
+	OuterPairs []primer.Pair
+	// Rounds are rounds 3..N.
+	Rounds []NestedRound
+	// Scorer, when set, scores every round (NestedProduct.Scored).
+	Scorer RoundScorer
+
+	// PerOuter overrides InnerPairs for the listed outer pair IDs. Self
+	// pairs use the inner pairs of the pair they were derived from.
+	PerOuter map[string][]primer.Pair
+}
+
+// innerFor returns the first-round inner pairs for products of outer pair id.
+func (v Nested) innerFor(id string) []primer.Pair {
+	if ps, ok := v.PerOuter[id]; ok {
+		return ps
+	}
+	if base, ok := common.SelfPairBase(id); ok {
+		if ps, ok := v.PerOuter[base]; ok {
+			return ps
+		}
+	}
+	return v.InnerPairs
+}
+
+// Extended reports whether products carry the full inner product tree
+// (NestedProduct.Inner).
+func (v Nested) Extended() bool {
+	return v.AllInner || v.InnerReuse != "" || len(v.Rounds) > 0
 }
 
 func (v Nested) Visit(p engine.Product) (bool, nestedoutput.NestedProduct, error) {
-	amp := []byte(p.Seq)
+	rounds := append([]NestedRound{{Pairs: v.innerFor(p.ExperimentID), Reuse: v.InnerReuse}}, v.Rounds...)
+	var outer primer.Pair
+	for _, op := range v.OuterPairs {
+		if op.ID == p.ExperimentID {
+			outer = op
+			break
+		}
+	}
 	eng := engine.New(v.EngineCfg)
-	hits := eng.SimulateBatch("amplicon", amp, v.InnerPairs)
+	hits := v.scan(eng, []byte(p.Seq), 0, 2, outer, rounds)
 
-	if len(hits) == 0 {
+	if len(hits) == 0 || (v.RequireInner && hits[0].Depth() < len(rounds)) {
 		if v.RequireInner {
 			return false, nestedoutput.NestedProduct{}, nil
 		}
-		return true, nestedoutput.NestedProduct{
+		np := nestedoutput.NestedProduct{
 			Product:     p,
 			InnerFound:  false,
 			InnerPairID: "",
-		}, nil
-	}
-
-	// Pick a deterministic best inner: fewest total mismatches, then longest,
-	// then leftmost. Sorting first avoids stale-index bugs after reordering.
-	sort.SliceStable(hits, func(i, j int) bool {
-		mi := hits[i].FwdMM + hits[i].RevMM
-		mj := hits[j].FwdMM + hits[j].RevMM
-		if mi != mj {
-			return mi < mj
-		}
-		if hits[i].Length != hits[j].Length {
-			return hits[i].Length > hits[j].Length
 		}
-		if hits[i].Start != hits[j].Start {
-			return hits[i].Start < hits[j].Start
+		if err := v.score(&np, hits); err != nil {
+			return false, np, err
 		}
-		if hits[i].End != hits[j].End {
-			return hits[i].End < hits[j].End
+		if v.Extended() {
+			np.Inner = hits
 		}
-		return hits[i].ExperimentID < hits[j].ExperimentID
-	})
-	inner := hits[0]
+		return true, np, nil
+	}
 
+	inner := hits[0]
 	np := nestedoutput.NestedProduct{
 		Product:     p, // outer
 		InnerFound:  true,
-		InnerPairID: inner.ExperimentID,
+		InnerPairID: inner.PairID,
 		InnerStart:  inner.Start, // relative to amplicon
 		InnerEnd:    inner.End,   // relative to amplicon
 		InnerLength: inner.Length,
@@ -62,5 +114,113 @@ func (v Nested) Visit(p engine.Product) (bool, nestedoutput.NestedProduct, error
 		InnerFwdMM:  inner.FwdMM,
 		InnerRevMM:  inner.RevMM,
 	}
+	if err := v.score(&np, hits); err != nil {
+		return false, np, err
+	}
+	if v.Extended() {
+		np.Inner = hits
+	}
 	return true, np, nil
 }
+
+// score fills the round scores of np from hits when a Scorer is set. Without
+// a complete inner chain (np.InnerFound false) the inner and nested scores
+// are NaN.
+func (v Nested) score(np *nestedoutput.NestedProduct, hits []nestedoutput.InnerHit) error {
+	if v.Scorer == nil {
+		return nil
+	}
+	outer, err := v.Scorer.ScoreRounds(np.Product, hits)
+	if err != nil {
+		return err
+	}
+	np.Product = outer
+	np.Scored = true
+	np.InnerScore, np.NestedScore = math.NaN(), math.NaN()
+	if np.InnerFound {
+		np.InnerScore = hits[0].ChainScore()
+		np.NestedScore = min(outer.Score, np.InnerScore)
+	}
+	return nil
+}
+
+// scan runs rounds[0] (round number round) on seq, the product of parent
+// starting at off in the outer amplicon, and recurses into each product with the remaining rounds.
+// Hits come back best first: deepest chain, then fewest total mismatches,
+// then longest, then leftmost. Without AllInner only the best hit is kept
+// at each level.
+func (v Nested) scan(eng *engine.Engine, seq []byte, off, round int, parent primer.Pair, rounds []NestedRound) []nestedoutput.InnerHit {
+	r := rounds[0]
+	pairs := r.Pairs
+	if r.Reuse != "" {
+		pairs = make([]primer.Pair, len(r.Pairs))
+		for i, ip := range r.Pairs {
+			if r.Reuse == ReuseForward {
+				ip.Forward = parent.Forward
+			} else {
+				ip.Reverse = parent.Reverse
+			}
+			pairs[i] = ip
+		}
+	}
+	byID := make(map[string]primer.Pair, len(pairs))
+	for _, ip := range pairs {
+		if ip.Forward != "" && ip.Reverse != "" {
+			byID[ip.ID] = ip
+		}
+	}
+	if len(byID) == 0 {
+		return nil
+	}
+	run := make([]primer.Pair, 0, len(byID))
+	for _, ip := range pairs {
+		if _, ok := byID[ip.ID]; ok {
+			run = append(run, ip)
+		}
+	}
+
+	var out []nestedoutput.InnerHit
+	for _, h := range eng.SimulateBatch("amplicon", seq, run) {
+		hit := nestedoutput.InnerHit{
+			Round:  round,
+			PairID: h.ExperimentID,
+			Start:  off + h.Start,
+			End:    off + h.End,
+			Length: h.Length,
+			Type:   h.Type,
+			FwdMM:  h.FwdMM,
+			RevMM:  h.RevMM,
+			Reused: r.Reuse,
+
+			FwdPrimer: h.FwdPrimer,
+			RevPrimer: h.RevPrimer,
+		}
+		if len(rounds) > 1 && h.Start >= 0 && h.End <= len(seq) {
+			hit.Inner = v.scan(eng, seq[h.Start:h.End], off+h.Start, round+1, byID[h.ExperimentID], rounds[1:])
+		}
+		out = append(out, hit)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if da, db := a.Depth(), b.Depth(); da != db {
+			return da > db
+		}
+		if ma, mb := a.FwdMM+a.RevMM, b.FwdMM+b.RevMM; ma != mb {
+			return ma < mb
+		}
+		if a.Length != b.Length {
+			return a.Length > b.Length
+		}
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		if a.End != b.End {
+			return a.End < b.End
+		}
+		return a.PairID < b.PairID
+	})
+	if !v.AllInner && len(out) > 1 {
+		out = out[:1]
+	}
+	return out
+}
@@ -3,6 +3,7 @@ package visitors
 import (
 	"ipcr-core/engine"
 	"ipcr-core/probe"
+	"ipcr/internal/common"
 	"ipcr/internal/probeoutput"
 	"strings"
 )
@@ -13,17 +14,35 @@ type Probe struct {
 	Seq     string // 5'→3'
 	MaxMM   int
 	Require bool
+
+	// PerPair overrides Seq for the listed pair IDs (panel "probe" column).
+	// Self pairs use the probe of the pair they were derived from.
+	PerPair map[string]string
+}
+
+// seqFor returns the probe to overlay on products of pair id.
+func (v Probe) seqFor(id string) string {
+	if s, ok := v.PerPair[id]; ok {
+		return s
+	}
+	if base, ok := common.SelfPairBase(id); ok {
+		if s, ok := v.PerPair[base]; ok {
+			return s
+		}
+	}
+	return v.Seq
 }
 
 func (v Probe) Visit(p engine.Product) (bool, probeoutput.AnnotatedProduct, error) {
-	ann := probe.AnnotateAmplicon(p.Seq, v.Seq, v.MaxMM)
+	seq := v.seqFor(p.ExperimentID)
+	ann := probe.AnnotateAmplicon(p.Seq, seq, v.MaxMM)
 	if v.Require && !ann.Found {
 		return false, probeoutput.AnnotatedProduct{}, nil
 	}
 	return true, probeoutput.AnnotatedProduct{
 		Product:     p,
 		ProbeName:   v.Name,
-		ProbeSeq:    strings.ToUpper(v.Seq),
+		ProbeSeq:    strings.ToUpper(seq),
 		ProbeFound:  ann.Found,
 		ProbeStrand: ann.Strand,
 		ProbePos:    ann.Pos,
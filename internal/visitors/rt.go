@@ -0,0 +1,50 @@
+package visitors
+
+import (
+	"ipcr-core/engine"
+	"ipcr-core/rt"
+	"ipcr/internal/rtoutput"
+)
+
+// RT labels RT-PCR products with their template. Without transcript models
+// every product comes from cDNA (RNA FASTA input); with them, products from
+// the spliced transcripts (SourceFile == GTF) are cDNA and all others gDNA.
+type RT struct {
+	GTF         string
+	Transcripts map[string]rt.Transcript // by transcript ID
+}
+
+func (v RT) Visit(p engine.Product) (bool, rtoutput.RTProduct, error) {
+	out := rtoutput.RTProduct{Product: p, Template: rtoutput.TemplateCDNA}
+	if v.Transcripts == nil {
+		return true, out, nil
+	}
+	if p.SourceFile != v.GTF {
+		out.Template = rtoutput.TemplateGDNA
+		return true, out, nil
+	}
+	t, ok := v.Transcripts[p.SequenceID]
+	if !ok {
+		return true, out, nil
+	}
+	out.Gene = t.Gene
+	out.GenomicLength = t.GenomicSpan(p.Start, p.End)
+
+	// The left site carries FwdPrimer, the right site RevPrimer; for
+	// "revcomp" products those are the pair's reverse and forward primers.
+	leftEnd, rightStart := p.Start+len(p.FwdPrimer), p.End-len(p.RevPrimer)
+	var left, right bool
+	for _, j := range t.Junctions() {
+		if j <= p.Start || j >= p.End {
+			continue
+		}
+		out.Junctions++
+		left = left || j < leftEnd
+		right = right || j > rightStart
+	}
+	out.FwdJunction, out.RevJunction = left, right
+	if p.Type == "revcomp" {
+		out.FwdJunction, out.RevJunction = right, left
+	}
+	return true, out, nil
+}
@@ -0,0 +1,51 @@
+package visitors
+
+import (
+	"ipcr-core/engine"
+	"ipcr-core/vcf"
+)
+
+// DefaultVariantWindow is the 3' window for variant hits when the terminal
+// window is disabled.
+const DefaultVariantWindow = 3
+
+// Variants annotates products with the known variants under their primer
+// sites. With FailAF ≥ 0 a product is dropped when a variant at or above
+// that allele frequency breaks either primer's 3' window (a mismatch or an
+// indel); variants without an AF count only when FailAF is 0. Next, when
+// set, visits the kept products (e.g. thermo scoring).
+type Variants struct {
+	Set    *vcf.Set
+	Window int // 3' window (usually the terminal window); ≤0: DefaultVariantWindow
+	FailAF float64
+	Next   func(engine.Product) (bool, engine.Product, error)
+}
+
+func (v Variants) Visit(p engine.Product) (bool, engine.Product, error) {
+	w := v.Window
+	if w <= 0 {
+		w = DefaultVariantWindow
+	}
+	p.Variants = engine.VerifyAlleles(p, v.Set.Overlap(p.SequenceID, p.Start, p.End), w)
+	if v.FailAF >= 0 {
+		for _, h := range p.Variants {
+			if v.fails(h) {
+				return false, engine.Product{}, nil
+			}
+		}
+	}
+	if v.Next != nil {
+		return v.Next(p)
+	}
+	return true, p, nil
+}
+
+func (v Variants) fails(h engine.VariantHit) bool {
+	if !h.ThreePrime || (h.Effect != engine.EffectMismatch && h.Effect != engine.EffectIndel) {
+		return false
+	}
+	if h.AF < 0 {
+		return v.FailAF == 0
+	}
+	return h.AF >= v.FailAF
+}
@@ -0,0 +1,114 @@
+// internal/writers/coords.go
+package writers
+
+import (
+	"fmt"
+	"io"
+	"ipcr-core/engine"
+	"ipcr/internal/common"
+	"ipcr/internal/nestedoutput"
+	"ipcr/internal/output"
+	"ipcr/internal/probeoutput"
+	"sort"
+)
+
+// Genome-coordinate formats share one writer across product kinds: each kind
+// supplies its base product and, for GFF3, extra product attributes.
+
+var coordFormats = []string{output.FormatBED, output.FormatBED12, output.FormatGFF3, output.FormatSAM}
+
+func writeCoords[T any](w io.Writer, format string, in <-chan T, sortOut, header bool, product func(T) engine.Product, attrs func(T) []string) error {
+	// The GFF3 version line is mandatory; the SAM header honours --no-header.
+	var err error
+	switch {
+	case format == output.FormatGFF3:
+		_, err = io.WriteString(w, output.GFF3Header+"\n")
+	case format == output.FormatSAM && header:
+		_, err = io.WriteString(w, output.SAMHeader)
+	}
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	write := func(x T) error {
+		n++
+		p := product(x)
+		switch format {
+		case output.FormatBED:
+			return output.WriteBED(w, p)
+		case output.FormatBED12:
+			return output.WriteBED12(w, p)
+		case output.FormatGFF3:
+			var extra []string
+			if attrs != nil {
+				extra = attrs(x)
+			}
+			return output.WriteGFF3(w, p, n, extra...)
+		case output.FormatSAM:
+			return output.WriteSAM(w, p, n)
+		}
+		return fmt.Errorf("unknown coordinate format %q", format)
+	}
+
+	if sortOut {
+		var list []T
+		for x := range in {
+			list = append(list, x)
+		}
+		sort.SliceStable(list, func(i, j int) bool { return common.LessProduct(product(list[i]), product(list[j])) })
+		for _, x := range list {
+			if err := write(x); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for x := range in {
+		if err := write(x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	for _, format := range coordFormats {
+		RegisterProduct(format, func(w io.Writer, payload interface{}) error {
+			args := payload.(productArgs)
+			return writeCoords(w, format, args.In, args.Sort, args.Header,
+				func(p engine.Product) engine.Product { return p }, nil)
+		})
+		RegisterAnnotated(format, func(w io.Writer, payload interface{}) error {
+			args := payload.(annotatedArgs)
+			return writeCoords(w, format, args.In, args.Sort, args.Header,
+				func(ap probeoutput.AnnotatedProduct) engine.Product { return ap.Product },
+				func(ap probeoutput.AnnotatedProduct) []string {
+					attrs := []string{"probe=" + output.GFFEscape(ap.ProbeName), fmt.Sprintf("probe_found=%t", ap.ProbeFound)}
+					if ap.ProbeFound {
+						attrs = append(attrs,
+							"probe_strand="+ap.ProbeStrand,
+							fmt.Sprintf("probe_pos=%d", ap.ProbePos),
+							fmt.Sprintf("probe_mm=%d", ap.ProbeMM))
+					}
+					return attrs
+				})
+		})
+		RegisterNested(format, func(w io.Writer, payload interface{}) error {
+			args := payload.(nestedArgs)
+			return writeCoords(w, format, args.In, args.Sort, args.Header,
+				func(np nestedoutput.NestedProduct) engine.Product { return np.Product },
+				func(np nestedoutput.NestedProduct) []string {
+					attrs := []string{fmt.Sprintf("inner_found=%t", np.InnerFound)}
+					if np.InnerFound {
+						attrs = append(attrs,
+							"inner_experiment_id="+output.GFFEscape(np.InnerPairID),
+							fmt.Sprintf("inner_start=%d", np.InnerStart),
+							fmt.Sprintf("inner_end=%d", np.InnerEnd),
+							"inner_type="+np.InnerType)
+					}
+					return attrs
+				})
+		})
+	}
+}
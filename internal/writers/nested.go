@@ -7,6 +7,7 @@ import (
 	"ipcr/internal/nestedoutput"
 	"ipcr/internal/output"
 	"ipcr/internal/pretty"
+	"math"
 	"sort"
 )
 
@@ -14,6 +15,9 @@ type nestedArgs struct {
 	Sort   bool
 	Header bool
 	Pretty bool
+	Rounds bool // one text row per inner product (nestedoutput.TSVHeaderNestedRounds)
+	Scores bool // products carry round scores (nestedoutput.TSVScoreColumns)
+	Rank   bool // sort by nested score, best first, instead of coordinates
 	Opt    pretty.Options
 	In     <-chan nestedoutput.NestedProduct
 }
@@ -26,13 +30,31 @@ func drainNested(ch <-chan nestedoutput.NestedProduct) []nestedoutput.NestedProd
 	return list
 }
 
+// sortNested orders list by coordinates, or by nested score (NaN last) with
+// coordinates breaking ties when rank is set.
+func sortNested(list []nestedoutput.NestedProduct, rank bool) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if rank {
+			aNaN, bNaN := math.IsNaN(a.NestedScore), math.IsNaN(b.NestedScore)
+			switch {
+			case aNaN != bNaN:
+				return bNaN
+			case !aNaN && a.NestedScore != b.NestedScore:
+				return a.NestedScore > b.NestedScore
+			}
+		}
+		return common.LessProduct(a.Product, b.Product)
+	})
+}
+
 func init() {
 	// JSON array
 	RegisterNested(output.FormatJSON, func(w io.Writer, payload interface{}) error {
 		args := payload.(nestedArgs)
 		list := drainNested(args.In)
 		if args.Sort {
-			sort.SliceStable(list, func(i, j int) bool { return common.LessProduct(list[i].Product, list[j].Product) })
+			sortNested(list, args.Rank)
 		}
 		return nestedoutput.WriteJSON(w, list)
 	})
@@ -43,7 +65,7 @@ func init() {
 		pipe, done := StartNestedJSONLWriter(w, 64)
 		if args.Sort {
 			list := drainNested(args.In)
-			sort.SliceStable(list, func(i, j int) bool { return common.LessProduct(list[i].Product, list[j].Product) })
+			sortNested(list, args.Rank)
 			for _, np := range list {
 				pipe <- np
 			}
@@ -66,12 +88,31 @@ func init() {
 			return nestedoutput.RenderPrettyWithOptions(np, args.Opt)
 		}
 
+		// Scored runs widen the header, so write it here.
+		header := args.Header
+		if header && args.Scores {
+			h := nestedoutput.TSVHeaderNested
+			if args.Rounds {
+				h = nestedoutput.TSVHeaderNestedRounds
+			}
+			if _, err := io.WriteString(w, h+nestedoutput.TSVScoreColumns+"\n"); err != nil {
+				return err
+			}
+			header = false
+		}
+
 		if args.Sort {
 			list := drainNested(args.In)
-			sort.SliceStable(list, func(i, j int) bool { return common.LessProduct(list[i].Product, list[j].Product) })
-			return nestedoutput.WriteTextWithRenderer(w, list, args.Header, render)
+			sortNested(list, args.Rank)
+			if args.Rounds {
+				return nestedoutput.WriteRoundsText(w, list, header, render)
+			}
+			return nestedoutput.WriteTextWithRenderer(w, list, header, render)
+		}
+		if args.Rounds {
+			return nestedoutput.StreamRoundsText(w, args.In, header, render)
 		}
-		return nestedoutput.StreamTextWithRenderer(w, args.In, args.Header, render)
+		return nestedoutput.StreamTextWithRenderer(w, args.In, header, render)
 	})
 }
 
@@ -87,19 +128,33 @@ func StartNestedWriterWithPretty(out io.Writer, format string, sortOut, header,
 
 // Full control: pretty + options
 func StartNestedWriterWithPrettyOptions(out io.Writer, format string, sortOut, header, prettyMode bool, popt pretty.Options, bufSize int) (chan<- nestedoutput.NestedProduct, <-chan error) {
+	return startNestedWriter(out, format, nestedArgs{Sort: sortOut, Header: header, Pretty: prettyMode, Opt: popt}, bufSize)
+}
+
+// StartNestedRoundsWriter is StartNestedWriterWithPretty for runs that report
+// every inner product: text output has one row per inner product.
+func StartNestedRoundsWriter(out io.Writer, format string, sortOut, header, prettyMode bool, bufSize int) (chan<- nestedoutput.NestedProduct, <-chan error) {
+	return startNestedWriter(out, format, nestedArgs{Sort: sortOut, Header: header, Pretty: prettyMode, Rounds: true, Opt: pretty.DefaultOptions}, bufSize)
+}
+
+// StartNestedScoredWriter starts a nested writer for runs that score both
+// rounds: text output gains the score columns and, with rankByScore, sorted
+// output is ordered by nested score.
+func StartNestedScoredWriter(out io.Writer, format string, sortOut, header, prettyMode, rounds, rankByScore bool, bufSize int) (chan<- nestedoutput.NestedProduct, <-chan error) {
+	return startNestedWriter(out, format, nestedArgs{
+		Sort: sortOut, Header: header, Pretty: prettyMode, Rounds: rounds, Scores: true, Rank: rankByScore, Opt: pretty.DefaultOptions,
+	}, bufSize)
+}
+
+func startNestedWriter(out io.Writer, format string, args nestedArgs, bufSize int) (chan<- nestedoutput.NestedProduct, <-chan error) {
 	if bufSize <= 0 {
 		bufSize = 64
 	}
 	in := make(chan nestedoutput.NestedProduct, bufSize)
 	errCh := make(chan error, 1)
 	go func() {
-		err := WriteNested(format, out, nestedArgs{
-			Sort:   sortOut,
-			Header: header,
-			Pretty: prettyMode,
-			Opt:    popt,
-			In:     in,
-		})
+		args.In = in
+		err := WriteNested(format, out, args)
 		errCh <- err
 	}()
 	return in, errCh
@@ -0,0 +1,68 @@
+package writers
+
+import (
+	"io"
+	"ipcr/internal/common"
+	"ipcr/internal/multiplexoutput"
+	"ipcr/internal/output"
+	"ipcr/internal/pretty"
+	"sort"
+)
+
+// StartProbedWriter writes multiplex products with their panel probe hits.
+// Every product is added to channels (when non-nil) before it is written.
+// Unsorted text and JSONL stream; JSON and --sort buffer the whole run.
+func StartProbedWriter(out io.Writer, format string, sortOut, header, prettyMode bool, channels *multiplexoutput.Channels, bufSize int) (chan<- multiplexoutput.ProbedProduct, <-chan error) {
+	if bufSize <= 0 {
+		bufSize = 64
+	}
+	in := make(chan multiplexoutput.ProbedProduct, bufSize)
+	errCh := make(chan error, 1)
+	var render func(multiplexoutput.ProbedProduct) string
+	if prettyMode && format == output.FormatText {
+		render = func(pp multiplexoutput.ProbedProduct) string { return pretty.RenderProduct(pp.Product) }
+	}
+	go func() {
+		tracked := make(chan multiplexoutput.ProbedProduct, bufSize)
+		go func() {
+			defer close(tracked)
+			for pp := range in {
+				if channels != nil {
+					channels.Add(pp)
+				}
+				tracked <- pp
+			}
+		}()
+
+		var err error
+		switch {
+		case sortOut || format == output.FormatJSON:
+			list := make([]multiplexoutput.ProbedProduct, 0, 128)
+			for pp := range tracked {
+				list = append(list, pp)
+			}
+			if sortOut {
+				sort.SliceStable(list, func(i, j int) bool {
+					return common.LessProduct(list[i].Product, list[j].Product)
+				})
+			}
+			switch format {
+			case output.FormatJSON:
+				err = multiplexoutput.WriteJSON(out, list)
+			case output.FormatJSONL:
+				err = multiplexoutput.WriteJSONL(out, list)
+			default:
+				err = multiplexoutput.WriteText(out, list, header, render)
+			}
+		case format == output.FormatJSONL:
+			err = multiplexoutput.StreamJSONL(out, tracked)
+		default:
+			err = multiplexoutput.StreamText(out, tracked, header, render)
+		}
+		for range tracked {
+			// drain after a write error so the pipeline can finish
+		}
+		errCh <- err
+	}()
+	return in, errCh
+}
@@ -16,9 +16,18 @@ type productArgs struct {
 	Scores        bool // NEW: include 'score' in TSV
 	RankByScore   bool // NEW: prefer score sort over coord
 	ThermoDetails bool // append compact NN thermodynamic diagnostics in text/TSV
+	Columns       TextColumns
 	In            <-chan engine.Product
 }
 
+// TextColumns selects optional columns appended to text/TSV product rows,
+// after the score and thermo columns.
+type TextColumns struct {
+	Indels    bool // fwd_indels and rev_indels (--max-indels)
+	Bisulfite bool // bisulfite_strand and methylation (--bisulfite)
+	Variants  bool // alt_allele and variants (--vcf)
+}
+
 func drainProducts(ch <-chan engine.Product) []engine.Product {
 	list := make([]engine.Product, 0, 128)
 	for p := range ch {
@@ -96,6 +105,15 @@ func init() {
 			if args.ThermoDetails {
 				h += "\t" + output.ThermoDetailsTSVHeader
 			}
+			if args.Columns.Indels {
+				h += "\t" + output.IndelsTSVHeader
+			}
+			if args.Columns.Bisulfite {
+				h += "\t" + output.BisulfiteTSVHeader
+			}
+			if args.Columns.Variants {
+				h += "\t" + output.VariantsTSVHeader
+			}
 			_, err := io.WriteString(w, h+"\n")
 			return err
 		}
@@ -110,6 +128,15 @@ func init() {
 			case args.ThermoDetails:
 				row = output.FormatRowTSVWithThermoDetails(p)
 			}
+			if args.Columns.Indels {
+				row += "\t" + output.FormatIndelsTSV(p)
+			}
+			if args.Columns.Bisulfite {
+				row += "\t" + p.BisulfiteStrand + "\t" + p.Methylation
+			}
+			if args.Columns.Variants {
+				row += "\t" + output.FormatVariantsTSV(p)
+			}
 			if _, err := io.WriteString(w, row+"\n"); err != nil {
 				return err
 			}
@@ -167,6 +194,12 @@ func StartProductWriterWithPrettyOptions(out io.Writer, format string, sort, hea
 }
 
 func StartProductWriterWithPrettyOptionsAndThermoDetails(out io.Writer, format string, sort, header, prettyMode, includeScore, rankByScore, thermoDetails bool, popt pretty.Options, bufSize int) (chan<- engine.Product, <-chan error) {
+	return StartProductWriterWithColumns(out, format, sort, header, prettyMode, includeScore, rankByScore, thermoDetails, popt, TextColumns{}, bufSize)
+}
+
+// StartProductWriterWithColumns is StartProductWriterWithPrettyOptionsAndThermoDetails
+// with optional text/TSV columns.
+func StartProductWriterWithColumns(out io.Writer, format string, sort, header, prettyMode, includeScore, rankByScore, thermoDetails bool, popt pretty.Options, cols TextColumns, bufSize int) (chan<- engine.Product, <-chan error) {
 	if bufSize <= 0 {
 		bufSize = 64
 	}
@@ -181,6 +214,7 @@ func StartProductWriterWithPrettyOptionsAndThermoDetails(out io.Writer, format s
 			Scores:        includeScore,
 			RankByScore:   rankByScore,
 			ThermoDetails: thermoDetails,
+			Columns:       cols,
 			In:            in,
 		})
 		errCh <- err
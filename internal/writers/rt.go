@@ -0,0 +1,56 @@
+package writers
+
+import (
+	"io"
+	"ipcr/internal/common"
+	"ipcr/internal/output"
+	"ipcr/internal/pretty"
+	"ipcr/internal/rtoutput"
+	"sort"
+)
+
+// StartRTWriter writes ipcr-rt products with their template annotations.
+// Unsorted text and JSONL stream; JSON and --sort buffer the whole run.
+func StartRTWriter(out io.Writer, format string, sortOut, header, prettyMode bool, bufSize int) (chan<- rtoutput.RTProduct, <-chan error) {
+	if bufSize <= 0 {
+		bufSize = 64
+	}
+	in := make(chan rtoutput.RTProduct, bufSize)
+	errCh := make(chan error, 1)
+	var render func(rtoutput.RTProduct) string
+	if prettyMode && format == output.FormatText {
+		render = func(p rtoutput.RTProduct) string { return pretty.RenderProduct(p.Product) }
+	}
+	go func() {
+		var err error
+		switch {
+		case sortOut || format == output.FormatJSON:
+			list := make([]rtoutput.RTProduct, 0, 128)
+			for p := range in {
+				list = append(list, p)
+			}
+			if sortOut {
+				sort.SliceStable(list, func(i, j int) bool {
+					return common.LessProduct(list[i].Product, list[j].Product)
+				})
+			}
+			switch format {
+			case output.FormatJSON:
+				err = rtoutput.WriteJSON(out, list)
+			case output.FormatJSONL:
+				err = rtoutput.WriteJSONL(out, list)
+			default:
+				err = rtoutput.WriteText(out, list, header, render)
+			}
+		case format == output.FormatJSONL:
+			err = rtoutput.StreamJSONL(out, in)
+		default:
+			err = rtoutput.StreamText(out, in, header, render)
+		}
+		for range in {
+			// drain after a write error so the pipeline can finish
+		}
+		errCh <- err
+	}()
+	return in, errCh
+}
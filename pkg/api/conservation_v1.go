@@ -0,0 +1,56 @@
+// pkg/api/conservation_v1.go
+package api
+
+// ConservationV1 is one pair of the alignment conservation report (ipcr
+// msa): how conserved the columns under each primer are across the
+// aligned sequences.
+type ConservationV1 struct {
+	ExperimentID string               `json:"experiment_id"`
+	Sequences    int                  `json:"sequences"` // aligned sequences
+	Forward      PrimerConservationV1 `json:"forward"`
+	Reverse      PrimerConservationV1 `json:"reverse"`
+}
+
+// PrimerConservationV1 is the conservation profile of one primer. Its
+// footprint is the alignment columns its bases map to in the sequences it
+// binds (Located, within the mismatch limit); every aligned sequence is
+// then read across those columns. Sequences, consensus and degenerate
+// consensus are in primer orientation, so the reverse primer's read as the
+// reverse complement of the alignment. Degenerate is the IUPAC primer
+// covering the most common site variants until at least TargetPercent of
+// the sequences match it (Covered); sites with gaps or ambiguous bases
+// cannot be covered.
+type PrimerConservationV1 struct {
+	Primer         string                 `json:"primer"`
+	Located        int                    `json:"located"`
+	StartColumn    int                    `json:"start_column"` // 0-based; -1 when not located
+	EndColumn      int                    `json:"end_column"`   // 0-based, inclusive
+	MeanEntropy    float64                `json:"mean_entropy"`
+	Consensus      string                 `json:"consensus"`
+	Degenerate     string                 `json:"degenerate"`
+	Degeneracy     int                    `json:"degeneracy"` // distinct primers in Degenerate; stops growing past 2^31
+	TargetPercent  float64                `json:"target_percent"`
+	Covered        int                    `json:"covered"`
+	PercentCovered float64                `json:"percent_covered"`
+	Positions      []ColumnConservationV1 `json:"positions"`
+}
+
+// ColumnConservationV1 is the alignment column under one primer position
+// (5'→3', 0-based). Base counts are in primer orientation; Other counts
+// ambiguous residues. Entropy is the Shannon entropy (bits, 0–2) of the
+// A/C/G/T counts; Consensus is the most common of them, or "-" when gaps
+// outnumber it.
+type ColumnConservationV1 struct {
+	Pos        int     `json:"pos"`
+	Base       string  `json:"base"`
+	Column     int     `json:"column"` // 0-based alignment column
+	A          int     `json:"a"`
+	C          int     `json:"c"`
+	G          int     `json:"g"`
+	T          int     `json:"t"`
+	Gap        int     `json:"gap"`
+	Other      int     `json:"other"`
+	Entropy    float64 `json:"entropy"`
+	Consensus  string  `json:"consensus"`
+	Degenerate string  `json:"degenerate"`
+}
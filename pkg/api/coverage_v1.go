@@ -0,0 +1,42 @@
+// pkg/api/coverage_v1.go
+package api
+
+// CoverageV1 is one pair of the population coverage report (--coverage):
+// how many of the scanned sequences it amplifies and how its primers match
+// the sites it amplifies from. Each amplified sequence contributes the
+// sites of its best product (fewest mismatches).
+type CoverageV1 struct {
+	ExperimentID     string           `json:"experiment_id"`
+	Sequences        int              `json:"sequences"` // sequences scanned
+	Amplified        int              `json:"amplified"` // sequences with at least one product
+	PercentAmplified float64          `json:"percent_amplified"`
+	Forward          PrimerCoverageV1 `json:"forward"`
+	Reverse          PrimerCoverageV1 `json:"reverse"`
+}
+
+// PrimerCoverageV1 summarizes the binding sites of one primer.
+type PrimerCoverageV1 struct {
+	Primer    string               `json:"primer"`
+	Sites     int                  `json:"sites"`
+	Positions []PositionCoverageV1 `json:"positions"`
+	Variants  []SiteVariantV1      `json:"variants"` // most common site sequences first
+}
+
+// PositionCoverageV1 is the mismatch tally at one primer position (5'→3',
+// 0-based). Substitutions counts the site bases (primer orientation) found
+// opposite mismatches; gapped sites count toward Mismatches only.
+type PositionCoverageV1 struct {
+	Pos           int            `json:"pos"`
+	Base          string         `json:"base"`
+	Mismatches    int            `json:"mismatches"`
+	MismatchFreq  float64        `json:"mismatch_freq"`
+	Substitutions map[string]int `json:"substitutions,omitempty"`
+}
+
+// SiteVariantV1 is one distinct primer-site sequence (primer orientation).
+type SiteVariantV1 struct {
+	Site       string  `json:"site"`
+	Count      int     `json:"count"`
+	Fraction   float64 `json:"fraction"`
+	Mismatches int     `json:"mismatches"`
+}
@@ -0,0 +1,29 @@
+// pkg/api/design_v1.go
+package api
+
+// DesignOligoV1 is one designed primer. Start/End are its 0-based, half-open
+// binding site on the plus strand of the template record.
+type DesignOligoV1 struct {
+	Seq         string  `json:"seq"`
+	Start       int     `json:"start"`
+	End         int     `json:"end"`
+	TmC         float64 `json:"tm_c"`
+	GC          float64 `json:"gc"`
+	HairpinDG   float64 `json:"hairpin_dg"`
+	SelfDimerDG float64 `json:"self_dimer_dg"`
+}
+
+// DesignPairV1 is one ranked pair emitted by ipcr-design --output json.
+// ExperimentID matches the id column of the TSV output.
+type DesignPairV1 struct {
+	ExperimentID string        `json:"experiment_id"`
+	SequenceID   string        `json:"sequence_id"`
+	Rank         int           `json:"rank"`
+	Forward      DesignOligoV1 `json:"forward"`
+	Reverse      DesignOligoV1 `json:"reverse"`
+	ProductLen   int           `json:"product_length"`
+	CrossDimerDG float64       `json:"cross_dimer_dg"`
+	Penalty      float64       `json:"penalty"`
+	MinProduct   int           `json:"min_product"`
+	MaxProduct   int           `json:"max_product"`
+}
@@ -0,0 +1,66 @@
+// pkg/api/diff_v1.go
+package api
+
+// Diff changes reported by ipcr diff.
+const (
+	DiffGained  = "gained"  // only in the second result set
+	DiffLost    = "lost"    // only in the first
+	DiffChanged = "changed" // in both, with different mismatches, score or inner product
+)
+
+// ProductDiffV1 is one product that differs between two result sets. A and
+// B describe it in the first and second set; a gained product has no A and
+// a lost one no B. Changes names what differs in a changed product:
+// "mismatches", "score", "inner", "round_scores" (a nested product's
+// outer_score or inner_score) or "inner_products", which Inner details.
+type ProductDiffV1 struct {
+	Change          string      `json:"change"`
+	Changes         []string    `json:"changes,omitempty"`
+	ExperimentID    string      `json:"experiment_id"`
+	SequenceID      string      `json:"sequence_id"`
+	Start           int         `json:"start"`
+	End             int         `json:"end"`
+	Type            string      `json:"type"`
+	SourceFile      string      `json:"source_file,omitempty"`
+	BisulfiteStrand string      `json:"bisulfite_strand,omitempty"`
+	Methylation     string      `json:"methylation,omitempty"`
+	A               *DiffSideV1 `json:"a,omitempty"`
+	B               *DiffSideV1 `json:"b,omitempty"`
+	ScoreDelta      *float64    `json:"score_delta,omitempty"` // B − A when both are scored
+
+	Inner []InnerDiffV1 `json:"inner_products,omitempty"` // nested results only
+}
+
+// InnerDiffV1 is one inner-round product of a nested result that is gained,
+// lost or changed (mismatches or score) inside its outer product. Start/End
+// are relative to the enclosing product, which Parent names
+// ("experiment_id:start-end") for rounds below the first inner one.
+type InnerDiffV1 struct {
+	Change       string      `json:"change"`
+	Parent       string      `json:"parent,omitempty"`
+	Round        int         `json:"round"`
+	ExperimentID string      `json:"experiment_id"`
+	Start        int         `json:"start"`
+	End          int         `json:"end"`
+	Type         string      `json:"type"`
+	A            *DiffSideV1 `json:"a,omitempty"`
+	B            *DiffSideV1 `json:"b,omitempty"`
+}
+
+// DiffSideV1 is a product as one result set reports it.
+type DiffSideV1 struct {
+	FwdMM      int      `json:"fwd_mm"`
+	RevMM      int      `json:"rev_mm"`
+	Score      *float64 `json:"score,omitempty"`
+	InnerFound *bool    `json:"inner_found,omitempty"` // nested results only
+}
+
+// DiffSummaryV1 counts the products of two result sets by outcome.
+type DiffSummaryV1 struct {
+	A         int `json:"a"`
+	B         int `json:"b"`
+	Gained    int `json:"gained"`
+	Lost      int `json:"lost"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+}
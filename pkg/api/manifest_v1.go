@@ -0,0 +1,68 @@
+// pkg/api/manifest_v1.go
+package api
+
+// ManifestSchemaV1 labels a ManifestV1 document.
+const ManifestSchemaV1 = "ipcr-manifest-v1"
+
+// ManifestV1 is the provenance sidecar written by --manifest: the resolved
+// options, inputs and versions behind one run, and what it produced.
+// --replay reruns it from Options.
+type ManifestV1 struct {
+	Schema        string         `json:"schema"`
+	Tool          string         `json:"tool"`
+	Version       string         `json:"version"`
+	EngineVersion string         `json:"engine_version"`
+	ThermoVersion string         `json:"thermo_version"`
+	OutputSchema  string         `json:"output_schema"`
+	Args          []string       `json:"args"`          // command line as given
+	Cwd           string         `json:"cwd,omitempty"` // working directory; relative input paths are resolved against it
+	Options       map[string]any `json:"options"`       // every option by flag name, defaults included
+	Thermo        *ThermoRunV1   `json:"thermo,omitempty"`
+	Inputs        []InputFileV1  `json:"inputs"`
+	Pairs         []PairCountV1  `json:"pairs"`
+	Products      int            `json:"products"`
+	ExitCode      int            `json:"exit_code"`
+	Started       string         `json:"started"` // RFC 3339
+	Finished      string         `json:"finished"`
+	WallSeconds   float64        `json:"wall_seconds"`
+}
+
+// InputFileV1 is one file read by the run. SHA256 covers the file as stored
+// (compressed or not); Records counts FASTA or index records.
+type InputFileV1 struct {
+	Role    string `json:"role"` // sequences|index|primers|oligos|regions|exclude_regions|vcf|assembly_map
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256,omitempty"` // empty for standard input
+	Bytes   int64  `json:"bytes"`
+	Records int    `json:"records,omitempty"`
+}
+
+// PairCountV1 is one scanned primer pair and the products reported for it.
+type PairCountV1 struct {
+	ID       string `json:"id"`
+	Forward  string `json:"forward"`
+	Reverse  string `json:"reverse"`
+	Products int    `json:"products"`
+}
+
+// ThermoRunV1 records the thermodynamic setup of an ipcr-thermo run: the
+// effective model and solution conditions, and the mismatch parameter sets
+// and citations behind the reported scores.
+type ThermoRunV1 struct {
+	Model         string       `json:"model"`
+	Conditions    ConditionsV1 `json:"conditions"`
+	ParameterSets []string     `json:"parameter_sets,omitempty"`
+	Citations     []string     `json:"citations,omitempty"`
+}
+
+// ConditionsV1 are the solution conditions in mol/L after defaults for
+// unparsable values.
+type ConditionsV1 struct {
+	AnnealTempC  float64 `json:"anneal_temp_c"`
+	NaM          float64 `json:"na_m"`
+	MgM          float64 `json:"mg_m"`
+	DntpM        float64 `json:"dntp_m"`
+	PrimerConcM  float64 `json:"primer_conc_m"`
+	SaltModel    string  `json:"salt_model"`
+	EffectiveNaM float64 `json:"effective_na_m"`
+}
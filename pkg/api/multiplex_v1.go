@@ -0,0 +1,83 @@
+// pkg/api/multiplex_v1.go
+package api
+
+// ProbeHitV1 is one panel probe found inside a multiplex amplicon.
+type ProbeHitV1 struct {
+	Name    string `json:"name"`
+	Assay   string `json:"assay"` // panel row the probe belongs to
+	Channel string `json:"channel,omitempty"`
+	Seq     string `json:"seq"`
+	Strand  string `json:"strand"` // "+"/"-"
+	Pos     int    `json:"pos"`
+	MM      int    `json:"mm,omitempty"`
+	Site    string `json:"site,omitempty"`
+	Cross   bool   `json:"cross_reactive,omitempty"` // probe of another assay
+}
+
+// ProbedProductV1 is the multiplex amplicon schema when panel rows carry
+// probes: the plain product plus every probe that binds it.
+type ProbedProductV1 struct {
+	ProductV1
+	Probes []ProbeHitV1 `json:"probes"`
+}
+
+// ChannelCollisionV1 reports one detection channel lit by products of more
+// than one assay on the same template.
+type ChannelCollisionV1 struct {
+	SourceFile string   `json:"source_file,omitempty"`
+	SequenceID string   `json:"sequence_id"`
+	Channel    string   `json:"channel"`
+	Assays     []string `json:"assays"`
+	Probes     []string `json:"probes"`
+}
+
+// GelBandV1 is one predicted product in a virtual gel lane.
+type GelBandV1 struct {
+	ExperimentID string `json:"experiment_id"`
+	Start        int    `json:"start"`
+	End          int    `json:"end"`
+	Length       int    `json:"length"`
+	Type         string `json:"type"`
+	// Bands of the same lane closer than the resolution ("id:length").
+	Unresolved []string `json:"unresolved_with,omitempty"`
+}
+
+// GelLaneV1 lists every product predicted on one template, longest first.
+type GelLaneV1 struct {
+	SourceFile string      `json:"source_file,omitempty"`
+	SequenceID string      `json:"sequence_id"`
+	Resolved   bool        `json:"resolved"` // no two bands closer than the resolution
+	Bands      []GelBandV1 `json:"bands"`
+}
+
+// AssayInteractionV1 is why two assays should not share a reaction pool.
+type AssayInteractionV1 struct {
+	AssayA        string  `json:"assay_a"`
+	AssayB        string  `json:"assay_b"`
+	CrossProducts int     `json:"cross_products"` // products primed by oligos of both assays
+	DimerBurden   float64 `json:"dimer_burden"`
+	Unresolved    bool    `json:"unresolved,omitempty"` // product sizes too close on a shared template
+	SamePool      bool    `json:"same_pool"`
+}
+
+// PartitionPoolV1 is one reaction pool proposed by ipcr-multiplex --partition.
+type PartitionPoolV1 struct {
+	Pool          int      `json:"pool"` // 1-based
+	File          string   `json:"file"`
+	Assays        []string `json:"assays"`
+	CrossProducts int      `json:"cross_products"`
+	DimerBurden   float64  `json:"dimer_burden"`
+	Unresolved    int      `json:"unresolved"` // assay pairs with clashing sizes
+}
+
+// PartitionV1 is the ipcr-multiplex --partition report: the pools, the
+// interactions left inside them against a single pool, and every assay pair
+// that interacts at all.
+type PartitionV1 struct {
+	Pools              []PartitionPoolV1    `json:"pools"`
+	SinglePoolProducts int                  `json:"single_pool_cross_products"`
+	SplitProducts      int                  `json:"split_cross_products"`
+	SinglePoolBurden   float64              `json:"single_pool_dimer_burden"`
+	SplitBurden        float64              `json:"split_dimer_burden"`
+	Interactions       []AssayInteractionV1 `json:"interactions"`
+}
@@ -14,6 +14,11 @@ type NestedProductV1 struct {
 	Seq            string `json:"seq,omitempty"`
 	SourceFile     string `json:"source_file,omitempty"`
 
+	FwdIndels []IndelV1 `json:"fwd_indels,omitempty"`
+	RevIndels []IndelV1 `json:"rev_indels,omitempty"`
+	FwdMasked int       `json:"fwd_masked,omitempty"`
+	RevMasked int       `json:"rev_masked,omitempty"`
+
 	// NEW: outer product score when available
 	Score float64 `json:"score,omitempty"`
 
@@ -25,4 +30,29 @@ type NestedProductV1 struct {
 	InnerType   string `json:"inner_type,omitempty"`
 	InnerFwdMM  int    `json:"inner_fwd_mm,omitempty"`
 	InnerRevMM  int    `json:"inner_rev_mm,omitempty"`
+
+	// Every inner product, best first (all-inner, semi-nested or N-round runs).
+	InnerProducts []NestedInnerV1 `json:"inner_products,omitempty"`
+
+	// Per-round thermo scores (higher is better) when both rounds were
+	// scored; nested_score is the weaker round. Absent without an inner product.
+	OuterScore  *float64 `json:"outer_score,omitempty"`
+	InnerScore  *float64 `json:"inner_score,omitempty"`
+	NestedScore *float64 `json:"nested_score,omitempty"`
+}
+
+// NestedInnerV1 is one inner-round product; Start/End are relative to the
+// outer amplicon. Inner lists the next round's products inside it.
+type NestedInnerV1 struct {
+	Round        int             `json:"round"`
+	ExperimentID string          `json:"experiment_id"`
+	Start        int             `json:"start"`
+	End          int             `json:"end"`
+	Length       int             `json:"length"`
+	Type         string          `json:"type"`
+	FwdMM        int             `json:"fwd_mm,omitempty"`
+	RevMM        int             `json:"rev_mm,omitempty"`
+	Reused       string          `json:"reused_primer,omitempty"`
+	Score        *float64        `json:"score,omitempty"`
+	Inner        []NestedInnerV1 `json:"inner_products,omitempty"`
 }
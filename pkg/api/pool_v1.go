@@ -0,0 +1,42 @@
+// pkg/api/pool_v1.go
+package api
+
+// PoolOligoV1 is one row/column of the ipcr-pool dimer matrix.
+type PoolOligoV1 struct {
+	ID    string `json:"id"`
+	Assay string `json:"assay"`
+	Role  string `json:"role"` // "fwd" | "rev" | "probe"
+	Seq   string `json:"seq"`
+}
+
+// PoolDimerV1 is one dimer at or below the burden threshold.
+type PoolDimerV1 struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"` // equal to A for self-dimers
+	DeltaG     float64 `json:"dg_kcal"`
+	TmC        float64 `json:"tm_c"`
+	ThreePrime bool    `json:"three_prime"`
+	Burden     float64 `json:"burden"`
+}
+
+// PoolV1 is one suggested reaction pool.
+type PoolV1 struct {
+	Pool   int      `json:"pool"` // 1-based
+	Assays []string `json:"assays"`
+	Burden float64  `json:"burden"`
+}
+
+// PoolAnalysisV1 is the ipcr-pool --output json document. Matrix cells are
+// null where no dimer stem was found; rows and columns follow Oligos.
+type PoolAnalysisV1 struct {
+	TempC            float64       `json:"temp_c"`
+	ThresholdKcal    float64       `json:"threshold_kcal"`
+	Oligos           []PoolOligoV1 `json:"oligos"`
+	DeltaG           [][]*float64  `json:"dg_kcal"`
+	TmC              [][]*float64  `json:"tm_c"`
+	ThreePrime       [][]bool      `json:"three_prime"`
+	Dimers           []PoolDimerV1 `json:"dimers"`
+	Pools            []PoolV1      `json:"pools"`
+	SinglePoolBurden float64       `json:"single_pool_burden"`
+	SplitBurden      float64       `json:"split_burden"`
+}
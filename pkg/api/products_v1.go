@@ -17,6 +17,28 @@ type ProductV1 struct {
 	Seq            string `json:"seq,omitempty"`
 	SourceFile     string `json:"source_file,omitempty"`
 
+	// Gap positions for indel-tolerant matches (--max-indels); omitted otherwise.
+	FwdIndels []IndelV1 `json:"fwd_indels,omitempty"`
+	RevIndels []IndelV1 `json:"rev_indels,omitempty"`
+
+	// Soft-masked (lowercase) reference bases under each primer site; only
+	// reported with --mask-policy report.
+	FwdMasked int `json:"fwd_masked,omitempty"`
+	RevMasked int `json:"rev_masked,omitempty"`
+
+	// Bisulfite-converted strand ("top" or "bottom") and the methylation
+	// state the product implies ("methylated", "unmethylated" or "any");
+	// only reported with --bisulfite.
+	BisulfiteStrand string `json:"bisulfite_strand,omitempty"`
+	Methylation     string `json:"methylation,omitempty"`
+
+	// Known variants (--vcf) under the primer sites.
+	Variants []VariantHitV1 `json:"variants,omitempty"`
+
+	// The alternate allele (its VCF ID, else "pos:ref>alt") a product needs:
+	// it forms with the allele applied but not on the reference (--vcf).
+	AltAllele string `json:"alt_allele,omitempty"`
+
 	// NEW: optional score, used by ipcr-thermo; omitted otherwise
 	Score float64 `json:"score,omitempty"`
 
@@ -24,6 +46,30 @@ type ProductV1 struct {
 	Thermo *ThermoDetailsV1 `json:"thermo,omitempty"`
 }
 
+// IndelV1 is one primer-site gap. Pos is the 0-based primer index (5'→3').
+// Op is "ins" when the template carries an extra base before Pos and "del"
+// when primer base Pos has no template partner.
+type IndelV1 struct {
+	Pos int    `json:"pos"`
+	Op  string `json:"op"`
+}
+
+// VariantHitV1 is a VCF allele under a primer site. Site is "fwd" or "rev"
+// as for fwd_mm/rev_mm; PrimerPos is the primer index (5'→3') of the
+// 3'-most affected base. Effect is "mismatch" (the alt allele breaks
+// pairing), "match" (it repairs a reference mismatch), "neutral" or "indel".
+type VariantHitV1 struct {
+	ID         string   `json:"id,omitempty"`
+	Pos        int      `json:"pos"` // 0-based
+	Ref        string   `json:"ref"`
+	Alt        string   `json:"alt"`
+	AF         *float64 `json:"af,omitempty"`
+	Site       string   `json:"site"`
+	PrimerPos  int      `json:"primer_pos"`
+	ThreePrime bool     `json:"three_prime"`
+	Effect     string   `json:"effect"`
+}
+
 // ThermoDetailsV1 is an optional extension object for ipcr-thermo NN modes.
 type ThermoDetailsV1 struct {
 	Model                   string                 `json:"model"`
@@ -215,6 +261,11 @@ type AnnotatedProductV1 struct {
 	Seq            string `json:"seq,omitempty"`
 	SourceFile     string `json:"source_file,omitempty"`
 
+	FwdIndels []IndelV1 `json:"fwd_indels,omitempty"`
+	RevIndels []IndelV1 `json:"rev_indels,omitempty"`
+	FwdMasked int       `json:"fwd_masked,omitempty"`
+	RevMasked int       `json:"rev_masked,omitempty"`
+
 	// NEW: surface base score when present
 	Score float64 `json:"score,omitempty"`
 
@@ -0,0 +1,14 @@
+// pkg/api/rt_v1.go
+package api
+
+// RTProductV1 is an ipcr-rt amplicon: the plain product plus the template it
+// came from. cDNA products are in transcript coordinates.
+type RTProductV1 struct {
+	ProductV1
+	Template        string   `json:"template"` // "cdna" or "gdna"
+	Gene            string   `json:"gene,omitempty"`
+	Junctions       int      `json:"junctions,omitempty"`        // exon-exon junctions inside the product
+	JunctionPrimers []string `json:"junction_primers,omitempty"` // "forward"/"reverse" primers spanning a junction
+	GenomicLength   int      `json:"genomic_length,omitempty"`   // genomic span of a cDNA product, introns included
+	CDNASpecific    bool     `json:"cdna_specific"`
+}
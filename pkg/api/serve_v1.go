@@ -0,0 +1,58 @@
+// pkg/api/serve_v1.go
+package api
+
+// JobRequestV1 submits a scan to `ipcr serve` (POST /v1/jobs). Primers come
+// from Panel (primer TSV text, as for --primers) or Forward/Reverse. Fields
+// left out of the JSON keep the CLI defaults.
+type JobRequestV1 struct {
+	Reference string `json:"reference"` // name given to --ref
+	Panel     string `json:"panel,omitempty"`
+	Forward   string `json:"forward,omitempty"`
+	Reverse   string `json:"reverse,omitempty"`
+
+	Mismatches     int    `json:"mismatches"`
+	MaxIndels      int    `json:"max_indels"`
+	MinLength      int    `json:"min_length"`
+	MaxLength      int    `json:"max_length"`
+	HitCap         int    `json:"hit_cap"`
+	TerminalWindow int    `json:"terminal_window"`
+	Self           bool   `json:"self"`
+	SeedLength     int    `json:"seed_length"`
+	Circular       bool   `json:"circular"`
+	MaskPolicy     string `json:"mask_policy"`
+	Bisulfite      bool   `json:"bisulfite"`
+	Products       bool   `json:"products"` // keep amplicon sequences in results
+}
+
+// Job states.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobDone      = "done"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// JobV1 is the status of a submitted job (GET /v1/jobs/{id}). Times are
+// RFC 3339.
+type JobV1 struct {
+	ID        string   `json:"id"`
+	Reference string   `json:"reference"`
+	State     string   `json:"state"`
+	Products  int      `json:"products"` // results so far
+	Error     string   `json:"error,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+	Submitted string   `json:"submitted"`
+	Started   string   `json:"started,omitempty"`
+	Finished  string   `json:"finished,omitempty"`
+}
+
+// ReferenceV1 describes a reference collection loaded by `ipcr serve`
+// (GET /v1/references).
+type ReferenceV1 struct {
+	Name    string   `json:"name"`
+	Files   []string `json:"files"`
+	Records int      `json:"records"`
+	Bases   int      `json:"bases"`
+	Indexed bool     `json:"indexed"` // loaded from an `ipcr index build` file
+}
@@ -0,0 +1,23 @@
+// pkg/api/sites_v1.go
+package api
+
+// BindingSiteV1 is one single-primer binding site reported by `ipcr sites`,
+// independent of whether a partner primer binds within amplicon range.
+// Mismatch and gap positions are 0-based primer indices (5'→3').
+type BindingSiteV1 struct {
+	ExperimentID   string    `json:"experiment_id"`
+	SequenceID     string    `json:"sequence_id"`
+	Primer         string    `json:"primer"` // "forward" | "reverse"
+	PrimerSeq      string    `json:"primer_seq"`
+	Strand         string    `json:"strand"` // "+" primer matches the record as written, "-" its reverse complement does
+	Start          int       `json:"start"`
+	End            int       `json:"end"`
+	Mismatches     int       `json:"mismatches"`
+	MismatchIdx    []int     `json:"mm_i,omitempty"`
+	Indels         []IndelV1 `json:"indels,omitempty"`
+	ThreePrimeMM   int       `json:"three_prime_mm"`   // mismatches/gaps within the 3' window
+	ThreePrimeDist int       `json:"three_prime_dist"` // distance of the closest mismatch/gap to the 3' end; -1 if none
+	Site           string    `json:"site"`             // template site in primer 5'→3' orientation
+	Masked         int       `json:"masked,omitempty"` // soft-masked bases in the site (--mask-policy report)
+	SourceFile     string    `json:"source_file,omitempty"`
+}
@@ -0,0 +1,13 @@
+// pkg/api/summary_v1.go
+package api
+
+// GenomeSummaryV1 is one row of the per-genome × per-pair summary report
+// (--summary). Every genome/pair combination is reported, including misses.
+type GenomeSummaryV1 struct {
+	Genome        string `json:"genome"`
+	ExperimentID  string `json:"experiment_id"`
+	Hit           bool   `json:"hit"`
+	Products      int    `json:"products"`
+	Lengths       []int  `json:"lengths"`                  // distinct product lengths, ascending
+	MinMismatches *int   `json:"min_mismatches,omitempty"` // min fwd_mm+rev_mm over products; omitted on no-hit
+}
@@ -0,0 +1,18 @@
+// pkg/api/validation_v1.go
+package api
+
+// ValidationV1 is the per-assay inclusivity/exclusivity report emitted by
+// ipcr-validate. Sensitivity/Specificity are omitted when their denominator
+// is zero (no target or no non-target genomes).
+type ValidationV1 struct {
+	ExperimentID   string   `json:"experiment_id"`
+	Assay          string   `json:"assay"` // "plain" | "probe" | "nested"
+	Targets        int      `json:"targets"`
+	TruePositives  int      `json:"true_positives"`
+	NonTargets     int      `json:"non_targets"`
+	FalsePositives int      `json:"false_positives"`
+	Sensitivity    *float64 `json:"sensitivity,omitempty"`
+	Specificity    *float64 `json:"specificity,omitempty"`
+	MissedTargets  []string `json:"missed_targets"`
+	OffTargetHits  []string `json:"off_target_hits"`
+}
@@ -0,0 +1,232 @@
+// pkg/ipcr/ipcr.go
+
+// Package ipcr runs in-silico PCR in-process. It is the library form of the
+// ipcr command line: Options mirror the shared CLI flags, Run streams the
+// products a scan finds, and Scan runs the same scan through a Visitor
+// (probe, nested or thermodynamic annotation) of the caller's choice.
+//
+// Results use the stable wire structs of package api, so a service can hand
+// them on as JSON unchanged.
+//
+// Cancellation follows the CLIs: when ctx is cancelled the scan stops, the
+// products already yielded stay valid, and iteration ends with an error for
+// which errors.Is(err, context.Canceled) holds (exit code 130 on the command
+// line).
+package ipcr
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"ipcr-core/primer"
+	"ipcr/internal/appcore"
+	"ipcr/internal/clibase"
+	"ipcr/internal/common"
+	"ipcr/internal/output"
+	"ipcr/internal/runutil"
+	"ipcr/pkg/api"
+)
+
+// Options are the scan settings shared by the ipcr tools. Each field is
+// named after, and validated like, the flag in its comment. Start from
+// DefaultOptions: the zero value turns off the product length cap, the hit
+// cap and the 3' terminal window.
+type Options struct {
+	// Primers: a panel file, or one inline pair. Request.Pairs replaces both.
+	PrimerFile string // --primers
+	Forward    string // --forward
+	Reverse    string // --reverse
+
+	// Templates: FASTA files, or a prebuilt index.
+	SeqFiles       []string // --sequences (and positionals)
+	Index          string   // --index
+	Regions        string   // --regions (BED)
+	ExcludeRegions string   // --exclude-regions (BED)
+	MaskPolicy     string   // --mask-policy: ignore | no-primer-in-mask | report
+	Bisulfite      bool     // --bisulfite
+
+	// PCR
+	Mismatches     int  // --mismatches
+	MaxIndels      int  // --max-indels
+	MinLen         int  // --min-length
+	MaxLen         int  // --max-length (0 = unbounded)
+	HitCap         int  // --hit-cap (0 = unlimited)
+	TerminalWindow int  // --terminal-window (<1 disables)
+	Self           bool // --self
+
+	// Performance
+	Threads    int  // --threads (0 = all CPUs)
+	ChunkSize  int  // --chunk-size (0 = no chunking)
+	SeedLength int  // --seed-length (0 = auto)
+	Circular   bool // --circular
+	DedupeCap  int  // --dedupe-cap
+
+	// Products keeps amplicon sequences in results (--products).
+	Products bool
+}
+
+// DefaultOptions returns the CLI defaults.
+func DefaultOptions() Options {
+	var c clibase.Common
+	clibase.Register(flag.NewFlagSet("ipcr", flag.ContinueOnError), &c)
+	return Options{
+		PrimerFile: c.PrimerFile, Forward: c.Fwd, Reverse: c.Rev,
+		SeqFiles: c.SeqFiles, Index: c.Index, Regions: c.Regions, ExcludeRegions: c.ExcludeRegions,
+		MaskPolicy: c.MaskPolicy, Bisulfite: c.Bisulfite,
+		Mismatches: c.Mismatches, MaxIndels: c.MaxIndels, MinLen: c.MinLen, MaxLen: c.MaxLen,
+		HitCap: c.HitCap, TerminalWindow: c.TerminalWindow, Self: c.Self,
+		Threads: c.Threads, ChunkSize: c.ChunkSize, SeedLength: c.SeedLength,
+		Circular: c.Circular, DedupeCap: c.DedupeCap,
+		Products: c.Products,
+	}
+}
+
+// common is o as parsed CLI flags, for validation.
+func (o Options) common() clibase.Common {
+	return clibase.Common{
+		PrimerFile: o.PrimerFile, Fwd: o.Forward, Rev: o.Reverse,
+		SeqFiles: o.SeqFiles, Index: o.Index, Regions: o.Regions, ExcludeRegions: o.ExcludeRegions,
+		MaskPolicy: o.MaskPolicy, Bisulfite: o.Bisulfite,
+		Mismatches: o.Mismatches, MaxIndels: o.MaxIndels, MinLen: o.MinLen, MaxLen: o.MaxLen,
+		HitCap: o.HitCap, TerminalWindow: o.TerminalWindow, Self: o.Self,
+		Threads: o.Threads, ChunkSize: o.ChunkSize, SeedLength: o.SeedLength,
+		Circular: o.Circular, DedupeCap: o.DedupeCap,
+		Output: output.FormatJSON, Products: o.Products,
+	}
+}
+
+// Request is one scan.
+type Request struct {
+	Options
+
+	// Pairs, when set, is the primer panel; Options.PrimerFile, Forward and
+	// Reverse must then be empty.
+	Pairs []primer.Pair
+
+	// Warn receives the notes the CLIs print to stderr (e.g. chunking turned
+	// off). nil discards them.
+	Warn func(string)
+}
+
+// Seq is a sequence of scan results. It has the shape of iter.Seq2[T, error],
+// so on Go 1.23 and later it can be ranged over directly:
+//
+//	for p, err := range seq { ... }
+type Seq[T any] func(yield func(T, error) bool)
+
+// Run scans req and yields every product found; see Scan.
+func Run(ctx context.Context, req Request) (Seq[api.ProductV1], error) {
+	return Scan[api.ProductV1](ctx, req, Plain{})
+}
+
+// Scan validates req, loads its primers and prepares the scan; problems the
+// CLIs report with exit code 2 are returned here. Ranging over the sequence
+// runs the scan and yields what v keeps, in pipeline order. A failure
+// during the scan, or ctx being cancelled, ends the sequence with one
+// (zero, err) element. Breaking out of the loop stops the scan. Each range
+// runs the scan again.
+func Scan[T any](ctx context.Context, req Request, v Visitor[T]) (Seq[T], error) {
+	o := req.Options
+	c := o.common()
+	panel := req.Pairs
+	if len(panel) > 0 {
+		if o.PrimerFile != "" || o.Forward != "" || o.Reverse != "" {
+			return nil, errors.New("primers given twice: Request.Pairs and --primers/--forward/--reverse")
+		}
+		if err := clibase.ValidateScan(&c); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := clibase.Validate(&c); err != nil {
+			return nil, err
+		}
+		if c.PrimerFile != "" {
+			var err error
+			if panel, err = primer.LoadTSV(c.PrimerFile); err != nil {
+				return nil, err
+			}
+		} else {
+			panel = []primer.Pair{{ID: "manual", Forward: c.Fwd, Reverse: c.Rev, MinProduct: o.MinLen, MaxProduct: o.MaxLen}}
+		}
+	}
+	if err := clibase.ValidateBisulfite(&c); err != nil {
+		return nil, err
+	}
+	o.MaskPolicy = c.MaskPolicy
+
+	visit, err := v.Bind(o, panel)
+	if err != nil {
+		return nil, err
+	}
+	pairs := panel
+	if o.Self {
+		pairs = common.AddSelfPairs(pairs)
+	}
+	warn := req.Warn
+	if warn == nil {
+		warn = func(string) {}
+	}
+	scan, err := appcore.NewScan(o.core(), pairs, false, o.Products || v.NeedSeq(), warn)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(yield func(T, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ch := make(chan T)
+		done := make(chan error, 1)
+		go func() {
+			_, err := appcore.Stream(ctx, scan, appcore.VisitorFunc[T](visit), func(x T) error {
+				select {
+				case ch <- x:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+			close(ch)
+			done <- err
+		}()
+		for x := range ch {
+			if !yield(x, nil) {
+				cancel()
+				for range ch {
+				}
+				<-done
+				return
+			}
+		}
+		if err := <-done; err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}, nil
+}
+
+// core is o as harness options.
+func (o Options) core() appcore.Options {
+	return appcore.Options{
+		SeqFiles: o.SeqFiles, Index: o.Index, Regions: o.Regions, ExcludeRegions: o.ExcludeRegions,
+		MaskPolicy: o.MaskPolicy, Bisulfite: o.Bisulfite, MaxMM: o.Mismatches, MaxIndels: o.MaxIndels,
+		TerminalWindow: runutil.EffectiveTerminalWindow(o.TerminalWindow),
+		MinLen:         o.MinLen, MaxLen: o.MaxLen, HitCap: o.HitCap, SeedLength: o.SeedLength,
+		Circular: o.Circular, Threads: o.Threads, ChunkSize: o.ChunkSize, DedupeCap: o.DedupeCap,
+		Quiet: true,
+	}
+}
+
+// Collect drains seq into a slice, stopping at the first error.
+func Collect[T any](seq Seq[T]) ([]T, error) {
+	var out []T
+	var err error
+	seq(func(x T, e error) bool {
+		if e != nil {
+			err = e
+			return false
+		}
+		out = append(out, x)
+		return true
+	})
+	return out, err
+}
@@ -0,0 +1,113 @@
+package ipcr
+
+import (
+	"context"
+	"errors"
+	"ipcr-core/primer"
+	"ipcr-core/seqtest"
+	"ipcr/pkg/api"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunAndVisitors(t *testing.T) {
+	dir := t.TempDir()
+	randSeq := seqtest.Random(21)
+	rc := func(s string) string { return string(primer.RevComp([]byte(s))) }
+	const fwd, rev = "AGAGTTTGATCCTGGCTCAG", "GGTTACCTTGTTACGACTTC"
+	const inF, inR, probe = "GCTAACGCATTAAGTACTCC", "CGTGCTTGTAGGCATTCAGC", "TTCGGATCCAGTACAAGGCA"
+	amp := fwd + randSeq(30) + inF + randSeq(20) + probe + randSeq(20) + rc(inR) + randSeq(30) + rc(rev)
+	fa := filepath.Join(dir, "ref.fa")
+	if err := os.WriteFile(fa, []byte(">a\n"+randSeq(50)+amp+randSeq(50)+"\n>b\n"+randSeq(80)+amp+randSeq(10)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := DefaultOptions()
+	o.Forward, o.Reverse, o.SeqFiles, o.Self = fwd, rev, []string{fa}, false
+	req := Request{Options: o}
+
+	seq, err := Run(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Collect(seq)
+	if err != nil || len(got) != 2 {
+		t.Fatalf("Run: %d products (%v)", len(got), err)
+	}
+	for _, p := range got {
+		if p.ExperimentID != "manual" || p.Length != len(amp) || p.Seq != "" {
+			t.Fatalf("product %+v", p)
+		}
+	}
+	// Each range runs the scan again; breaking out stops it.
+	n := 0
+	seq(func(api.ProductV1, error) bool { n++; return false })
+	if n != 1 {
+		t.Fatalf("break after first product: %d yields", n)
+	}
+
+	withSeq := req
+	withSeq.Products = true
+	if got, err := collect(Run(context.Background(), withSeq)); err != nil || len(got) == 0 || got[0].Seq != amp {
+		t.Fatalf("--products: %+v (%v)", got, err)
+	}
+
+	ann, err := collect(Scan(context.Background(), req, Probe{Seq: probe, Require: true}))
+	if err != nil || len(ann) != 2 || !ann[0].ProbeFound || ann[0].ProbeName != "probe" {
+		t.Fatalf("probe: %+v (%v)", ann, err)
+	}
+	nested, err := collect(Scan(context.Background(), req, Nested{
+		Inner: []primer.Pair{{ID: "inner", Forward: inF, Reverse: inR}}, RequireInner: true,
+	}))
+	if err != nil || len(nested) != 2 || !nested[0].InnerFound {
+		t.Fatalf("nested: %+v (%v)", nested, err)
+	}
+	scored, err := collect(Scan(context.Background(), req, DefaultThermo()))
+	if err != nil || len(scored) != 2 || scored[0].Score == 0 {
+		t.Fatalf("thermo: %+v (%v)", scored, err)
+	}
+
+	// Panel pairs replace the primer flags.
+	panel := Request{Options: DefaultOptions(), Pairs: []primer.Pair{{ID: "p1", Forward: fwd, Reverse: rev}}}
+	panel.SeqFiles, panel.Self = []string{fa}, false
+	if got, err := collect(Run(context.Background(), panel)); err != nil || len(got) != 2 || got[0].ExperimentID != "p1" {
+		t.Fatalf("panel: %+v (%v)", got, err)
+	}
+
+	// A cancelled context ends the sequence with context.Canceled, as the
+	// CLIs exit 130.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	seq, err = Run(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Collect(seq); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled scan: %v", err)
+	}
+
+	// CLI validation errors come back before any scanning.
+	for _, bad := range []Request{
+		{Options: Options{Forward: fwd, Reverse: rev}},
+		{Options: Options{Forward: fwd, SeqFiles: []string{fa}}},
+		{Options: Options{Forward: fwd, Reverse: rev, SeqFiles: []string{fa}, MaskPolicy: "hide"}},
+		{Options: Options{PrimerFile: "x.tsv", SeqFiles: []string{fa}}, Pairs: panel.Pairs},
+	} {
+		if _, err := Run(context.Background(), bad); err == nil || strings.HasPrefix(err.Error(), "error:") {
+			t.Fatalf("%+v: want a plain error, got %v", bad.Options, err)
+		}
+	}
+	if _, err := Scan(context.Background(), req, Probe{Seq: "ACGZ"}); err == nil {
+		t.Fatal("bad probe sequence accepted")
+	}
+}
+
+// collect runs a scan as Collect does, passing on a setup error.
+func collect[T any](seq Seq[T], err error) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	return Collect(seq)
+}
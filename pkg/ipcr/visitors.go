@@ -0,0 +1,278 @@
+// pkg/ipcr/visitors.go
+package ipcr
+
+import (
+	"flag"
+	"fmt"
+	"ipcr-core/engine"
+	"ipcr-core/primer"
+	"ipcr-core/thermo"
+	"ipcr/internal/nestedoutput"
+	"ipcr/internal/output"
+	"ipcr/internal/probeoutput"
+	"ipcr/internal/runutil"
+	"ipcr/internal/thermocli"
+	"ipcr/internal/thermomodel"
+	"ipcr/internal/thermovisitors"
+	"ipcr/internal/visitors"
+	"ipcr/pkg/api"
+	"strings"
+)
+
+// VisitFunc turns one product into a result, or drops it (keep false). It
+// is called from a single goroutine.
+type VisitFunc[T any] func(engine.Product) (keep bool, out T, err error)
+
+// Visitor annotates or filters the products of a scan. Plain, Probe, Nested
+// and Thermo are the visitors of ipcr, ipcr-probe, ipcr-nested and
+// ipcr-thermo.
+type Visitor[T any] interface {
+	// NeedSeq reports whether the visitor reads Product.Seq.
+	NeedSeq() bool
+	// Bind prepares a scan with o over panel (before self pairs are added).
+	Bind(o Options, panel []primer.Pair) (VisitFunc[T], error)
+}
+
+// Plain keeps every product, as ipcr does.
+type Plain struct{}
+
+func (Plain) NeedSeq() bool { return false }
+
+func (Plain) Bind(Options, []primer.Pair) (VisitFunc[api.ProductV1], error) {
+	return func(p engine.Product) (bool, api.ProductV1, error) {
+		return true, output.ToAPIProduct(p), nil
+	}, nil
+}
+
+// Probe annotates products with an internal probe site, as ipcr-probe does.
+type Probe struct {
+	Name    string // --probe-name (default "probe")
+	Seq     string // --probe, 5'→3'
+	MaxMM   int    // --probe-max-mm
+	Require bool   // --require-probe (on by default in ipcr-probe): drop products without a probe site
+}
+
+func (Probe) NeedSeq() bool { return true }
+
+func (v Probe) Bind(o Options, _ []primer.Pair) (VisitFunc[api.AnnotatedProductV1], error) {
+	seq, err := primer.Validate(v.Seq)
+	if err != nil {
+		return nil, fmt.Errorf("--probe: %w", err)
+	}
+	if v.MaxMM < 0 {
+		return nil, fmt.Errorf("--probe-max-mm must be ≥ 0")
+	}
+	name := v.Name
+	if name == "" {
+		name = "probe"
+	}
+	pv := visitors.Probe{Name: name, Seq: seq, MaxMM: v.MaxMM, Require: v.Require}
+	return func(p engine.Product) (bool, api.AnnotatedProductV1, error) {
+		keep, ap, err := pv.Visit(p)
+		if !keep || err != nil {
+			return keep, api.AnnotatedProductV1{}, err
+		}
+		out := probeoutput.ToAPIAnnotated(ap)
+		if !o.Products {
+			out.Seq = ""
+		}
+		return true, out, nil
+	}, nil
+}
+
+// Nested rescans each outer product with inner primers, as ipcr-nested
+// does. The scan's primers are the outer pairs; the inner scan uses the
+// same mismatch, indel, seed and terminal-window settings.
+type Nested struct {
+	Inner        []primer.Pair // --inner-primers, or --inner-forward/--inner-reverse
+	RequireInner bool          // --require-inner: drop outer products without an inner hit
+	AllInner     bool          // --all-inner: report every inner product, not just the best
+}
+
+func (Nested) NeedSeq() bool { return true }
+
+func (v Nested) Bind(o Options, _ []primer.Pair) (VisitFunc[api.NestedProductV1], error) {
+	if len(v.Inner) == 0 {
+		return nil, fmt.Errorf("nested scan needs inner primers")
+	}
+	nv := visitors.Nested{
+		InnerPairs: v.Inner,
+		EngineCfg: engine.Config{
+			MaxMM:          o.Mismatches,
+			MaxIndels:      o.MaxIndels,
+			TerminalWindow: runutil.EffectiveTerminalWindow(o.TerminalWindow),
+			SeedLen:        o.SeedLength,
+		},
+		RequireInner: v.RequireInner,
+		AllInner:     v.AllInner,
+	}
+	return func(p engine.Product) (bool, api.NestedProductV1, error) {
+		keep, np, err := nv.Visit(p)
+		if !keep || err != nil {
+			return keep, api.NestedProductV1{}, err
+		}
+		out := nestedoutput.ToAPINested(np)
+		if !o.Products {
+			out.Seq = ""
+		}
+		return true, out, nil
+	}, nil
+}
+
+// Thermo scores products by primer binding, as ipcr-thermo does, and sets
+// ProductV1.Score and Thermo. Results arrive in scan order; ipcr-thermo's
+// default ranking is a sort by Score, best first. Start from DefaultThermo.
+type Thermo struct {
+	Model       string  // --thermo-model
+	AnnealTempC float64 // --anneal-temp (°C)
+
+	// Buffer, as concentrations such as "50mM" or "250nM".
+	Na         string // --na
+	Mg         string // --mg
+	Dntp       string // --dntp
+	PrimerConc string // --primer-conc
+	SaltModel  string // --salt-model
+
+	AllowIndel     bool   // --allow-indel
+	SingleStranded bool   // --single-stranded
+	ScoreProfile   string // --score-profile: binding | pcr | gel
+
+	IUPACPolicy        string // --iupac-thermo-policy
+	IUPACMaxExpansions int    // --iupac-thermo-max-expansions
+
+	Probe      string // --probe (optional)
+	ProbeName  string // --probe-name
+	ProbeMaxMM int    // --probe-max-mm
+}
+
+// DefaultThermo returns the ipcr-thermo defaults.
+func DefaultThermo() Thermo {
+	d := thermoDefaults()
+	return Thermo{
+		Model:              d.ThermoModel,
+		AnnealTempC:        d.AnnealTempC,
+		Na:                 d.NaSpec,
+		Mg:                 d.MgSpec,
+		Dntp:               d.DntpSpec,
+		PrimerConc:         d.PrimerConcSpec,
+		SaltModel:          d.SaltModel,
+		AllowIndel:         d.AllowIndel,
+		SingleStranded:     d.SingleStranded,
+		ScoreProfile:       d.ScoreProfile,
+		IUPACPolicy:        d.IUPACThermoPolicy,
+		IUPACMaxExpansions: d.IUPACThermoMaxExpansions,
+		ProbeName:          d.ProbeName,
+		ProbeMaxMM:         d.ProbeMaxMM,
+	}
+}
+
+// thermoDefaults returns ipcr-thermo's flag defaults, including the
+// scoring knobs Thermo does not expose.
+func thermoDefaults() thermocli.Options {
+	var d thermocli.Options
+	thermocli.Register(flag.NewFlagSet("ipcr-thermo", flag.ContinueOnError), &d)
+	return d
+}
+
+func (Thermo) NeedSeq() bool { return true }
+
+func (v Thermo) Bind(o Options, panel []primer.Pair) (VisitFunc[api.ProductV1], error) {
+	mode, err := thermomodel.Parse(v.Model)
+	if err != nil {
+		return nil, err
+	}
+	if !mode.Implemented() {
+		return nil, fmt.Errorf("--thermo-model %q is reserved for staged rollout but is not implemented yet; use %q", mode, thermomodel.LegacyHeuristic)
+	}
+	saltModel, err := thermo.ParseSaltModel(v.SaltModel)
+	if err != nil {
+		return nil, fmt.Errorf("--salt-model: %w", err)
+	}
+	policy, err := thermo.ParseIUPACThermoPolicy(v.IUPACPolicy)
+	if err != nil {
+		return nil, err
+	}
+	if v.IUPACMaxExpansions < 1 {
+		return nil, fmt.Errorf("--iupac-thermo-max-expansions must be >= 1")
+	}
+	profile := strings.ToLower(v.ScoreProfile)
+	switch profile {
+	case "binding", "pcr", "gel":
+	default:
+		return nil, fmt.Errorf("--score-profile must be binding, pcr or gel")
+	}
+	cond := thermo.Conditions{AnnealC: v.AnnealTempC, SaltModel: saltModel}
+	for _, f := range []struct {
+		name, spec string
+		dst        *float64
+	}{
+		{"--na", v.Na, &cond.NaM},
+		{"--mg", v.Mg, &cond.MgM},
+		{"--dntp", v.Dntp, &cond.DntpM},
+		{"--primer-conc", v.PrimerConc, &cond.PrimerTotalM},
+	} {
+		if *f.dst, err = thermo.ParseConc(f.spec); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+	probe := ""
+	if strings.TrimSpace(v.Probe) != "" {
+		if probe, err = primer.Validate(v.Probe); err != nil {
+			return nil, fmt.Errorf("--probe: %w", err)
+		}
+		if mode == thermomodel.LegacyHeuristic {
+			mode = thermomodel.NNDuplexV1 // probe thermodynamics need NN endpoints, as in ipcr-thermo
+		}
+	}
+	refs := make([]thermovisitors.PrimerRef, 0, 2*len(panel))
+	for _, p := range panel {
+		refs = append(refs,
+			thermovisitors.PrimerRef{ID: p.ID + ":fwd", Seq: strings.ToUpper(p.Forward)},
+			thermovisitors.PrimerRef{ID: p.ID + ":rev", Seq: strings.ToUpper(p.Reverse)})
+	}
+
+	d := thermoDefaults()
+	score := thermovisitors.Score{
+		Model:                    mode,
+		Conditions:               cond,
+		AnnealTempC:              v.AnnealTempC,
+		Na_M:                     cond.EffectiveNaM(),
+		PrimerConc_M:             cond.PrimerTotalM,
+		AllowIndels:              v.AllowIndel,
+		SingleStranded:           v.SingleStranded,
+		StructHairpin:            d.StructHairpin,
+		StructDimer:              d.StructDimer,
+		StructScale:              d.StructScale,
+		PanelPrimers:             refs,
+		IUPACThermoPolicy:        policy,
+		IUPACThermoMaxExpansions: v.IUPACMaxExpansions,
+		ScoreProfile:             profile,
+		ExtAlpha:                 d.ExtAlpha,
+		ExtWeight:                d.ExtWeight,
+		LenKneeBP:                d.LenKneeBP,
+		LenSteep:                 d.LenSteep,
+		LenMaxPenC:               d.LenMaxPenC,
+		BindWeight:               d.BindWeight,
+		BandMassWeight:           d.BandMassWeight,
+		ProbeSeq:                 probe,
+		ProbeName:                v.ProbeName,
+		ProbeMaxMM:               v.ProbeMaxMM,
+		ProbeThermo:              d.ProbeThermo,
+		ProbeScoreMode:           d.ProbeScoreMode,
+		ProbeMinMarginC:          d.ProbeMinMarginC,
+		ProbeWeight:              d.ProbeWeight,
+		UseAutoDenom:             strings.ToLower(d.DenomMode) == "auto",
+	}
+	return func(p engine.Product) (bool, api.ProductV1, error) {
+		keep, sp, err := score.Visit(p)
+		if !keep || err != nil {
+			return keep, api.ProductV1{}, err
+		}
+		out := output.ToAPIProduct(sp)
+		out.Score = sp.Score // the CLIs print it only in thermo builds
+		if !o.Products {
+			out.Seq = ""
+		}
+		return true, out, nil
+	}, nil
+}